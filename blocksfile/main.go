@@ -0,0 +1,178 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage/adapter"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage/adapter/export"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage/adapter/filesystem"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/scribe/log"
+	"os"
+)
+
+const usage = `usage: %s <command> [flags]
+
+commands:
+  verify    verify the blocks and hash chain of a blocks file, optionally truncating it after the last good block
+  export    export a range of blocks from a data dir into a portable stream file
+  import    validate a stream file from a trusted source and append its blocks to a data dir, block proofs are not validated
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintf(os.Stderr, usage, os.Args[0])
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "verify":
+		err = verify(os.Args[2:])
+	case "export":
+		err = exportBlocks(os.Args[2:])
+	case "import":
+		err = importBlocks(os.Args[2:])
+	default:
+		fmt.Fprintf(os.Stderr, usage, os.Args[0])
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+}
+
+func verify(args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	filename := flags.String("file", "", "path/to/blocks, a single blocks file segment")
+	maxBlockSize := flags.Uint("max-block-size", 64*1024*1024, "max block size in bytes, as configured for the node")
+	vchainId := flags.Uint("vchain", 0, "expected virtual chain id, by default the one in the file header")
+	protocolVersion := flags.Uint("protocol-version", 0, "expected block protocol version, by default any")
+	truncate := flags.Bool("truncate", false, "truncate the file after the last good block, only the last segment of a data dir may be truncated and the node must not be running")
+	_ = flags.Parse(args)
+
+	if *filename == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	report, err := filesystem.VerifyBlocksFile(*filename, &filesystem.VerifyOptions{
+		MaxBlockSizeInBytes: uint32(*maxBlockSize),
+		VirtualChainId:      primitives.VirtualChainId(*vchainId),
+		ProtocolVersion:     primitives.ProtocolVersion(*protocolVersion),
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("virtual chain id: %d\n", report.VirtualChainId)
+	fmt.Printf("good blocks: %d (heights %d to %d), %d of %d bytes\n", report.NumBlocks, report.FirstBlockHeight, report.LastGoodBlockHeight, report.ValidBytes, report.FileSize)
+	if report.Err == nil {
+		fmt.Println("blocks file is valid")
+		return nil
+	}
+	fmt.Printf("first bad block height: %d, %s\n", report.FirstBadBlockHeight(), report.Err)
+
+	if !*truncate {
+		return fmt.Errorf("blocks file is invalid, run again with -truncate to drop blocks from height %d on", report.FirstBadBlockHeight())
+	}
+	err = filesystem.TruncateBlocksFile(*filename, report)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("truncated blocks file to %d bytes, last block height is %d\n", report.ValidBytes, report.LastGoodBlockHeight)
+	return nil
+}
+
+// the node must not be running on the data dir, opening it takes the blocks file lock
+func openBlockPersistence(ctx context.Context, dataDir string, vchainId uint) (adapter.BlockPersistence, error) {
+	cfg := config.ForProduction("")
+	cfg.SetString(config.BLOCK_STORAGE_FILE_SYSTEM_DATA_DIR, dataDir)
+	cfg.SetUint32(config.VIRTUAL_CHAIN_ID, uint32(vchainId))
+
+	logger := log.GetLogger().WithOutput(log.NewFormattingOutput(os.Stderr, log.NewHumanReadableFormatter()))
+	return filesystem.NewBlockPersistence(ctx, cfg, logger, metric.NewRegistry())
+}
+
+func exportBlocks(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	dataDir := flags.String("data-dir", "", "path/to/blocks/data/dir")
+	vchainId := flags.Uint("vchain", 0, "virtual chain id of the blocks")
+	filename := flags.String("file", "", "path/to/stream, the file to export to")
+	first := flags.Uint64("from", 1, "first block height to export")
+	last := flags.Uint64("to", 0, "last block height to export, by default the last block")
+	_ = flags.Parse(args)
+
+	if *dataDir == "" || *vchainId == 0 || *filename == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	persistence, err := openBlockPersistence(ctx, *dataDir, *vchainId)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(*filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	manifest, err := export.Export(file, persistence, primitives.VirtualChainId(*vchainId), primitives.BlockHeight(*first), primitives.BlockHeight(*last))
+	if err == nil {
+		err = file.Sync()
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("exported blocks %d to %d of virtual chain %d\n", manifest.FirstBlockHeight, manifest.LastBlockHeight, manifest.VirtualChainId)
+	return nil
+}
+
+func importBlocks(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	dataDir := flags.String("data-dir", "", "path/to/blocks/data/dir")
+	vchainId := flags.Uint("vchain", 0, "virtual chain id of the blocks")
+	filename := flags.String("file", "", "path/to/stream, the file to import from")
+	trustedSource := flags.Bool("trusted-source", false, "required, block proofs are not validated so the stream must come from a trusted source")
+	_ = flags.Parse(args)
+
+	if *dataDir == "" || *vchainId == 0 || *filename == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	file, err := os.Open(*filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	persistence, err := openBlockPersistence(ctx, *dataDir, *vchainId)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := export.Import(file, persistence, primitives.VirtualChainId(*vchainId), *trustedSource)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("imported blocks %d to %d of virtual chain %d\n", manifest.FirstBlockHeight, manifest.LastBlockHeight, manifest.VirtualChainId)
+	return nil
+}
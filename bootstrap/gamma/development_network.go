@@ -10,7 +10,11 @@ import (
 	"context"
 	"github.com/orbs-network/orbs-network-go/bootstrap/inmemory"
 	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/crypto/signer"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
 	gossipAdapter "github.com/orbs-network/orbs-network-go/services/gossip/adapter/memory"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter/relay"
 	"github.com/orbs-network/orbs-network-go/test/crypto/keys"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/scribe/log"
@@ -30,7 +34,6 @@ func NewDevelopmentNetwork(ctx context.Context, logger log.Logger, overrideConfi
 		privateKeys[nodeAddress.KeyForMap()] = keys.EcdsaSecp256K1KeyPairForTests(i).PrivateKey()
 		nodeOrder = append(nodeOrder, nodeAddress)
 	}
-	sharedTransport := gossipAdapter.NewTransport(ctx, logger, validatorNodes)
 	cfgTemplate := config.TemplateForGamma(
 		validatorNodes,
 		keys.EcdsaSecp256K1KeyPairForTests(0).NodeAddress(),
@@ -45,6 +48,15 @@ func NewDevelopmentNetwork(ctx context.Context, logger log.Logger, overrideConfi
 		panic(err)
 	}
 
+	var sharedTransport adapter.Transport = gossipAdapter.NewTransport(ctx, logger, validatorNodes)
+	if configWithOverrides.GossipTopology() == config.GOSSIP_TOPOLOGY_RELAY {
+		signers := make(map[string]signer.Signer)
+		for key, privateKey := range privateKeys {
+			signers[key] = signer.NewLocalSigner(privateKey, logger)
+		}
+		sharedTransport = relay.NewTransport(sharedTransport, nodeOrder, signers, configWithOverrides, logger, metric.NewRegistry())
+	}
+
 	network := inmemory.NewNetworkWithNumOfNodes(validatorNodes, nodeOrder, privateKeys, logger, configWithOverrides, sharedTransport, nil)
 	network.CreateAndStartNodes(ctx, numNodes)
 	return network
@@ -56,7 +56,7 @@ func StartGammaServer(config GammaServerConfig) *GammaServer {
 	rootLogger.Info("finished creating development network")
 
 	httpServer := httpserver.NewHttpServer(httpserver.NewServerConfig(config.ServerAddress, config.Profiling),
-		rootLogger, network.PublicApi(0), network.MetricRegistry(0))
+		rootLogger, network.PublicApi(0), network.MetricRegistry(0), nil)
 
 	s := &GammaServer{
 		OrbsProcess: bootstrap.NewOrbsProcess(rootLogger, cancel, httpServer),
@@ -8,13 +8,17 @@ package httpserver
 
 import (
 	"context"
+	"encoding/hex"
 	"fmt"
 	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/crypto/bloom"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/statestorage"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/pprof"
+	"strconv"
 	"time"
 
 	"github.com/orbs-network/membuffers/go"
@@ -33,6 +37,10 @@ type httpErr struct {
 	message  string
 }
 
+// Exports the node's persisted state, nil when the node does not serve state snapshots. The snapshot holds the full state
+// and is served to anyone reaching the http port, so nodes serve it only when STATE_STORAGE_SERVE_SNAPSHOTS is set
+type StateSnapshotFunc func() (*statestorage.StateSnapshot, error)
+
 type HttpServer interface {
 	GracefulShutdown(timeout time.Duration)
 	Port() int
@@ -44,6 +52,7 @@ type server struct {
 	publicApi      services.PublicApi
 	metricRegistry metric.Registry
 	config         config.HttpServerConfig
+	stateSnapshot  StateSnapshotFunc
 
 	port int
 }
@@ -68,12 +77,13 @@ func (ln tcpKeepAliveListener) Accept() (net.Conn, error) {
 	return tc, nil
 }
 
-func NewHttpServer(cfg config.HttpServerConfig, logger log.Logger, publicApi services.PublicApi, metricRegistry metric.Registry) HttpServer {
+func NewHttpServer(cfg config.HttpServerConfig, logger log.Logger, publicApi services.PublicApi, metricRegistry metric.Registry, stateSnapshot StateSnapshotFunc) HttpServer {
 	server := &server{
 		logger:         logger.WithTags(LogTag),
 		publicApi:      publicApi,
 		metricRegistry: metricRegistry,
 		config:         cfg,
+		stateSnapshot:  stateSnapshot,
 	}
 
 	if listener, err := server.listen(server.config.HttpAddress()); err != nil {
@@ -121,10 +131,14 @@ func (s *server) createRouter() http.Handler {
 	router.Handle("/api/v1/run-query", http.HandlerFunc(wrapHandlerWithCORS(s.runQueryHandler)))
 	router.Handle("/api/v1/get-transaction-status", http.HandlerFunc(wrapHandlerWithCORS(s.getTransactionStatusHandler)))
 	router.Handle("/api/v1/get-transaction-receipt-proof", http.HandlerFunc(wrapHandlerWithCORS(s.getTransactionReceiptProofHandler)))
+	router.Handle("/api/v1/get-transaction-receipts-proof", http.HandlerFunc(wrapHandlerWithCORS(s.getTransactionReceiptsProofHandler)))
 	router.Handle("/api/v1/get-block", http.HandlerFunc(wrapHandlerWithCORS(s.getBlockHandler)))
+	router.Handle("/api/v1/get-state-proof", http.HandlerFunc(wrapHandlerWithCORS(s.getStateProofHandler)))
+	router.Handle("/api/v1/find-events", http.HandlerFunc(wrapHandlerWithCORS(s.findEventsHandler)))
 	router.Handle("/metrics", http.HandlerFunc(wrapHandlerWithCORS(s.dumpMetricsAsJSON)))
 	router.Handle("/metrics.json", http.HandlerFunc(wrapHandlerWithCORS(s.dumpMetricsAsJSON)))
 	router.Handle("/metrics.prometheus", http.HandlerFunc(wrapHandlerWithCORS(s.dumpMetricsAsPrometheus)))
+	router.Handle("/state-snapshot", http.HandlerFunc(wrapHandlerWithCORS(s.stateSnapshotHandler)))
 	router.Handle("/robots.txt", http.HandlerFunc(s.robots))
 	router.Handle("/debug/logs/filter-on", http.HandlerFunc(s.filterOn))
 	router.Handle("/debug/logs/filter-off", http.HandlerFunc(s.filterOff))
@@ -150,6 +164,54 @@ func readInput(r *http.Request) ([]byte, *httpErr) {
 	return bytes, nil
 }
 
+// the optional block-height query parameter, zero when missing which stands for the most recent block height
+func readBlockHeightParam(r *http.Request) (primitives.BlockHeight, *httpErr) {
+	return readHeightParam(r, "block-height")
+}
+
+// zero when the parameter is missing
+func readHeightParam(r *http.Request, name string) (primitives.BlockHeight, *httpErr) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	height, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, &httpErr{http.StatusBadRequest, log.Error(err), fmt.Sprintf("http request %s parameter is not a valid block height", name)}
+	}
+	return primitives.BlockHeight(height), nil
+}
+
+// the event-name, contract-name and hex encoded address query parameters of a find events request
+func readEventsQueryParams(r *http.Request) (*bloom.EventsQuery, *httpErr) {
+	eventName := r.URL.Query().Get("event-name")
+	if eventName == "" {
+		return nil, &httpErr{http.StatusBadRequest, nil, "http request event-name parameter is missing"}
+	}
+	address, err := hex.DecodeString(r.URL.Query().Get("address"))
+	if err != nil {
+		return nil, &httpErr{http.StatusBadRequest, log.Error(err), "http request address parameter is not hex encoded"}
+	}
+	return &bloom.EventsQuery{
+		ContractName: primitives.ContractName(r.URL.Query().Get("contract-name")),
+		EventName:    primitives.EventName(eventName),
+		Address:      address,
+	}, nil
+}
+
+// the contract-name and hex encoded key query parameters of a state proof request
+func readStateKeyParams(r *http.Request) (primitives.ContractName, []byte, *httpErr) {
+	contractName := r.URL.Query().Get("contract-name")
+	if contractName == "" {
+		return "", nil, &httpErr{http.StatusBadRequest, nil, "http request contract-name parameter is missing"}
+	}
+	key, err := hex.DecodeString(r.URL.Query().Get("key"))
+	if err != nil {
+		return "", nil, &httpErr{http.StatusBadRequest, log.Error(err), "http request key parameter is not hex encoded"}
+	}
+	return primitives.ContractName(contractName), key, nil
+}
+
 func validate(m membuffers.Message) *httpErr {
 	if !m.IsValid() {
 		return &httpErr{http.StatusBadRequest, log.Stringable("request", m), "http request is not a valid membuffer"}
@@ -7,8 +7,13 @@
 package httpserver
 
 import (
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/crypto/merkle"
+	"github.com/orbs-network/orbs-network-go/services/publicapi"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/protocol/client"
 	"github.com/orbs-network/orbs-spec/types/go/services"
 	"github.com/orbs-network/scribe/log"
@@ -21,6 +26,53 @@ type IndexResponse struct {
 	Version     config.Version
 }
 
+// Byte fields are hex encoded, the results block header and proof as their raw membuffers
+type GetStateProofResponse struct {
+	BlockHeight        uint64
+	ContractName       string
+	Key                string
+	Value              string
+	StateMerkleRoot    string
+	Proof              *merkle.TrieProof
+	ResultsBlockHeader string
+	ResultsBlockProof  string
+}
+
+// The body of a receipts proof request, transaction hashes are hex encoded
+type GetTransactionReceiptsProofRequest struct {
+	BlockHeight uint64
+	Txhashes    []string
+}
+
+// Byte fields are hex encoded, the receipts and the results block header and proof as their raw membuffers.
+// The receipts are in the order of the proof's indices, the proof verifies with merkle.VerifyMultiProof
+type GetTransactionReceiptsProofResponse struct {
+	BlockHeight         uint64
+	TransactionReceipts []string
+	NumReceipts         int
+	Indices             []int
+	Hashes              []string
+	ResultsBlockHeader  string
+	ResultsBlockProof   string
+}
+
+// Byte fields are hex encoded, the arguments of an event as the packed argument array protocol.ArgumentArrayReader reads.
+// The blocks from FromBlockHeight to ToBlockHeight were searched, a client searching a longer
+// range continues from the block after ToBlockHeight
+type FindEventsResponse struct {
+	FromBlockHeight uint64
+	ToBlockHeight   uint64
+	Events          []*FoundEventResponse
+}
+
+type FoundEventResponse struct {
+	BlockHeight  uint64
+	Txhash       string
+	ContractName string
+	EventName    string
+	Arguments    string
+}
+
 // Serves both index and 404 because router is built that way
 func (s *server) Index(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path != "/" {
@@ -101,6 +153,32 @@ func (s *server) dumpMetricsAsPrometheus(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+func (s *server) stateSnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.stateSnapshot == nil {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusNotImplemented, nil, "state snapshots are not served by this node"})
+		return
+	}
+
+	snapshot, err := s.stateSnapshot()
+	if err != nil {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusInternalServerError, log.Error(err), "failed taking state snapshot"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("X-ORBS-BLOCK-HEIGHT", fmt.Sprintf("%d", snapshot.Height))
+	w.Header().Set("X-ORBS-BLOCK-TIMESTAMP", sprintfTimestamp(snapshot.Timestamp))
+	err = snapshot.Encode(w)
+	if err != nil {
+		s.logger.Info("error writing state snapshot response", log.Error(err))
+	}
+}
+
 func (s *server) sendTransactionHandler(w http.ResponseWriter, r *http.Request) {
 	bytes, e := readInput(r)
 	if e != nil {
@@ -158,8 +236,23 @@ func (s *server) runQueryHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.logger.Info("http server received run-query", log.Stringable("request", clientRequest))
-	result, err := s.publicApi.RunQuery(r.Context(), &services.RunQueryInput{ClientRequest: clientRequest})
+	blockHeight, e := readBlockHeightParam(r)
+	if e != nil {
+		s.writeErrorResponseAndLog(w, e)
+		return
+	}
+
+	s.logger.Info("http server received run-query", log.Stringable("request", clientRequest), log.Uint64("block-height", uint64(blockHeight)))
+	var result *services.RunQueryOutput
+	var err error
+	if blockHeight == 0 {
+		result, err = s.publicApi.RunQuery(r.Context(), &services.RunQueryInput{ClientRequest: clientRequest})
+	} else if runner, ok := s.publicApi.(publicapi.HistoricalQueries); ok {
+		result, err = runner.RunQueryAtBlockHeight(r.Context(), &services.RunQueryInput{ClientRequest: clientRequest}, blockHeight)
+	} else {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusBadRequest, nil, "run-query at a past block height is not supported"})
+		return
+	}
 	if result != nil && result.ClientResponse != nil {
 		s.writeMembuffResponse(w, result.ClientResponse, result.ClientResponse.RequestResult(), err)
 	} else {
@@ -232,3 +325,190 @@ func (s *server) getBlockHandler(w http.ResponseWriter, r *http.Request) {
 		s.writeErrorResponseAndLog(w, &httpErr{http.StatusInternalServerError, log.Error(err), err.Error()})
 	}
 }
+
+func (s *server) getStateProofHandler(w http.ResponseWriter, r *http.Request) {
+	contractName, key, e := readStateKeyParams(r)
+	if e != nil {
+		s.writeErrorResponseAndLog(w, e)
+		return
+	}
+
+	blockHeight, e := readBlockHeightParam(r)
+	if e != nil {
+		s.writeErrorResponseAndLog(w, e)
+		return
+	}
+
+	getter, ok := s.publicApi.(publicapi.HistoricalQueries)
+	if !ok {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusNotImplemented, nil, "state proofs are not served by this node"})
+		return
+	}
+
+	s.logger.Info("http server received get-state-proof", log.String("contract", string(contractName)), log.Uint64("block-height", uint64(blockHeight)))
+	result, err := getter.GetStateProof(r.Context(), &publicapi.GetStateProofInput{BlockHeight: blockHeight, ContractName: contractName, Key: key})
+	if err != nil {
+		code := http.StatusInternalServerError
+		if result != nil {
+			code = translateRequestStatusToHttpCode(result.RequestStatus)
+		}
+		s.writeErrorResponseAndLog(w, &httpErr{code, log.Error(err), err.Error()})
+		return
+	}
+	if result == nil {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusInternalServerError, nil, "http request failed without a result"})
+		return
+	}
+
+	data, _ := json.Marshal(&GetStateProofResponse{
+		BlockHeight:        uint64(result.BlockHeight),
+		ContractName:       string(contractName),
+		Key:                hex.EncodeToString(key),
+		Value:              hex.EncodeToString(result.Value),
+		StateMerkleRoot:    hex.EncodeToString(result.StateMerkleRoot),
+		Proof:              result.Proof,
+		ResultsBlockHeader: hex.EncodeToString(result.ResultsBlockHeader.Raw()),
+		ResultsBlockProof:  hex.EncodeToString(result.ResultsBlockProof.Raw()),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-ORBS-REQUEST-RESULT", result.RequestStatus.String())
+	w.Header().Set("X-ORBS-BLOCK-HEIGHT", fmt.Sprintf("%d", result.BlockHeight))
+	w.Header().Set("X-ORBS-BLOCK-TIMESTAMP", sprintfTimestamp(result.BlockTimestamp))
+	_, err = w.Write(data)
+	if err != nil {
+		s.logger.Info("error writing response", log.Error(err))
+	}
+}
+
+func (s *server) getTransactionReceiptsProofHandler(w http.ResponseWriter, r *http.Request) {
+	bytes, e := readInput(r)
+	if e != nil {
+		s.writeErrorResponseAndLog(w, e)
+		return
+	}
+	request := &GetTransactionReceiptsProofRequest{}
+	if err := json.Unmarshal(bytes, request); err != nil {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusBadRequest, log.Error(err), "http request is not a valid receipts proof request"})
+		return
+	}
+	txHashes := make([]primitives.Sha256, 0, len(request.Txhashes))
+	for _, txHash := range request.Txhashes {
+		decoded, err := hex.DecodeString(txHash)
+		if err != nil {
+			s.writeErrorResponseAndLog(w, &httpErr{http.StatusBadRequest, log.Error(err), "http request transaction hash is not hex encoded"})
+			return
+		}
+		txHashes = append(txHashes, decoded)
+	}
+
+	getter, ok := s.publicApi.(publicapi.HistoricalQueries)
+	if !ok {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusNotImplemented, nil, "receipts proofs are not served by this node"})
+		return
+	}
+
+	s.logger.Info("http server received get-transaction-receipts-proof", log.Uint64("block-height", request.BlockHeight), log.Int("num-transactions", len(txHashes)))
+	result, err := getter.GetTransactionReceiptsProof(r.Context(), &publicapi.GetTransactionReceiptsProofInput{BlockHeight: primitives.BlockHeight(request.BlockHeight), Txhashes: txHashes})
+	if err != nil {
+		code := http.StatusInternalServerError
+		if result != nil {
+			code = translateRequestStatusToHttpCode(result.RequestStatus)
+		}
+		s.writeErrorResponseAndLog(w, &httpErr{code, log.Error(err), err.Error()})
+		return
+	}
+	if result == nil {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusInternalServerError, nil, "http request failed without a result"})
+		return
+	}
+
+	response := &GetTransactionReceiptsProofResponse{
+		BlockHeight:        uint64(result.BlockHeight),
+		NumReceipts:        result.Proof.NumValues,
+		Indices:            result.Proof.Indices,
+		ResultsBlockHeader: hex.EncodeToString(result.ResultsBlockHeader.Raw()),
+		ResultsBlockProof:  hex.EncodeToString(result.ResultsBlockProof.Raw()),
+	}
+	for _, receipt := range result.TransactionReceipts {
+		response.TransactionReceipts = append(response.TransactionReceipts, hex.EncodeToString(receipt.Raw()))
+	}
+	for _, h := range result.Proof.Hashes {
+		response.Hashes = append(response.Hashes, hex.EncodeToString(h))
+	}
+	data, _ := json.Marshal(response)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-ORBS-REQUEST-RESULT", result.RequestStatus.String())
+	w.Header().Set("X-ORBS-BLOCK-HEIGHT", fmt.Sprintf("%d", result.BlockHeight))
+	w.Header().Set("X-ORBS-BLOCK-TIMESTAMP", sprintfTimestamp(result.BlockTimestamp))
+	_, err = w.Write(data)
+	if err != nil {
+		s.logger.Info("error writing response", log.Error(err))
+	}
+}
+
+func (s *server) findEventsHandler(w http.ResponseWriter, r *http.Request) {
+	query, e := readEventsQueryParams(r)
+	if e != nil {
+		s.writeErrorResponseAndLog(w, e)
+		return
+	}
+	fromHeight, e := readHeightParam(r, "from-block-height")
+	if e != nil {
+		s.writeErrorResponseAndLog(w, e)
+		return
+	}
+	toHeight, e := readHeightParam(r, "to-block-height")
+	if e != nil {
+		s.writeErrorResponseAndLog(w, e)
+		return
+	}
+	if fromHeight == 0 {
+		fromHeight = 1
+	}
+
+	finder, ok := s.publicApi.(publicapi.HistoricalQueries)
+	if !ok {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusNotImplemented, nil, "events are not searched by this node"})
+		return
+	}
+
+	s.logger.Info("http server received find-events", log.String("event-name", string(query.EventName)), log.Uint64("from-block-height", uint64(fromHeight)), log.Uint64("to-block-height", uint64(toHeight)))
+	result, err := finder.FindEvents(r.Context(), &publicapi.FindEventsInput{FromBlockHeight: fromHeight, ToBlockHeight: toHeight, Query: query})
+	if err != nil {
+		code := http.StatusInternalServerError
+		if result != nil {
+			code = translateRequestStatusToHttpCode(result.RequestStatus)
+		}
+		s.writeErrorResponseAndLog(w, &httpErr{code, log.Error(err), err.Error()})
+		return
+	}
+	if result == nil {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusInternalServerError, nil, "http request failed without a result"})
+		return
+	}
+
+	response := &FindEventsResponse{
+		FromBlockHeight: uint64(result.FromBlockHeight),
+		ToBlockHeight:   uint64(result.ToBlockHeight),
+		Events:          []*FoundEventResponse{},
+	}
+	for _, found := range result.Events {
+		response.Events = append(response.Events, &FoundEventResponse{
+			BlockHeight:  uint64(found.BlockHeight),
+			Txhash:       hex.EncodeToString(found.Txhash),
+			ContractName: string(found.Event.ContractName()),
+			EventName:    string(found.Event.EventName()),
+			Arguments:    hex.EncodeToString(found.Event.RawOutputArgumentArrayWithHeader()),
+		})
+	}
+	data, _ := json.Marshal(response)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-ORBS-REQUEST-RESULT", result.RequestStatus.String())
+	_, err = w.Write(data)
+	if err != nil {
+		s.logger.Info("error writing response", log.Error(err))
+	}
+}
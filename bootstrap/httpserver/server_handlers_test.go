@@ -8,8 +8,15 @@ package httpserver
 
 import (
 	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
 	"github.com/orbs-network/go-mock"
+	"github.com/orbs-network/orbs-network-go/crypto/bloom"
+	"github.com/orbs-network/orbs-network-go/crypto/merkle"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/publicapi"
+	"github.com/orbs-network/orbs-network-go/services/statestorage"
 	"github.com/orbs-network/orbs-network-go/test/builders"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/protocol"
@@ -27,7 +34,7 @@ import (
 func makeServer(tb testing.TB, papiMock *services.MockPublicApi) HttpServer {
 	logger := log.DefaultTestingLogger(tb)
 
-	return NewHttpServer(NewServerConfig(":0", false), logger, papiMock, metric.NewRegistry())
+	return NewHttpServer(NewServerConfig(":0", false), logger, papiMock, metric.NewRegistry(), nil)
 }
 
 func TestHttpServer_Robots(t *testing.T) {
@@ -166,6 +173,73 @@ func TestHttpServer_RunQuery_Error(t *testing.T) {
 	// actual values are checked in the server_test.go as unit test of internal writeErrorResponseAndLog
 }
 
+// serves historical queries at a block height through the mocked RunQuery, the fakes of the other historical queries override them
+type historicalPublicApi struct {
+	*services.MockPublicApi
+	blockHeight primitives.BlockHeight
+}
+
+func newHistoricalPublicApi() *historicalPublicApi {
+	return &historicalPublicApi{MockPublicApi: &services.MockPublicApi{}}
+}
+
+func (p *historicalPublicApi) RunQueryAtBlockHeight(ctx context.Context, input *services.RunQueryInput, blockHeight primitives.BlockHeight) (*services.RunQueryOutput, error) {
+	p.blockHeight = blockHeight
+	return p.RunQuery(ctx, input)
+}
+
+func (p *historicalPublicApi) GetStateProof(ctx context.Context, input *publicapi.GetStateProofInput) (*publicapi.GetStateProofOutput, error) {
+	return nil, errors.New("state proofs are not faked")
+}
+
+func (p *historicalPublicApi) GetTransactionReceiptsProof(ctx context.Context, input *publicapi.GetTransactionReceiptsProofInput) (*publicapi.GetTransactionReceiptsProofOutput, error) {
+	return nil, errors.New("receipts proofs are not faked")
+}
+
+func (p *historicalPublicApi) FindEvents(ctx context.Context, input *publicapi.FindEventsInput) (*publicapi.FindEventsOutput, error) {
+	return nil, errors.New("events are not faked")
+}
+
+func TestHttpServer_RunQuery_AtBlockHeight(t *testing.T) {
+	papiMock := newHistoricalPublicApi()
+	response := &client.RunQueryResponseBuilder{
+		RequestResult: &client.RequestResultBuilder{
+			RequestStatus: protocol.REQUEST_STATUS_COMPLETED,
+			BlockHeight:   7,
+		},
+	}
+	papiMock.When("RunQuery", mock.Any, mock.Any).Times(1).Return(&services.RunQueryOutput{ClientResponse: response.Build()})
+
+	s := NewHttpServer(NewServerConfig(":0", false), log.DefaultTestingLogger(t), papiMock, metric.NewRegistry(), nil)
+
+	request := (&client.RunQueryRequestBuilder{
+		SignedQuery: &protocol.SignedQueryBuilder{},
+	}).Build()
+
+	req, _ := http.NewRequest("POST", "/api/v1/run-query?block-height=7", bytes.NewReader(request.Raw()))
+	rec := httptest.NewRecorder()
+	s.(*server).runQueryHandler(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code, "should succeed")
+	require.EqualValues(t, 7, papiMock.blockHeight, "should run the query at the requested block height")
+}
+
+func TestHttpServer_RunQuery_AtBlockHeightBadRequest(t *testing.T) {
+	s := makeServer(t, &services.MockPublicApi{})
+
+	request := (&client.RunQueryRequestBuilder{
+		SignedQuery: &protocol.SignedQueryBuilder{},
+	}).Build()
+
+	for _, url := range []string{"/api/v1/run-query?block-height=seven", "/api/v1/run-query?block-height=7"} {
+		req, _ := http.NewRequest("POST", url, bytes.NewReader(request.Raw()))
+		rec := httptest.NewRecorder()
+		s.(*server).runQueryHandler(rec, req)
+
+		require.Equal(t, http.StatusBadRequest, rec.Code, "should fail with 400 for %s", url)
+	}
+}
+
 func TestHttpServer_GetTransactionStatus_Basic(t *testing.T) {
 	papiMock := &services.MockPublicApi{}
 	response := &client.GetTransactionStatusResponseBuilder{
@@ -302,6 +376,213 @@ func TestHttpServer_GetBlock_Error(t *testing.T) {
 	// actual values are checked in the server_test.go as unit test of internal writeErrorResponseAndLog
 }
 
+type stateProofPublicApi struct {
+	*historicalPublicApi
+	input  *publicapi.GetStateProofInput
+	output *publicapi.GetStateProofOutput
+	err    error
+}
+
+func (p *stateProofPublicApi) GetStateProof(ctx context.Context, input *publicapi.GetStateProofInput) (*publicapi.GetStateProofOutput, error) {
+	p.input = input
+	return p.output, p.err
+}
+
+func TestHttpServer_GetStateProof_Basic(t *testing.T) {
+	blockPair := builders.BlockPair().WithHeight(7).Build()
+	papiMock := &stateProofPublicApi{historicalPublicApi: newHistoricalPublicApi(), output: &publicapi.GetStateProofOutput{
+		RequestStatus:      protocol.REQUEST_STATUS_COMPLETED,
+		BlockHeight:        7,
+		Value:              []byte("value"),
+		StateMerkleRoot:    blockPair.ResultsBlock.Header.PreExecutionStateMerkleRootHash(),
+		Proof:              &merkle.TrieProof{},
+		ResultsBlockHeader: blockPair.ResultsBlock.Header,
+		ResultsBlockProof:  blockPair.ResultsBlock.BlockProof,
+	}}
+
+	s := NewHttpServer(NewServerConfig(":0", false), log.DefaultTestingLogger(t), papiMock, metric.NewRegistry(), nil)
+
+	req, _ := http.NewRequest("GET", "/api/v1/get-state-proof?contract-name=foo&key=626172&block-height=7", nil)
+	rec := httptest.NewRecorder()
+	s.(*server).getStateProofHandler(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code, "should succeed")
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"), "should have json content type")
+	require.Equal(t, &publicapi.GetStateProofInput{BlockHeight: 7, ContractName: "foo", Key: []byte("bar")}, papiMock.input, "should request the proof of the query parameters")
+
+	response := &GetStateProofResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), response))
+	require.Equal(t, hex.EncodeToString([]byte("value")), response.Value, "should have the hex encoded value")
+	require.Equal(t, hex.EncodeToString(blockPair.ResultsBlock.Header.Raw()), response.ResultsBlockHeader, "should have the hex encoded results block header")
+	require.NotNil(t, response.Proof, "should have the trie proof")
+}
+
+func TestHttpServer_GetStateProof_Error(t *testing.T) {
+	papiMock := &stateProofPublicApi{historicalPublicApi: newHistoricalPublicApi(), output: &publicapi.GetStateProofOutput{
+		RequestStatus: protocol.REQUEST_STATUS_BAD_REQUEST,
+	}, err: errors.Errorf("stam")}
+
+	s := NewHttpServer(NewServerConfig(":0", false), log.DefaultTestingLogger(t), papiMock, metric.NewRegistry(), nil)
+
+	for _, url := range []string{"/api/v1/get-state-proof?key=626172", "/api/v1/get-state-proof?contract-name=foo&key=bar", "/api/v1/get-state-proof?contract-name=foo&key=626172"} {
+		req, _ := http.NewRequest("GET", url, nil)
+		rec := httptest.NewRecorder()
+		s.(*server).getStateProofHandler(rec, req)
+
+		require.Equal(t, http.StatusBadRequest, rec.Code, "should fail with 400 for %s", url)
+	}
+}
+
+type receiptsProofPublicApi struct {
+	*historicalPublicApi
+	input  *publicapi.GetTransactionReceiptsProofInput
+	output *publicapi.GetTransactionReceiptsProofOutput
+	err    error
+}
+
+func (p *receiptsProofPublicApi) GetTransactionReceiptsProof(ctx context.Context, input *publicapi.GetTransactionReceiptsProofInput) (*publicapi.GetTransactionReceiptsProofOutput, error) {
+	p.input = input
+	return p.output, p.err
+}
+
+func TestHttpServer_GetTransactionReceiptsProof_Basic(t *testing.T) {
+	blockPair := builders.BlockPair().WithHeight(7).WithTransactions(3).WithReceiptsForTransactions().Build()
+	receipts := blockPair.ResultsBlock.TransactionReceipts
+	proofHash := primitives.Sha256(bytes.Repeat([]byte{1}, 32))
+	papiMock := &receiptsProofPublicApi{historicalPublicApi: newHistoricalPublicApi(), output: &publicapi.GetTransactionReceiptsProofOutput{
+		RequestStatus:       protocol.REQUEST_STATUS_COMPLETED,
+		BlockHeight:         7,
+		TransactionReceipts: []*protocol.TransactionReceipt{receipts[0], receipts[2]},
+		Proof:               &merkle.OrderedTreeMultiProof{NumValues: 3, Indices: []int{0, 2}, Hashes: []primitives.Sha256{proofHash}},
+		ResultsBlockHeader:  blockPair.ResultsBlock.Header,
+		ResultsBlockProof:   blockPair.ResultsBlock.BlockProof,
+	}}
+
+	s := NewHttpServer(NewServerConfig(":0", false), log.DefaultTestingLogger(t), papiMock, metric.NewRegistry(), nil)
+
+	body, _ := json.Marshal(&GetTransactionReceiptsProofRequest{BlockHeight: 7, Txhashes: []string{hex.EncodeToString(receipts[2].Txhash()), hex.EncodeToString(receipts[0].Txhash())}})
+	req, _ := http.NewRequest("POST", "/api/v1/get-transaction-receipts-proof", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	s.(*server).getTransactionReceiptsProofHandler(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code, "should succeed")
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"), "should have json content type")
+	require.Equal(t, &publicapi.GetTransactionReceiptsProofInput{BlockHeight: 7, Txhashes: []primitives.Sha256{receipts[2].Txhash(), receipts[0].Txhash()}}, papiMock.input, "should request the proof of the body's transactions")
+
+	response := &GetTransactionReceiptsProofResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), response))
+	require.Equal(t, []string{hex.EncodeToString(receipts[0].Raw()), hex.EncodeToString(receipts[2].Raw())}, response.TransactionReceipts, "should have the hex encoded receipts")
+	require.Equal(t, 3, response.NumReceipts, "should have the number of receipts in the block")
+	require.Equal(t, []int{0, 2}, response.Indices, "should have the proof indices")
+	require.Equal(t, []string{hex.EncodeToString(proofHash)}, response.Hashes, "should have the hex encoded proof hashes")
+	require.Equal(t, hex.EncodeToString(blockPair.ResultsBlock.Header.Raw()), response.ResultsBlockHeader, "should have the hex encoded results block header")
+}
+
+func TestHttpServer_GetTransactionReceiptsProof_Error(t *testing.T) {
+	papiMock := &receiptsProofPublicApi{historicalPublicApi: newHistoricalPublicApi(), output: &publicapi.GetTransactionReceiptsProofOutput{
+		RequestStatus: protocol.REQUEST_STATUS_BAD_REQUEST,
+	}, err: errors.Errorf("stam")}
+
+	s := NewHttpServer(NewServerConfig(":0", false), log.DefaultTestingLogger(t), papiMock, metric.NewRegistry(), nil)
+
+	for _, body := range []string{`not json`, `{"BlockHeight": 7, "Txhashes": ["not hex"]}`, `{"BlockHeight": 7, "Txhashes": ["626172"]}`} {
+		req, _ := http.NewRequest("POST", "/api/v1/get-transaction-receipts-proof", bytes.NewReader([]byte(body)))
+		rec := httptest.NewRecorder()
+		s.(*server).getTransactionReceiptsProofHandler(rec, req)
+
+		require.Equal(t, http.StatusBadRequest, rec.Code, "should fail with 400 for %s", body)
+	}
+}
+
+type eventsPublicApi struct {
+	*historicalPublicApi
+	input  *publicapi.FindEventsInput
+	output *publicapi.FindEventsOutput
+	err    error
+}
+
+func (p *eventsPublicApi) FindEvents(ctx context.Context, input *publicapi.FindEventsInput) (*publicapi.FindEventsOutput, error) {
+	p.input = input
+	return p.output, p.err
+}
+
+func TestHttpServer_FindEvents_Basic(t *testing.T) {
+	address := builders.ClientAddressForEd25519SignerForTests(5)
+	event := (&protocol.EventBuilder{
+		ContractName:        "MyToken",
+		EventName:           "Transfer",
+		OutputArgumentArray: builders.PackedArgumentArrayEncode([]byte(address), uint64(10)),
+	}).Build()
+	papiMock := &eventsPublicApi{historicalPublicApi: newHistoricalPublicApi(), output: &publicapi.FindEventsOutput{
+		RequestStatus:   protocol.REQUEST_STATUS_COMPLETED,
+		FromBlockHeight: 3,
+		ToBlockHeight:   9,
+		Events:          []*bloom.FoundEvent{{BlockHeight: 4, Txhash: primitives.Sha256{0xab}, Event: event}},
+	}}
+
+	s := NewHttpServer(NewServerConfig(":0", false), log.DefaultTestingLogger(t), papiMock, metric.NewRegistry(), nil)
+
+	req, _ := http.NewRequest("GET", "/api/v1/find-events?event-name=Transfer&contract-name=MyToken&from-block-height=3&address="+hex.EncodeToString(address), nil)
+	rec := httptest.NewRecorder()
+	s.(*server).findEventsHandler(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code, "should succeed")
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"), "should have json content type")
+	require.Equal(t, &publicapi.FindEventsInput{
+		FromBlockHeight: 3,
+		Query:           &bloom.EventsQuery{ContractName: "MyToken", EventName: "Transfer", Address: address},
+	}, papiMock.input, "should search the query params")
+
+	response := &FindEventsResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), response))
+	require.EqualValues(t, 9, response.ToBlockHeight, "should return the last block searched")
+	require.Equal(t, []*FoundEventResponse{{
+		BlockHeight:  4,
+		Txhash:       "ab",
+		ContractName: "MyToken",
+		EventName:    "Transfer",
+		Arguments:    hex.EncodeToString(event.RawOutputArgumentArrayWithHeader()),
+	}}, response.Events, "should return the events found")
+}
+
+func TestHttpServer_FindEvents_Error(t *testing.T) {
+	papiMock := &eventsPublicApi{historicalPublicApi: newHistoricalPublicApi(), output: &publicapi.FindEventsOutput{
+		RequestStatus: protocol.REQUEST_STATUS_BAD_REQUEST,
+	}, err: errors.Errorf("stam")}
+
+	s := NewHttpServer(NewServerConfig(":0", false), log.DefaultTestingLogger(t), papiMock, metric.NewRegistry(), nil)
+
+	for _, query := range []string{"", "event-name=Transfer&address=not-hex", "event-name=Transfer&from-block-height=x", "event-name=Transfer"} {
+		req, _ := http.NewRequest("GET", "/api/v1/find-events?"+query, nil)
+		rec := httptest.NewRecorder()
+		s.(*server).findEventsHandler(rec, req)
+
+		require.Equal(t, http.StatusBadRequest, rec.Code, "should fail with 400 for %s", query)
+	}
+}
+
+func TestHttpServer_HistoricalQueries_FailWithoutResult(t *testing.T) {
+	for _, err := range []error{errors.Errorf("stam"), nil} {
+		s := NewHttpServer(NewServerConfig(":0", false), log.DefaultTestingLogger(t), &stateProofPublicApi{historicalPublicApi: newHistoricalPublicApi(), err: err}, metric.NewRegistry(), nil)
+		req, _ := http.NewRequest("GET", "/api/v1/get-state-proof?contract-name=foo&key=626172", nil)
+		rec := httptest.NewRecorder()
+		s.(*server).getStateProofHandler(rec, req)
+		require.Equal(t, http.StatusInternalServerError, rec.Code, "get state proof should fail with 500 when error is %v", err)
+
+		s = NewHttpServer(NewServerConfig(":0", false), log.DefaultTestingLogger(t), &receiptsProofPublicApi{historicalPublicApi: newHistoricalPublicApi(), err: err}, metric.NewRegistry(), nil)
+		req, _ = http.NewRequest("POST", "/api/v1/get-transaction-receipts-proof", bytes.NewReader([]byte(`{"BlockHeight": 7, "Txhashes": ["626172"]}`)))
+		rec = httptest.NewRecorder()
+		s.(*server).getTransactionReceiptsProofHandler(rec, req)
+		require.Equal(t, http.StatusInternalServerError, rec.Code, "get transaction receipts proof should fail with 500 when error is %v", err)
+
+		s = NewHttpServer(NewServerConfig(":0", false), log.DefaultTestingLogger(t), &eventsPublicApi{historicalPublicApi: newHistoricalPublicApi(), err: err}, metric.NewRegistry(), nil)
+		req, _ = http.NewRequest("GET", "/api/v1/find-events?event-name=Transfer", nil)
+		rec = httptest.NewRecorder()
+		s.(*server).findEventsHandler(rec, req)
+		require.Equal(t, http.StatusInternalServerError, rec.Code, "find events should fail with 500 when error is %v", err)
+	}
+}
+
 func TestHttpServer_Index(t *testing.T) {
 	papiMock := &services.MockPublicApi{}
 	s := makeServer(t, papiMock)
@@ -319,3 +600,37 @@ func TestHttpServer_Index(t *testing.T) {
 	require.Equal(t, http.StatusNotFound, recNotFound.Code, "should return 404")
 
 }
+
+func TestHttpServer_StateSnapshot(t *testing.T) {
+	s := makeServer(t, nil)
+	s.(*server).stateSnapshot = func() (*statestorage.StateSnapshot, error) {
+		return &statestorage.StateSnapshot{
+			VirtualChainId: 42,
+			Height:         7,
+			Timestamp:      primitives.TimestampNano(time.Now().UnixNano()),
+			MerkleRoot:     primitives.Sha256{1, 2, 3},
+		}, nil
+	}
+
+	req, _ := http.NewRequest("GET", "/state-snapshot", nil)
+	rec := httptest.NewRecorder()
+	s.(*server).stateSnapshotHandler(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code, "should succeed")
+	require.Equal(t, "7", rec.Header().Get("X-ORBS-BLOCK-HEIGHT"), "should have the snapshot block height")
+
+	snapshot, err := statestorage.ReadStateSnapshot(rec.Body)
+	require.NoError(t, err)
+	require.EqualValues(t, 7, snapshot.Height)
+	require.EqualValues(t, primitives.Sha256{1, 2, 3}, snapshot.MerkleRoot)
+}
+
+func TestHttpServer_StateSnapshot_NotServed(t *testing.T) {
+	s := makeServer(t, nil)
+
+	req, _ := http.NewRequest("GET", "/state-snapshot", nil)
+	rec := httptest.NewRecorder()
+	s.(*server).stateSnapshotHandler(rec, req)
+
+	require.Equal(t, http.StatusNotImplemented, rec.Code, "should fail with 501")
+}
@@ -139,6 +139,7 @@ func (n *Network) CreateAndStartNodes(ctx context.Context, numOfNodesToStart int
 			n.Transport,
 			node.blockPersistence,
 			node.statePersistence,
+			nil,
 			node.stateBlockHeightReporter,
 			node.transactionPoolBlockTracker,
 			node.nativeCompiler,
@@ -11,13 +11,24 @@ import (
 	"fmt"
 	"github.com/orbs-network/orbs-network-go/bootstrap/httpserver"
 	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/crypto/signer"
+	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	blockStorageAdapter "github.com/orbs-network/orbs-network-go/services/blockstorage/adapter"
 	"github.com/orbs-network/orbs-network-go/services/blockstorage/adapter/filesystem"
+	"github.com/orbs-network/orbs-network-go/services/consensuscontext"
 	ethereumAdapter "github.com/orbs-network/orbs-network-go/services/crosschainconnector/ethereum/adapter"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter/relay"
 	"github.com/orbs-network/orbs-network-go/services/gossip/adapter/tcp"
 	nativeProcessorAdapter "github.com/orbs-network/orbs-network-go/services/processor/native/adapter"
-	stateStorageAdapter "github.com/orbs-network/orbs-network-go/services/statestorage/adapter/memory"
+	"github.com/orbs-network/orbs-network-go/services/statestorage"
+	stateStorageAdapter "github.com/orbs-network/orbs-network-go/services/statestorage/adapter"
+	stateStorageFilesystemAdapter "github.com/orbs-network/orbs-network-go/services/statestorage/adapter/filesystem"
+	stateStorageMemoryAdapter "github.com/orbs-network/orbs-network-go/services/statestorage/adapter/memory"
 	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	"net/http"
 	"time"
 )
 
@@ -50,15 +61,128 @@ func NewNode(nodeConfig config.NodeConfig, logger log.Logger) Node {
 		panic(fmt.Sprintf("failed initializing blocks database, err=%s", err.Error()))
 	}
 
-	transport := tcp.NewDirectTransport(ctx, nodeConfig, nodeLogger, metricRegistry)
-	statePersistence := stateStorageAdapter.NewStatePersistence(metricRegistry)
+	statePersistence, err := newStatePersistence(ctx, nodeConfig, nodeLogger, metricRegistry)
+	if err != nil {
+		panic(fmt.Sprintf("failed initializing state database, err=%s", err.Error()))
+	}
+
+	var stateSnapshot *statestorage.StateSnapshot
+	if snapshotUrl := nodeConfig.StateStorageSnapshotUrl(); snapshotUrl != "" {
+		stateSnapshot, err = fetchStateSnapshot(snapshotUrl, nodeConfig, statePersistence, nodeLogger)
+		if err != nil {
+			panic(fmt.Sprintf("failed fetching state snapshot, err=%s", err.Error()))
+		}
+	}
+
+	err = verifyPersistedState(statePersistence, blockPersistence)
+	if err != nil {
+		panic(fmt.Sprintf("failed verifying persisted state against blocks, err=%s", err.Error()))
+	}
+
+	transportSigner, err := signer.New(nodeConfig, nodeLogger)
+	if err != nil {
+		panic(fmt.Sprintf("failed to create gossip transport signer: %s", err))
+	}
+
+	transport := newGossipTransport(ctx, nodeConfig, transportSigner, nodeLogger, metricRegistry)
 	ethereumConnection := ethereumAdapter.NewEthereumRpcConnection(nodeConfig, logger)
 	nativeCompiler := nativeProcessorAdapter.NewNativeCompiler(nodeConfig, nodeLogger, metricRegistry)
-	nodeLogic := NewNodeLogic(ctx, transport, blockPersistence, statePersistence, nil, nil, nativeCompiler, nodeLogger, metricRegistry, nodeConfig, ethereumConnection)
-	httpServer := httpserver.NewHttpServer(nodeConfig, nodeLogger, nodeLogic.PublicApi(), metricRegistry)
+	nodeLogic := NewNodeLogic(ctx, transport, blockPersistence, statePersistence, stateSnapshot, nil, nil, nativeCompiler, nodeLogger, metricRegistry, nodeConfig, ethereumConnection)
+	var serveStateSnapshot httpserver.StateSnapshotFunc
+	if nodeConfig.StateStorageServeSnapshots() {
+		serveStateSnapshot = func() (*statestorage.StateSnapshot, error) {
+			return statestorage.TakeStateSnapshot(nodeConfig.VirtualChainId(), statePersistence)
+		}
+	}
+	httpServer := httpserver.NewHttpServer(nodeConfig, nodeLogger, nodeLogic.PublicApi(), metricRegistry, serveStateSnapshot)
 
 	return &node{
 		logic:       nodeLogic,
 		OrbsProcess: NewOrbsProcess(nodeLogger, ctxCancel, httpServer),
 	}
 }
+
+// the transports of both topologies follow the elected validators, NewNodeLogic finds the listener on the transport it is given
+type electedValidatorsFollowingTransport interface {
+	adapter.Transport
+	consensuscontext.ElectedValidatorsListener
+}
+
+func newGossipTransport(ctx context.Context, nodeConfig config.NodeConfig, nodeSigner signer.Signer, logger log.Logger, metricRegistry metric.Registry) electedValidatorsFollowingTransport {
+	if nodeConfig.GossipTopology() != config.GOSSIP_TOPOLOGY_RELAY {
+		return tcp.NewDirectTransport(ctx, nodeConfig, nodeSigner, logger, metricRegistry)
+	}
+
+	link := tcp.NewDirectTransport(ctx, relay.NeighborsOnly(nodeConfig, nodeConfig.GossipRelayMaxDegree()), nodeSigner, logger, metricRegistry)
+	signers := map[string]signer.Signer{nodeConfig.NodeAddress().KeyForMap(): nodeSigner}
+	return relay.NewTransport(link, relay.MembersOf(nodeConfig.NodeAddress(), nodeConfig.GossipPeers()), signers, nodeConfig, logger, metricRegistry)
+}
+
+func newStatePersistence(ctx context.Context, nodeConfig config.NodeConfig, logger log.Logger, metricRegistry metric.Registry) (stateStorageAdapter.StatePersistence, error) {
+	if nodeConfig.StateStorageFileSystemPersistence() {
+		return stateStorageFilesystemAdapter.NewStatePersistence(ctx, nodeConfig, logger, metricRegistry)
+	}
+	if nodeConfig.StateStorageArchivalMode() {
+		return stateStorageMemoryAdapter.NewArchivalStatePersistence(metricRegistry), nil
+	}
+	return stateStorageMemoryAdapter.NewStatePersistence(metricRegistry), nil
+}
+
+// the merkle root of the persisted state can only be checked once block storage holds the block following it
+func verifyPersistedState(statePersistence stateStorageAdapter.StatePersistence, blockPersistence blockStorageAdapter.BlockPersistence) error {
+	stateHeight, _, _, err := statePersistence.ReadMetadata()
+	if err != nil {
+		return err
+	}
+	lastBlockHeight, err := blockPersistence.GetLastBlockHeight()
+	if err != nil {
+		return err
+	}
+	if stateHeight == 0 || lastBlockHeight <= stateHeight {
+		return nil
+	}
+
+	nextBlock, err := blockPersistence.GetResultsBlock(stateHeight + 1)
+	if err != nil {
+		return errors.Wrapf(err, "failed reading block %d following the persisted state", stateHeight+1)
+	}
+	return statestorage.VerifyPersistedState(statePersistence, nextBlock.Header)
+}
+
+// A node which already has state of its own ignores the snapshot. The snapshot is only checked to be self consistent here,
+// anyone reaching the url could serve it, so state storage imports it once block sync commits the block following it
+// and that block expects the merkle root of the snapshot
+func fetchStateSnapshot(url string, nodeConfig config.NodeConfig, statePersistence stateStorageAdapter.StatePersistence, logger log.Logger) (*statestorage.StateSnapshot, error) {
+	height, _, _, err := statePersistence.ReadMetadata()
+	if err != nil {
+		return nil, err
+	}
+	if height > 0 {
+		logger.Info("state already exists, skipping state snapshot import", logfields.BlockHeight(height))
+		return nil, nil
+	}
+
+	client := &http.Client{Timeout: nodeConfig.StateStorageSnapshotFetchTimeout()}
+	res, err := client.Get(url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed fetching state snapshot from %s", url)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed fetching state snapshot from %s, http status %s", url, res.Status)
+	}
+
+	snapshot, err := statestorage.ReadStateSnapshot(res.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed reading state snapshot from %s", url)
+	}
+
+	err = statestorage.VerifyStateSnapshot(snapshot, nodeConfig.VirtualChainId())
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("fetched state snapshot, importing it once the following block is synced", log.String("url", url), logfields.BlockHeight(snapshot.Height))
+	return snapshot, nil
+}
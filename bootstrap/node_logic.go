@@ -8,7 +8,9 @@ package bootstrap
 
 import (
 	"context"
+	"fmt"
 	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/crypto/signer"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
 	"github.com/orbs-network/orbs-network-go/services/blockstorage"
 	blockStorageAdapter "github.com/orbs-network/orbs-network-go/services/blockstorage/adapter"
@@ -47,6 +49,7 @@ func NewNodeLogic(
 	gossipTransport gossipAdapter.Transport,
 	blockPersistence blockStorageAdapter.BlockPersistence,
 	statePersistence stateStorageAdapter.StatePersistence,
+	stateSnapshot *statestorage.StateSnapshot,
 	stateBlockHeightReporter stateStorageAdapter.BlockHeightReporter,
 	transactionPoolBlockHeightReporter transactionpool.BlockHeightReporter,
 	nativeCompiler nativeProcessorAdapter.Compiler,
@@ -58,6 +61,11 @@ func NewNodeLogic(
 
 	config.NewValidator(logger).ValidateNodeLogic(nodeConfig)
 
+	nodeSigner, err := signer.New(nodeConfig, logger)
+	if err != nil {
+		panic(fmt.Sprintf("failed to create node signer: %s", err))
+	}
+
 	processors := make(map[protocol.ProcessorType]services.Processor)
 	processors[protocol.PROCESSOR_TYPE_NATIVE] = native.NewNativeProcessor(nativeCompiler, nodeConfig, logger, metricRegistry)
 
@@ -65,16 +73,22 @@ func NewNodeLogic(
 	crosschainConnectors[protocol.CROSSCHAIN_CONNECTOR_TYPE_ETHEREUM] = ethereum.NewEthereumCrosschainConnector(ethereumConnection, nodeConfig, logger, metricRegistry)
 
 	gossipService := gossip.NewGossip(gossipTransport, nodeConfig, logger)
-	stateStorageService := statestorage.NewStateStorage(nodeConfig, statePersistence, stateBlockHeightReporter, logger, metricRegistry)
-	virtualMachineService := virtualmachine.NewVirtualMachine(stateStorageService, processors, crosschainConnectors, logger)
-	transactionPoolService := transactionpool.NewTransactionPool(ctx, gossipService, virtualMachineService, transactionPoolBlockHeightReporter, nodeConfig, logger, metricRegistry)
+	var stateStorageService services.StateStorage
+	if stateSnapshot != nil {
+		stateStorageService = statestorage.NewStateStorageImportingSnapshot(nodeConfig, statePersistence, stateSnapshot, stateBlockHeightReporter, logger, metricRegistry)
+	} else {
+		stateStorageService = statestorage.NewStateStorage(nodeConfig, statePersistence, stateBlockHeightReporter, logger, metricRegistry)
+	}
+	virtualMachineService := virtualmachine.NewVirtualMachine(stateStorageService, processors, crosschainConnectors, nodeConfig, logger, metricRegistry)
+	transactionPoolService := transactionpool.NewTransactionPool(ctx, gossipService, virtualMachineService, transactionPoolBlockHeightReporter, nodeSigner, nodeConfig, logger, metricRegistry)
 	serviceSyncCommitters := []servicesync.BlockPairCommitter{servicesync.NewStateStorageCommitter(stateStorageService), servicesync.NewTxPoolCommitter(transactionPoolService)}
 	blockStorageService := blockstorage.NewBlockStorage(ctx, nodeConfig, blockPersistence, gossipService, logger, metricRegistry, serviceSyncCommitters)
-	publicApiService := publicapi.NewPublicApi(nodeConfig, transactionPoolService, virtualMachineService, blockStorageService, logger, metricRegistry)
-	consensusContextService := consensuscontext.NewConsensusContext(transactionPoolService, virtualMachineService, stateStorageService, nodeConfig, logger, metricRegistry)
+	publicApiService := publicapi.NewPublicApi(nodeConfig, transactionPoolService, virtualMachineService, blockStorageService, stateStorageService, logger, metricRegistry)
+	electedValidatorsListener, _ := gossipTransport.(consensuscontext.ElectedValidatorsListener) // the in-memory transports of tests ignore elections
+	consensusContextService := consensuscontext.NewConsensusContext(transactionPoolService, virtualMachineService, stateStorageService, electedValidatorsListener, nodeConfig, logger, metricRegistry)
 
-	benchmarkConsensusAlgo := benchmarkconsensus.NewBenchmarkConsensusAlgo(ctx, gossipService, blockStorageService, consensusContextService, logger, nodeConfig, metricRegistry)
-	leanHelixAlgo := leanhelixconsensus.NewLeanHelixConsensusAlgo(ctx, gossipService, blockStorageService, consensusContextService, logger, nodeConfig, metricRegistry)
+	benchmarkConsensusAlgo := benchmarkconsensus.NewBenchmarkConsensusAlgo(ctx, gossipService, blockStorageService, consensusContextService, nodeSigner, logger, nodeConfig, metricRegistry)
+	leanHelixAlgo := leanhelixconsensus.NewLeanHelixConsensusAlgo(ctx, gossipService, blockStorageService, consensusContextService, nodeSigner, logger, nodeConfig, metricRegistry)
 
 	consensusAlgos := make([]services.ConsensusAlgo, 0)
 	consensusAlgos = append(consensusAlgos, benchmarkConsensusAlgo)
@@ -7,6 +7,7 @@
 package config
 
 import (
+	"github.com/orbs-network/orbs-network-go/crypto/keys"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/protocol"
 	"github.com/orbs-network/orbs-spec/types/go/protocol/consensus"
@@ -32,6 +33,9 @@ type NodeConfig interface {
 	LeanHelixConsensusMinimumCommitteeSize() uint32
 	LeanHelixConsensusMaximumCommitteeSize() uint32
 	LeanHelixShowDebug() bool
+	LeanHelixBlsSignatures() bool // random seeds only, block proofs keep an ECDSA signature per committee member
+	NodeBlsPrivateKey() keys.BlsBn256PrivateKey
+	LeanHelixBlsGroupPublicKey() keys.BlsBn256PublicKey
 
 	// benchmark consensus
 	BenchmarkConsensusRetryInterval() time.Duration
@@ -43,12 +47,21 @@ type NodeConfig interface {
 	BlockSyncNoCommitInterval() time.Duration
 	BlockSyncCollectResponseTimeout() time.Duration
 	BlockSyncCollectChunksTimeout() time.Duration
-	BlockStorageTransactionReceiptQueryTimestampGrace() time.Duration
 	BlockStorageFileSystemDataDir() string
 	BlockStorageFileSystemMaxBlockSizeInBytes() uint32
+	BlockStorageFileSystemMaxSegmentSizeInBytes() uint32
+	BlockStorageFileSystemPruningKeepBlocks() uint32
+	BlockStorageFileSystemPruningKeepWindow() time.Duration
+	BlockStorageFileSystemCompression() bool
 
 	// state storage
 	StateStorageHistorySnapshotNum() uint32
+	StateStorageFileSystemPersistence() bool
+	StateStorageFileSystemDataDir() string
+	StateStorageSnapshotUrl() string
+	StateStorageSnapshotFetchTimeout() time.Duration
+	StateStorageServeSnapshots() bool
+	StateStorageArchivalMode() bool
 
 	// block tracker
 	BlockTrackerGraceDistance() uint32
@@ -73,6 +86,13 @@ type NodeConfig interface {
 	GossipConnectionKeepAliveInterval() time.Duration
 	GossipNetworkTimeout() time.Duration
 	GossipReconnectInterval() time.Duration
+	GossipTopology() string
+	GossipRelayMaxDegree() uint32
+	GossipRelayMaxHops() uint32
+	GossipCompressionMinSizeBytes() uint32
+
+	// virtual machine
+	VirtualMachineSignatureVerificationWorkers() uint32
 
 	// public api
 	PublicApiSendTransactionTimeout() time.Duration
@@ -101,6 +121,10 @@ type NodeConfig interface {
 
 	// NTP Network Time Protocol
 	NTPEndpoint() string
+
+	// signer, the node private key is used in-process when no endpoint is set
+	SignerEndpoint() string
+	SignerRequestTimeout() time.Duration
 }
 
 type OverridableConfig interface {
@@ -119,6 +143,8 @@ type mutableNodeConfig interface {
 	SetGossipPeers(peers map[string]GossipPeer) mutableNodeConfig
 	SetNodeAddress(key primitives.NodeAddress) mutableNodeConfig
 	SetNodePrivateKey(key primitives.EcdsaSecp256K1PrivateKey) mutableNodeConfig
+	SetNodeBlsPrivateKey(key keys.BlsBn256PrivateKey) mutableNodeConfig
+	SetLeanHelixBlsGroupPublicKey(key keys.BlsBn256PublicKey) mutableNodeConfig
 	SetBenchmarkConsensusConstantLeader(key primitives.NodeAddress) mutableNodeConfig
 	SetActiveConsensusAlgo(algoType consensus.ConsensusAlgoType) mutableNodeConfig
 	MergeWithFileConfig(source string) (mutableNodeConfig, error)
@@ -131,14 +157,22 @@ type BlockStorageConfig interface {
 	BlockSyncNoCommitInterval() time.Duration
 	BlockSyncCollectResponseTimeout() time.Duration
 	BlockSyncCollectChunksTimeout() time.Duration
-	BlockStorageTransactionReceiptQueryTimestampGrace() time.Duration
-	TransactionExpirationWindow() time.Duration
 	BlockTrackerGraceTimeout() time.Duration
 }
 
 type FilesystemBlockPersistenceConfig interface {
 	BlockStorageFileSystemDataDir() string
 	BlockStorageFileSystemMaxBlockSizeInBytes() uint32
+	BlockStorageFileSystemMaxSegmentSizeInBytes() uint32
+	BlockStorageFileSystemPruningKeepBlocks() uint32
+	BlockStorageFileSystemPruningKeepWindow() time.Duration
+	BlockStorageFileSystemCompression() bool
+	VirtualChainId() primitives.VirtualChainId
+}
+
+type FilesystemStatePersistenceConfig interface {
+	StateStorageFileSystemDataDir() string
+	StateStorageArchivalMode() bool
 	VirtualChainId() primitives.VirtualChainId
 }
 
@@ -149,6 +183,12 @@ type GossipTransportConfig interface {
 	GossipConnectionKeepAliveInterval() time.Duration
 	GossipNetworkTimeout() time.Duration
 	GossipReconnectInterval() time.Duration
+	GossipCompressionMinSizeBytes() uint32
+}
+
+type GossipRelayConfig interface {
+	GossipRelayMaxDegree() uint32
+	GossipRelayMaxHops() uint32
 }
 
 // Config based on https://github.com/orbs-network/orbs-spec/blob/master/behaviors/config/services.md#consensus-context
@@ -161,6 +201,10 @@ type ConsensusContextConfig interface {
 	ConsensusContextSystemTimestampAllowedJitter() time.Duration
 }
 
+type VirtualMachineConfig interface {
+	VirtualMachineSignatureVerificationWorkers() uint32
+}
+
 type PublicApiConfig interface {
 	PublicApiSendTransactionTimeout() time.Duration
 	PublicApiNodeSyncWarningTime() time.Duration
@@ -169,13 +213,13 @@ type PublicApiConfig interface {
 
 type StateStorageConfig interface {
 	StateStorageHistorySnapshotNum() uint32
+	StateStorageArchivalMode() bool
 	BlockTrackerGraceDistance() uint32
 	BlockTrackerGraceTimeout() time.Duration
 }
 
 type TransactionPoolConfig interface {
 	NodeAddress() primitives.NodeAddress
-	NodePrivateKey() primitives.EcdsaSecp256K1PrivateKey
 	VirtualChainId() primitives.VirtualChainId
 	BlockTrackerGraceDistance() uint32
 	BlockTrackerGraceTimeout() time.Duration
@@ -202,10 +246,13 @@ type NativeProcessorConfig interface {
 
 type LeanHelixConsensusConfig interface {
 	NodeAddress() primitives.NodeAddress
-	NodePrivateKey() primitives.EcdsaSecp256K1PrivateKey
 	LeanHelixConsensusRoundTimeoutInterval() time.Duration
 	LeanHelixConsensusMaximumCommitteeSize() uint32
 	LeanHelixShowDebug() bool
+	LeanHelixBlsSignatures() bool
+	NodeBlsPrivateKey() keys.BlsBn256PrivateKey
+	LeanHelixBlsGroupPublicKey() keys.BlsBn256PublicKey
+	GenesisValidatorNodes() map[string]ValidatorNode
 	ActiveConsensusAlgo() consensus.ConsensusAlgoType
 	VirtualChainId() primitives.VirtualChainId
 	NetworkType() protocol.SignerNetworkType
@@ -213,6 +260,8 @@ type LeanHelixConsensusConfig interface {
 
 type ValidatorNode interface {
 	NodeAddress() primitives.NodeAddress
+	BlsPublicKey() keys.BlsBn256PublicKey // empty unless configured
+	BlsShareIndex() uint32
 }
 
 type GossipPeer interface {
@@ -30,13 +30,15 @@ func (c *config) MergeWithFileConfig(source string) (mutableNodeConfig, error) {
 
 func (c *config) Clone() mutableNodeConfig {
 	return &config{
-		activeConsensusAlgo:     c.activeConsensusAlgo,
-		constantConsensusLeader: c.constantConsensusLeader,
-		genesisValidatorNodes:   c.genesisValidatorNodes,
-		gossipPeers:             c.gossipPeers,
-		nodePrivateKey:          c.nodePrivateKey,
-		nodeAddress:             c.nodeAddress,
-		kv:                      cloneMap(c.kv),
+		activeConsensusAlgo:        c.activeConsensusAlgo,
+		constantConsensusLeader:    c.constantConsensusLeader,
+		genesisValidatorNodes:      c.genesisValidatorNodes,
+		gossipPeers:                c.gossipPeers,
+		nodePrivateKey:             c.nodePrivateKey,
+		nodeBlsPrivateKey:          c.nodeBlsPrivateKey,
+		leanHelixBlsGroupPublicKey: c.leanHelixBlsGroupPublicKey,
+		nodeAddress:                c.nodeAddress,
+		kv:                         cloneMap(c.kv),
 	}
 }
 
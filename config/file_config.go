@@ -10,6 +10,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"github.com/orbs-network/orbs-network-go/crypto/keys"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/protocol/consensus"
 	"strconv"
@@ -47,11 +48,21 @@ func parseUint32(f64 float64) (uint32, error) {
 	}
 }
 
+// validators are listed by address, or as objects when their BLS public key share is configured as well
 func parseNodes(value interface{}) (nodes map[string]ValidatorNode, err error) {
 	nodes = make(map[string]ValidatorNode)
 
 	if nodeList, ok := value.([]interface{}); ok {
 		for _, item := range nodeList {
+			if kv, ok := item.(map[string]interface{}); ok {
+				node, err := parseNodeWithBlsKey(kv)
+				if err != nil {
+					return nodes, err
+				}
+				nodes[node.NodeAddress().KeyForMap()] = node
+				continue
+			}
+
 			address := item.(string)
 
 			if nodeAddress, err := hex.DecodeString(address); err != nil {
@@ -69,6 +80,29 @@ func parseNodes(value interface{}) (nodes map[string]ValidatorNode, err error) {
 	return nodes, nil
 }
 
+func parseNodeWithBlsKey(kv map[string]interface{}) (ValidatorNode, error) {
+	address, _ := kv["address"].(string)
+	nodeAddress, err := hex.DecodeString(address)
+	if err != nil {
+		return nil, err
+	}
+
+	blsPublicKeyHex, _ := kv["bls-public-key"].(string)
+	blsPublicKey, err := hex.DecodeString(blsPublicKeyHex)
+	if err != nil {
+		return nil, err
+	}
+
+	var blsShareIndex uint32
+	if index, ok := kv["bls-share-index"].(float64); ok {
+		if blsShareIndex, err = parseUint32(index); err != nil {
+			return nil, err
+		}
+	}
+
+	return NewHardCodedValidatorNodeWithBlsKey(nodeAddress, blsPublicKey, blsShareIndex), nil
+}
+
 func parsePeers(value interface{}) (peers map[string]GossipPeer, err error) {
 	peers = make(map[string]GossipPeer)
 
@@ -99,6 +133,8 @@ func parsePeers(value interface{}) (peers map[string]GossipPeer, err error) {
 }
 
 func populateConfig(cfg mutableNodeConfig, data map[string]interface{}) error {
+	var keystorePath, keystorePassphraseFile string
+
 	for key, value := range data {
 		var duration time.Duration
 		var numericValue uint32
@@ -153,6 +189,35 @@ func populateConfig(cfg mutableNodeConfig, data map[string]interface{}) error {
 			continue
 		}
 
+		// the keystore is decrypted once all keys are read, its passphrase file may come after it
+		if key == "node-keystore-path" {
+			keystorePath, _ = value.(string)
+			continue
+		}
+
+		if key == "node-keystore-passphrase-file" {
+			keystorePassphraseFile, _ = value.(string)
+			continue
+		}
+
+		if key == "node-bls-private-key" {
+			var privateKey []byte
+			if privateKey, err = hex.DecodeString(value.(string)); err != nil {
+				return fmt.Errorf("could not decode value for config key %s: %s", key, err)
+			}
+			cfg.SetNodeBlsPrivateKey(keys.BlsBn256PrivateKey(privateKey))
+			continue
+		}
+
+		if key == "lean-helix-bls-group-public-key" {
+			var publicKey []byte
+			if publicKey, err = hex.DecodeString(value.(string)); err != nil {
+				return fmt.Errorf("could not decode value for config key %s: %s", key, err)
+			}
+			cfg.SetLeanHelixBlsGroupPublicKey(keys.BlsBn256PublicKey(publicKey))
+			continue
+		}
+
 		if key == "ethereum-finality-blocks-component" {
 			var finalityBlocksComponent uint32
 			finalityBlocksComponent, err = parseUint32(value.(float64))
@@ -169,7 +234,9 @@ func populateConfig(cfg mutableNodeConfig, data map[string]interface{}) error {
 
 		if key == "genesis-validator-addresses" {
 			var nodes map[string]ValidatorNode
-			nodes, err = parseNodes(value)
+			if nodes, err = parseNodes(value); err != nil {
+				return fmt.Errorf("could not decode value for config key %s: %s", key, err)
+			}
 			cfg.SetGenesisValidatorNodes(nodes)
 			continue
 		}
@@ -190,5 +257,11 @@ func populateConfig(cfg mutableNodeConfig, data map[string]interface{}) error {
 		}
 	}
 
+	if keystorePath != "" {
+		if err := loadNodeKeystore(cfg, keystorePath, keystorePassphraseFile); err != nil {
+			return fmt.Errorf("could not load node keystore: %s", err)
+		}
+	}
+
 	return nil
 }
@@ -8,6 +8,7 @@ package config
 
 import (
 	"encoding/hex"
+	"fmt"
 	"github.com/orbs-network/orbs-network-go/test/crypto/keys"
 	"github.com/stretchr/testify/require"
 	"io/ioutil"
@@ -118,6 +119,40 @@ func TestSetGenesisValidatorNodes(t *testing.T) {
 	require.EqualValues(t, node1, cfg.GenesisValidatorNodes()[keyPair.NodeAddress().KeyForMap()])
 }
 
+func TestSetGenesisValidatorNodesWithBlsKeys(t *testing.T) {
+	thresholdKeys := keys.BlsBn256ThresholdKeysForTests(2, 2)
+	cfg, err := newEmptyFileConfig(fmt.Sprintf(`{
+	"lean-helix-bls-signatures": true,
+	"node-bls-private-key": "%s",
+	"lean-helix-bls-group-public-key": "%s",
+	"genesis-validator-addresses": [
+    {"address":"a328846cd5b4979d68a8c58a9bdfeee657b34de7","bls-public-key":"%s","bls-share-index":1},
+    {"address":"d27e2e7398e2582f63d0800330010b3e58952ff6","bls-public-key":"%s","bls-share-index":2}
+	]
+}`, thresholdKeys.Shares[0].PrivateKeyHex(), hex.EncodeToString(thresholdKeys.GroupPublicKey), thresholdKeys.Shares[0].PublicKeyHex(), thresholdKeys.Shares[1].PublicKeyHex()))
+
+	require.NotNil(t, cfg)
+	require.NoError(t, err)
+	require.True(t, cfg.LeanHelixBlsSignatures())
+	require.EqualValues(t, thresholdKeys.Shares[0].PrivateKey(), cfg.NodeBlsPrivateKey())
+	require.EqualValues(t, thresholdKeys.GroupPublicKey, cfg.LeanHelixBlsGroupPublicKey())
+	require.EqualValues(t, 2, len(cfg.GenesisValidatorNodes()))
+
+	node2 := cfg.GenesisValidatorNodes()[keys.EcdsaSecp256K1KeyPairForTests(1).NodeAddress().KeyForMap()]
+	require.EqualValues(t, thresholdKeys.Shares[1].PublicKey(), node2.BlsPublicKey())
+	require.EqualValues(t, 2, node2.BlsShareIndex())
+}
+
+func TestSetGenesisValidatorNodesWithInvalidBlsKey(t *testing.T) {
+	_, err := newEmptyFileConfig(`{
+	"genesis-validator-addresses": [
+    {"address":"a328846cd5b4979d68a8c58a9bdfeee657b34de7","bls-public-key":"not hex","bls-share-index":1}
+	]
+}`)
+
+	require.Error(t, err)
+}
+
 func TestSetGossipPeers(t *testing.T) {
 	cfg, err := newEmptyFileConfig(`{
 	"federation-nodes": [
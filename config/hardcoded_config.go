@@ -7,6 +7,7 @@
 package config
 
 import (
+	"github.com/orbs-network/orbs-network-go/crypto/keys"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/protocol"
 	"github.com/orbs-network/orbs-spec/types/go/protocol/consensus"
@@ -14,7 +15,9 @@ import (
 )
 
 type hardCodedValidatorNode struct {
-	nodeAddress primitives.NodeAddress
+	nodeAddress   primitives.NodeAddress
+	blsPublicKey  keys.BlsBn256PublicKey
+	blsShareIndex uint32
 }
 
 type hardCodedGossipPeer struct {
@@ -30,13 +33,15 @@ type NodeConfigValue struct {
 }
 
 type config struct {
-	kv                      map[string]NodeConfigValue
-	genesisValidatorNodes   map[string]ValidatorNode
-	gossipPeers             map[string]GossipPeer
-	nodeAddress             primitives.NodeAddress
-	nodePrivateKey          primitives.EcdsaSecp256K1PrivateKey
-	constantConsensusLeader primitives.NodeAddress
-	activeConsensusAlgo     consensus.ConsensusAlgoType
+	kv                         map[string]NodeConfigValue
+	genesisValidatorNodes      map[string]ValidatorNode
+	gossipPeers                map[string]GossipPeer
+	nodeAddress                primitives.NodeAddress
+	nodePrivateKey             primitives.EcdsaSecp256K1PrivateKey
+	nodeBlsPrivateKey          keys.BlsBn256PrivateKey
+	leanHelixBlsGroupPublicKey keys.BlsBn256PublicKey
+	constantConsensusLeader    primitives.NodeAddress
+	activeConsensusAlgo        consensus.ConsensusAlgoType
 }
 
 const (
@@ -51,18 +56,23 @@ const (
 	LEAN_HELIX_CONSENSUS_MINIMUM_COMMITTEE_SIZE = "LEAN_HELIX_CONSENSUS_MINIMUM_COMMITTEE_SIZE"
 	LEAN_HELIX_CONSENSUS_MAXIMUM_COMMITTEE_SIZE = "LEAN_HELIX_CONSENSUS_MAXIMUM_COMMITTEE_SIZE"
 	LEAN_HELIX_SHOW_DEBUG                       = "LEAN_HELIX_SHOW_DEBUG"
+	LEAN_HELIX_BLS_SIGNATURES                   = "LEAN_HELIX_BLS_SIGNATURES"
 
 	BLOCK_SYNC_NUM_BLOCKS_IN_BATCH      = "BLOCK_SYNC_NUM_BLOCKS_IN_BATCH"
 	BLOCK_SYNC_NO_COMMIT_INTERVAL       = "BLOCK_SYNC_NO_COMMIT_INTERVAL"
 	BLOCK_SYNC_COLLECT_RESPONSE_TIMEOUT = "BLOCK_SYNC_COLLECT_RESPONSE_TIMEOUT"
 	BLOCK_SYNC_COLLECT_CHUNKS_TIMEOUT   = "BLOCK_SYNC_COLLECT_CHUNKS_TIMEOUT"
 
-	BLOCK_STORAGE_TRANSACTION_RECEIPT_QUERY_TIMESTAMP_GRACE = "BLOCK_STORAGE_TRANSACTION_RECEIPT_QUERY_TIMESTAMP_GRACE"
-
 	CONSENSUS_CONTEXT_MAXIMUM_TRANSACTIONS_IN_BLOCK   = "CONSENSUS_CONTEXT_MAXIMUM_TRANSACTIONS_IN_BLOCK"
 	CONSENSUS_CONTEXT_SYSTEM_TIMESTAMP_ALLOWED_JITTER = "CONSENSUS_CONTEXT_SYSTEM_TIMESTAMP_ALLOWED_JITTER"
 
-	STATE_STORAGE_HISTORY_SNAPSHOT_NUM = "STATE_STORAGE_HISTORY_SNAPSHOT_NUM"
+	STATE_STORAGE_HISTORY_SNAPSHOT_NUM    = "STATE_STORAGE_HISTORY_SNAPSHOT_NUM"
+	STATE_STORAGE_FILE_SYSTEM_PERSISTENCE = "STATE_STORAGE_FILE_SYSTEM_PERSISTENCE"
+	STATE_STORAGE_FILE_SYSTEM_DATA_DIR    = "STATE_STORAGE_FILE_SYSTEM_DATA_DIR"
+	STATE_STORAGE_SNAPSHOT_URL            = "STATE_STORAGE_SNAPSHOT_URL"
+	STATE_STORAGE_SNAPSHOT_FETCH_TIMEOUT  = "STATE_STORAGE_SNAPSHOT_FETCH_TIMEOUT"
+	STATE_STORAGE_SERVE_SNAPSHOTS         = "STATE_STORAGE_SERVE_SNAPSHOTS"
+	STATE_STORAGE_ARCHIVAL_MODE           = "STATE_STORAGE_ARCHIVAL_MODE"
 
 	BLOCK_TRACKER_GRACE_DISTANCE = "BLOCK_TRACKER_GRACE_DISTANCE"
 	BLOCK_TRACKER_GRACE_TIMEOUT  = "BLOCK_TRACKER_GRACE_TIMEOUT"
@@ -81,6 +91,12 @@ const (
 	GOSSIP_CONNECTION_KEEP_ALIVE_INTERVAL = "GOSSIP_CONNECTION_KEEP_ALIVE_INTERVAL"
 	GOSSIP_NETWORK_TIMEOUT                = "GOSSIP_NETWORK_TIMEOUT"
 	GOSSIP_RECONNECT_INTERVAL             = "GOSSIP_RECONNECT_INTERVAL"
+	GOSSIP_TOPOLOGY                       = "GOSSIP_TOPOLOGY"
+	GOSSIP_RELAY_MAX_DEGREE               = "GOSSIP_RELAY_MAX_DEGREE"
+	GOSSIP_RELAY_MAX_HOPS                 = "GOSSIP_RELAY_MAX_HOPS"
+	GOSSIP_COMPRESSION_MIN_SIZE_BYTES     = "GOSSIP_COMPRESSION_MIN_SIZE_BYTES"
+
+	VIRTUAL_MACHINE_SIGNATURE_VERIFICATION_WORKERS = "VIRTUAL_MACHINE_SIGNATURE_VERIFICATION_WORKERS"
 
 	PUBLIC_API_SEND_TRANSACTION_TIMEOUT = "PUBLIC_API_SEND_TRANSACTION_TIMEOUT"
 	PUBLIC_API_NODE_SYNC_WARNING_TIME   = "PUBLIC_API_NODE_SYNC_WARNING_TIME"
@@ -99,14 +115,27 @@ const (
 	LOGGER_FILE_TRUNCATION_INTERVAL = "LOGGER_FILE_TRUNCATION_INTERVAL"
 	LOGGER_FULL_LOG                 = "LOGGER_FULL_LOG"
 
-	BLOCK_STORAGE_FILE_SYSTEM_DATA_DIR                = "BLOCK_STORAGE_FILE_SYSTEM_DATA_DIR"
-	BLOCK_STORAGE_FILE_SYSTEM_MAX_BLOCK_SIZE_IN_BYTES = "BLOCK_STORAGE_FILE_SYSTEM_MAX_BLOCK_SIZE_IN_BYTES"
+	BLOCK_STORAGE_FILE_SYSTEM_DATA_DIR                  = "BLOCK_STORAGE_FILE_SYSTEM_DATA_DIR"
+	BLOCK_STORAGE_FILE_SYSTEM_MAX_BLOCK_SIZE_IN_BYTES   = "BLOCK_STORAGE_FILE_SYSTEM_MAX_BLOCK_SIZE_IN_BYTES"
+	BLOCK_STORAGE_FILE_SYSTEM_MAX_SEGMENT_SIZE_IN_BYTES = "BLOCK_STORAGE_FILE_SYSTEM_MAX_SEGMENT_SIZE_IN_BYTES"
+	BLOCK_STORAGE_FILE_SYSTEM_PRUNING_KEEP_BLOCKS       = "BLOCK_STORAGE_FILE_SYSTEM_PRUNING_KEEP_BLOCKS"
+	BLOCK_STORAGE_FILE_SYSTEM_PRUNING_KEEP_WINDOW       = "BLOCK_STORAGE_FILE_SYSTEM_PRUNING_KEEP_WINDOW"
+	BLOCK_STORAGE_FILE_SYSTEM_COMPRESSION               = "BLOCK_STORAGE_FILE_SYSTEM_COMPRESSION"
 
 	PROFILING = "PROFILING"
 
 	HTTP_ADDRESS = "HTTP_ADDRESS"
 
 	NTP_ENDPOINT = "NTP_ENDPOINT"
+
+	SIGNER_ENDPOINT        = "SIGNER_ENDPOINT"
+	SIGNER_REQUEST_TIMEOUT = "SIGNER_REQUEST_TIMEOUT"
+)
+
+// values of GOSSIP_TOPOLOGY
+const (
+	GOSSIP_TOPOLOGY_FULL_MESH = "full-mesh" // a direct connection to every gossip peer
+	GOSSIP_TOPOLOGY_RELAY     = "relay"     // connections to a bounded number of neighbors which forward messages multi-hop
 )
 
 func NewHardCodedValidatorNode(nodeAddress primitives.NodeAddress) ValidatorNode {
@@ -115,6 +144,15 @@ func NewHardCodedValidatorNode(nodeAddress primitives.NodeAddress) ValidatorNode
 	}
 }
 
+// validators of a committee signing random seeds with BLS hold the share of the group key at their share index
+func NewHardCodedValidatorNodeWithBlsKey(nodeAddress primitives.NodeAddress, blsPublicKey keys.BlsBn256PublicKey, blsShareIndex uint32) ValidatorNode {
+	return &hardCodedValidatorNode{
+		nodeAddress:   nodeAddress,
+		blsPublicKey:  blsPublicKey,
+		blsShareIndex: blsShareIndex,
+	}
+}
+
 func NewHardCodedGossipPeer(gossipPort int, gossipEndpoint string) GossipPeer {
 	return &hardCodedGossipPeer{
 		gossipPort:     gossipPort,
@@ -157,6 +195,16 @@ func (c *config) SetNodePrivateKey(key primitives.EcdsaSecp256K1PrivateKey) muta
 	return c
 }
 
+func (c *config) SetNodeBlsPrivateKey(key keys.BlsBn256PrivateKey) mutableNodeConfig {
+	c.nodeBlsPrivateKey = key
+	return c
+}
+
+func (c *config) SetLeanHelixBlsGroupPublicKey(key keys.BlsBn256PublicKey) mutableNodeConfig {
+	c.leanHelixBlsGroupPublicKey = key
+	return c
+}
+
 func (c *config) SetBenchmarkConsensusConstantLeader(key primitives.NodeAddress) mutableNodeConfig {
 	c.constantConsensusLeader = key
 	return c
@@ -181,6 +229,14 @@ func (c *hardCodedValidatorNode) NodeAddress() primitives.NodeAddress {
 	return c.nodeAddress
 }
 
+func (c *hardCodedValidatorNode) BlsPublicKey() keys.BlsBn256PublicKey {
+	return c.blsPublicKey
+}
+
+func (c *hardCodedValidatorNode) BlsShareIndex() uint32 {
+	return c.blsShareIndex
+}
+
 func (c *hardCodedGossipPeer) GossipPort() int {
 	return c.gossipPort
 }
@@ -197,6 +253,14 @@ func (c *config) NodePrivateKey() primitives.EcdsaSecp256K1PrivateKey {
 	return c.nodePrivateKey
 }
 
+func (c *config) NodeBlsPrivateKey() keys.BlsBn256PrivateKey {
+	return c.nodeBlsPrivateKey
+}
+
+func (c *config) LeanHelixBlsGroupPublicKey() keys.BlsBn256PublicKey {
+	return c.leanHelixBlsGroupPublicKey
+}
+
 func (c *config) ProtocolVersion() primitives.ProtocolVersion {
 	return primitives.ProtocolVersion(c.kv[PROTOCOL_VERSION].Uint32Value)
 }
@@ -237,6 +301,10 @@ func (c *config) LeanHelixShowDebug() bool {
 	return c.kv[LEAN_HELIX_SHOW_DEBUG].BoolValue
 }
 
+func (c *config) LeanHelixBlsSignatures() bool {
+	return c.kv[LEAN_HELIX_BLS_SIGNATURES].BoolValue
+}
+
 func (c *config) BlockSyncNumBlocksInBatch() uint32 {
 	return c.kv[BLOCK_SYNC_NUM_BLOCKS_IN_BATCH].Uint32Value
 }
@@ -249,10 +317,6 @@ func (c *config) BlockSyncCollectResponseTimeout() time.Duration {
 	return c.kv[BLOCK_SYNC_COLLECT_RESPONSE_TIMEOUT].DurationValue
 }
 
-func (c *config) BlockStorageTransactionReceiptQueryTimestampGrace() time.Duration {
-	return c.kv[BLOCK_STORAGE_TRANSACTION_RECEIPT_QUERY_TIMESTAMP_GRACE].DurationValue
-}
-
 func (c *config) ConsensusContextMaximumTransactionsInBlock() uint32 {
 	return c.kv[CONSENSUS_CONTEXT_MAXIMUM_TRANSACTIONS_IN_BLOCK].Uint32Value
 }
@@ -265,6 +329,30 @@ func (c *config) StateStorageHistorySnapshotNum() uint32 {
 	return c.kv[STATE_STORAGE_HISTORY_SNAPSHOT_NUM].Uint32Value
 }
 
+func (c *config) StateStorageFileSystemPersistence() bool {
+	return c.kv[STATE_STORAGE_FILE_SYSTEM_PERSISTENCE].BoolValue
+}
+
+func (c *config) StateStorageFileSystemDataDir() string {
+	return c.kv[STATE_STORAGE_FILE_SYSTEM_DATA_DIR].StringValue
+}
+
+func (c *config) StateStorageSnapshotUrl() string {
+	return c.kv[STATE_STORAGE_SNAPSHOT_URL].StringValue
+}
+
+func (c *config) StateStorageSnapshotFetchTimeout() time.Duration {
+	return c.kv[STATE_STORAGE_SNAPSHOT_FETCH_TIMEOUT].DurationValue
+}
+
+func (c *config) StateStorageServeSnapshots() bool {
+	return c.kv[STATE_STORAGE_SERVE_SNAPSHOTS].BoolValue
+}
+
+func (c *config) StateStorageArchivalMode() bool {
+	return c.kv[STATE_STORAGE_ARCHIVAL_MODE].BoolValue
+}
+
 func (c *config) BlockTrackerGraceDistance() uint32 {
 	return c.kv[BLOCK_TRACKER_GRACE_DISTANCE].Uint32Value
 }
@@ -309,6 +397,10 @@ func (c *config) TransactionPoolNodeSyncRejectTime() time.Duration {
 	return c.kv[TRANSACTION_POOL_NODE_SYNC_REJECT_TIME].DurationValue
 }
 
+func (c *config) VirtualMachineSignatureVerificationWorkers() uint32 {
+	return c.kv[VIRTUAL_MACHINE_SIGNATURE_VERIFICATION_WORKERS].Uint32Value
+}
+
 func (c *config) PublicApiSendTransactionTimeout() time.Duration {
 	return c.kv[PUBLIC_API_SEND_TRANSACTION_TIMEOUT].DurationValue
 }
@@ -345,6 +437,22 @@ func (c *config) GossipReconnectInterval() time.Duration {
 	return c.kv[GOSSIP_RECONNECT_INTERVAL].DurationValue
 }
 
+func (c *config) GossipTopology() string {
+	return c.kv[GOSSIP_TOPOLOGY].StringValue
+}
+
+func (c *config) GossipRelayMaxDegree() uint32 {
+	return c.kv[GOSSIP_RELAY_MAX_DEGREE].Uint32Value
+}
+
+func (c *config) GossipRelayMaxHops() uint32 {
+	return c.kv[GOSSIP_RELAY_MAX_HOPS].Uint32Value
+}
+
+func (c *config) GossipCompressionMinSizeBytes() uint32 {
+	return c.kv[GOSSIP_COMPRESSION_MIN_SIZE_BYTES].Uint32Value
+}
+
 func (c *config) BenchmarkConsensusRequiredQuorumPercentage() uint32 {
 	return c.kv[BENCHMARK_CONSENSUS_REQUIRED_QUORUM_PERCENTAGE].Uint32Value
 }
@@ -393,6 +501,22 @@ func (c *config) BlockStorageFileSystemMaxBlockSizeInBytes() uint32 {
 	return c.kv[BLOCK_STORAGE_FILE_SYSTEM_MAX_BLOCK_SIZE_IN_BYTES].Uint32Value
 }
 
+func (c *config) BlockStorageFileSystemMaxSegmentSizeInBytes() uint32 {
+	return c.kv[BLOCK_STORAGE_FILE_SYSTEM_MAX_SEGMENT_SIZE_IN_BYTES].Uint32Value
+}
+
+func (c *config) BlockStorageFileSystemPruningKeepBlocks() uint32 {
+	return c.kv[BLOCK_STORAGE_FILE_SYSTEM_PRUNING_KEEP_BLOCKS].Uint32Value
+}
+
+func (c *config) BlockStorageFileSystemPruningKeepWindow() time.Duration {
+	return c.kv[BLOCK_STORAGE_FILE_SYSTEM_PRUNING_KEEP_WINDOW].DurationValue
+}
+
+func (c *config) BlockStorageFileSystemCompression() bool {
+	return c.kv[BLOCK_STORAGE_FILE_SYSTEM_COMPRESSION].BoolValue
+}
+
 func (c *config) Profiling() bool {
 	return c.kv[PROFILING].BoolValue
 }
@@ -404,3 +528,11 @@ func (c *config) HttpAddress() string {
 func (c *config) NTPEndpoint() string {
 	return c.kv[NTP_ENDPOINT].StringValue
 }
+
+func (c *config) SignerEndpoint() string {
+	return c.kv[SIGNER_ENDPOINT].StringValue
+}
+
+func (c *config) SignerRequestTimeout() time.Duration {
+	return c.kv[SIGNER_REQUEST_TIMEOUT].DurationValue
+}
@@ -0,0 +1,71 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package config
+
+import (
+	"bytes"
+	"github.com/orbs-network/orbs-network-go/crypto/digest"
+	"github.com/orbs-network/orbs-network-go/crypto/keys"
+	"github.com/orbs-network/orbs-network-go/crypto/keystore"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// the environment variable holding the keystore passphrase when no passphrase file is configured
+const NODE_KEYSTORE_PASSPHRASE_ENV = "ORBS_NODE_KEYSTORE_PASSPHRASE"
+
+// Reads the passphrase of the node keystore from the passphrase file when given, ignoring the line break ending it,
+// or else from the environment
+func ReadKeystorePassphrase(passphraseFile string) ([]byte, error) {
+	if passphraseFile != "" {
+		contents, err := ioutil.ReadFile(passphraseFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read keystore passphrase file")
+		}
+		return []byte(strings.TrimRight(string(contents), "\r\n")), nil
+	}
+
+	passphrase, found := os.LookupEnv(NODE_KEYSTORE_PASSPHRASE_ENV)
+	if !found {
+		return nil, errors.Errorf("no keystore passphrase file configured and %s is not set", NODE_KEYSTORE_PASSPHRASE_ENV)
+	}
+	return []byte(passphrase), nil
+}
+
+// sets the node private key of the keystore, and the node address derived from it. A configured node address must be the
+// one derived from the key, otherwise the node would sign with a key other nodes do not expect of it
+func loadNodeKeystore(cfg mutableNodeConfig, keystorePath string, passphraseFile string) error {
+	contents, err := ioutil.ReadFile(keystorePath)
+	if err != nil {
+		return errors.Wrapf(err, "could not read node keystore")
+	}
+	passphrase, err := ReadKeystorePassphrase(passphraseFile)
+	if err != nil {
+		return err
+	}
+
+	privateKey, err := keystore.Decrypt(contents, passphrase)
+	if err != nil {
+		return errors.Wrapf(err, "could not decrypt node keystore %s", keystorePath)
+	}
+
+	publicKey, err := keys.EcdsaSecp256K1PublicKeyOf(privateKey)
+	if err != nil {
+		return err
+	}
+	nodeAddress := primitives.NodeAddress(digest.CalcNodeAddressFromPublicKey(publicKey))
+	if len(cfg.NodeAddress()) != 0 && !bytes.Equal(cfg.NodeAddress(), nodeAddress) {
+		return errors.Errorf("configured node address %s does not match node address %s of the keystore key", cfg.NodeAddress(), nodeAddress)
+	}
+
+	cfg.SetNodePrivateKey(privateKey)
+	cfg.SetNodeAddress(nodeAddress)
+	return nil
+}
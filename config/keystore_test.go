@@ -0,0 +1,92 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package config
+
+import (
+	"fmt"
+	"github.com/orbs-network/orbs-network-go/crypto/keystore"
+	"github.com/orbs-network/orbs-network-go/test/crypto/keys"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeKeystoreForTests(t *testing.T, dir string, keyPair *keys.TestEcdsaSecp256K1KeyPair, passphrase string) string {
+	contents, err := keystore.Encrypt(keyPair.PrivateKey(), []byte(passphrase), keystore.LIGHT_SCRYPT_N, keystore.LIGHT_SCRYPT_P)
+	require.NoError(t, err)
+	path := filepath.Join(dir, "keystore.json")
+	require.NoError(t, ioutil.WriteFile(path, contents, 0600))
+	return path
+}
+
+func TestSetNodeKeystoreWithPassphraseFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	keyPair := keys.EcdsaSecp256K1KeyPairForTests(0)
+	keystorePath := writeKeystoreForTests(t, dir, keyPair, "secret")
+	passphrasePath := filepath.Join(dir, "passphrase")
+	require.NoError(t, ioutil.WriteFile(passphrasePath, []byte("secret\n"), 0600))
+
+	cfg, err := newEmptyFileConfig(fmt.Sprintf(`{"node-keystore-path": "%s", "node-keystore-passphrase-file": "%s"}`, keystorePath, passphrasePath))
+
+	require.NoError(t, err)
+	require.EqualValues(t, keyPair.PrivateKey(), cfg.NodePrivateKey())
+	require.EqualValues(t, keyPair.NodeAddress(), cfg.NodeAddress(), "node address should be derived from the keystore key")
+}
+
+func TestSetNodeKeystoreWithPassphraseFromEnvironment(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	keyPair := keys.EcdsaSecp256K1KeyPairForTests(1)
+	keystorePath := writeKeystoreForTests(t, dir, keyPair, "secret")
+
+	require.NoError(t, os.Setenv(NODE_KEYSTORE_PASSPHRASE_ENV, "secret"))
+	defer os.Unsetenv(NODE_KEYSTORE_PASSPHRASE_ENV)
+
+	cfg, err := newEmptyFileConfig(fmt.Sprintf(`{"node-keystore-path": "%s"}`, keystorePath))
+
+	require.NoError(t, err)
+	require.EqualValues(t, keyPair.PrivateKey(), cfg.NodePrivateKey())
+}
+
+func TestSetNodeKeystoreWithWrongPassphrase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	keystorePath := writeKeystoreForTests(t, dir, keys.EcdsaSecp256K1KeyPairForTests(0), "secret")
+
+	require.NoError(t, os.Setenv(NODE_KEYSTORE_PASSPHRASE_ENV, "not the secret"))
+	defer os.Unsetenv(NODE_KEYSTORE_PASSPHRASE_ENV)
+
+	_, err = newEmptyFileConfig(fmt.Sprintf(`{"node-keystore-path": "%s"}`, keystorePath))
+	require.Error(t, err, "wrong passphrase should fail the config")
+}
+
+func TestSetNodeKeystoreWithMismatchingNodeAddress(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	keystorePath := writeKeystoreForTests(t, dir, keys.EcdsaSecp256K1KeyPairForTests(0), "secret")
+
+	require.NoError(t, os.Setenv(NODE_KEYSTORE_PASSPHRASE_ENV, "secret"))
+	defer os.Unsetenv(NODE_KEYSTORE_PASSPHRASE_ENV)
+
+	_, err = newEmptyFileConfig(fmt.Sprintf(`{"node-keystore-path": "%s", "node-address": "%s"}`, keystorePath, keys.EcdsaSecp256K1KeyPairForTests(1).NodeAddress()))
+	require.Error(t, err, "node address not derived from the keystore key should fail the config")
+
+	cfg, err := newEmptyFileConfig(fmt.Sprintf(`{"node-keystore-path": "%s", "node-address": "%s"}`, keystorePath, keys.EcdsaSecp256K1KeyPairForTests(0).NodeAddress()))
+	require.NoError(t, err, "node address derived from the keystore key should be accepted")
+	require.EqualValues(t, keys.EcdsaSecp256K1KeyPairForTests(0).NodeAddress(), cfg.NodeAddress())
+}
@@ -35,6 +35,16 @@ func ForGossipAdapterTests(nodeAddress primitives.NodeAddress, gossipListenPort
 	cfg.SetDuration(GOSSIP_CONNECTION_KEEP_ALIVE_INTERVAL, 20*time.Millisecond)
 	cfg.SetDuration(GOSSIP_NETWORK_TIMEOUT, 1*time.Second)
 	cfg.SetDuration(GOSSIP_RECONNECT_INTERVAL, 20*time.Millisecond)
+	cfg.SetUint32(GOSSIP_COMPRESSION_MIN_SIZE_BYTES, 64)
+
+	return cfg
+}
+
+func ForGossipRelayTests(maxDegree uint32, maxHops uint32) GossipRelayConfig {
+	cfg := emptyConfig()
+
+	cfg.SetUint32(GOSSIP_RELAY_MAX_DEGREE, maxDegree)
+	cfg.SetUint32(GOSSIP_RELAY_MAX_HOPS, maxHops)
 
 	return cfg
 }
@@ -54,6 +64,13 @@ func ForConsensusContextTests(genesisValidatorNodes map[string]ValidatorNode) Co
 	return cfg
 }
 
+func ForVirtualMachineTests(signatureVerificationWorkers uint32) VirtualMachineConfig {
+	cfg := emptyConfig()
+
+	cfg.SetUint32(VIRTUAL_MACHINE_SIGNATURE_VERIFICATION_WORKERS, signatureVerificationWorkers)
+	return cfg
+}
+
 func ForPublicApiTests(virtualChain uint32, txTimeout time.Duration, outOfSyncWarningTime time.Duration) PublicApiConfig {
 	cfg := emptyConfig()
 
@@ -72,6 +89,14 @@ func ForStateStorageTest(numOfStateRevisionsToRetain uint32, graceBlockDiff uint
 	return cfg
 }
 
+func ForArchivalStateStorageTest(numOfStateRevisionsToRetain uint32) StateStorageConfig {
+	cfg := emptyConfig()
+
+	cfg.SetUint32(STATE_STORAGE_HISTORY_SNAPSHOT_NUM, numOfStateRevisionsToRetain)
+	cfg.SetBool(STATE_STORAGE_ARCHIVAL_MODE, true)
+	return cfg
+}
+
 func ForTransactionPoolTests(sizeLimit uint32, keyPair *testKeys.TestEcdsaSecp256K1KeyPair, timeBetweenEmptyBlocks time.Duration) TransactionPoolConfig {
 	cfg := emptyConfig()
 	cfg.SetNodeAddress(keyPair.NodeAddress())
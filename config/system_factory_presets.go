@@ -28,6 +28,7 @@ func defaultProductionConfig() mutableNodeConfig {
 	cfg.SetUint32(LEAN_HELIX_CONSENSUS_MINIMUM_COMMITTEE_SIZE, 4)
 	cfg.SetUint32(LEAN_HELIX_CONSENSUS_MAXIMUM_COMMITTEE_SIZE, 22)
 	cfg.SetBool(LEAN_HELIX_SHOW_DEBUG, false)
+	cfg.SetBool(LEAN_HELIX_BLS_SIGNATURES, false)
 
 	// if above round time, we'll have leader changes when no traffic
 	cfg.SetDuration(TRANSACTION_POOL_TIME_BETWEEN_EMPTY_BLOCKS, 5*time.Second)
@@ -56,11 +57,12 @@ func defaultProductionConfig() mutableNodeConfig {
 	cfg.SetDuration(BLOCK_SYNC_COLLECT_CHUNKS_TIMEOUT, 5*time.Second)
 	cfg.SetDuration(PUBLIC_API_SEND_TRANSACTION_TIMEOUT, 20*time.Second)
 
+	// Ed25519 signatures of a proposed block verified in parallel
+	cfg.SetUint32(VIRTUAL_MACHINE_SIGNATURE_VERIFICATION_WORKERS, 8)
+
 	// 5 empty blocks
 	cfg.SetDuration(PUBLIC_API_NODE_SYNC_WARNING_TIME, 50*time.Second)
 
-	cfg.SetDuration(BLOCK_STORAGE_TRANSACTION_RECEIPT_QUERY_TIMESTAMP_GRACE, 5*time.Second)
-
 	cfg.SetUint32(STATE_STORAGE_HISTORY_SNAPSHOT_NUM, 5)
 	cfg.SetUint32(TRANSACTION_POOL_PENDING_POOL_SIZE_IN_BYTES, 20*1024*1024)
 	cfg.SetDuration(TRANSACTION_EXPIRATION_WINDOW, 30*time.Minute)
@@ -79,6 +81,14 @@ func defaultProductionConfig() mutableNodeConfig {
 	cfg.SetDuration(GOSSIP_RECONNECT_INTERVAL, 1*time.Second)
 	cfg.SetDuration(GOSSIP_NETWORK_TIMEOUT, 30*time.Second)
 
+	// with a relay topology every node links to at most 8 neighbors, 16 hops span the overlay of a few thousand nodes
+	cfg.SetString(GOSSIP_TOPOLOGY, GOSSIP_TOPOLOGY_FULL_MESH)
+	cfg.SetUint32(GOSSIP_RELAY_MAX_DEGREE, 8)
+	cfg.SetUint32(GOSSIP_RELAY_MAX_HOPS, 16)
+
+	// frames smaller than 4KB, such as most consensus messages, gain little from compression
+	cfg.SetUint32(GOSSIP_COMPRESSION_MIN_SIZE_BYTES, 4*1024)
+
 	// 10 minutes + 60 blocks is about 25 minutes
 	cfg.SetDuration(ETHEREUM_FINALITY_TIME_COMPONENT, 10*time.Minute)
 	cfg.SetUint32(ETHEREUM_FINALITY_BLOCKS_COMPONENT, 60)
@@ -90,6 +100,16 @@ func defaultProductionConfig() mutableNodeConfig {
 	cfg.SetString(PROCESSOR_ARTIFACT_PATH, filepath.Join(GetProjectSourceTmpPath(), "processor-artifacts"))
 	cfg.SetString(BLOCK_STORAGE_FILE_SYSTEM_DATA_DIR, "/usr/local/var/orbs") // TODO V1 use build tags to replace with /var/lib/orbs for linux
 	cfg.SetUint32(BLOCK_STORAGE_FILE_SYSTEM_MAX_BLOCK_SIZE_IN_BYTES, 64*1024*1024)
+	cfg.SetUint32(BLOCK_STORAGE_FILE_SYSTEM_MAX_SEGMENT_SIZE_IN_BYTES, 1024*1024*1024)
+	cfg.SetUint32(BLOCK_STORAGE_FILE_SYSTEM_PRUNING_KEEP_BLOCKS, 0) // archival, keeps all blocks
+	cfg.SetDuration(BLOCK_STORAGE_FILE_SYSTEM_PRUNING_KEEP_WINDOW, 0)
+	cfg.SetBool(BLOCK_STORAGE_FILE_SYSTEM_COMPRESSION, false)
+	cfg.SetBool(STATE_STORAGE_FILE_SYSTEM_PERSISTENCE, false)
+	cfg.SetString(STATE_STORAGE_FILE_SYSTEM_DATA_DIR, "/usr/local/var/orbs")
+	cfg.SetString(STATE_STORAGE_SNAPSHOT_URL, "")
+	cfg.SetDuration(STATE_STORAGE_SNAPSHOT_FETCH_TIMEOUT, 10*time.Minute)
+	cfg.SetBool(STATE_STORAGE_SERVE_SNAPSHOTS, false) // the snapshot endpoint is not authenticated, enable only where the http port is private
+	cfg.SetBool(STATE_STORAGE_ARCHIVAL_MODE, false)   // keeps every revision of the state to serve reads at past block heights
 
 	cfg.SetDuration(LOGGER_FILE_TRUNCATION_INTERVAL, 24*time.Hour)
 	cfg.SetBool(LOGGER_FULL_LOG, false)
@@ -97,6 +117,9 @@ func defaultProductionConfig() mutableNodeConfig {
 	cfg.SetBool(PROFILING, false)
 	cfg.SetString(HTTP_ADDRESS, ":8080")
 
+	cfg.SetString(SIGNER_ENDPOINT, "") // signs with node-private-key in-process
+	cfg.SetDuration(SIGNER_REQUEST_TIMEOUT, 1*time.Second)
+
 	return cfg
 }
 
@@ -166,6 +189,7 @@ func ForE2E(
 
 	cfg.SetUint32(BLOCK_STORAGE_FILE_SYSTEM_MAX_BLOCK_SIZE_IN_BYTES, 64*1024*1024)
 	cfg.SetString(BLOCK_STORAGE_FILE_SYSTEM_DATA_DIR, filepath.Join(blockStorageDataDirPrefix, nodeAddress.String()))
+	cfg.SetString(STATE_STORAGE_FILE_SYSTEM_DATA_DIR, filepath.Join(blockStorageDataDirPrefix, nodeAddress.String()))
 
 	cfg.SetBool(PROCESSOR_SANITIZE_DEPLOYED_CONTRACTS, false)
 	if processorArtifactPath != "" {
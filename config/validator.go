@@ -32,13 +32,27 @@ func (v *validator) ValidateNodeLogic(cfg NodeConfig) {
 	v.requireGT(cfg.BlockSyncNoCommitInterval, cfg.BenchmarkConsensusRetryInterval, "node sync timeout must be greater than benchmark consensus timeout")
 	v.requireGT(cfg.BlockSyncNoCommitInterval, cfg.LeanHelixConsensusRoundTimeoutInterval, "node sync timeout must be greater than lean helix round timeout")
 	v.requireNonEmpty(cfg.NodeAddress(), "node address must not be empty")
-	v.requireNonEmpty(cfg.NodePrivateKey(), "node private key must not be empty")
 	v.requireNonEmptyValidatorMap(cfg.GenesisValidatorNodes(), "genesis validator list must not be empty")
-	v.requireCorrectNodeAddressAndPrivateKey(cfg.NodeAddress(), cfg.NodePrivateKey())
+
+	// a remote signer holds the key, it can only be checked once the node signs
+	if cfg.SignerEndpoint() == "" {
+		v.requireNonEmpty(cfg.NodePrivateKey(), "node private key must not be empty")
+		v.requireCorrectNodeAddressAndPrivateKey(cfg.NodeAddress(), cfg.NodePrivateKey())
+	}
 }
 
 func (v *validator) ValidateMainNode(cfg NodeConfig) {
 	v.requireNonEmptyPeerMap(cfg.GossipPeers(), "gossip peer list must not be empty")
+
+	switch cfg.GossipTopology() {
+	case GOSSIP_TOPOLOGY_FULL_MESH:
+	case GOSSIP_TOPOLOGY_RELAY:
+		if cfg.GossipRelayMaxDegree() < 2 || cfg.GossipRelayMaxHops() < 1 {
+			panic(fmt.Sprintf("gossip relay must link to at least 2 neighbors and forward at least 1 hop; max degree=%d max hops=%d", cfg.GossipRelayMaxDegree(), cfg.GossipRelayMaxHops()))
+		}
+	default:
+		panic(fmt.Sprintf("gossip topology must be %s or %s; topology=%s", GOSSIP_TOPOLOGY_FULL_MESH, GOSSIP_TOPOLOGY_RELAY, cfg.GossipTopology()))
+	}
 }
 
 func (v *validator) requireGT(d1 func() time.Duration, d2 func() time.Duration, msg string) {
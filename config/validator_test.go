@@ -72,6 +72,27 @@ func TestValidateConfig_PanicsOnInvalidKeys(t *testing.T) {
 	})
 }
 
+func TestValidateMainNode_PanicsOnUnknownGossipTopology(t *testing.T) {
+	v := validator{log.DefaultTestingLogger(t)}
+
+	cfg := defaultProductionConfig()
+	cfg.SetGossipPeers(map[string]GossipPeer{"p1": NewHardCodedGossipPeer(4400, "127.0.0.1")})
+
+	require.NotPanics(t, func() {
+		v.ValidateMainNode(cfg)
+	})
+
+	cfg.SetString(GOSSIP_TOPOLOGY, GOSSIP_TOPOLOGY_RELAY)
+	require.NotPanics(t, func() {
+		v.ValidateMainNode(cfg)
+	})
+
+	cfg.SetString(GOSSIP_TOPOLOGY, "star")
+	require.Panics(t, func() {
+		v.ValidateMainNode(cfg)
+	})
+}
+
 func defaultNodeAddress() primitives.NodeAddress {
 	addr, _ := hex.DecodeString("a328846cd5b4979d68a8c58a9bdfeee657b34de7")
 	return primitives.NodeAddress(addr)
@@ -0,0 +1,123 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package bloom
+
+import (
+	"github.com/orbs-network/orbs-network-go/crypto/digest"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/pkg/errors"
+)
+
+// 8192 bits keep false positives well below 1% for blocks of several hundred transactions
+const BLOCK_FILTER_SIZE_BYTES = 1024
+const BLOCK_FILTER_NUM_HASHES = 4
+
+// items of different kinds never collide, an event is added both with and without the contract emitting it
+const (
+	itemKindContract = byte(iota + 1)
+	itemKindEventName
+	itemKindContractEvent
+	itemKindAddress
+)
+
+// The filter of a results block over the contracts called and emitting events, the names of emitted events and the addresses
+// involved - transaction signers and addresses passed as event arguments. A block it excludes holds none of the searched items.
+type BlockFilter struct {
+	filter *Filter
+}
+
+func NewBlockFilter() *BlockFilter {
+	return &BlockFilter{NewFilter(BLOCK_FILTER_SIZE_BYTES, BLOCK_FILTER_NUM_HASHES)}
+}
+
+func NewBlockFilterFromRaw(raw []byte) (*BlockFilter, error) {
+	if len(raw) != BLOCK_FILTER_SIZE_BYTES {
+		return nil, errors.Errorf("block filter size is %d bytes, expected %d", len(raw), BLOCK_FILTER_SIZE_BYTES)
+	}
+	return &BlockFilter{NewFilterFromRaw(raw, BLOCK_FILTER_NUM_HASHES)}, nil
+}
+
+// Adds the items of all the block's transactions and receipts
+func CalcBlockFilter(block *protocol.BlockPairContainer) *BlockFilter {
+	f := NewBlockFilter()
+	for _, signedTx := range block.TransactionsBlock.SignedTransactions {
+		tx := signedTx.Transaction()
+		f.AddContract(tx.ContractName())
+		if tx.Signer().Scheme() == protocol.SIGNER_SCHEME_EDDSA {
+			if address, err := digest.CalcClientAddressOfEd25519Signer(tx.Signer()); err == nil {
+				f.AddAddress(address)
+			}
+		}
+	}
+	for _, receipt := range block.ResultsBlock.TransactionReceipts {
+		for i := protocol.EventsArrayReader(receipt.RawOutputEventsArrayWithHeader()).EventsIterator(); i.HasNext(); {
+			event := i.NextEvents()
+			f.AddEvent(event.ContractName(), event.EventName())
+			for _, address := range EventAddressArguments(event) {
+				f.AddAddress(address)
+			}
+		}
+	}
+	return f
+}
+
+// the event's bytes arguments of the size of an address, which the filter indexes as addresses
+func EventAddressArguments(event *protocol.Event) []primitives.ClientAddress {
+	var addresses []primitives.ClientAddress
+	for i := protocol.ArgumentArrayReader(event.RawOutputArgumentArrayWithHeader()).ArgumentsIterator(); i.HasNext(); {
+		arg := i.NextArguments()
+		if arg.IsTypeBytesValue() && len(arg.BytesValue()) == digest.CLIENT_ADDRESS_SIZE_BYTES {
+			addresses = append(addresses, arg.BytesValue())
+		}
+	}
+	return addresses
+}
+
+func (f *BlockFilter) AddContract(contractName primitives.ContractName) {
+	f.filter.Add(filterItem(itemKindContract, []byte(contractName)))
+}
+
+func (f *BlockFilter) AddEvent(contractName primitives.ContractName, eventName primitives.EventName) {
+	f.AddContract(contractName)
+	f.filter.Add(filterItem(itemKindEventName, []byte(eventName)))
+	f.filter.Add(filterItem(itemKindContractEvent, []byte(contractName), []byte(eventName)))
+}
+
+func (f *BlockFilter) AddAddress(address primitives.ClientAddress) {
+	f.filter.Add(filterItem(itemKindAddress, address))
+}
+
+func (f *BlockFilter) MayContainContract(contractName primitives.ContractName) bool {
+	return f.filter.Test(filterItem(itemKindContract, []byte(contractName)))
+}
+
+// the contract name is optional, when empty the event may have been emitted by any contract
+func (f *BlockFilter) MayContainEvent(contractName primitives.ContractName, eventName primitives.EventName) bool {
+	if contractName == "" {
+		return f.filter.Test(filterItem(itemKindEventName, []byte(eventName)))
+	}
+	return f.filter.Test(filterItem(itemKindContractEvent, []byte(contractName), []byte(eventName)))
+}
+
+func (f *BlockFilter) MayContainAddress(address primitives.ClientAddress) bool {
+	return f.filter.Test(filterItem(itemKindAddress, address))
+}
+
+func (f *BlockFilter) Raw() []byte {
+	return f.filter.Raw()
+}
+
+// parts are length prefixed so a contract and event name pair can not be taken for another
+func filterItem(kind byte, parts ...[]byte) []byte {
+	item := []byte{kind}
+	for _, part := range parts {
+		item = append(item, byte(len(part)>>8), byte(len(part)))
+		item = append(item, part...)
+	}
+	return item
+}
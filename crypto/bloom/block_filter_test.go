@@ -0,0 +1,84 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package bloom_test
+
+import (
+	"fmt"
+	"github.com/orbs-network/orbs-network-go/crypto/bloom"
+	"github.com/orbs-network/orbs-network-go/crypto/digest"
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFilter_HoldsEveryItemAdded(t *testing.T) {
+	f := bloom.NewFilter(bloom.BLOCK_FILTER_SIZE_BYTES, bloom.BLOCK_FILTER_NUM_HASHES)
+	for i := 0; i < 1000; i++ {
+		f.Add([]byte(fmt.Sprintf("item %d", i)))
+	}
+	for i := 0; i < 1000; i++ {
+		require.True(t, f.Test([]byte(fmt.Sprintf("item %d", i))), "item %d should be in the filter", i)
+	}
+
+	restored := bloom.NewFilterFromRaw(f.Raw(), bloom.BLOCK_FILTER_NUM_HASHES)
+	require.True(t, restored.Test([]byte("item 17")), "filter restored from raw should hold the items")
+}
+
+func TestFilter_FalsePositivesAreRare(t *testing.T) {
+	f := bloom.NewFilter(bloom.BLOCK_FILTER_SIZE_BYTES, bloom.BLOCK_FILTER_NUM_HASHES)
+	for i := 0; i < 500; i++ {
+		f.Add([]byte(fmt.Sprintf("item %d", i)))
+	}
+
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if f.Test([]byte(fmt.Sprintf("other item %d", i))) {
+			falsePositives++
+		}
+	}
+	require.True(t, falsePositives < 100, "expected under 1%% false positives, got %d out of 10000", falsePositives)
+}
+
+func TestBlockFilter_OfBlockHoldsContractsEventsAndAddresses(t *testing.T) {
+	tx := builders.TransferTransaction().Build()
+	signerAddress, err := digest.CalcClientAddressOfEd25519Signer(tx.Transaction().Signer())
+	require.NoError(t, err)
+	targetAddress := builders.ClientAddressForEd25519SignerForTests(7)
+
+	receipt := builders.TransactionReceipt().WithTransaction(tx.Transaction()).WithEvents(&protocol.EventBuilder{
+		ContractName:        "MyToken",
+		EventName:           "Transfer",
+		OutputArgumentArray: builders.PackedArgumentArrayEncode([]byte(targetAddress), uint64(10)),
+	}).Build()
+	block := builders.BlockPair().WithTransaction(tx).WithReceipt(receipt).Build()
+
+	f := bloom.CalcBlockFilter(block)
+
+	require.True(t, f.MayContainContract("BenchmarkToken"), "should hold the contract called")
+	require.True(t, f.MayContainContract("MyToken"), "should hold the contract emitting the event")
+	require.True(t, f.MayContainEvent("", "Transfer"), "should hold the event name")
+	require.True(t, f.MayContainEvent("MyToken", "Transfer"), "should hold the event of its contract")
+	require.True(t, f.MayContainAddress(signerAddress), "should hold the signer address")
+	require.True(t, f.MayContainAddress(targetAddress), "should hold the address passed to the event")
+
+	require.False(t, f.MayContainEvent("", "Approval"), "should not hold another event")
+	require.False(t, f.MayContainEvent("BenchmarkToken", "Transfer"), "should not hold the event of another contract")
+	require.False(t, f.MayContainAddress(builders.ClientAddressForEd25519SignerForTests(8)), "should not hold another address")
+}
+
+func TestBlockFilter_FromRaw(t *testing.T) {
+	f := bloom.NewBlockFilter()
+	f.AddEvent("MyToken", "Transfer")
+
+	restored, err := bloom.NewBlockFilterFromRaw(f.Raw())
+	require.NoError(t, err)
+	require.True(t, restored.MayContainEvent("MyToken", "Transfer"), "restored filter should hold the event")
+
+	_, err = bloom.NewBlockFilterFromRaw(make([]byte, 10))
+	require.Error(t, err, "filter of the wrong size should be rejected")
+}
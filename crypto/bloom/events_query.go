@@ -0,0 +1,78 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package bloom
+
+import (
+	"bytes"
+	"github.com/orbs-network/orbs-network-go/crypto/digest"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+)
+
+// The events searched for in blocks. The contract name and address are optional, an event matches the address
+// when the transaction emitting it was signed by it or when the address is passed as one of the event's arguments
+type EventsQuery struct {
+	ContractName primitives.ContractName
+	EventName    primitives.EventName
+	Address      primitives.ClientAddress
+}
+
+type FoundEvent struct {
+	BlockHeight primitives.BlockHeight
+	Txhash      primitives.Sha256
+	Event       *protocol.Event
+}
+
+// true when the block of the filter surely holds no matching event
+func (q *EventsQuery) ExcludedBy(f *BlockFilter) bool {
+	if !f.MayContainEvent(q.ContractName, q.EventName) {
+		return true
+	}
+	return len(q.Address) > 0 && !f.MayContainAddress(q.Address)
+}
+
+func (q *EventsQuery) FindInBlock(block *protocol.BlockPairContainer) []*FoundEvent {
+	signers := make(map[string]primitives.ClientAddress)
+	if len(q.Address) > 0 {
+		for _, signedTx := range block.TransactionsBlock.SignedTransactions {
+			if address, err := digest.CalcClientAddressOfEd25519Signer(signedTx.Transaction().Signer()); err == nil {
+				signers[string(digest.CalcTxHash(signedTx.Transaction()))] = address
+			}
+		}
+	}
+
+	var found []*FoundEvent
+	for _, receipt := range block.ResultsBlock.TransactionReceipts {
+		for i := protocol.EventsArrayReader(receipt.RawOutputEventsArrayWithHeader()).EventsIterator(); i.HasNext(); {
+			event := i.NextEvents()
+			if event.EventName() != q.EventName || (q.ContractName != "" && event.ContractName() != q.ContractName) {
+				continue
+			}
+			if len(q.Address) > 0 && !q.involvesAddress(event, signers[string(receipt.Txhash())]) {
+				continue
+			}
+			found = append(found, &FoundEvent{
+				BlockHeight: block.ResultsBlock.Header.BlockHeight(),
+				Txhash:      receipt.Txhash(),
+				Event:       event,
+			})
+		}
+	}
+	return found
+}
+
+func (q *EventsQuery) involvesAddress(event *protocol.Event, signer primitives.ClientAddress) bool {
+	if bytes.Equal(signer, q.Address) {
+		return true
+	}
+	for _, arg := range EventAddressArguments(event) {
+		if bytes.Equal(arg, q.Address) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package bloom
+
+import (
+	"encoding/binary"
+	"github.com/orbs-network/orbs-network-go/crypto/hash"
+)
+
+// A bloom filter of byte strings. Every item sets numHashes bits, derived from the SHA256 of the item by double hashing,
+// so a filter may report an item it does not hold but never misses one it does
+type Filter struct {
+	bits      []byte
+	numHashes uint8
+}
+
+func NewFilter(sizeInBytes int, numHashes uint8) *Filter {
+	return &Filter{
+		bits:      make([]byte, sizeInBytes),
+		numHashes: numHashes,
+	}
+}
+
+// the raw bits are used as is, they must have been created with the same number of hashes
+func NewFilterFromRaw(raw []byte, numHashes uint8) *Filter {
+	return &Filter{
+		bits:      raw,
+		numHashes: numHashes,
+	}
+}
+
+func (f *Filter) Add(item []byte) {
+	for _, loc := range f.locations(item) {
+		f.bits[loc/8] |= 1 << (loc % 8)
+	}
+}
+
+func (f *Filter) Test(item []byte) bool {
+	for _, loc := range f.locations(item) {
+		if f.bits[loc/8]&(1<<(loc%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *Filter) Raw() []byte {
+	return f.bits
+}
+
+func (f *Filter) locations(item []byte) []uint64 {
+	size := uint64(len(f.bits)) * 8
+	if size == 0 {
+		return nil
+	}
+	sum := hash.CalcSha256(item)
+	h1 := binary.BigEndian.Uint64(sum[0:8])
+	h2 := binary.BigEndian.Uint64(sum[8:16]) | 1 // odd, so the locations do not repeat when size is a power of two
+
+	locs := make([]uint64, f.numHashes)
+	for i := range locs {
+		locs[i] = (h1 + uint64(i)*h2) % size
+	}
+	return locs
+}
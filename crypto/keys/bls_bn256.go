@@ -0,0 +1,141 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package keys
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+	"github.com/pkg/errors"
+	"io"
+	"math/big"
+)
+
+// BLS keys over the bn256 (alt_bn128) pairing friendly curve, public keys are points of G2 and signatures points of G1
+
+const (
+	BLS_BN256_PUBLIC_KEY_SIZE_BYTES  = 128
+	BLS_BN256_PRIVATE_KEY_SIZE_BYTES = 32
+)
+
+// the order of the curve's groups, private keys are the scalars below it
+var BlsBn256Order, _ = new(big.Int).SetString("21888242871839275222246405745257275088548364400416034343698204186575808495617", 10)
+
+type BlsBn256PublicKey []byte
+type BlsBn256PrivateKey []byte
+
+func (k BlsBn256PrivateKey) Scalar() (*big.Int, error) {
+	if len(k) != BLS_BN256_PRIVATE_KEY_SIZE_BYTES {
+		return nil, errors.Errorf("bls private key size is %d bytes, expected %d", len(k), BLS_BN256_PRIVATE_KEY_SIZE_BYTES)
+	}
+	scalar := new(big.Int).SetBytes(k)
+	if scalar.Sign() == 0 || scalar.Cmp(BlsBn256Order) >= 0 {
+		return nil, errors.New("bls private key is out of range")
+	}
+	return scalar, nil
+}
+
+type BlsBn256KeyPair struct {
+	publicKey  BlsBn256PublicKey
+	privateKey BlsBn256PrivateKey
+}
+
+func NewBlsBn256KeyPair(publicKey BlsBn256PublicKey, privateKey BlsBn256PrivateKey) *BlsBn256KeyPair {
+	return &BlsBn256KeyPair{publicKey, privateKey}
+}
+
+func (k *BlsBn256KeyPair) PublicKey() BlsBn256PublicKey {
+	return k.publicKey
+}
+
+func (k *BlsBn256KeyPair) PrivateKey() BlsBn256PrivateKey {
+	return k.privateKey
+}
+
+func (k *BlsBn256KeyPair) PublicKeyHex() string {
+	return hex.EncodeToString(k.publicKey)
+}
+
+func (k *BlsBn256KeyPair) PrivateKeyHex() string {
+	return hex.EncodeToString(k.privateKey)
+}
+
+func GenerateBlsBn256Key() (*BlsBn256KeyPair, error) {
+	scalar, err := randomBlsBn256Scalar(cryptorand.Reader)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot create key pair")
+	}
+	return blsBn256KeyPairOf(scalar), nil
+}
+
+func BlsBn256PublicKeyOf(privateKey BlsBn256PrivateKey) (BlsBn256PublicKey, error) {
+	scalar, err := privateKey.Scalar()
+	if err != nil {
+		return nil, err
+	}
+	return new(bn256.G2).ScalarBaseMult(scalar).Marshal(), nil
+}
+
+// Key shares of a group key, any threshold of which sign for the group. Share i is the group private polynomial evaluated at i+1,
+// its share index, so the key of share i verifies signature shares of that share and the group public key verifies group signatures
+type BlsBn256ThresholdKeys struct {
+	GroupPublicKey BlsBn256PublicKey
+	Shares         []*BlsBn256KeyPair
+}
+
+// Deals key shares of a new group key by evaluating a random polynomial of degree threshold-1. The dealer is trusted,
+// it knows the group private key while dealing and does not keep it
+func GenerateBlsBn256ThresholdKeys(random io.Reader, threshold int, numShares int) (*BlsBn256ThresholdKeys, error) {
+	if threshold < 1 || threshold > numShares {
+		return nil, errors.Errorf("threshold %d is invalid for %d shares", threshold, numShares)
+	}
+
+	coefficients := make([]*big.Int, threshold)
+	for i := range coefficients {
+		scalar, err := randomBlsBn256Scalar(random)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot create threshold keys")
+		}
+		coefficients[i] = scalar
+	}
+
+	keys := &BlsBn256ThresholdKeys{
+		GroupPublicKey: new(bn256.G2).ScalarBaseMult(coefficients[0]).Marshal(),
+	}
+	for index := 1; index <= numShares; index++ {
+		keys.Shares = append(keys.Shares, blsBn256KeyPairOf(evaluatePolynomial(coefficients, big.NewInt(int64(index)))))
+	}
+	return keys, nil
+}
+
+// by Horner's rule, modulo the group order
+func evaluatePolynomial(coefficients []*big.Int, x *big.Int) *big.Int {
+	result := new(big.Int)
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		result.Mul(result, x)
+		result.Add(result, coefficients[i])
+		result.Mod(result, BlsBn256Order)
+	}
+	return result
+}
+
+func randomBlsBn256Scalar(random io.Reader) (*big.Int, error) {
+	for {
+		scalar, err := cryptorand.Int(random, BlsBn256Order)
+		if err != nil {
+			return nil, err
+		}
+		if scalar.Sign() != 0 {
+			return scalar, nil
+		}
+	}
+}
+
+func blsBn256KeyPairOf(scalar *big.Int) *BlsBn256KeyPair {
+	return NewBlsBn256KeyPair(new(bn256.G2).ScalarBaseMult(scalar).Marshal(), math.PaddedBigBytes(scalar, BLS_BN256_PRIVATE_KEY_SIZE_BYTES))
+}
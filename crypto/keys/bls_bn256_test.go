@@ -0,0 +1,43 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package keys
+
+import (
+	cryptorand "crypto/rand"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestGenerateBlsBn256Key(t *testing.T) {
+	keyPair, err := GenerateBlsBn256Key()
+	require.NoError(t, err, "should not fail")
+
+	t.Logf("Public: %s", keyPair.PublicKeyHex())
+	t.Logf("Private: %s", keyPair.PrivateKeyHex())
+	require.Equal(t, BLS_BN256_PUBLIC_KEY_SIZE_BYTES, len(keyPair.publicKey), "public key length should match")
+	require.Equal(t, BLS_BN256_PRIVATE_KEY_SIZE_BYTES, len(keyPair.privateKey), "private key length should match")
+
+	publicKey, err := BlsBn256PublicKeyOf(keyPair.PrivateKey())
+	require.NoError(t, err)
+	require.Equal(t, keyPair.PublicKey(), publicKey, "public key should be derived from the private key")
+}
+
+func TestGenerateBlsBn256ThresholdKeys(t *testing.T) {
+	thresholdKeys, err := GenerateBlsBn256ThresholdKeys(cryptorand.Reader, 3, 4)
+	require.NoError(t, err, "should not fail")
+
+	require.Len(t, thresholdKeys.Shares, 4, "should deal a share to each")
+	require.Equal(t, BLS_BN256_PUBLIC_KEY_SIZE_BYTES, len(thresholdKeys.GroupPublicKey), "group public key length should match")
+	for _, share := range thresholdKeys.Shares {
+		publicKey, err := BlsBn256PublicKeyOf(share.PrivateKey())
+		require.NoError(t, err)
+		require.Equal(t, share.PublicKey(), publicKey, "public key of share should be derived from its private key")
+	}
+
+	_, err = GenerateBlsBn256ThresholdKeys(cryptorand.Reader, 5, 4)
+	require.Error(t, err, "threshold above the number of shares should fail")
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/ethereum/go-ethereum/crypto/secp256k1"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/pkg/errors"
+	"math/big"
 )
 
 // if there is no go-ethereum dependency in the project
@@ -61,3 +62,16 @@ func GenerateEcdsaSecp256K1Key() (*EcdsaSecp256K1KeyPair, error) {
 	privateKey := math.PaddedBigBytes(pri.D, pri.Params().BitSize/8)
 	return NewEcdsaSecp256K1KeyPair(publicKeyWithBytePrefix[1:], privateKey), nil
 }
+
+func EcdsaSecp256K1PublicKeyOf(privateKey primitives.EcdsaSecp256K1PrivateKey) (primitives.EcdsaSecp256K1PublicKey, error) {
+	if len(privateKey) != ECDSA_SECP256K1_PRIVATE_KEY_SIZE_BYTES {
+		return nil, errors.Errorf("ecdsa secp256k1 private key size is %d bytes, expected %d", len(privateKey), ECDSA_SECP256K1_PRIVATE_KEY_SIZE_BYTES)
+	}
+	curve := secp256k1.S256()
+	d := new(big.Int).SetBytes(privateKey)
+	if d.Sign() == 0 || d.Cmp(curve.Params().N) >= 0 {
+		return nil, errors.New("ecdsa secp256k1 private key is out of range")
+	}
+	x, y := curve.ScalarBaseMult(privateKey)
+	return elliptic.Marshal(curve, x, y)[1:], nil
+}
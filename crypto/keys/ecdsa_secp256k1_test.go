@@ -22,6 +22,18 @@ func TestGenerateEcdsaSecp256K1Key(t *testing.T) {
 	require.Equal(t, ECDSA_SECP256K1_PRIVATE_KEY_SIZE_BYTES, len(keyPair.privateKey), "private key length should match")
 }
 
+func TestEcdsaSecp256K1PublicKeyOf(t *testing.T) {
+	keyPair, err := GenerateEcdsaSecp256K1Key()
+	require.NoError(t, err, "should not fail")
+
+	publicKey, err := EcdsaSecp256K1PublicKeyOf(keyPair.PrivateKey())
+	require.NoError(t, err)
+	require.Equal(t, keyPair.PublicKey(), publicKey, "public key should be derived from the private key")
+
+	_, err = EcdsaSecp256K1PublicKeyOf(make([]byte, ECDSA_SECP256K1_PRIVATE_KEY_SIZE_BYTES))
+	require.Error(t, err, "zero private key should fail")
+}
+
 func TestGenerate10KeysForTests(t *testing.T) {
 	for i := 0; i < 10; i++ {
 		keyPair, err := GenerateEcdsaSecp256K1Key()
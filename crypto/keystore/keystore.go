@@ -0,0 +1,211 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	cryptorand "crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/orbs-network/orbs-network-go/crypto/digest"
+	"github.com/orbs-network/orbs-network-go/crypto/hash"
+	"github.com/orbs-network/orbs-network-go/crypto/keys"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Keystore files are in the Web3 Secret Storage format (version 3) of Ethereum keystores: the private key is encrypted with
+// AES-128-CTR by a key derived from the passphrase with scrypt, and authenticated by the Keccak256 of the rest of the derived
+// key and the ciphertext. Keystores of Ethereum tools holding a secp256k1 key decrypt as node keys and vice versa.
+
+const (
+	// the parameters of Ethereum's keystores, about a second and 256MB to derive the key
+	STANDARD_SCRYPT_N = 1 << 18
+	STANDARD_SCRYPT_P = 1
+
+	// much faster to derive and much weaker, for tests
+	LIGHT_SCRYPT_N = 1 << 12
+	LIGHT_SCRYPT_P = 6
+)
+
+const (
+	keystoreVersion = 3
+	scryptR         = 8
+	scryptDKLen     = 32
+	cipherName      = "aes-128-ctr"
+	kdfName         = "scrypt"
+)
+
+type keystoreJson struct {
+	Address string     `json:"address"`
+	Crypto  cryptoJson `json:"crypto"`
+	Id      string     `json:"id"`
+	Version int        `json:"version"`
+}
+
+type cryptoJson struct {
+	Cipher       string           `json:"cipher"`
+	CipherText   string           `json:"ciphertext"`
+	CipherParams cipherParamsJson `json:"cipherparams"`
+	KDF          string           `json:"kdf"`
+	KDFParams    scryptParamsJson `json:"kdfparams"`
+	MAC          string           `json:"mac"`
+}
+
+type cipherParamsJson struct {
+	IV string `json:"iv"`
+}
+
+type scryptParamsJson struct {
+	DKLen int    `json:"dklen"`
+	N     int    `json:"n"`
+	P     int    `json:"p"`
+	R     int    `json:"r"`
+	Salt  string `json:"salt"`
+}
+
+func Encrypt(privateKey primitives.EcdsaSecp256K1PrivateKey, passphrase []byte, scryptN int, scryptP int) ([]byte, error) {
+	publicKey, err := keys.EcdsaSecp256K1PublicKeyOf(privateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot encrypt keystore")
+	}
+
+	salt, err := randomBytes(32)
+	if err != nil {
+		return nil, err
+	}
+	derivedKey, err := scrypt.Key(passphrase, salt, scryptN, scryptR, scryptP, scryptDKLen)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed deriving keystore key")
+	}
+
+	iv, err := randomBytes(aes.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+	cipherText, err := aesCtr(derivedKey[:16], iv, privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := randomUuid()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(&keystoreJson{
+		Address: hex.EncodeToString(digest.CalcNodeAddressFromPublicKey(publicKey)),
+		Crypto: cryptoJson{
+			Cipher:       cipherName,
+			CipherText:   hex.EncodeToString(cipherText),
+			CipherParams: cipherParamsJson{IV: hex.EncodeToString(iv)},
+			KDF:          kdfName,
+			KDFParams: scryptParamsJson{
+				DKLen: scryptDKLen,
+				N:     scryptN,
+				P:     scryptP,
+				R:     scryptR,
+				Salt:  hex.EncodeToString(salt),
+			},
+			MAC: hex.EncodeToString(calcMac(derivedKey, cipherText)),
+		},
+		Id:      id,
+		Version: keystoreVersion,
+	})
+}
+
+// fails on a wrong passphrase, before decrypting anything
+func Decrypt(keystore []byte, passphrase []byte) (primitives.EcdsaSecp256K1PrivateKey, error) {
+	k := &keystoreJson{}
+	if err := json.Unmarshal(keystore, k); err != nil {
+		return nil, errors.Wrap(err, "failed decoding keystore")
+	}
+	if k.Version != keystoreVersion {
+		return nil, errors.Errorf("keystore version %d is not supported, expected %d", k.Version, keystoreVersion)
+	}
+	if k.Crypto.Cipher != cipherName || k.Crypto.KDF != kdfName {
+		return nil, errors.Errorf("keystore cipher %s and kdf %s are not supported, expected %s and %s", k.Crypto.Cipher, k.Crypto.KDF, cipherName, kdfName)
+	}
+
+	salt, err := hex.DecodeString(k.Crypto.KDFParams.Salt)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed decoding keystore salt")
+	}
+	iv, err := hex.DecodeString(k.Crypto.CipherParams.IV)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed decoding keystore iv")
+	}
+	cipherText, err := hex.DecodeString(k.Crypto.CipherText)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed decoding keystore ciphertext")
+	}
+	mac, err := hex.DecodeString(k.Crypto.MAC)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed decoding keystore mac")
+	}
+
+	params := k.Crypto.KDFParams
+	if params.DKLen != scryptDKLen {
+		return nil, errors.Errorf("keystore derived key length %d is not supported, expected %d", params.DKLen, scryptDKLen)
+	}
+	derivedKey, err := scrypt.Key(passphrase, salt, params.N, params.R, params.P, params.DKLen)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed deriving keystore key")
+	}
+	if subtle.ConstantTimeCompare(calcMac(derivedKey, cipherText), mac) != 1 {
+		return nil, errors.New("keystore passphrase is wrong")
+	}
+
+	privateKey, err := aesCtr(derivedKey[:16], iv, cipherText)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := keys.EcdsaSecp256K1PublicKeyOf(privateKey); err != nil {
+		return nil, errors.Wrap(err, "keystore does not hold a node key")
+	}
+	return privateKey, nil
+}
+
+func calcMac(derivedKey []byte, cipherText []byte) []byte {
+	return hash.CalcKeccak256(append(append([]byte{}, derivedKey[16:32]...), cipherText...))
+}
+
+func aesCtr(key []byte, iv []byte, in []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != aes.BlockSize {
+		return nil, errors.Errorf("keystore iv size is %d bytes, expected %d", len(iv), aes.BlockSize)
+	}
+	out := make([]byte, len(in))
+	cipher.NewCTR(block, iv).XORKeyStream(out, in)
+	return out, nil
+}
+
+func randomBytes(size int) ([]byte, error) {
+	b := make([]byte, size)
+	if _, err := cryptorand.Read(b); err != nil {
+		return nil, errors.Wrap(err, "failed reading random bytes")
+	}
+	return b, nil
+}
+
+// a version 4 uuid
+func randomUuid() (string, error) {
+	b, err := randomBytes(16)
+	if err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package keystore
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"github.com/orbs-network/orbs-network-go/test/crypto/keys"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// the scrypt test vector of the Web3 Secret Storage definition
+const web3TestVectorKeystore = `{
+	"crypto": {
+		"cipher": "aes-128-ctr",
+		"cipherparams": {"iv": "83dbcc02d8ccb40e466191a123791e0e"},
+		"ciphertext": "d172bf743a674da9cdad04534d56926ef8358534d458fffccd4e6ad2fbde479c",
+		"kdf": "scrypt",
+		"kdfparams": {"dklen": 32, "n": 262144, "r": 1, "p": 8, "salt": "ab0c7876052600dd703518d6fc3fe8984592145b591fc8fb5c6d43190334ba19"},
+		"mac": "2103ac29920d71da29f15d75b4a16dbe95cfd7ff8faea1056c33131d846e3097"
+	},
+	"id": "3198bc9c-6672-5ab3-d995-4942343ae5b6",
+	"version": 3
+}`
+
+func TestEncryptAndDecrypt(t *testing.T) {
+	keyPair := keys.EcdsaSecp256K1KeyPairForTests(0)
+
+	keystore, err := Encrypt(keyPair.PrivateKey(), []byte("passphrase"), LIGHT_SCRYPT_N, LIGHT_SCRYPT_P)
+	require.NoError(t, err)
+
+	decoded := &keystoreJson{}
+	require.NoError(t, json.Unmarshal(keystore, decoded))
+	require.Equal(t, hex.EncodeToString(keyPair.NodeAddress()), decoded.Address, "keystore should name the node address")
+
+	privateKey, err := Decrypt(keystore, []byte("passphrase"))
+	require.NoError(t, err)
+	require.Equal(t, keyPair.PrivateKey(), privateKey, "decrypted private key should be the one encrypted")
+}
+
+func TestDecryptWithWrongPassphraseFails(t *testing.T) {
+	keystore, err := Encrypt(keys.EcdsaSecp256K1KeyPairForTests(0).PrivateKey(), []byte("passphrase"), LIGHT_SCRYPT_N, LIGHT_SCRYPT_P)
+	require.NoError(t, err)
+
+	_, err = Decrypt(keystore, []byte("other passphrase"))
+	require.Error(t, err, "wrong passphrase should fail")
+}
+
+func TestDecryptWeb3TestVector(t *testing.T) {
+	privateKey, err := Decrypt([]byte(web3TestVectorKeystore), []byte("testpassword"))
+	require.NoError(t, err)
+	require.Equal(t, "7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d", hex.EncodeToString(privateKey))
+}
@@ -24,6 +24,7 @@ type node struct {
 	hash  primitives.Sha256
 	left  *node
 	right *node
+	store NodeStore // set only on nodes not loaded from their store yet, which hold nothing but their hash
 }
 
 func createNode(path []byte, valueHash primitives.Sha256) *node {
@@ -58,6 +59,7 @@ func (n *node) setChild(bit byte, child *node) {
 }
 
 func (n *node) clone() *node {
+	n = n.resolve()
 	result := &node{
 		path:  n.path,
 		value: n.value,
@@ -189,6 +191,9 @@ func lastCommonPathIndex(current *node, path []byte) (i int) {
 type nodeHasherFunc func(n *node) primitives.Sha256
 
 func collapseAndHash(current *node, sandbox dirtyNodes, f nodeHasherFunc) *node {
+	if current.store != nil { // never loaded so never changed
+		return current
+	}
 	if !current.isLeaf() {
 		collapseDirtyChildren(current, sandbox, f)
 	}
@@ -219,7 +224,7 @@ func collapseDirtyChildren(current *node, sandbox dirtyNodes, f nodeHasherFunc)
 }
 
 func collapseOnlyChild(current *node, onlyChild byte) *node {
-	child := current.getChild(onlyChild)
+	child := current.getChild(onlyChild).resolve()
 	combinedPath := append(current.path, byte(onlyChild))
 	combinedPath = append(combinedPath, child.path...)
 	current = child.clone()
@@ -0,0 +1,238 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package merkle
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/orbs-network/orbs-network-go/crypto/hash"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/pkg/errors"
+	"io"
+	"sync"
+)
+
+// Content addressed storage of trie nodes, keyed by node hash. A node is stored once and counts its references,
+// one from every stored parent node and one for every forest root it is, so it is deleted once nothing refers to it.
+// Changes are durable only once committed, along with the forest roots they leave behind.
+type NodeStore interface {
+	Get(hash primitives.Sha256) ([]byte, error)
+	Put(hash primitives.Sha256, data []byte) (bool, error) // adds a reference, returns true if the node was new
+	Reference(hash primitives.Sha256) error
+	Release(hash primitives.Sha256) ([]byte, bool, error) // removes a reference, returns the node's data if it was deleted
+	Roots() ([]primitives.Sha256, error)
+	Commit(roots []primitives.Sha256) error
+}
+
+type storedNode struct {
+	data []byte
+	refs uint32
+}
+
+type MemoryNodeStore struct {
+	mutex sync.RWMutex
+	nodes map[string]*storedNode
+	roots []primitives.Sha256
+}
+
+func NewMemoryNodeStore() *MemoryNodeStore {
+	return &MemoryNodeStore{nodes: make(map[string]*storedNode)}
+}
+
+func (s *MemoryNodeStore) Get(hash primitives.Sha256) ([]byte, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	n, ok := s.nodes[string(hash)]
+	if !ok {
+		return nil, errors.Errorf("trie node %s not found", hash)
+	}
+	return n.data, nil
+}
+
+func (s *MemoryNodeStore) Put(hash primitives.Sha256, data []byte) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if n, ok := s.nodes[string(hash)]; ok {
+		n.refs++
+		return false, nil
+	}
+	s.nodes[string(hash)] = &storedNode{data: data, refs: 1}
+	return true, nil
+}
+
+func (s *MemoryNodeStore) Reference(hash primitives.Sha256) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	n, ok := s.nodes[string(hash)]
+	if !ok {
+		return errors.Errorf("trie node %s not found", hash)
+	}
+	n.refs++
+	return nil
+}
+
+func (s *MemoryNodeStore) Release(hash primitives.Sha256) ([]byte, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	n, ok := s.nodes[string(hash)]
+	if !ok {
+		return nil, false, errors.Errorf("trie node %s not found", hash)
+	}
+	n.refs--
+	if n.refs > 0 {
+		return nil, false, nil
+	}
+	delete(s.nodes, string(hash))
+	return n.data, true, nil
+}
+
+func (s *MemoryNodeStore) Roots() ([]primitives.Sha256, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return append([]primitives.Sha256{}, s.roots...), nil
+}
+
+func (s *MemoryNodeStore) Commit(roots []primitives.Sha256) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.roots = append([]primitives.Sha256{}, roots...)
+	return nil
+}
+
+func (s *MemoryNodeStore) Len() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return len(s.nodes)
+}
+
+// a node as stored: its path bits packed, its value hash and the hashes of its children
+func encodeNode(n *node) []byte {
+	buf := &bytes.Buffer{}
+	writeUvarint(buf, uint64(len(n.path)))
+	packed := make([]byte, (len(n.path)+7)/8)
+	for i, bit := range n.path {
+		packed[i/8] |= bit << uint(7-(i%8))
+	}
+	buf.Write(packed)
+
+	writeUvarint(buf, uint64(len(n.value)))
+	buf.Write(n.value)
+
+	var children byte
+	if n.left != nil {
+		children |= 1
+	}
+	if n.right != nil {
+		children |= 2
+	}
+	buf.WriteByte(children)
+	if n.left != nil {
+		buf.Write(n.left.hash)
+	}
+	if n.right != nil {
+		buf.Write(n.right.hash)
+	}
+	return buf.Bytes()
+}
+
+// children are left unloaded, verifies the node matches the hash it was stored by
+func decodeNode(nodeHash primitives.Sha256, data []byte, store NodeStore) (*node, error) {
+	r := bytes.NewReader(data)
+	pathLen, err := binary.ReadUvarint(r)
+	if err != nil || pathLen > uint64(len(data))*8 {
+		return nil, errors.New("invalid path length")
+	}
+	packed := make([]byte, (pathLen+7)/8)
+	if _, err := io.ReadFull(r, packed); err != nil {
+		return nil, errors.Wrap(err, "failed reading path")
+	}
+
+	valueLen, err := binary.ReadUvarint(r)
+	if err != nil || valueLen > uint64(r.Len()) {
+		return nil, errors.New("invalid value length")
+	}
+	value := make([]byte, valueLen)
+	if _, err := io.ReadFull(r, value); err != nil {
+		return nil, errors.Wrap(err, "failed reading value")
+	}
+
+	n := createNode(toBin(packed, int(pathLen)), value)
+	children, err := r.ReadByte()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed reading children")
+	}
+	if children&1 != 0 {
+		if n.left, err = readStubNode(r, store); err != nil {
+			return nil, err
+		}
+	}
+	if children&2 != 0 {
+		if n.right, err = readStubNode(r, store); err != nil {
+			return nil, err
+		}
+	}
+	if r.Len() != 0 {
+		return nil, errors.Errorf("%d unexpected trailing bytes", r.Len())
+	}
+
+	n.hash = hashTrieNode(n)
+	if !n.hash.Equal(nodeHash) {
+		return nil, errors.Errorf("node hash is %s", n.hash)
+	}
+	return n, nil
+}
+
+func readStubNode(r io.Reader, store NodeStore) (*node, error) {
+	h := make(primitives.Sha256, hash.SHA256_HASH_SIZE_BYTES)
+	if _, err := io.ReadFull(r, h); err != nil {
+		return nil, errors.Wrap(err, "failed reading child hash")
+	}
+	return &node{hash: h, store: store}, nil
+}
+
+func writeUvarint(buf *bytes.Buffer, v uint64) {
+	b := make([]byte, binary.MaxVarintLen64)
+	buf.Write(b[:binary.PutUvarint(b, v)])
+}
+
+// raised while walking a trie which cannot be loaded from its node store, recovered into an error by the forest
+type nodeStoreError struct {
+	err error
+}
+
+// a node not loaded from its store holds only its hash, loading it never modifies the shared stub
+func (n *node) resolve() *node {
+	if n == nil || n.store == nil {
+		return n
+	}
+	data, err := n.store.Get(n.hash)
+	if err != nil {
+		panic(&nodeStoreError{errors.Wrapf(err, "failed to load trie node %s", n.hash)})
+	}
+	loaded, err := decodeNode(n.hash, data, n.store)
+	if err != nil {
+		panic(&nodeStoreError{errors.Wrapf(err, "failed to decode trie node %s", n.hash)})
+	}
+	return loaded
+}
+
+func recoverNodeStoreError(err *error) {
+	if p := recover(); p != nil {
+		if e, ok := p.(*nodeStoreError); ok {
+			*err = e.err
+			return
+		}
+		panic(p)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package merkle
+
+import (
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNodeStore_EncodeDecodeNode(t *testing.T) {
+	f, root := NewForest()
+	root1 := updateEntries(f, root, "0000", "baz1", "0100", "baz2", "0110", "baz3")
+
+	for _, n := range []*node{f.findRoot(root), f.findRoot(root1), f.findRoot(root1).left, f.findRoot(root1).right} {
+		decoded, err := decodeNode(n.hash, encodeNode(n), nil)
+		require.NoError(t, err)
+		require.Equal(t, n.path, decoded.path, "decoded path should equal the original")
+		require.Equal(t, n.value, decoded.value, "decoded value should equal the original")
+		require.Equal(t, n.hash, decoded.hash, "decoded hash should equal the original")
+	}
+
+	data := encodeNode(f.findRoot(root1))
+	data[len(data)-1] ^= 1
+	_, err := decodeNode(root1, data, nil)
+	require.Error(t, err, "a node which does not match its hash should be rejected")
+}
+
+func TestForestWithNodeStore_MatchesMemoryForest(t *testing.T) {
+	memoryForest, memoryRoot := NewForest()
+	storeForest, storeRoot, err := NewForestWithNodeStore(NewMemoryNodeStore())
+	require.NoError(t, err)
+	require.Equal(t, memoryRoot, storeRoot, "empty roots should be equal")
+
+	keyValues := [][]string{
+		{"0000", "baz1", "0100", "baz2", "0110", "baz3"},
+		{"0110", "baz4", "1000", "baz5"},
+		{"0000", "", "0100", ""},
+	}
+	for _, kv := range keyValues {
+		memoryRoot = updateEntries(memoryForest, memoryRoot, kv...)
+		storeRoot = updateEntries(storeForest, storeRoot, kv...)
+		require.Equal(t, memoryRoot, storeRoot, "roots should be equal after update")
+
+		for _, key := range []string{"0000", "0110", "0111", "1000"} {
+			storeProof := getProof(t, storeForest, storeRoot, key)
+			require.Equal(t, getProof(t, memoryForest, memoryRoot, key), storeProof, "proofs should be equal")
+		}
+	}
+	verifyProof(t, storeForest, storeRoot, getProof(t, storeForest, storeRoot, "0110"), "0110", "baz4", true)
+	verifyProof(t, storeForest, storeRoot, getProof(t, storeForest, storeRoot, "0000"), "0000", "", true)
+}
+
+func TestForestWithNodeStore_ForgetDeletesUnreferencedNodes(t *testing.T) {
+	memoryForest, memoryRoot := NewForest()
+	store := NewMemoryNodeStore()
+	f, root, err := NewForestWithNodeStore(store)
+	require.NoError(t, err)
+
+	root1 := updateEntries(f, root, "0000", "baz1", "0100", "baz2", "0110", "baz3")
+	root2 := updateEntries(f, root1, "0110", "baz4", "1000", "baz5")
+	memoryRoot = updateEntries(memoryForest, updateEntries(memoryForest, memoryRoot, "0000", "baz1", "0100", "baz2", "0110", "baz3"), "0110", "baz4", "1000", "baz5")
+	require.Equal(t, memoryRoot, root2)
+
+	f.Forget(root)
+	f.Forget(root1)
+	require.Equal(t, []primitives.Sha256{root2}, f.Roots())
+	require.Equal(t, countDistinctNodes(memoryForest.findRoot(memoryRoot), map[string]bool{}), store.Len(), "only the nodes of the remaining trie should be stored")
+
+	f.Forget(root2)
+	require.Zero(t, store.Len(), "no nodes should be stored without roots")
+}
+
+func TestForestWithNodeStore_RestoresRootsCommittedToStore(t *testing.T) {
+	store := NewMemoryNodeStore()
+	f, root, err := NewForestWithNodeStore(store)
+	require.NoError(t, err)
+	root1 := updateEntries(f, root, "0000", "baz1", "0100", "baz2")
+	f.Forget(root)
+
+	restored, emptyRoot, err := NewForestWithNodeStore(store)
+	require.NoError(t, err)
+	require.Equal(t, []primitives.Sha256{root1, emptyRoot}, restored.Roots(), "should restore the committed roots followed by the empty root")
+
+	verifyProof(t, restored, root1, getProof(t, restored, root1, "0100"), "0100", "baz2", true)
+	root2 := updateEntries(restored, root1, "0100", "baz3")
+	verifyProof(t, restored, root2, getProof(t, restored, root2, "0100"), "0100", "baz3", true)
+}
+
+func TestForestWithNodeStore_FailsOnMissingNode(t *testing.T) {
+	store := NewMemoryNodeStore()
+	f, root, err := NewForestWithNodeStore(store)
+	require.NoError(t, err)
+	root1 := updateEntries(f, root, "0000", "baz1", "0100", "baz2")
+
+	delete(store.nodes, string(root1))
+
+	_, err = f.GetProof(root1, hexStringToBytes("0000"))
+	require.Error(t, err, "proof of a trie missing from the store should fail")
+	_, err = f.Update(root1, TrieDiffs{{Key: hexStringToBytes("0000"), Value: zeroValueHash}})
+	require.Error(t, err, "update of a trie missing from the store should fail")
+}
+
+func countDistinctNodes(n *node, seen map[string]bool) int {
+	if n == nil || seen[string(n.hash)] {
+		return 0
+	}
+	seen[string(n.hash)] = true
+	return 1 + countDistinctNodes(n.left, seen) + countDistinctNodes(n.right, seen)
+}
@@ -0,0 +1,137 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package merkle
+
+import (
+	"bytes"
+	"github.com/orbs-network/orbs-network-go/crypto/hash"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/pkg/errors"
+	"sort"
+)
+
+// Proves the values at several indices of an ordered tree at once. Paths to the root share their upper nodes,
+// so each hash needed to compute the root is held once, in the order the verifier consumes them:
+// level by level from the leaves up, and within a level from left to right.
+type OrderedTreeMultiProof struct {
+	NumValues int   // of the tree, it determines the tree's shape
+	Indices   []int // ascending, of the proven values
+	Hashes    []primitives.Sha256
+}
+
+// Builds the tree of the values once and collects the hashes proving all the given indices, which may repeat and come in any order
+func GetOrderedTreeMultiProof(values []primitives.Sha256, indices []int) (*OrderedTreeMultiProof, error) {
+	if len(indices) == 0 {
+		return nil, errors.Errorf("no indices to prove")
+	}
+	known := uniqueSortedIndices(indices)
+	for _, index := range known {
+		if index < 0 || index >= len(values) {
+			return nil, errors.Errorf("index %d for proof is out of bounds", index)
+		}
+	}
+
+	proof := &OrderedTreeMultiProof{NumValues: len(values), Indices: append([]int{}, known...)}
+
+	level := values
+	for len(level) > 1 {
+		for i, position := range known {
+			sibling := position ^ 1
+			isSiblingKnown := (i > 0 && known[i-1] == sibling) || (i < len(known)-1 && known[i+1] == sibling)
+			if sibling < len(level) && !isSiblingKnown {
+				proof.Hashes = append(proof.Hashes, level[sibling])
+			}
+		}
+		known = parentPositions(known)
+
+		next := make([]primitives.Sha256, len(level)/2+1)
+		next = next[:calculateOrderedTreeRootCollapseOneLevel(level, next, len(level))]
+		level = next
+	}
+
+	return proof, nil
+}
+
+// Verifies a multi proof as produced by GetOrderedTreeMultiProof, the values are those at the proof's indices, in the same order
+func VerifyMultiProof(values []primitives.Sha256, proof *OrderedTreeMultiProof, root primitives.Sha256) error {
+	if proof == nil || len(proof.Indices) == 0 {
+		return errors.Errorf("proof has no indices")
+	}
+	if len(values) != len(proof.Indices) {
+		return errors.Errorf("got %d values for %d proof indices", len(values), len(proof.Indices))
+	}
+	for i, index := range proof.Indices {
+		if index < 0 || index >= proof.NumValues {
+			return errors.Errorf("proof index %d is out of bounds", index)
+		}
+		if i > 0 && index <= proof.Indices[i-1] {
+			return errors.Errorf("proof indices are not strictly ascending")
+		}
+	}
+	for i, h := range proof.Hashes {
+		if len(h) != hash.SHA256_HASH_SIZE_BYTES {
+			return errors.Errorf("proof hash %d has an invalid size", i)
+		}
+	}
+
+	known := append([]int{}, proof.Indices...)
+	current := append([]primitives.Sha256{}, values...)
+	consumed := 0
+	for size := proof.NumValues; size > 1; size = (size + 1) / 2 {
+		var next []primitives.Sha256
+		for i := 0; i < len(known); i++ {
+			position := known[i]
+			sibling := position ^ 1
+			switch {
+			case sibling >= size: // the last node of an odd level is carried up as is
+				next = append(next, current[i])
+			case i < len(known)-1 && known[i+1] == sibling:
+				next = append(next, hashTwo(current[i], current[i+1]))
+				i++
+			default:
+				if consumed == len(proof.Hashes) {
+					return errors.Errorf("proof has too few hashes")
+				}
+				next = append(next, hashTwo(current[i], proof.Hashes[consumed]))
+				consumed++
+			}
+		}
+		known = parentPositions(known)
+		current = next
+	}
+
+	if consumed != len(proof.Hashes) {
+		return errors.Errorf("proof has %d unused hashes", len(proof.Hashes)-consumed)
+	}
+	if !bytes.Equal(root, current[0]) {
+		return errors.Errorf("proof hash did not match the root")
+	}
+	return nil
+}
+
+func uniqueSortedIndices(indices []int) []int {
+	sorted := append([]int{}, indices...)
+	sort.Ints(sorted)
+	unique := sorted[:0]
+	for i, index := range sorted {
+		if i == 0 || index != sorted[i-1] {
+			unique = append(unique, index)
+		}
+	}
+	return unique
+}
+
+// siblings share a parent, which appears once
+func parentPositions(positions []int) []int {
+	var parents []int
+	for _, position := range positions {
+		if len(parents) == 0 || parents[len(parents)-1] != position/2 {
+			parents = append(parents, position/2)
+		}
+	}
+	return parents
+}
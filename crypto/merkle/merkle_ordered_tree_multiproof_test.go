@@ -0,0 +1,129 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package merkle
+
+import (
+	"github.com/orbs-network/orbs-network-go/crypto/hash"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/stretchr/testify/require"
+	"math/rand"
+	"testing"
+)
+
+func TestMultiProof_VerifiesAgainstRootForAnySetOfIndices(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+	for numValues := 1; numValues <= 33; numValues++ {
+		values := generateHashValueList(rnd.Perm(numValues))
+		root := CalculateOrderedTreeRoot(values)
+
+		for i := 0; i < 20; i++ {
+			indices := rnd.Perm(numValues)[:1+rnd.Intn(numValues)]
+			proof, err := GetOrderedTreeMultiProof(values, indices)
+			require.NoError(t, err)
+			require.NoError(t, VerifyMultiProof(valuesAt(values, proof.Indices), proof, root), "multi proof of %v out of %d values should verify", indices, numValues)
+		}
+	}
+}
+
+func TestMultiProof_OfSingleIndexHoldsTheSingleProofHashes(t *testing.T) {
+	values := generateHashValueList([]int{8, 7, 6, 5, 4, 3, 2, 1, 0, -1, -2})
+	tree := NewOrderedTree(values)
+	for index := range values {
+		single, err := tree.GetProof(index)
+		require.NoError(t, err)
+		proof, err := GetOrderedTreeMultiProof(values, []int{index})
+		require.NoError(t, err)
+
+		require.Len(t, proof.Hashes, len(single), "proof of index %d has the wrong size", index)
+		for i, h := range proof.Hashes {
+			require.Equal(t, single[len(single)-1-i], h, "proof of index %d differs at level %d", index, i)
+		}
+	}
+}
+
+func TestMultiProof_SharesHashesOfCommonPaths(t *testing.T) {
+	values := generateHashValueList([]int{8, 7, 6, 5, 4, 3, 2, 1})
+
+	proof, err := GetOrderedTreeMultiProof(values, []int{3, 0, 1, 2, 3})
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 1, 2, 3}, proof.Indices, "indices should be sorted and unique")
+	require.Len(t, proof.Hashes, 1, "only the hash of the right half should be needed")
+	require.Equal(t, hashTwo(hashTwo(values[4], values[5]), hashTwo(values[6], values[7])), proof.Hashes[0])
+
+	proof, err = GetOrderedTreeMultiProof(values, allIndices(len(values)))
+	require.NoError(t, err)
+	require.Empty(t, proof.Hashes, "proving all values should need no hashes")
+}
+
+func TestMultiProof_OutOfBounds(t *testing.T) {
+	values := generateHashValueList([]int{1, 2, 3})
+
+	_, err := GetOrderedTreeMultiProof(values, []int{0, 3})
+	require.Error(t, err, "index past the last value should fail")
+	_, err = GetOrderedTreeMultiProof(values, []int{-1})
+	require.Error(t, err, "negative index should fail")
+	_, err = GetOrderedTreeMultiProof(values, nil)
+	require.Error(t, err, "no indices should fail")
+}
+
+func TestMultiProof_RejectsTampering(t *testing.T) {
+	values := generateHashValueList([]int{8, 7, 6, 5, 4, 3, 2, 1, 0, -1, -2, 1000, 55})
+	root := CalculateOrderedTreeRoot(values)
+	proof, err := GetOrderedTreeMultiProof(values, []int{2, 7, 12})
+	require.NoError(t, err)
+	proven := valuesAt(values, proof.Indices)
+	require.NoError(t, VerifyMultiProof(proven, proof, root))
+
+	wrongValue := append([]primitives.Sha256{}, proven...)
+	wrongValue[1] = hash.CalcSha256([]byte("other"))
+	require.Error(t, VerifyMultiProof(wrongValue, proof, root), "wrong value should not verify")
+
+	swapped := []primitives.Sha256{proven[1], proven[0], proven[2]}
+	require.Error(t, VerifyMultiProof(swapped, proof, root), "values out of order should not verify")
+
+	require.Error(t, VerifyMultiProof(proven[:2], proof, root), "missing value should not verify")
+
+	otherIndices := *proof
+	otherIndices.Indices = []int{2, 6, 12}
+	require.Error(t, VerifyMultiProof(valuesAt(values, otherIndices.Indices), &otherIndices, root), "proof of other indices should not verify")
+
+	unsorted := *proof
+	unsorted.Indices = []int{7, 2, 12}
+	require.Error(t, VerifyMultiProof(proven, &unsorted, root), "unsorted indices should be rejected")
+
+	otherSize := *proof
+	otherSize.NumValues = 16
+	require.Error(t, VerifyMultiProof(proven, &otherSize, root), "proof of another tree shape should not verify")
+
+	missingHash := *proof
+	missingHash.Hashes = proof.Hashes[:len(proof.Hashes)-1]
+	require.Error(t, VerifyMultiProof(proven, &missingHash, root), "proof with a missing hash should be rejected")
+
+	extraHash := *proof
+	extraHash.Hashes = append(append([]primitives.Sha256{}, proof.Hashes...), root)
+	require.Error(t, VerifyMultiProof(proven, &extraHash, root), "proof with an extra hash should be rejected")
+
+	shortHash := *proof
+	shortHash.Hashes = append([]primitives.Sha256{proof.Hashes[0][:10]}, proof.Hashes[1:]...)
+	require.Error(t, VerifyMultiProof(proven, &shortHash, root), "proof with a short hash should be rejected")
+}
+
+func valuesAt(values []primitives.Sha256, indices []int) []primitives.Sha256 {
+	res := make([]primitives.Sha256, len(indices))
+	for i, index := range indices {
+		res[i] = values[index]
+	}
+	return res
+}
+
+func allIndices(numValues int) []int {
+	res := make([]int, numValues)
+	for i := range res {
+		res[i] = i
+	}
+	return res
+}
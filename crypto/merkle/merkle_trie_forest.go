@@ -17,11 +17,34 @@ import (
 type Forest struct {
 	mutex sync.Mutex
 	roots []*node
+
+	store      NodeStore  // nil when every node is kept in memory
+	storeMutex sync.Mutex // changes to the store are committed one forest operation at a time
 }
 
 func NewForest() (*Forest, primitives.Sha256) {
 	var emptyNode = createEmptyTrieNode()
-	return &Forest{sync.Mutex{}, []*node{emptyNode}}, emptyNode.hash
+	return &Forest{roots: []*node{emptyNode}}, emptyNode.hash
+}
+
+// Keeps the tries in a node store rather than in memory, loading only the nodes along the paths being updated or proven.
+// The roots committed to the store are restored, followed by a new root of the empty trie.
+func NewForestWithNodeStore(store NodeStore) (*Forest, primitives.Sha256, error) {
+	rootHashes, err := store.Roots()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to read trie roots from node store")
+	}
+
+	f := &Forest{store: store}
+	for _, rootHash := range rootHashes {
+		f.roots = append(f.roots, &node{hash: rootHash, store: store})
+	}
+
+	emptyNode := createEmptyTrieNode()
+	if err := f.storeRoot(emptyNode); err != nil {
+		return nil, nil, err
+	}
+	return f, emptyNode.hash, nil
 }
 
 func createEmptyTrieNode() *node {
@@ -50,6 +73,76 @@ func (f *Forest) appendRoot(root *node) {
 	f.roots = append(f.roots, root)
 }
 
+// The hashes of every root in the forest, oldest first
+func (f *Forest) Roots() []primitives.Sha256 {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	result := make([]primitives.Sha256, 0, len(f.roots))
+	for _, root := range f.roots {
+		result = append(result, root.hash)
+	}
+	return result
+}
+
+func (f *Forest) storeRoot(root *node) error {
+	f.storeMutex.Lock()
+	defer f.storeMutex.Unlock()
+
+	if err := f.storeNode(root); err != nil {
+		return errors.Wrap(err, "failed to store trie nodes")
+	}
+	f.appendRoot(&node{hash: root.hash, store: f.store}) // keeps no loaded nodes in memory
+	return f.commit()
+}
+
+// stores every node not stored yet, a node stored before only gains a reference
+func (f *Forest) storeNode(n *node) error {
+	if n.store != nil {
+		return f.store.Reference(n.hash)
+	}
+
+	created, err := f.store.Put(n.hash, encodeNode(n))
+	if err != nil || !created {
+		return err
+	}
+	for _, child := range []*node{n.left, n.right} {
+		if child != nil {
+			if err := f.storeNode(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (f *Forest) releaseNode(nodeHash primitives.Sha256) error {
+	data, deleted, err := f.store.Release(nodeHash)
+	if err != nil || !deleted {
+		return err
+	}
+
+	n, err := decodeNode(nodeHash, data, f.store)
+	if err != nil {
+		return errors.Wrapf(err, "failed to decode released trie node %s", nodeHash)
+	}
+	for _, child := range []*node{n.left, n.right} {
+		if child != nil {
+			if err := f.releaseNode(child.hash); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (f *Forest) commit() error {
+	if err := f.store.Commit(f.Roots()); err != nil {
+		return errors.Wrap(err, "failed to commit trie nodes")
+	}
+	return nil
+}
+
 type TrieProofNode struct {
 	otherChildHash primitives.Sha256 // the "other child"'s hash
 	prefixSize     int               // "my" prefix size
@@ -71,8 +164,10 @@ func (tp *TrieProof) appendToProof(n, otherChild *node) {
 	tp.nodes = append(tp.nodes, &TrieProofNode{otherChild.hash, len(n.path)})
 }
 
-func (f *Forest) GetProof(rootHash primitives.Sha256, path []byte) (*TrieProof, error) {
-	current := f.findRoot(rootHash)
+func (f *Forest) GetProof(rootHash primitives.Sha256, path []byte) (result *TrieProof, err error) {
+	defer recoverNodeStoreError(&err)
+
+	current := f.findRoot(rootHash).resolve()
 	if current == nil {
 		return nil, errors.Errorf("unknown root")
 	}
@@ -89,10 +184,10 @@ func (f *Forest) GetProof(rootHash primitives.Sha256, path []byte) (*TrieProof,
 		sibling := current
 		if p[0] == 0 {
 			sibling = parent.right
-			current = parent.left
+			current = parent.left.resolve()
 		} else {
 			sibling = parent.left
-			current = parent.right
+			current = parent.right.resolve()
 		}
 
 		if current != nil {
@@ -118,94 +213,35 @@ func (f *Forest) GetProof(rootHash primitives.Sha256, path []byte) (*TrieProof,
 	return proof, nil
 }
 
+// See VerifyTrieProof, which does not need a forest
 func (f *Forest) Verify(rootHash primitives.Sha256, proof *TrieProof, path []byte, valueHash primitives.Sha256) (bool, error) {
-	if proof == nil || len(proof.nodes) == 0 {
-		return valueHash.Equal(zeroValueHash), nil
-	}
-
-	lastNodePathIndex := calculateLastNodePathIndex(proof)
-	pathFromVerify := toBin(path, toBinSize(path))
-
-	if !verifyProofIsSelfConsistent(rootHash, proof, pathFromVerify, lastNodePathIndex) {
-		return false, errors.Errorf("proof is not self consistent with given key")
-	}
-
-	if !valueHash.Equal(zeroValueHash) {
-		return verifyProofInclusion(proof, pathFromVerify, valueHash, lastNodePathIndex), nil
-	} else {
-		return verifyProofExclusion(proof, pathFromVerify, lastNodePathIndex)
-	}
-}
-
-func calculateLastNodePathIndex(proof *TrieProof) int {
-	LastNodeIndex := len(proof.nodes) - 1
-	lastNodePathIndex := 0
-	for i := 0; i < LastNodeIndex; i++ {
-		lastNodePathIndex = lastNodePathIndex + proof.nodes[i].prefixSize + 1
-	}
-	return lastNodePathIndex
-}
-
-func verifyProofIsSelfConsistent(rootHash primitives.Sha256, proof *TrieProof, pathFromVerify []byte, lastNodePathIndex int) bool {
-	lastNodeIndex := len(proof.nodes) - 1
-	keyEndInd := lastNodePathIndex + proof.nodes[lastNodeIndex].prefixSize
-	keyStartInd := lastNodePathIndex
-	currentHash := proof.nodes[lastNodeIndex].otherChildHash
-
-	for i := lastNodeIndex - 1; i >= 0; i-- {
-		keyEndInd = keyStartInd - 1
-		keyStartInd = keyEndInd - proof.nodes[i].prefixSize
-		if pathFromVerify[keyEndInd] == 0 {
-			currentHash = hashBytes(currentHash, proof.nodes[i].otherChildHash, pathFromVerify[keyStartInd:keyEndInd])
-		} else {
-			currentHash = hashBytes(proof.nodes[i].otherChildHash, currentHash, pathFromVerify[keyStartInd:keyEndInd])
-		}
-	}
-
-	return bytes.Equal(currentHash, rootHash)
+	return verifyTrieProof(rootHash, proof, path, valueHash)
 }
 
-func verifyProofInclusion(proof *TrieProof, verifyPath []byte, verifyValueHash primitives.Sha256, lastNodePathIndex int) bool {
-	lastNodeIndex := len(proof.nodes) - 1
-	calculatedHash := hashBytes(verifyValueHash, verifyPath[lastNodePathIndex:])
-	lastNodeHash := proof.nodes[lastNodeIndex].otherChildHash
-	return bytes.Equal(lastNodeHash, calculatedHash)
-}
-
-func verifyProofExclusion(proof *TrieProof, pathFromVerify []byte, lastNodePathIndex int) (bool, error) {
-	pathLen := len(proof.path)
-	if pathLen != len(pathFromVerify) {
-		return false, errors.Errorf("proof length is not consistent with given key length")
+// With a node store, the nodes no other root refers to are deleted. A store failure only leaves nodes behind,
+// the changes not committed yet are committed along with the next forest operation.
+func (f *Forest) Forget(rootHash primitives.Sha256) {
+	if f.store == nil {
+		f.forgetRoot(rootHash)
+		return
 	}
 
-	lastNodeIndex := len(proof.nodes) - 1
-	lastNodePrefix := proof.path[lastNodePathIndex : lastNodePathIndex+proof.nodes[lastNodeIndex].prefixSize]
+	f.storeMutex.Lock()
+	defer f.storeMutex.Unlock()
 
-	var calculatedHash []byte
-	if proof.extraHashRight != nil {
-		calculatedHash = hashBytes(proof.extraHashLeft, proof.extraHashRight, lastNodePrefix)
-	} else {
-		calculatedHash = hashBytes(proof.extraHashLeft, lastNodePrefix)
+	if f.forgetRoot(rootHash) {
+		_ = f.releaseNode(rootHash)
+		_ = f.commit()
 	}
-	lastNodeHash := proof.nodes[lastNodeIndex].otherChildHash
-	isHashEqual := bytes.Equal(lastNodeHash, calculatedHash)
-
-	isBeginOfPathEqual := bytes.Equal(proof.path[:lastNodePathIndex], pathFromVerify[:lastNodePathIndex])
-	isEndOfPathEqual := true
-	if lastNodePathIndex < pathLen {
-		isEndOfPathEqual = bytes.Equal(lastNodePrefix, pathFromVerify[lastNodePathIndex:])
-	}
-
-	return isHashEqual && isBeginOfPathEqual && !isEndOfPathEqual, nil
 }
 
-func (f *Forest) Forget(rootHash primitives.Sha256) {
+func (f *Forest) forgetRoot(rootHash primitives.Sha256) bool {
 	f.mutex.Lock()
 	defer f.mutex.Unlock()
 
-	if f.roots[0].hash.Equal(rootHash) { // optimization for most likely use
+	if len(f.roots) > 0 && f.roots[0].hash.Equal(rootHash) { // optimization for most likely use
 		f.roots = f.roots[1:]
-		return
+		return true
 	}
 
 	found := false
@@ -218,6 +254,7 @@ func (f *Forest) Forget(rootHash primitives.Sha256) {
 		}
 	}
 	f.roots = newRoots
+	return found
 }
 
 type TrieDiff struct {
@@ -226,7 +263,9 @@ type TrieDiff struct {
 }
 type TrieDiffs []*TrieDiff
 
-func (f *Forest) Update(rootMerkle primitives.Sha256, diffs TrieDiffs) (primitives.Sha256, error) {
+func (f *Forest) Update(rootMerkle primitives.Sha256, diffs TrieDiffs) (result primitives.Sha256, err error) {
+	defer recoverNodeStoreError(&err)
+
 	root := f.findRoot(rootMerkle)
 	if root == nil {
 		return nil, errors.Errorf("must start with valid root")
@@ -243,6 +282,12 @@ func (f *Forest) Update(rootMerkle primitives.Sha256, diffs TrieDiffs) (primitiv
 		root = createEmptyTrieNode()
 	}
 
+	if f.store != nil {
+		if err := f.storeRoot(root); err != nil {
+			return nil, err
+		}
+		return root.hash, nil
+	}
 	f.appendRoot(root)
 	return root.hash, nil
 }
@@ -8,6 +8,7 @@ package merkle
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"github.com/orbs-network/orbs-network-go/crypto/hash"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
@@ -47,7 +48,7 @@ func TestTrieNodeHashFunc_Table(t *testing.T) {
 		name string
 		n    *node
 	}{
-		{"empty leaf node", &node{[]byte{}, primitives.Sha256{}, primitives.Sha256{}, nil, nil}},
+		{"empty leaf node", &node{path: []byte{}, value: primitives.Sha256{}, hash: primitives.Sha256{}}},
 		{"leaf node", &node{path: leftPrefix, value: leftValue}},
 		{"node with left", &node{path: []byte{1, 1, 1, 1}, left: leftLeaf, right: nil}},
 		{"node with left no prefix", &node{path: []byte{}, left: leftLeaf, right: nil}},
@@ -321,9 +322,9 @@ func TestProof_ValidationForMissingKeyDivergentInMiddle(t *testing.T) {
 	verifyProof(t, f, root1, proof, key, "non-zero", false)
 	// verify with wrong key that is diff in core node - proof is inconsistent
 	verifInconsistentProof(t, f, root1, proof, "02000000", "")
-	// verify with wrong key that is diff in leaf node - exclusion possible
+	// verify with wrong key whose path goes through the proof's last node - exclusion not proven
 	verifyProof(t, f, root1, proof, "00000011", "baz3", false)
-	verifyProof(t, f, root1, proof, "00000011", "", true)
+	verifyProof(t, f, root1, proof, "00000011", "", false)
 }
 
 func TestProof_ValidationKeyWithLeavesWithNoPrefix(t *testing.T) {
@@ -384,6 +385,34 @@ func TestProof_AddConvegingPathsWithExactValues(t *testing.T) {
 	verifyProof(t, f, root2, proof4, "acdcda", "1", true)
 }
 
+func TestProof_JSONRoundTrip(t *testing.T) {
+	f, root := NewForest()
+	root1 := updateEntries(f, root, "0000", "baz1", "0100", "baz2", "0110", "baz3")
+
+	for _, key := range []string{"0110", "0111"} {
+		data, err := json.Marshal(getProof(t, f, root1, key))
+		require.NoError(t, err)
+
+		decoded := &TrieProof{}
+		require.NoError(t, json.Unmarshal(data, decoded))
+		require.Equal(t, getProof(t, f, root1, key), decoded, "decoded proof should equal the original")
+	}
+	verifyProof(t, f, root1, getProof(t, f, root1, "0110"), "0110", "baz3", true)
+}
+
+func TestProof_JSONRejectsMalformedProof(t *testing.T) {
+	f, root := NewForest()
+	root1 := updateEntries(f, root, "0000", "baz1", "0100", "baz2")
+	data, err := json.Marshal(getProof(t, f, root1, "0000"))
+	require.NoError(t, err)
+
+	shortPath := strings.Replace(string(data), `"Path":"0000000000000000"`, `"Path":"00"`, 1)
+	require.Error(t, json.Unmarshal([]byte(shortPath), &TrieProof{}), "proof nodes longer than the path should be rejected")
+
+	badPath := strings.Replace(string(data), `"Path":"0000000000000000"`, `"Path":"0000000000000002"`, 1)
+	require.Error(t, json.Unmarshal([]byte(badPath), &TrieProof{}), "proof path with a non binary digit should be rejected")
+}
+
 // =================
 // helper funcs for working with keys represented by hex value strings
 // used when the general relations between keys are length wise
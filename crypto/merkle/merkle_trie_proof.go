@@ -0,0 +1,111 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package merkle
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"github.com/orbs-network/orbs-network-go/crypto/hash"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// The trie proof as sent to clients: hashes are hex encoded and the path holds a '0' or '1' character per key bit
+type trieProofJSON struct {
+	Nodes          []*trieProofNodeJSON
+	Path           string
+	ExtraHashLeft  string
+	ExtraHashRight string
+}
+
+type trieProofNodeJSON struct {
+	OtherChildHash string
+	PrefixSize     int
+}
+
+func (tp *TrieProof) MarshalJSON() ([]byte, error) {
+	nodes := make([]*trieProofNodeJSON, 0, len(tp.nodes))
+	for _, n := range tp.nodes {
+		nodes = append(nodes, &trieProofNodeJSON{OtherChildHash: hex.EncodeToString(n.otherChildHash), PrefixSize: n.prefixSize})
+	}
+
+	var path strings.Builder
+	for _, bit := range tp.path {
+		path.WriteByte('0' + bit)
+	}
+
+	return json.Marshal(&trieProofJSON{
+		Nodes:          nodes,
+		Path:           path.String(),
+		ExtraHashLeft:  hex.EncodeToString(tp.extraHashLeft),
+		ExtraHashRight: hex.EncodeToString(tp.extraHashRight),
+	})
+}
+
+func (tp *TrieProof) UnmarshalJSON(data []byte) error {
+	var in trieProofJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	path := make([]byte, len(in.Path))
+	for i := range in.Path {
+		if in.Path[i] != '0' && in.Path[i] != '1' {
+			return errors.Errorf("trie proof path has an invalid character at bit %d", i)
+		}
+		path[i] = in.Path[i] - '0'
+	}
+
+	nodes := make([]*TrieProofNode, 0, len(in.Nodes))
+	nodesPathLen := 0
+	for i, n := range in.Nodes {
+		otherChildHash, err := hex.DecodeString(n.OtherChildHash)
+		if err != nil || len(otherChildHash) != hash.SHA256_HASH_SIZE_BYTES {
+			return errors.Errorf("trie proof node %d has an invalid hash", i)
+		}
+		if n.PrefixSize < 0 {
+			return errors.Errorf("trie proof node %d has a negative prefix size", i)
+		}
+		nodesPathLen += n.PrefixSize
+		if i < len(in.Nodes)-1 {
+			nodesPathLen++ // the arc to the next node
+		}
+		nodes = append(nodes, &TrieProofNode{otherChildHash: otherChildHash, prefixSize: n.PrefixSize})
+	}
+	if nodesPathLen > len(path) {
+		return errors.Errorf("trie proof nodes span %d bits while the proof path has %d", nodesPathLen, len(path))
+	}
+
+	extraHashLeft, err := decodeOptionalHash(in.ExtraHashLeft)
+	if err != nil {
+		return errors.Wrap(err, "trie proof has an invalid left hash")
+	}
+	extraHashRight, err := decodeOptionalHash(in.ExtraHashRight)
+	if err != nil {
+		return errors.Wrap(err, "trie proof has an invalid right hash")
+	}
+
+	tp.nodes = nodes
+	tp.path = path
+	tp.extraHashLeft = extraHashLeft
+	tp.extraHashRight = extraHashRight
+	return nil
+}
+
+func decodeOptionalHash(s string) ([]byte, error) {
+	if s == "" {
+		return nil, nil
+	}
+	h, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(h) != hash.SHA256_HASH_SIZE_BYTES {
+		return nil, errors.Errorf("hash is %d bytes long", len(h))
+	}
+	return h, nil
+}
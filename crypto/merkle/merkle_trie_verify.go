@@ -0,0 +1,149 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package merkle
+
+import (
+	"bytes"
+	"github.com/orbs-network/orbs-network-go/crypto/hash"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/pkg/errors"
+)
+
+// Verifies a trie proof as produced by Forest.GetProof (and as decoded from its JSON form) using nothing but SHA256,
+// so clients holding only a trusted state merkle root can check it. See the README for the proof structure.
+//
+// Returns true if the proof shows the key holds the value in the trie of the given root. An empty value is proven
+// by an exclusion proof, showing the key is not in the trie. Returns false if the proof is consistent with the root
+// and key but shows a different value, and an error if it is malformed or not consistent with the root and key.
+// The key is the trie key, for state it is SHA256(contract name, state key).
+func VerifyTrieProof(rootHash primitives.Sha256, proof *TrieProof, key []byte, value []byte) (bool, error) {
+	return verifyTrieProof(rootHash, proof, key, hash.CalcSha256(value))
+}
+
+// The root of a trie holding no keys, the only root an empty proof is valid for
+func EmptyTrieRootHash() primitives.Sha256 {
+	return hashBytes(zeroValueHash, []byte{})
+}
+
+func verifyTrieProof(rootHash primitives.Sha256, proof *TrieProof, key []byte, valueHash primitives.Sha256) (bool, error) {
+	if proof == nil || len(proof.nodes) == 0 {
+		if !rootHash.Equal(EmptyTrieRootHash()) {
+			return false, errors.Errorf("empty proof is valid only for an empty trie")
+		}
+		return valueHash.Equal(zeroValueHash), nil
+	}
+
+	pathFromVerify := toBin(key, toBinSize(key))
+	if err := validateTrieProof(proof, len(pathFromVerify)); err != nil {
+		return false, err
+	}
+	lastNodePathIndex := calculateLastNodePathIndex(proof)
+
+	if !verifyProofIsSelfConsistent(rootHash, proof, pathFromVerify, lastNodePathIndex) {
+		return false, errors.Errorf("proof is not self consistent with given key")
+	}
+
+	if !valueHash.Equal(zeroValueHash) {
+		return verifyProofInclusion(proof, pathFromVerify, valueHash, lastNodePathIndex), nil
+	} else {
+		return verifyProofExclusion(proof, pathFromVerify, lastNodePathIndex)
+	}
+}
+
+// a proof may come from an untrusted source, it must not index past the key or hold hashes of the wrong size
+func validateTrieProof(proof *TrieProof, keyBits int) error {
+	pathLen := 0
+	for i, n := range proof.nodes {
+		if len(n.otherChildHash) != hash.SHA256_HASH_SIZE_BYTES {
+			return errors.Errorf("proof node %d has an invalid hash", i)
+		}
+		if n.prefixSize < 0 {
+			return errors.Errorf("proof node %d has a negative prefix size", i)
+		}
+		pathLen += n.prefixSize
+		if i < len(proof.nodes)-1 {
+			pathLen++ // the branch bit to the next node
+		}
+		if pathLen > keyBits {
+			return errors.Errorf("proof path is longer than the key")
+		}
+	}
+
+	if len(proof.extraHashLeft) != 0 && len(proof.extraHashLeft) != hash.SHA256_HASH_SIZE_BYTES {
+		return errors.Errorf("proof has an invalid left hash")
+	}
+	if len(proof.extraHashRight) != 0 && len(proof.extraHashRight) != hash.SHA256_HASH_SIZE_BYTES {
+		return errors.Errorf("proof has an invalid right hash")
+	}
+	return nil
+}
+
+func calculateLastNodePathIndex(proof *TrieProof) int {
+	LastNodeIndex := len(proof.nodes) - 1
+	lastNodePathIndex := 0
+	for i := 0; i < LastNodeIndex; i++ {
+		lastNodePathIndex = lastNodePathIndex + proof.nodes[i].prefixSize + 1
+	}
+	return lastNodePathIndex
+}
+
+func verifyProofIsSelfConsistent(rootHash primitives.Sha256, proof *TrieProof, pathFromVerify []byte, lastNodePathIndex int) bool {
+	lastNodeIndex := len(proof.nodes) - 1
+	keyEndInd := lastNodePathIndex + proof.nodes[lastNodeIndex].prefixSize
+	keyStartInd := lastNodePathIndex
+	currentHash := proof.nodes[lastNodeIndex].otherChildHash
+
+	for i := lastNodeIndex - 1; i >= 0; i-- {
+		keyEndInd = keyStartInd - 1
+		keyStartInd = keyEndInd - proof.nodes[i].prefixSize
+		if pathFromVerify[keyEndInd] == 0 {
+			currentHash = hashBytes(currentHash, proof.nodes[i].otherChildHash, pathFromVerify[keyStartInd:keyEndInd])
+		} else {
+			currentHash = hashBytes(proof.nodes[i].otherChildHash, currentHash, pathFromVerify[keyStartInd:keyEndInd])
+		}
+	}
+
+	return bytes.Equal(currentHash, rootHash)
+}
+
+func verifyProofInclusion(proof *TrieProof, verifyPath []byte, verifyValueHash primitives.Sha256, lastNodePathIndex int) bool {
+	lastNodeIndex := len(proof.nodes) - 1
+	calculatedHash := hashBytes(verifyValueHash, verifyPath[lastNodePathIndex:])
+	lastNodeHash := proof.nodes[lastNodeIndex].otherChildHash
+	return bytes.Equal(lastNodeHash, calculatedHash)
+}
+
+// the last node must diverge from the key within its own prefix, a node whose prefix matches the key is on the key's path.
+// comparing the prefix with the whole rest of the key would let a proof stop short at any node along the key's path
+func verifyProofExclusion(proof *TrieProof, pathFromVerify []byte, lastNodePathIndex int) (bool, error) {
+	pathLen := len(proof.path)
+	if pathLen != len(pathFromVerify) {
+		return false, errors.Errorf("proof length is not consistent with given key length")
+	}
+	if len(proof.extraHashLeft) == 0 {
+		return false, errors.Errorf("exclusion proof is missing the last node's pre-image")
+	}
+
+	lastNodeIndex := len(proof.nodes) - 1
+	lastNodePrefixEnd := lastNodePathIndex + proof.nodes[lastNodeIndex].prefixSize
+	lastNodePrefix := proof.path[lastNodePathIndex:lastNodePrefixEnd]
+
+	var calculatedHash []byte
+	if proof.extraHashRight != nil {
+		calculatedHash = hashBytes(proof.extraHashLeft, proof.extraHashRight, lastNodePrefix)
+	} else {
+		calculatedHash = hashBytes(proof.extraHashLeft, lastNodePrefix)
+	}
+	lastNodeHash := proof.nodes[lastNodeIndex].otherChildHash
+	isHashEqual := bytes.Equal(lastNodeHash, calculatedHash)
+
+	isBeginOfPathEqual := bytes.Equal(proof.path[:lastNodePathIndex], pathFromVerify[:lastNodePathIndex])
+	isLastNodePrefixEqual := bytes.Equal(lastNodePrefix, pathFromVerify[lastNodePathIndex:lastNodePrefixEnd])
+	isLeafPrefixComplete := proof.extraHashRight != nil || lastNodePrefixEnd == pathLen // keys are of fixed length
+
+	return isHashEqual && isBeginOfPathEqual && !isLastNodePrefixEqual && isLeafPrefixComplete, nil
+}
@@ -0,0 +1,182 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package merkle
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// set to regenerate the test vectors file after changing the proof format or the cases below
+const updateTrieProofVectorsEnv = "UPDATE_TRIE_PROOF_VECTORS"
+
+var trieProofVectorsFile = filepath.Join("testdata", "trie_proof_vectors.json")
+
+// A portable test case for trie proof verifiers: verifying the proof of the key and value against the root
+// should give the result, one of "valid", "invalid" (proves another value) or "error" (malformed or inconsistent).
+// Hashes, keys and values are hex encoded, an empty value stands for a key not in the trie.
+type trieProofVector struct {
+	Name   string
+	Root   string
+	Key    string
+	Value  string
+	Proof  *TrieProof
+	Result string
+}
+
+func TestVerifyTrieProof_TestVectors(t *testing.T) {
+	for _, v := range readTrieProofVectors(t) {
+		t.Run(v.Name, func(t *testing.T) {
+			root, err := hex.DecodeString(v.Root)
+			require.NoError(t, err)
+			key, err := hex.DecodeString(v.Key)
+			require.NoError(t, err)
+			value, err := hex.DecodeString(v.Value)
+			require.NoError(t, err)
+
+			verified, err := VerifyTrieProof(root, v.Proof, key, value)
+			switch v.Result {
+			case "valid":
+				require.NoError(t, err)
+				require.True(t, verified, "proof should verify")
+			case "invalid":
+				require.NoError(t, err)
+				require.False(t, verified, "proof should not verify")
+			case "error":
+				require.Error(t, err, "proof should be rejected")
+			default:
+				require.Fail(t, "unknown result", v.Result)
+			}
+		})
+	}
+}
+
+func TestVerifyTrieProof_TestVectorsMatchForest(t *testing.T) {
+	generated := generateTrieProofVectors(t)
+	if os.Getenv(updateTrieProofVectorsEnv) != "" {
+		data, err := json.MarshalIndent(generated, "", "  ")
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(trieProofVectorsFile, append(data, '\n'), 0644))
+	}
+
+	require.Equal(t, generated, readTrieProofVectors(t), "test vectors are out of date, run with %s=1 to regenerate", updateTrieProofVectorsEnv)
+}
+
+func TestVerifyTrieProof_RejectsMalformedProof(t *testing.T) {
+	f, root := NewForest()
+	root1 := updateEntries(f, root, "0000", "baz1", "0100", "baz2", "0110", "baz3")
+	key := hexStringToBytes("0110")
+
+	longPrefix := getProof(t, f, root1, "0110")
+	longPrefix.nodes[0].prefixSize = 100
+	_, err := VerifyTrieProof(root1, longPrefix, key, []byte("baz3"))
+	require.Error(t, err, "proof longer than the key should be rejected")
+
+	shortHash := getProof(t, f, root1, "0110")
+	shortHash.nodes[1].otherChildHash = shortHash.nodes[1].otherChildHash[:10]
+	_, err = VerifyTrieProof(root1, shortHash, key, []byte("baz3"))
+	require.Error(t, err, "proof with a short hash should be rejected")
+
+	_, err = VerifyTrieProof(root1, &TrieProof{}, key, []byte{})
+	require.Error(t, err, "empty proof should be rejected for a non empty trie")
+
+	verified, err := VerifyTrieProof(root, &TrieProof{}, key, []byte{})
+	require.NoError(t, err)
+	require.True(t, verified, "empty proof should prove exclusion from an empty trie")
+}
+
+func readTrieProofVectors(t *testing.T) []*trieProofVector {
+	data, err := ioutil.ReadFile(trieProofVectorsFile)
+	require.NoError(t, err)
+	var vectors []*trieProofVector
+	require.NoError(t, json.Unmarshal(data, &vectors))
+	return vectors
+}
+
+// the cases of the forest proof tests above, and proofs forged from them
+func generateTrieProofVectors(t *testing.T) []*trieProofVector {
+	var vectors []*trieProofVector
+	add := func(name string, root []byte, proof *TrieProof, key string, value string, result string) {
+		proof = roundTripTrieProof(t, proof) // as read by clients
+		vectors = append(vectors, &trieProofVector{
+			Name:   name,
+			Root:   hex.EncodeToString(root),
+			Key:    key,
+			Value:  hex.EncodeToString([]byte(value)),
+			Proof:  proof,
+			Result: result,
+		})
+	}
+
+	f, root := NewForest()
+	add("empty trie, exclusion", root, getProof(t, f, root, "deaddead"), "deaddead", "", "valid")
+	add("empty trie, inclusion", root, getProof(t, f, root, "deaddead"), "deaddead", "non-zero", "invalid")
+	root1 := updateEntries(f, root, "abcdef01", "val")
+	add("single leaf, inclusion", root1, getProof(t, f, root1, "abcdef01"), "abcdef01", "val", "valid")
+	add("single leaf, exclusion", root1, getProof(t, f, root1, "deaddead"), "deaddead", "", "valid")
+	add("single leaf, empty proof", root1, &TrieProof{}, "deaddead", "", "error")
+
+	f, root = NewForest()
+	root1 = updateEntries(f, root, "abc1", "baz1", "abd1", "baz2")
+	proof := getProof(t, f, root1, "abc1")
+	add("branch, inclusion", root1, proof, "abc1", "baz1", "valid")
+	add("branch, other value", root1, proof, "abc1", "baz2", "invalid")
+	add("branch, exclusion of included key", root1, proof, "abc1", "", "invalid")
+	add("branch, exclusion diverging in leaf", root1, proof, "abc6", "", "valid")
+	add("branch, key diverging above leaf", root1, proof, "abd1", "baz1", "error")
+	add("branch, wrong root", root, proof, "abc1", "baz1", "error")
+
+	f, root = NewForest()
+	root1 = updateEntries(f, root, "0000", "baz1", "0100", "baz2", "0110", "baz3")
+	proof = getProof(t, f, root1, "0110")
+	add("two levels, inclusion", root1, proof, "0110", "baz3", "valid")
+	add("two levels, exclusion of included key", root1, proof, "0110", "", "invalid")
+	add("two levels, key diverging above leaf", root1, proof, "0100", "baz3", "error")
+	add("two levels, exclusion", root1, getProof(t, f, root1, "0111"), "0111", "", "valid")
+	add("two levels, short exclusion", root1, getProof(t, f, root1, "0001"), "0001", "", "valid")
+	add("two levels, inclusion on short branch", root1, getProof(t, f, root1, "0000"), "0000", "baz1", "valid")
+
+	core := f.findRoot(root1).right // the node branching to 0100 and 0110
+	truncated := getProof(t, f, root1, "0110")
+	truncated.nodes = append(truncated.nodes[:1], &TrieProofNode{otherChildHash: core.hash, prefixSize: len(core.path)})
+	truncated.extraHashLeft, truncated.extraHashRight = core.left.hash, core.right.hash
+	add("two levels, exclusion forged by stopping on the key's path", root1, truncated, "0110", "", "invalid")
+
+	f, root = NewForest()
+	root1 = updateEntries(f, root, "00000000", "baz1", "00100000", "baz2", "00000111", "baz3")
+	proof = getProof(t, f, root1, "00001111")
+	add("exclusion diverging in core node", root1, proof, "00001111", "", "valid")
+	add("exclusion of key under the last node", root1, proof, "00000011", "", "invalid")
+	add("key diverging above core node", root1, proof, "02000000", "", "error")
+
+	f, root = NewForest()
+	root1 = updateEntries(f, root, "0000", "baz1", "0001", "baz2", "0100", "baz3", "0101", "baz4")
+	proof = getProof(t, f, root1, "0001")
+	add("leaves without prefix, inclusion", root1, proof, "0001", "baz2", "valid")
+	add("leaves without prefix, exclusion of included key", root1, proof, "0001", "", "invalid")
+
+	f, root = NewForest()
+	root1 = updateEntries(f, root, "abcd1234", "baz", "abc12300", "qux", "abc12345", "quux1234", "aadd1234", "foo", "12345678", "hello")
+	add("five keys, inclusion", root1, getProof(t, f, root1, "abc12345"), "abc12345", "quux1234", "valid")
+	add("five keys, exclusion", root1, getProof(t, f, root1, "abc12346"), "abc12346", "", "valid")
+	add("five keys, inclusion of first key", root1, getProof(t, f, root1, "12345678"), "12345678", "hello", "valid")
+
+	return vectors
+}
+
+func roundTripTrieProof(t *testing.T, proof *TrieProof) *TrieProof {
+	data, err := json.Marshal(proof)
+	require.NoError(t, err)
+	decoded := &TrieProof{}
+	require.NoError(t, json.Unmarshal(data, decoded))
+	return decoded
+}
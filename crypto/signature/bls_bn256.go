@@ -0,0 +1,160 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package signature
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+	"github.com/orbs-network/orbs-network-go/crypto/hash"
+	"github.com/orbs-network/orbs-network-go/crypto/keys"
+	"github.com/pkg/errors"
+	"math/big"
+)
+
+const (
+	BLS_BN256_SIGNATURE_SIZE_BYTES = 64
+)
+
+// the prime of the curve's base field, where y^2 = x^3 + 3
+var blsBn256FieldPrime, _ = new(big.Int).SetString("21888242871839275222246405745257275088696311157297823662689037894645226208583", 10)
+
+// the field prime is 3 mod 4, so the square root of a quadratic residue a is a^((p+1)/4)
+var blsBn256SqrtExponent = new(big.Int).Rsh(new(big.Int).Add(blsBn256FieldPrime, big.NewInt(1)), 2)
+
+var blsBn256G2Generator = new(bn256.G2).ScalarBaseMult(big.NewInt(1))
+
+// Signatures are the hash of the data on G1 multiplied by the private key, so signatures of key shares combine to
+// the signature of their group key, and every threshold of signature shares combines to the same group signature
+func SignBlsBn256(privateKey keys.BlsBn256PrivateKey, data []byte) ([]byte, error) {
+	scalar, err := privateKey.Scalar()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot sign with bls bn256")
+	}
+	point, err := hashToBlsBn256G1(data)
+	if err != nil {
+		return nil, err
+	}
+	return new(bn256.G1).ScalarMult(point, scalar).Marshal(), nil
+}
+
+func VerifyBlsBn256(publicKey keys.BlsBn256PublicKey, data []byte, sig []byte) bool {
+	if len(sig) != BLS_BN256_SIGNATURE_SIZE_BYTES {
+		return false
+	}
+	sigPoint := new(bn256.G1)
+	if _, err := sigPoint.Unmarshal(sig); err != nil {
+		return false
+	}
+	publicKeyPoint, err := unmarshalBlsBn256PublicKey(publicKey)
+	if err != nil {
+		return false
+	}
+	point, err := hashToBlsBn256G1(data)
+	if err != nil {
+		return false
+	}
+
+	// e(sig, g2) == e(H(data), publicKey)
+	return bn256.PairingCheck([]*bn256.G1{sigPoint, new(bn256.G1).Neg(point)}, []*bn256.G2{blsBn256G2Generator, publicKeyPoint})
+}
+
+// Combines signature shares over the same data of at least the threshold of distinct key shares to the group signature,
+// by Lagrange interpolation at zero of the share indices. Shares must be verified first, a single bad share spoils the result
+func RecoverBlsBn256ThresholdSignature(shareIndices []uint32, sigShares [][]byte) ([]byte, error) {
+	if len(shareIndices) != len(sigShares) {
+		return nil, errors.Errorf("got %d share indices for %d signature shares", len(shareIndices), len(sigShares))
+	}
+	if len(sigShares) == 0 {
+		return nil, errors.New("no signature shares to recover the group signature from")
+	}
+
+	xs := make([]*big.Int, len(shareIndices))
+	seen := make(map[uint32]bool, len(shareIndices))
+	for i, index := range shareIndices {
+		if index == 0 || seen[index] {
+			return nil, errors.Errorf("share index %d is zero or repeats", index)
+		}
+		seen[index] = true
+		xs[i] = big.NewInt(int64(index))
+	}
+
+	var sum *bn256.G1
+	for i, share := range sigShares {
+		point := new(bn256.G1)
+		if _, err := point.Unmarshal(share); err != nil || len(share) != BLS_BN256_SIGNATURE_SIZE_BYTES {
+			return nil, errors.Errorf("signature share of share index %d is invalid", shareIndices[i])
+		}
+		term := new(bn256.G1).ScalarMult(point, lagrangeCoefficientAtZero(i, xs))
+		if sum == nil {
+			sum = term
+		} else {
+			sum = new(bn256.G1).Add(sum, term)
+		}
+	}
+	return sum.Marshal(), nil
+}
+
+// the product over j != i of x_j / (x_j - x_i), modulo the group order
+func lagrangeCoefficientAtZero(i int, xs []*big.Int) *big.Int {
+	numerator := big.NewInt(1)
+	denominator := big.NewInt(1)
+	for j, x := range xs {
+		if j == i {
+			continue
+		}
+		numerator.Mul(numerator, x)
+		numerator.Mod(numerator, keys.BlsBn256Order)
+		denominator.Mul(denominator, new(big.Int).Sub(x, xs[i]))
+		denominator.Mod(denominator, keys.BlsBn256Order)
+	}
+	return numerator.Mul(numerator, denominator.ModInverse(denominator, keys.BlsBn256Order)).Mod(numerator, keys.BlsBn256Order)
+}
+
+func unmarshalBlsBn256PublicKey(publicKey keys.BlsBn256PublicKey) (*bn256.G2, error) {
+	if len(publicKey) != keys.BLS_BN256_PUBLIC_KEY_SIZE_BYTES || bytes.Equal(publicKey, make([]byte, keys.BLS_BN256_PUBLIC_KEY_SIZE_BYTES)) {
+		return nil, errors.New("bls public key is invalid")
+	}
+	point := new(bn256.G2)
+	if _, err := point.Unmarshal(publicKey); err != nil {
+		return nil, err
+	}
+
+	// G2 points outside the group of the curve's order would let a signature verify against more than one data
+	if !bytes.Equal(new(bn256.G2).ScalarMult(point, keys.BlsBn256Order).Marshal(), make([]byte, keys.BLS_BN256_PUBLIC_KEY_SIZE_BYTES)) {
+		return nil, errors.New("bls public key is not in the curve's group")
+	}
+	return point, nil
+}
+
+// try and increment: the first x derived from the data and a counter for which x^3 + 3 has a square root y, about half do
+func hashToBlsBn256G1(data []byte) (*bn256.G1, error) {
+	counter := make([]byte, 4)
+	for i := uint32(0); i < 256; i++ {
+		binary.BigEndian.PutUint32(counter, i)
+		x := new(big.Int).SetBytes(hash.CalcSha256(counter, data))
+		x.Mod(x, blsBn256FieldPrime)
+
+		rhs := new(big.Int).Exp(x, big.NewInt(3), blsBn256FieldPrime)
+		rhs.Add(rhs, big.NewInt(3)).Mod(rhs, blsBn256FieldPrime)
+		y := new(big.Int).Exp(rhs, blsBn256SqrtExponent, blsBn256FieldPrime)
+		if new(big.Int).Exp(y, big.NewInt(2), blsBn256FieldPrime).Cmp(rhs) != 0 {
+			continue
+		}
+
+		encoded := make([]byte, 64)
+		xBytes, yBytes := x.Bytes(), y.Bytes()
+		copy(encoded[32-len(xBytes):32], xBytes)
+		copy(encoded[64-len(yBytes):], yBytes)
+		point := new(bn256.G1)
+		if _, err := point.Unmarshal(encoded); err != nil {
+			return nil, errors.Wrap(err, "failed to hash to a curve point")
+		}
+		return point, nil
+	}
+	return nil, errors.New("failed to hash to a curve point")
+}
@@ -0,0 +1,78 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package signature
+
+import (
+	"github.com/orbs-network/orbs-network-go/crypto/hash"
+	"github.com/orbs-network/orbs-network-go/test/crypto/keys"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+var someDataToSign_BlsBn256 = hash.CalcSha256([]byte("this is what we want to sign"))
+
+func signSharesBlsBn256(t *testing.T, numShares int, shareIndices ...uint32) [][]byte {
+	thresholdKeys := keys.BlsBn256ThresholdKeysForTests(3, numShares)
+	var sigShares [][]byte
+	for _, index := range shareIndices {
+		sig, err := SignBlsBn256(thresholdKeys.Shares[index-1].PrivateKey(), someDataToSign_BlsBn256)
+		require.NoError(t, err)
+		sigShares = append(sigShares, sig)
+	}
+	return sigShares
+}
+
+func TestSignBlsBn256(t *testing.T) {
+	kp := keys.BlsBn256ThresholdKeysForTests(3, 4).Shares[0]
+
+	sig, err := SignBlsBn256(kp.PrivateKey(), someDataToSign_BlsBn256)
+	require.NoError(t, err)
+	require.Equal(t, BLS_BN256_SIGNATURE_SIZE_BYTES, len(sig))
+
+	ok := VerifyBlsBn256(kp.PublicKey(), someDataToSign_BlsBn256, sig)
+	require.True(t, ok, "verification should succeed")
+}
+
+func TestSignBlsBn256InvalidPrivateKey(t *testing.T) {
+	_, err := SignBlsBn256([]byte{0}, someDataToSign_BlsBn256)
+	require.Error(t, err, "sign with invalid pk should fail")
+}
+
+func TestVerifyBlsBn256RejectsOtherDataAndKeys(t *testing.T) {
+	thresholdKeys := keys.BlsBn256ThresholdKeysForTests(3, 4)
+	sig, err := SignBlsBn256(thresholdKeys.Shares[0].PrivateKey(), someDataToSign_BlsBn256)
+	require.NoError(t, err)
+
+	require.False(t, VerifyBlsBn256(thresholdKeys.Shares[0].PublicKey(), hash.CalcSha256([]byte("other data")), sig), "verification of other data should fail")
+	require.False(t, VerifyBlsBn256(thresholdKeys.Shares[1].PublicKey(), someDataToSign_BlsBn256, sig), "verification with another key should fail")
+	require.False(t, VerifyBlsBn256([]byte{0}, someDataToSign_BlsBn256, sig), "verification with an invalid key should fail")
+	require.False(t, VerifyBlsBn256(thresholdKeys.Shares[0].PublicKey(), someDataToSign_BlsBn256, sig[1:]), "verification of an invalid signature should fail")
+}
+
+func TestRecoverBlsBn256ThresholdSignature(t *testing.T) {
+	groupPublicKey := keys.BlsBn256ThresholdKeysForTests(3, 4).GroupPublicKey
+
+	sig, err := RecoverBlsBn256ThresholdSignature([]uint32{1, 2, 4}, signSharesBlsBn256(t, 4, 1, 2, 4))
+	require.NoError(t, err)
+	require.Equal(t, BLS_BN256_SIGNATURE_SIZE_BYTES, len(sig))
+	require.True(t, VerifyBlsBn256(groupPublicKey, someDataToSign_BlsBn256, sig), "group signature should verify with the group public key")
+
+	otherSig, err := RecoverBlsBn256ThresholdSignature([]uint32{4, 3, 2, 1}, signSharesBlsBn256(t, 4, 4, 3, 2, 1))
+	require.NoError(t, err)
+	require.Equal(t, sig, otherSig, "every threshold of shares should recover the same group signature")
+}
+
+func TestRecoverBlsBn256ThresholdSignatureBelowThreshold(t *testing.T) {
+	groupPublicKey := keys.BlsBn256ThresholdKeysForTests(3, 4).GroupPublicKey
+
+	sig, err := RecoverBlsBn256ThresholdSignature([]uint32{1, 3}, signSharesBlsBn256(t, 4, 1, 3))
+	require.NoError(t, err)
+	require.False(t, VerifyBlsBn256(groupPublicKey, someDataToSign_BlsBn256, sig), "signature of fewer shares than the threshold should not verify")
+
+	_, err = RecoverBlsBn256ThresholdSignature([]uint32{1, 1}, signSharesBlsBn256(t, 4, 1, 1))
+	require.Error(t, err, "repeating share should fail")
+}
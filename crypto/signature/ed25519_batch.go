@@ -0,0 +1,75 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package signature
+
+import (
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"sync"
+)
+
+type Ed25519Verification struct {
+	PublicKey  primitives.Ed25519PublicKey
+	Signature  primitives.Ed25519Sig
+	SignedData func() []byte // called by the goroutine verifying the signature, so computing the data is spread over the workers too
+}
+
+// Verifies Ed25519 signatures one by one on several goroutines, this is not Ed25519 batch verification. The workers are shared
+// by all callers, so concurrent calls together never verify on more than maxWorkers goroutines besides the calling ones
+type Ed25519ParallelVerifier struct {
+	workers chan struct{} // holds a token for every worker busy verifying
+}
+
+func NewEd25519ParallelVerifier(maxWorkers int) *Ed25519ParallelVerifier {
+	if maxWorkers < 0 {
+		maxWorkers = 0
+	}
+	return &Ed25519ParallelVerifier{workers: make(chan struct{}, maxWorkers)}
+}
+
+// valid[i] is the result of verifications[i]. The calling goroutine verifies as well, and is joined by as many free workers
+// as there are signatures left, so a call returns even when all workers are busy with the calls of others
+func (v *Ed25519ParallelVerifier) Verify(verifications []*Ed25519Verification) (valid []bool) {
+	valid = make([]bool, len(verifications))
+
+	indices := make(chan int, len(verifications))
+	for i := range verifications {
+		indices <- i
+	}
+	close(indices)
+
+	verifyAll := func() {
+		for i := range indices {
+			valid[i] = VerifyEd25519(verifications[i].PublicKey, verifications[i].SignedData(), verifications[i].Signature)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for helpers := 1; helpers < len(verifications) && v.acquireWorker(); helpers++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer v.releaseWorker()
+			verifyAll()
+		}()
+	}
+	verifyAll()
+	wg.Wait()
+	return
+}
+
+func (v *Ed25519ParallelVerifier) acquireWorker() bool {
+	select {
+	case v.workers <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (v *Ed25519ParallelVerifier) releaseWorker() {
+	<-v.workers
+}
@@ -0,0 +1,92 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package signature
+
+import (
+	"fmt"
+	"github.com/orbs-network/orbs-network-go/test/crypto/keys"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+)
+
+func ed25519VerificationsForTests(tb testing.TB, count int) []*Ed25519Verification {
+	verifications := make([]*Ed25519Verification, count)
+	for i := range verifications {
+		kp := keys.Ed25519KeyPairForTests(i % 10)
+		data := []byte(fmt.Sprintf("transaction %d", i))
+		sig, err := SignEd25519(kp.PrivateKey(), data)
+		require.NoError(tb, err)
+		verifications[i] = &Ed25519Verification{PublicKey: kp.PublicKey(), SignedData: signedData(data), Signature: sig}
+	}
+	return verifications
+}
+
+func signedData(data []byte) func() []byte {
+	return func() []byte {
+		return data
+	}
+}
+
+func TestEd25519ParallelVerifierReportsEachSignature(t *testing.T) {
+	for _, workers := range []int{0, 1, 4, 100} {
+		verifications := ed25519VerificationsForTests(t, 20)
+		verifications[3].SignedData = signedData([]byte("other data"))
+		verifications[17].PublicKey = keys.Ed25519KeyPairForTests(9).PublicKey()
+		verifications[17].SignedData = signedData([]byte("transaction 16"))
+
+		valid := NewEd25519ParallelVerifier(workers).Verify(verifications)
+
+		require.Len(t, valid, len(verifications))
+		for i, ok := range valid {
+			require.Equal(t, i != 3 && i != 17, ok, "verification %d with %d workers should match its signature", i, workers)
+		}
+	}
+}
+
+func TestEd25519ParallelVerifierOfNone(t *testing.T) {
+	require.Empty(t, NewEd25519ParallelVerifier(4).Verify(nil), "empty batch should have no results")
+}
+
+func TestEd25519ParallelVerifierSharesWorkersBetweenConcurrentCalls(t *testing.T) {
+	verifier := NewEd25519ParallelVerifier(2)
+
+	results := make([][]bool, 8)
+	var wg sync.WaitGroup
+	for c := range results {
+		verifications := ed25519VerificationsForTests(t, 50)
+		wg.Add(1)
+		go func(c int) {
+			defer wg.Done()
+			results[c] = verifier.Verify(verifications)
+		}(c)
+	}
+	wg.Wait()
+
+	for c, valid := range results {
+		require.Len(t, valid, 50)
+		for i, ok := range valid {
+			require.True(t, ok, "verification %d of call %d should match its signature", i, c)
+		}
+	}
+
+	require.Empty(t, verifier.workers, "expected every worker to be released")
+}
+
+func BenchmarkEd25519ParallelVerifier(b *testing.B) {
+	for _, size := range []int{100, 1000} {
+		verifications := ed25519VerificationsForTests(b, size)
+		for _, workers := range []int{1, 4, 8} {
+			b.Run(fmt.Sprintf("%d-signatures-%d-workers", size, workers), func(b *testing.B) {
+				verifier := NewEd25519ParallelVerifier(workers)
+				for i := 0; i < b.N; i++ {
+					verifier.Verify(verifications)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package signer
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/crypto/digest"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/scribe/log"
+)
+
+type localSigner struct {
+	privateKey primitives.EcdsaSecp256K1PrivateKey
+	logger     log.Logger
+}
+
+func NewLocalSigner(privateKey primitives.EcdsaSecp256K1PrivateKey, logger log.Logger) Signer {
+	return &localSigner{
+		privateKey: privateKey,
+		logger:     logger.WithTags(LogTag),
+	}
+}
+
+func (s *localSigner) SignAsNode(ctx context.Context, blockHeight primitives.BlockHeight, data []byte) (primitives.EcdsaSecp256K1Sig, error) {
+	sig, err := digest.SignAsNode(s.privateKey, data)
+	auditSignRequest(s.logger, blockHeight, data, err)
+	return sig, err
+}
@@ -0,0 +1,134 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package signer
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const signPath = "/api/v1/sign"
+
+// the body of sign requests to a signer process and of its responses, binary values are hex encoded
+type signRequest struct {
+	BlockHeight uint64 `json:"block-height"`
+	Data        string `json:"data"`
+}
+
+type signResponse struct {
+	Signature string `json:"signature"`
+}
+
+// Requests signatures of a signer process holding the node private key, over HTTP on a Unix socket
+type remoteSigner struct {
+	url    string
+	client *http.Client
+	logger log.Logger
+}
+
+// The endpoint is unix:// followed by the path of the signer process's socket. Network endpoints are rejected since sign
+// requests carry no credentials, the socket is refused when other users may connect to it, see Signer
+func NewRemoteSigner(endpoint string, timeout time.Duration, logger log.Logger) (Signer, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Scheme != "unix" {
+		return nil, errors.Errorf("unsupported signer endpoint scheme %s, a signer process serves sign requests on a unix socket only", parsed.Scheme)
+	}
+
+	socketPath := parsed.Path
+	dialer := &net.Dialer{Timeout: timeout}
+	return &remoteSigner{
+		url: "http://signer" + signPath,
+		client: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					if err := checkSocketPermissions(socketPath); err != nil {
+						return nil, err
+					}
+					return dialer.DialContext(ctx, "unix", socketPath)
+				},
+			},
+		},
+		logger: logger.WithTags(LogTag, log.String("signer-endpoint", endpoint)),
+	}, nil
+}
+
+// anyone who may connect to the socket may sign as the node, the owner and group of the signer process are trusted
+func checkSocketPermissions(socketPath string) error {
+	info, err := os.Stat(socketPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find signer socket %s", socketPath)
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return errors.Errorf("signer endpoint %s is not a unix socket", socketPath)
+	}
+	if info.Mode().Perm()&0007 != 0 {
+		return errors.Errorf("signer socket %s may be accessed by any user (mode %s), it must be accessible only to the owner and group of the signer process", socketPath, info.Mode().Perm())
+	}
+	return nil
+}
+
+func (s *remoteSigner) SignAsNode(ctx context.Context, blockHeight primitives.BlockHeight, data []byte) (primitives.EcdsaSecp256K1Sig, error) {
+	sig, err := s.requestSignature(ctx, blockHeight, data)
+	auditSignRequest(s.logger, blockHeight, data, err)
+	return sig, err
+}
+
+func (s *remoteSigner) requestSignature(ctx context.Context, blockHeight primitives.BlockHeight, data []byte) (primitives.EcdsaSecp256K1Sig, error) {
+	body, err := json.Marshal(&signRequest{
+		BlockHeight: uint64(blockHeight),
+		Data:        hex.EncodeToString(data),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := s.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "signer request failed")
+	}
+	defer res.Body.Close()
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed reading signer response")
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("signer responded with http status %s: %s", res.Status, strings.TrimSpace(string(resBody)))
+	}
+
+	response := &signResponse{}
+	if err := json.Unmarshal(resBody, response); err != nil {
+		return nil, errors.Wrap(err, "failed decoding signer response")
+	}
+	sig, err := hex.DecodeString(response.Signature)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed decoding signature of signer response")
+	}
+	return primitives.EcdsaSecp256K1Sig(sig), nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package signer
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"syscall"
+)
+
+// Serves the sign requests of remote signers for a signer process, which signs with the signer it is given.
+// Requests are not authenticated, so the handler must only be served on a listener of ListenUnixSocket, see Signer
+func NewHttpHandler(signer Signer, logger log.Logger) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(signPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "sign requests must be posted", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed reading sign request", http.StatusBadRequest)
+			return
+		}
+		request := &signRequest{}
+		if err := json.Unmarshal(body, request); err != nil {
+			http.Error(w, "failed decoding sign request", http.StatusBadRequest)
+			return
+		}
+		data, err := hex.DecodeString(request.Data)
+		if err != nil {
+			http.Error(w, "failed decoding data of sign request", http.StatusBadRequest)
+			return
+		}
+
+		sig, err := signer.SignAsNode(r.Context(), primitives.BlockHeight(request.BlockHeight), data)
+		if err != nil {
+			logger.Info("failed serving sign request", log.Error(err))
+			http.Error(w, "failed signing", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(&signResponse{Signature: hex.EncodeToString(sig)})
+	})
+	return mux
+}
+
+// Listens on a unix socket which only the owner and group of the signer process may connect to, the node's signer endpoint
+// points at it. The umask is set while the socket is created so it is never accessible to others, call it before
+// starting goroutines which create files
+func ListenUnixSocket(socketPath string) (net.Listener, error) {
+	oldMask := syscall.Umask(0117)
+	listener, err := net.Listen("unix", socketPath)
+	syscall.Umask(oldMask)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to listen on signer socket %s", socketPath)
+	}
+
+	if err := checkSocketPermissions(socketPath); err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package signer
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/crypto/hash"
+	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	"time"
+)
+
+var LogTag = log.String("component", "signer")
+
+// Signs data as the node, like digest.SignAsNode the data is hashed by the signer. The block height is the one the node
+// signs for, zero when the signature is not over a block, and is recorded in the audit log.
+//
+// A signer process keeps the node private key out of the node, so a compromised node may sign as the node while it runs
+// but cannot take the key away. The signer process signs whatever it is asked to and sign requests carry no credentials,
+// so whoever may connect to it may sign as the node. It therefore serves only on a unix socket which only its owner and
+// group may access, and the node refuses sockets other users may access. Users of that group, and root, are trusted.
+type Signer interface {
+	SignAsNode(ctx context.Context, blockHeight primitives.BlockHeight, data []byte) (primitives.EcdsaSecp256K1Sig, error)
+}
+
+type Config interface {
+	NodePrivateKey() primitives.EcdsaSecp256K1PrivateKey
+	SignerEndpoint() string
+	SignerRequestTimeout() time.Duration
+}
+
+// The node signs with its private key in-process unless a signer endpoint is configured
+func New(config Config, logger log.Logger) (Signer, error) {
+	if config.SignerEndpoint() == "" {
+		return NewLocalSigner(config.NodePrivateKey(), logger), nil
+	}
+
+	remote, err := NewRemoteSigner(config.SignerEndpoint(), config.SignerRequestTimeout(), logger)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create signer of endpoint %s", config.SignerEndpoint())
+	}
+	return remote, nil
+}
+
+func auditSignRequest(logger log.Logger, blockHeight primitives.BlockHeight, data []byte, err error) {
+	if err != nil {
+		logger.Error("failed signing as node", logfields.BlockHeight(blockHeight), log.Stringable("data-hash", primitives.Sha256(hash.CalcSha256(data))), log.Error(err))
+		return
+	}
+	logger.Info("signed as node", logfields.BlockHeight(blockHeight), log.Stringable("data-hash", primitives.Sha256(hash.CalcSha256(data))))
+}
@@ -0,0 +1,95 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package signer
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/crypto/digest"
+	"github.com/orbs-network/orbs-network-go/test/crypto/keys"
+	"github.com/orbs-network/scribe/log"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var someDataToSign = []byte("this is what we want to sign")
+
+func TestLocalSigner_SignsAsNode(t *testing.T) {
+	keyPair := keys.EcdsaSecp256K1KeyPairForTests(0)
+	s := NewLocalSigner(keyPair.PrivateKey(), log.DefaultTestingLogger(t))
+
+	sig, err := s.SignAsNode(context.Background(), 17, someDataToSign)
+	require.NoError(t, err)
+	require.NoError(t, digest.VerifyNodeSignature(keyPair.NodeAddress(), someDataToSign, sig), "signature should verify with the node address")
+}
+
+func TestRemoteSigner_SignsOverUnixSocket(t *testing.T) {
+	keyPair := keys.EcdsaSecp256K1KeyPairForTests(0)
+	logger := log.DefaultTestingLogger(t)
+	socketPath, stop := serveSignerOnUnixSocket(t, NewLocalSigner(keyPair.PrivateKey(), logger), logger)
+	defer stop()
+
+	s, err := NewRemoteSigner("unix://"+socketPath, 1*time.Second, logger)
+	require.NoError(t, err)
+
+	sig, err := s.SignAsNode(context.Background(), 17, someDataToSign)
+	require.NoError(t, err)
+	require.NoError(t, digest.VerifyNodeSignature(keyPair.NodeAddress(), someDataToSign, sig), "signature should verify with the node address")
+}
+
+func TestRemoteSigner_RefusesSocketAccessibleToAnyUser(t *testing.T) {
+	keyPair := keys.EcdsaSecp256K1KeyPairForTests(0)
+	logger := log.DefaultTestingLoggerAllowingErrors(t, "failed signing as node")
+	socketPath, stop := serveSignerOnUnixSocket(t, NewLocalSigner(keyPair.PrivateKey(), logger), logger)
+	defer stop()
+	require.NoError(t, os.Chmod(socketPath, 0666))
+
+	s, err := NewRemoteSigner("unix://"+socketPath, 1*time.Second, logger)
+	require.NoError(t, err)
+
+	_, err = s.SignAsNode(context.Background(), 17, someDataToSign)
+	require.Error(t, err, "a socket any user may connect to lets them sign as the node")
+}
+
+func TestRemoteSigner_FailsWhenSignerProcessFails(t *testing.T) {
+	logger := log.DefaultTestingLoggerAllowingErrors(t, "failed signing as node")
+	socketPath, stop := serveSignerOnUnixSocket(t, NewLocalSigner(nil, logger), logger)
+	defer stop()
+
+	s, err := NewRemoteSigner("unix://"+socketPath, 1*time.Second, logger)
+	require.NoError(t, err)
+
+	_, err = s.SignAsNode(context.Background(), 17, someDataToSign)
+	require.Error(t, err, "signer process without a key should fail the sign request")
+}
+
+func TestNewRemoteSigner_RejectsNetworkEndpoint(t *testing.T) {
+	for _, endpoint := range []string{"http://127.0.0.1:7777", "https://127.0.0.1:7777", "ftp://127.0.0.1:7777"} {
+		_, err := NewRemoteSigner(endpoint, 1*time.Second, log.DefaultTestingLogger(t))
+		require.Error(t, err, "sign requests are not authenticated and should not be sent to %s", endpoint)
+	}
+}
+
+func serveSignerOnUnixSocket(t *testing.T, signer Signer, logger log.Logger) (string, func()) {
+	dir, err := ioutil.TempDir("", "signer")
+	require.NoError(t, err)
+
+	socketPath := filepath.Join(dir, "signer.sock")
+	listener, err := ListenUnixSocket(socketPath)
+	require.NoError(t, err)
+
+	server := &http.Server{Handler: NewHttpHandler(signer, logger)}
+	go server.Serve(listener)
+	return socketPath, func() {
+		server.Close()
+		os.RemoveAll(dir)
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/crypto/digest"
+	"github.com/orbs-network/orbs-network-go/crypto/keys"
+	"github.com/orbs-network/orbs-network-go/crypto/keystore"
+	"github.com/pkg/errors"
+	"os"
+)
+
+// Generates a new node key into a new keystore file encrypted with the passphrase, and prints the node address to configure
+func generateKey(args []string) error {
+	flags := flag.NewFlagSet("generate-key", flag.ExitOnError)
+	keystorePath := flags.String("keystore", "", "path/to/keystore.json to create, must not exist")
+	passphraseFile := flags.String("passphrase-file", "", "path/to/passphrase, by default the passphrase is read from "+config.NODE_KEYSTORE_PASSPHRASE_ENV)
+	lightScrypt := flags.Bool("light-scrypt", false, "derive the encryption key with much less memory and time, for tests only")
+	_ = flags.Parse(args)
+
+	if *keystorePath == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	passphrase, err := config.ReadKeystorePassphrase(*passphraseFile)
+	if err != nil {
+		return err
+	}
+	if len(passphrase) == 0 {
+		return errors.New("keystore passphrase must not be empty")
+	}
+
+	keyPair, err := keys.GenerateEcdsaSecp256K1Key()
+	if err != nil {
+		return err
+	}
+
+	scryptN, scryptP := keystore.STANDARD_SCRYPT_N, keystore.STANDARD_SCRYPT_P
+	if *lightScrypt {
+		scryptN, scryptP = keystore.LIGHT_SCRYPT_N, keystore.LIGHT_SCRYPT_P
+	}
+	contents, err := keystore.Encrypt(keyPair.PrivateKey(), passphrase, scryptN, scryptP)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(*keystorePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return errors.Wrap(err, "could not create keystore file")
+	}
+	if _, err := file.Write(contents); err != nil {
+		file.Close()
+		return errors.Wrap(err, "could not write keystore file")
+	}
+	if err := file.Close(); err != nil {
+		return errors.Wrap(err, "could not write keystore file")
+	}
+
+	fmt.Println(hex.EncodeToString(digest.CalcNodeAddressFromPublicKey(keyPair.PublicKey())))
+	return nil
+}
@@ -88,6 +88,14 @@ func getConfig(configFiles config.ArrayFlags, httpAddress string) (config.NodeCo
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "generate-key" {
+		if err := generateKey(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	httpAddress := flag.String("listen", ":8080", "ip address and port for http server")
 	silentLog := flag.Bool("silent", false, "disable output to stdout")
 	pathToLog := flag.String("log", "", "path/to/node.log")
@@ -0,0 +1,181 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package export
+
+import (
+	"fmt"
+	"github.com/orbs-network/orbs-network-go/crypto/digest"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage/adapter"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/pkg/errors"
+	"io"
+)
+
+const scanPageSize = 100
+
+// Writes blocks from first to last into an export stream. A last height of zero exports up to the last block held.
+func Export(w io.Writer, persistence adapter.BlockPersistence, vchainId primitives.VirtualChainId, first, last primitives.BlockHeight) (*Manifest, error) {
+	top, err := persistence.GetLastBlockHeight()
+	if err != nil {
+		return nil, err
+	}
+	if first == 0 {
+		first = 1
+	}
+	if last == 0 || last > top {
+		last = top
+	}
+	if first > last {
+		return nil, fmt.Errorf("no blocks to export from block height %d, last block height is %d", first, top)
+	}
+
+	manifest := newManifest(vchainId, first, last)
+	stream := newStreamWriter(w)
+	err = stream.writeManifest(manifest)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed writing export stream manifest")
+	}
+
+	next := first
+	var writeErr error
+	err = persistence.ScanBlocks(first, scanPageSize, func(h primitives.BlockHeight, page []*protocol.BlockPairContainer) (wantsMore bool) {
+		for _, block := range page {
+			if next > last {
+				return false
+			}
+			if block.TransactionsBlock.Header.VirtualChainId() != vchainId {
+				writeErr = fmt.Errorf("block belongs to virtual chain %d, exporting virtual chain %d", block.TransactionsBlock.Header.VirtualChainId(), vchainId)
+				return false
+			}
+			writeErr = stream.writeBlock(block)
+			if writeErr != nil {
+				return false
+			}
+			next++
+		}
+		return next <= last
+	})
+	if err == nil {
+		err = writeErr
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed exporting block %d", next)
+	}
+	if next <= last {
+		return nil, fmt.Errorf("failed exporting blocks, scan stopped at block height %d", next)
+	}
+
+	err = stream.writeTrailer()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed writing export stream trailer")
+	}
+	return manifest, nil
+}
+
+// Writes the blocks of an export stream following the last block held. The whole stream is validated before any block is
+// written - its manifest, rolling hash, and that its blocks belong to the virtual chain and extend the chain held.
+// Block proofs are not validated, that requires the committee of every height which only a running node knows. A stream
+// is therefore only as trustworthy as its source, and the caller must assert it exported the stream itself or trusts who did.
+func Import(r io.ReadSeeker, persistence adapter.BlockPersistence, vchainId primitives.VirtualChainId, sourceIsTrusted bool) (*Manifest, error) {
+	if !sourceIsTrusted {
+		return nil, errors.New("block proofs of an export stream are not validated, only import streams from a trusted source")
+	}
+
+	manifest, err := validate(r, persistence, vchainId)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid export stream")
+	}
+
+	_, err = r.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	stream := newStreamReader(r)
+	_, err = stream.readManifest()
+	if err != nil {
+		return nil, err
+	}
+	for h := manifest.FirstBlockHeight; h <= manifest.LastBlockHeight; h++ {
+		block, err := stream.readBlock()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed reading block %d", h)
+		}
+		added, err := persistence.WriteNextBlock(block)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed importing block %d", h)
+		}
+		if !added {
+			return nil, fmt.Errorf("failed importing block %d, a block was written at this height concurrently", h)
+		}
+	}
+	return manifest, nil
+}
+
+func validate(r io.Reader, persistence adapter.BlockPersistence, vchainId primitives.VirtualChainId) (*Manifest, error) {
+	stream := newStreamReader(r)
+	manifest, err := stream.readManifest()
+	if err != nil {
+		return nil, err
+	}
+	if primitives.VirtualChainId(manifest.VirtualChainId) != vchainId {
+		return nil, fmt.Errorf("virtual chain id mismatch. found vchain id %d expected %d", manifest.VirtualChainId, vchainId)
+	}
+
+	prev, err := persistence.GetLastBlock()
+	if err != nil {
+		return nil, err
+	}
+	if expected := getBlockHeight(prev) + 1; primitives.BlockHeight(manifest.FirstBlockHeight) != expected {
+		return nil, fmt.Errorf("stream starts at block height %d, expected block height %d", manifest.FirstBlockHeight, expected)
+	}
+
+	for h := primitives.BlockHeight(manifest.FirstBlockHeight); h <= primitives.BlockHeight(manifest.LastBlockHeight); h++ {
+		block, err := stream.readBlock()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed reading block %d", h)
+		}
+		err = validateBlock(block, prev, h, vchainId)
+		if err != nil {
+			return nil, err
+		}
+		prev = block
+	}
+
+	err = stream.readTrailer()
+	if err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func validateBlock(block, prev *protocol.BlockPairContainer, height primitives.BlockHeight, vchainId primitives.VirtualChainId) error {
+	txHeader, rxHeader := block.TransactionsBlock.Header, block.ResultsBlock.Header
+	if txHeader.BlockHeight() != height || rxHeader.BlockHeight() != height {
+		return fmt.Errorf("expected block height %d, found block heights %d and %d", height, txHeader.BlockHeight(), rxHeader.BlockHeight())
+	}
+	if txHeader.VirtualChainId() != vchainId || rxHeader.VirtualChainId() != vchainId {
+		return fmt.Errorf("block %d virtual chain id mismatch. found vchain ids %d and %d expected %d", height, txHeader.VirtualChainId(), rxHeader.VirtualChainId(), vchainId)
+	}
+	if prev == nil {
+		return nil
+	}
+	if !txHeader.PrevBlockHashPtr().Equal(digest.CalcTransactionsBlockHash(prev.TransactionsBlock)) {
+		return fmt.Errorf("block %d transactions block prev block hash does not match the previous block", height)
+	}
+	if !rxHeader.PrevBlockHashPtr().Equal(digest.CalcResultsBlockHash(prev.ResultsBlock)) {
+		return fmt.Errorf("block %d results block prev block hash does not match the previous block", height)
+	}
+	return nil
+}
+
+func getBlockHeight(block *protocol.BlockPairContainer) primitives.BlockHeight {
+	if block == nil {
+		return 0
+	}
+	return block.TransactionsBlock.Header.BlockHeight()
+}
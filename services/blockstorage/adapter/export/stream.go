@@ -0,0 +1,269 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"github.com/orbs-network/membuffers/go"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/pkg/errors"
+	"hash"
+	"io"
+)
+
+// An export stream is a manifest, a record per block and a trailer. It does not depend on the layout of the blocks files
+// so it can be moved between environments running different storage versions.
+//
+// Every block record starts with the number of signed transactions, receipts and state diffs it holds, followed by its
+// membuffers messages, each prefixed by its length. The rolling hash in the trailer covers all block records in order.
+const streamMagic = uint32(0x50584b42) // "BKXP"
+const streamVersion = uint32(0)
+
+const maxMessageSize = 64 * 1024 * 1024
+
+type Manifest struct {
+	Magic            uint32
+	Version          uint32
+	VirtualChainId   uint32
+	FirstBlockHeight uint64
+	LastBlockHeight  uint64
+}
+
+type trailer struct {
+	RollingHash [sha256.Size]byte
+}
+
+type recordHeader struct {
+	NumTransactions uint32
+	NumReceipts     uint32
+	NumStateDiffs   uint32
+}
+
+func newManifest(vchainId primitives.VirtualChainId, first, last primitives.BlockHeight) *Manifest {
+	return &Manifest{
+		Magic:            streamMagic,
+		Version:          streamVersion,
+		VirtualChainId:   uint32(vchainId),
+		FirstBlockHeight: uint64(first),
+		LastBlockHeight:  uint64(last),
+	}
+}
+
+func (m *Manifest) validate() error {
+	if m.Magic != streamMagic {
+		return fmt.Errorf("invalid export stream magic number %v", m.Magic)
+	}
+	if m.Version != streamVersion {
+		return fmt.Errorf("invalid export stream version %d", m.Version)
+	}
+	if m.FirstBlockHeight == 0 || m.FirstBlockHeight > m.LastBlockHeight {
+		return fmt.Errorf("invalid export stream height range %d to %d", m.FirstBlockHeight, m.LastBlockHeight)
+	}
+	return nil
+}
+
+type streamWriter struct {
+	w           *bufio.Writer
+	rollingHash hash.Hash
+}
+
+func newStreamWriter(w io.Writer) *streamWriter {
+	return &streamWriter{
+		w:           bufio.NewWriter(w),
+		rollingHash: sha256.New(),
+	}
+}
+
+func (s *streamWriter) writeManifest(manifest *Manifest) error {
+	return binary.Write(s.w, binary.LittleEndian, manifest)
+}
+
+func (s *streamWriter) writeTrailer() error {
+	t := &trailer{}
+	copy(t.RollingHash[:], s.rollingHash.Sum(nil))
+	err := binary.Write(s.w, binary.LittleEndian, t)
+	if err != nil {
+		return err
+	}
+	return s.w.Flush()
+}
+
+func (s *streamWriter) writeBlock(block *protocol.BlockPairContainer) error {
+	tb, rb := block.TransactionsBlock, block.ResultsBlock
+
+	buf := &bytes.Buffer{}
+	_ = binary.Write(buf, binary.LittleEndian, &recordHeader{
+		NumTransactions: uint32(len(tb.SignedTransactions)),
+		NumReceipts:     uint32(len(rb.TransactionReceipts)),
+		NumStateDiffs:   uint32(len(rb.ContractStateDiffs)),
+	})
+	writeMessage(buf, tb.Header)
+	writeMessage(buf, tb.Metadata)
+	writeMessage(buf, tb.BlockProof)
+	writeMessage(buf, rb.Header)
+	writeMessage(buf, rb.BlockProof)
+	for _, tx := range tb.SignedTransactions {
+		writeMessage(buf, tx)
+	}
+	for _, receipt := range rb.TransactionReceipts {
+		writeMessage(buf, receipt)
+	}
+	for _, diff := range rb.ContractStateDiffs {
+		writeMessage(buf, diff)
+	}
+
+	_, _ = s.rollingHash.Write(buf.Bytes())
+	_, err := s.w.Write(buf.Bytes())
+	return err
+}
+
+func writeMessage(w io.Writer, message membuffers.Message) {
+	_ = binary.Write(w, binary.LittleEndian, uint32(len(message.Raw())))
+	_, _ = w.Write(message.Raw())
+}
+
+// block records are read through the rolling hash, the manifest and trailer are read around it
+type streamReader struct {
+	raw         *bufio.Reader
+	r           io.Reader
+	rollingHash hash.Hash
+}
+
+func newStreamReader(r io.Reader) *streamReader {
+	raw := bufio.NewReader(r)
+	rollingHash := sha256.New()
+	return &streamReader{
+		raw:         raw,
+		r:           io.TeeReader(raw, rollingHash),
+		rollingHash: rollingHash,
+	}
+}
+
+func (s *streamReader) readManifest() (*Manifest, error) {
+	manifest := &Manifest{}
+	err := binary.Read(s.raw, binary.LittleEndian, manifest)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed reading export stream manifest")
+	}
+	return manifest, manifest.validate()
+}
+
+// the stream must end right after the trailer
+func (s *streamReader) readTrailer() error {
+	t := &trailer{}
+	err := binary.Read(s.raw, binary.LittleEndian, t)
+	if err != nil {
+		return errors.Wrap(noEOF(err), "failed reading export stream trailer")
+	}
+	if !bytes.Equal(t.RollingHash[:], s.rollingHash.Sum(nil)) {
+		return fmt.Errorf("export stream rolling hash mismatch. computed: %x recorded: %x", s.rollingHash.Sum(nil), t.RollingHash)
+	}
+	if _, err := s.raw.ReadByte(); err != io.EOF {
+		return fmt.Errorf("export stream has unexpected data following its trailer")
+	}
+	return nil
+}
+
+func (s *streamReader) readBlock() (*protocol.BlockPairContainer, error) {
+	header := &recordHeader{}
+	err := binary.Read(s.r, binary.LittleEndian, header)
+	if err != nil {
+		return nil, errors.Wrap(noEOF(err), "failed reading block record")
+	}
+
+	txHeader, err := s.readMessage()
+	if err != nil {
+		return nil, err
+	}
+	txMetadata, err := s.readMessage()
+	if err != nil {
+		return nil, err
+	}
+	txProof, err := s.readMessage()
+	if err != nil {
+		return nil, err
+	}
+	rxHeader, err := s.readMessage()
+	if err != nil {
+		return nil, err
+	}
+	rxProof, err := s.readMessage()
+	if err != nil {
+		return nil, err
+	}
+
+	block := &protocol.BlockPairContainer{
+		TransactionsBlock: &protocol.TransactionsBlockContainer{
+			Header:     protocol.TransactionsBlockHeaderReader(txHeader),
+			Metadata:   protocol.TransactionsBlockMetadataReader(txMetadata),
+			BlockProof: protocol.TransactionsBlockProofReader(txProof),
+		},
+		ResultsBlock: &protocol.ResultsBlockContainer{
+			Header:     protocol.ResultsBlockHeaderReader(rxHeader),
+			BlockProof: protocol.ResultsBlockProofReader(rxProof),
+		},
+	}
+	if block.TransactionsBlock.Header.NumSignedTransactions() != header.NumTransactions ||
+		block.ResultsBlock.Header.NumTransactionReceipts() != header.NumReceipts ||
+		block.ResultsBlock.Header.NumContractStateDiffs() != header.NumStateDiffs {
+		return nil, fmt.Errorf("block record counts do not match block %d headers", block.ResultsBlock.Header.BlockHeight())
+	}
+
+	for i := uint32(0); i < header.NumTransactions; i++ {
+		raw, err := s.readMessage()
+		if err != nil {
+			return nil, err
+		}
+		block.TransactionsBlock.SignedTransactions = append(block.TransactionsBlock.SignedTransactions, protocol.SignedTransactionReader(raw))
+	}
+	for i := uint32(0); i < header.NumReceipts; i++ {
+		raw, err := s.readMessage()
+		if err != nil {
+			return nil, err
+		}
+		block.ResultsBlock.TransactionReceipts = append(block.ResultsBlock.TransactionReceipts, protocol.TransactionReceiptReader(raw))
+	}
+	for i := uint32(0); i < header.NumStateDiffs; i++ {
+		raw, err := s.readMessage()
+		if err != nil {
+			return nil, err
+		}
+		block.ResultsBlock.ContractStateDiffs = append(block.ResultsBlock.ContractStateDiffs, protocol.ContractStateDiffReader(raw))
+	}
+	return block, nil
+}
+
+func (s *streamReader) readMessage() ([]byte, error) {
+	var size uint32
+	err := binary.Read(s.r, binary.LittleEndian, &size)
+	if err != nil {
+		return nil, errors.Wrap(noEOF(err), "failed reading message length")
+	}
+	if size > maxMessageSize {
+		return nil, fmt.Errorf("message size %d exceeds max size %d", size, maxMessageSize)
+	}
+
+	raw := make([]byte, size)
+	_, err = io.ReadFull(s.r, raw)
+	if err != nil {
+		return nil, errors.Wrap(noEOF(err), "failed reading message")
+	}
+	return raw, nil
+}
+
+// only the end of a block record may end the stream
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
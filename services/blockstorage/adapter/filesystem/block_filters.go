@@ -0,0 +1,240 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package filesystem
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/crypto/bloom"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const filtersFilename = "block_filters"
+
+const filtersFormatMagic = uint32(0x544c4642) // "BFLT"
+
+var filterRecordSize = int64(binary.Size(uint64(0)) + bloom.BLOCK_FILTER_SIZE_BYTES + checksumSize)
+
+// Keeps the bloom filter of every block in fixed size records, so the filter of a block is read without reading the block.
+// Like the index files it is not synced to disk, and filters it is missing are computed again from the blocks files on startup.
+// Filters of pruned blocks remain in the file.
+type blockFilterStore struct {
+	sync.RWMutex
+	firstBlockHeight primitives.BlockHeight // of the first record
+	topBlockHeight   primitives.BlockHeight
+
+	filename string
+	file     *os.File
+	header   *blocksFileHeader
+	logger   log.Logger
+}
+
+// keeps records up to topBlockHeight, records beyond it belong to blocks which did not survive in the blocks files.
+// A file ending before firstBlockHeight is recreated, filters of pruned blocks can not be computed again
+func openBlockFilterStore(conf config.FilesystemBlockPersistenceConfig, firstBlockHeight, topBlockHeight primitives.BlockHeight, logger log.Logger) (*blockFilterStore, error) {
+	filename := filtersFileName(conf)
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open block filters file %s", filename)
+	}
+
+	store := &blockFilterStore{
+		firstBlockHeight: firstBlockHeight,
+		topBlockHeight:   firstBlockHeight - 1,
+		filename:         filename,
+		file:             file,
+		header:           newSidecarFileHeader(filtersFormatMagic, 0, uint32(conf.VirtualChainId())),
+		logger:           logger,
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		closeSilently(file, logger)
+		return nil, errors.Wrapf(err, "failed to open block filters file %s", filename)
+	}
+	if info.Size() == 0 {
+		logger.Info("creating new block filters file", log.String("path", filename))
+		store.reset(firstBlockHeight)
+		return store, nil
+	}
+
+	err = store.readRecords(info.Size(), topBlockHeight)
+	if err == nil && store.topBlockHeight+1 < firstBlockHeight {
+		err = fmt.Errorf("block filters file ends at block height %d before first block height %d", store.topBlockHeight, firstBlockHeight)
+	}
+	if err != nil {
+		logger.Info("block filters file is invalid, recreating it", log.String("path", filename), log.Error(err))
+		store.reset(firstBlockHeight)
+	}
+
+	return store, nil
+}
+
+// records are of fixed size, so only the first and last ones are read. A torn tail is dropped record by record
+func (s *blockFilterStore) readRecords(fileSize int64, topBlockHeight primitives.BlockHeight) error {
+	err := readSidecarFileHeader(io.NewSectionReader(s.file, 0, fileSize), s.header)
+	if err != nil {
+		return err
+	}
+
+	headerSize := int64(binary.Size(s.header) + checksumSize)
+	numRecords := (fileSize - headerSize) / filterRecordSize
+	if numRecords > 0 {
+		first, _, err := s.readRecordAt(headerSize)
+		if err != nil {
+			return errors.Wrap(err, "failed reading first record")
+		}
+		if first > topBlockHeight+1 {
+			return fmt.Errorf("block filters file starts at block height %d after top block height %d", first, topBlockHeight)
+		}
+		s.firstBlockHeight = first
+		if maxRecords := int64(topBlockHeight) - int64(first) + 1; numRecords > maxRecords {
+			numRecords = maxRecords
+		}
+	}
+	for ; numRecords > 0; numRecords-- {
+		height, _, err := s.readRecordAt(headerSize + (numRecords-1)*filterRecordSize)
+		if err == nil && height == s.firstBlockHeight+primitives.BlockHeight(numRecords-1) {
+			break
+		}
+		s.logger.Info("block filters file has an invalid tail record, ignoring it", log.Int64("record", numRecords-1), log.Error(err))
+	}
+	s.topBlockHeight = s.firstBlockHeight + primitives.BlockHeight(numRecords) - 1
+
+	validSize := headerSize + numRecords*filterRecordSize
+	err = s.file.Truncate(validSize)
+	if err == nil {
+		_, err = s.file.Seek(validSize, io.SeekStart)
+	}
+	return err
+}
+
+func (s *blockFilterStore) readRecordAt(offset int64) (primitives.BlockHeight, []byte, error) {
+	record := make([]byte, filterRecordSize)
+	_, err := s.file.ReadAt(record, offset)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	body := record[:len(record)-checksumSize]
+	sum32 := binary.LittleEndian.Uint32(record[len(body):])
+	if computed := crc32.Checksum(body, crc32.MakeTable(crc32.Castagnoli)); computed != sum32 {
+		return 0, nil, fmt.Errorf("block filter record checksum mismatch. computed: %v recorded: %v", computed, sum32)
+	}
+	return primitives.BlockHeight(binary.LittleEndian.Uint64(body)), body[binary.Size(uint64(0)):], nil
+}
+
+func encodeFilterRecord(height primitives.BlockHeight, filter *bloom.BlockFilter) []byte {
+	buf := &bytes.Buffer{}
+	_ = binary.Write(buf, binary.LittleEndian, uint64(height))
+	buf.Write(filter.Raw())
+	_ = binary.Write(buf, binary.LittleEndian, crc32.Checksum(buf.Bytes(), crc32.MakeTable(crc32.Castagnoli)))
+	return buf.Bytes()
+}
+
+// callers must add blocks in order, starting right after the height the store was loaded up to
+func (s *blockFilterStore) add(block *protocol.BlockPairContainer) {
+	height := block.ResultsBlock.Header.BlockHeight()
+	record := encodeFilterRecord(height, bloom.CalcBlockFilter(block))
+
+	s.Lock()
+	defer s.Unlock()
+
+	if s.file == nil {
+		return
+	}
+	if height != s.topBlockHeight+1 {
+		s.disable(fmt.Errorf("block height %d added after block height %d", height, s.topBlockHeight))
+		return
+	}
+	_, err := s.file.Write(record)
+	if err != nil {
+		s.disable(err)
+		return
+	}
+	s.topBlockHeight++
+}
+
+func (s *blockFilterStore) read(height primitives.BlockHeight) (*bloom.BlockFilter, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	if s.file == nil {
+		return nil, fmt.Errorf("block filters file is disabled")
+	}
+	if height < s.firstBlockHeight || height > s.topBlockHeight {
+		return nil, fmt.Errorf("block filters file does not hold block height %d", height)
+	}
+
+	offset := int64(binary.Size(s.header)+checksumSize) + int64(height-s.firstBlockHeight)*filterRecordSize
+	recordHeight, raw, err := s.readRecordAt(offset)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read block filter of block height %d", height)
+	}
+	if recordHeight != height {
+		return nil, fmt.Errorf("block filters file holds block height %d where block height %d was expected", recordHeight, height)
+	}
+	return bloom.NewBlockFilterFromRaw(raw)
+}
+
+func (s *blockFilterStore) getTopBlockHeight() primitives.BlockHeight {
+	s.RLock()
+	defer s.RUnlock()
+	return s.topBlockHeight
+}
+
+func (s *blockFilterStore) reset(firstBlockHeight primitives.BlockHeight) {
+	s.firstBlockHeight = firstBlockHeight
+	s.topBlockHeight = firstBlockHeight - 1
+	if s.file == nil {
+		return
+	}
+
+	err := s.file.Truncate(0)
+	if err == nil {
+		_, err = s.file.Seek(0, io.SeekStart)
+	}
+	if err == nil {
+		err = s.header.write(s.file)
+	}
+	if err != nil {
+		s.disable(err)
+	}
+}
+
+func (s *blockFilterStore) disable(err error) {
+	s.logger.Error("failed writing block filters file, disabling it until restart", log.Error(err), log.String("filename", s.filename))
+	s.closeFile()
+}
+
+func (s *blockFilterStore) close() {
+	s.Lock()
+	defer s.Unlock()
+	s.closeFile()
+}
+
+func (s *blockFilterStore) closeFile() {
+	if s.file == nil {
+		return
+	}
+	closeSilently(s.file, s.logger)
+	s.file = nil
+}
+
+func filtersFileName(config config.FilesystemBlockPersistenceConfig) string {
+	return filepath.Join(config.BlockStorageFileSystemDataDir(), filtersFilename)
+}
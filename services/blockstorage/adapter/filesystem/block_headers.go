@@ -0,0 +1,251 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package filesystem
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const headersFilename = "block_headers"
+
+const headersFormatMagic = uint32(0x52444842) // "BHDR"
+
+// Keeps the fixed section of every block - its headers, metadata and proofs - so they can still be served for proofs once
+// the block itself was pruned. Records are written the way the fixed section is written in the blocks files.
+// Unlike the index files pruning relies on it, so it is synced before any blocks are pruned and pruning stops if writing it fails.
+type blockHeaderStore struct {
+	sync.RWMutex
+	offsets    []int64 // offsets[h-1] is where the record of block height h starts
+	nextOffset int64
+
+	filename string
+	file     *os.File
+	header   *blocksFileHeader
+	codec    *codec
+	logger   log.Logger
+}
+
+// loads records up to topBlockHeight, records beyond it belong to blocks which did not survive in the blocks files.
+// An invalid file is recreated from the blocks files unless it holds the only copy of pruned block headers.
+func openBlockHeaderStore(conf config.FilesystemBlockPersistenceConfig, firstBlockHeight, topBlockHeight primitives.BlockHeight, c *codec, logger log.Logger) (*blockHeaderStore, error) {
+	filename := headersFileName(conf)
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open block headers file %s", filename)
+	}
+
+	store := &blockHeaderStore{
+		filename: filename,
+		file:     file,
+		header:   newSidecarFileHeader(headersFormatMagic, 0, uint32(conf.VirtualChainId())),
+		codec:    c,
+		logger:   logger,
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		closeSilently(file, logger)
+		return nil, errors.Wrapf(err, "failed to open block headers file %s", filename)
+	}
+	if info.Size() == 0 {
+		logger.Info("creating new block headers file", log.String("path", filename))
+		err = store.reset()
+	} else {
+		err = store.readRecords(topBlockHeight)
+		if err != nil && firstBlockHeight == 1 {
+			logger.Info("block headers file is invalid, recreating it", log.String("path", filename), log.Error(err))
+			err = store.reset()
+		}
+	}
+	if err != nil {
+		closeSilently(file, logger)
+		return nil, errors.Wrapf(err, "failed to read block headers file %s", filename)
+	}
+
+	return store, nil
+}
+
+func (s *blockHeaderStore) reset() error {
+	s.offsets = nil
+	s.nextOffset = 0
+
+	err := s.file.Truncate(0)
+	if err == nil {
+		_, err = s.file.Seek(0, io.SeekStart)
+	}
+	if err == nil {
+		err = s.header.write(s.file)
+	}
+	if err != nil {
+		return err
+	}
+	s.nextOffset = int64(binary.Size(s.header) + checksumSize)
+	return nil
+}
+
+func (s *blockHeaderStore) readRecords(topBlockHeight primitives.BlockHeight) error {
+	r := bufio.NewReader(s.file)
+
+	err := readSidecarFileHeader(r, s.header)
+	if err != nil {
+		return err
+	}
+
+	s.nextOffset = int64(binary.Size(s.header) + checksumSize)
+	for primitives.BlockHeight(len(s.offsets)) < topBlockHeight {
+		block, recordSize, err := s.readRecord(r)
+		if err != nil {
+			if err != io.EOF {
+				s.logger.Info("block headers file has an invalid tail, ignoring it", log.Int64("valid-bytes", s.nextOffset), log.Error(err))
+			}
+			break
+		}
+		if h := block.ResultsBlock.Header.BlockHeight(); h != primitives.BlockHeight(len(s.offsets)+1) {
+			s.logger.Info("block headers file has an out of order record, ignoring it and what follows", log.Int64("valid-bytes", s.nextOffset))
+			break
+		}
+
+		s.offsets = append(s.offsets, s.nextOffset)
+		s.nextOffset += int64(recordSize)
+	}
+
+	err = s.file.Truncate(s.nextOffset)
+	if err == nil {
+		_, err = s.file.Seek(s.nextOffset, io.SeekStart)
+	}
+	return err
+}
+
+func (s *blockHeaderStore) readRecord(r io.Reader) (*protocol.BlockPairContainer, int, error) {
+	budget := newReadingBudget(s.codec.maxBlockSize, 0)
+	fixed, sum32, err := s.codec.readFixedSection(fullReader{r}, budget)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = verifySectionChecksum("headers record", sum32, fixed.transactionsBlockHeader, fixed.transactionsBlockMetadata, fixed.transactionsBlockProof, fixed.resultsBlockHeader, fixed.resultsBlockProof)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return &protocol.BlockPairContainer{
+		TransactionsBlock: &protocol.TransactionsBlockContainer{
+			Header:     fixed.transactionsBlockHeader,
+			Metadata:   fixed.transactionsBlockMetadata,
+			BlockProof: fixed.transactionsBlockProof,
+		},
+		ResultsBlock: &protocol.ResultsBlockContainer{
+			Header:     fixed.resultsBlockHeader,
+			BlockProof: fixed.resultsBlockProof,
+		},
+	}, budget.bytesRead + checksumSize, nil
+}
+
+// callers must add blocks in order, starting right after the height the store was loaded up to
+func (s *blockHeaderStore) add(block *protocol.BlockPairContainer) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.file == nil {
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	err := s.codec.writeFixedBlockSectionWithChecksum(buf, block)
+	if err == nil {
+		_, err = s.file.Write(buf.Bytes())
+	}
+	if err != nil {
+		s.disable(err)
+		return
+	}
+
+	s.offsets = append(s.offsets, s.nextOffset)
+	s.nextOffset += int64(buf.Len())
+}
+
+func (s *blockHeaderStore) getTopBlockHeight() primitives.BlockHeight {
+	s.RLock()
+	defer s.RUnlock()
+	return primitives.BlockHeight(len(s.offsets))
+}
+
+// returns a block pair holding only the headers, metadata and proofs of the block
+func (s *blockHeaderStore) read(height primitives.BlockHeight) (*protocol.BlockPairContainer, error) {
+	s.RLock()
+	if height < 1 || height > primitives.BlockHeight(len(s.offsets)) {
+		s.RUnlock()
+		return nil, fmt.Errorf("block headers file does not hold block height %d", height)
+	}
+	offset, nextOffset := s.offsets[height-1], s.nextOffset
+	s.RUnlock()
+
+	file, err := os.Open(s.filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open block headers file for reading")
+	}
+	defer closeSilently(file, s.logger)
+
+	block, _, err := s.readRecord(io.NewSectionReader(file, offset, nextOffset-offset))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read block headers of block height %d", height)
+	}
+	return block, nil
+}
+
+// pruning must not remove blocks whose headers may still be lost in a crash
+func (s *blockHeaderStore) sync() error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.file == nil {
+		return fmt.Errorf("block headers file was disabled after failing to write it")
+	}
+	return s.file.Sync()
+}
+
+func (s *blockHeaderStore) disable(err error) {
+	s.logger.Error("failed writing block headers file, disabling it and pruning until restart", log.Error(err), log.String("filename", s.filename))
+	closeSilently(s.file, s.logger)
+	s.file = nil
+}
+
+func (s *blockHeaderStore) close() {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.file == nil {
+		return
+	}
+	closeSilently(s.file, s.logger)
+	s.file = nil
+}
+
+func headersFileName(config config.FilesystemBlockPersistenceConfig) string {
+	return filepath.Join(config.BlockStorageFileSystemDataDir(), headersFilename)
+}
+
+// readChunk expects each read to fill its buffer, which buffered readers do not guarantee
+type fullReader struct {
+	r io.Reader
+}
+
+func (f fullReader) Read(p []byte) (int, error) {
+	return io.ReadFull(f.r, p)
+}
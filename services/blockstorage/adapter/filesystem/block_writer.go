@@ -31,6 +31,11 @@ func newBlockWriter(ws writerSyncer, codec blockCodec) *blockWriter {
 	}
 }
 
+// callers must hold the lock
+func (bw *blockWriter) switchTo(ws writerSyncer) {
+	bw.ws = ws
+}
+
 func (bw *blockWriter) writeBlock(blockPair *protocol.BlockPairContainer) (int, error) {
 	bytes, err := bw.codec.encode(blockPair, bw.ws)
 	if err != nil {
@@ -7,6 +7,8 @@
 package filesystem
 
 import (
+	"bytes"
+	"compress/flate"
 	"encoding/binary"
 	"fmt"
 	"github.com/orbs-network/membuffers/go"
@@ -14,10 +16,12 @@ import (
 	"github.com/pkg/errors"
 	"hash/crc32"
 	"io"
+	"io/ioutil"
 	"unsafe"
 )
 
 const blockHeaderSize = int(unsafe.Sizeof(blockHeader{}))
+const compressionHeaderSize = int(unsafe.Sizeof(compressionHeader{}))
 const checksumSize = int(unsafe.Sizeof(uint32(0)))
 const chunkLengthSize = int(unsafe.Sizeof(uint32(0)))
 
@@ -25,14 +29,20 @@ const orbsFormatMagic = uint32(0x5342524f) // "ORBS"
 const orbsFormatVersion = 0
 const blockMagic = uint32(0x6b4f4c42) // "BLOk"
 const blockVersion = 0
+const compressedBlockVersion = 1 // the block header is followed by a compression header and the dynamic sections are compressed
+
+const compressionAlgorithmFlate = uint32(1)
 
 type codec struct {
-	maxBlockSize int
+	maxBlockSize            int
+	compressDynamicSections bool
 }
 
-func newCodec(maxBlockSize uint32) *codec {
+// blocks of either version are decoded regardless of compressDynamicSections, which only affects encoding
+func newCodec(maxBlockSize uint32, compressDynamicSections bool) *codec {
 	return &codec{
-		maxBlockSize: int(maxBlockSize),
+		maxBlockSize:            int(maxBlockSize),
+		compressDynamicSections: compressDynamicSections,
 	}
 }
 
@@ -52,6 +62,23 @@ type blockHeader struct {
 	TxsSize      uint32
 }
 
+// The section sizes of the block header remain the uncompressed sizes, so the max block size applies to decompressed blocks
+type compressionHeader struct {
+	Algorithm      uint32
+	CompressedSize uint32
+}
+
+func (ch *compressionHeader) read(r io.Reader) error {
+	err := binary.Read(r, binary.LittleEndian, ch)
+	if err != nil {
+		return err
+	}
+	if ch.Algorithm != compressionAlgorithmFlate {
+		return fmt.Errorf("invalid block compression algorithm %d", ch.Algorithm)
+	}
+	return nil
+}
+
 func diskChunkSize(bytes []byte) uint32 {
 	return uint32(chunkLengthSize) + uint32(len(bytes))
 }
@@ -101,7 +128,7 @@ func (bh *blockHeader) read(r io.Reader) error {
 		return fmt.Errorf("invalid block magic number %v", bh.Magic)
 	}
 
-	if bh.Version != blockVersion {
+	if bh.Version != blockVersion && bh.Version != compressedBlockVersion {
 		return fmt.Errorf("invalid block version %d", bh.Version)
 	}
 
@@ -197,43 +224,87 @@ func (c *codec) encode(block *protocol.BlockPairContainer, w io.Writer) (int, er
 		blockHeader.addTx(tx)
 	}
 
+	if blockHeaderSize+blockHeader.totalSize() > c.maxBlockSize {
+		return 0, fmt.Errorf("block size exceeds max limit. block size is %d bytes", blockHeaderSize+blockHeader.totalSize())
+	}
+
+	var compressed *bytes.Buffer
+	if c.compressDynamicSections {
+		var err error
+		compressed, err = c.compressDynamicBlockSections(block)
+		if err != nil {
+			return 0, err
+		}
+		if compressed.Len() >= int(blockHeader.ReceiptsSize+blockHeader.DiffsSize+blockHeader.TxsSize)+checksumSize*3 {
+			compressed = nil // not worth decompressing on every read
+		}
+	}
+
 	fullBlockChecksum := crc32.New(crc32.MakeTable(crc32.Castagnoli))
 	fullBlockWriter := newChecksumWriter(w, fullBlockChecksum)
+	if compressed != nil {
+		blockHeader.Version = compressedBlockVersion
+	}
 	err := blockHeader.write(fullBlockWriter)
 	if err != nil {
 		return 0, err
 	}
+	if compressed != nil {
+		err = binary.Write(fullBlockWriter, binary.LittleEndian, &compressionHeader{Algorithm: compressionAlgorithmFlate, CompressedSize: uint32(compressed.Len())})
+		if err != nil {
+			return 0, err
+		}
+	}
 
 	err = c.writeFixedBlockSectionWithChecksum(fullBlockWriter, block)
 	if err != nil {
 		return 0, err
 	}
 
-	err = c.writeDynamicBlockSectionWithChecksum(fullBlockWriter, transactionReceiptsToMessages(rb.TransactionReceipts))
+	if compressed != nil {
+		_, err = fullBlockWriter.Write(compressed.Bytes())
+	} else {
+		err = c.writeDynamicBlockSections(fullBlockWriter, block)
+	}
 	if err != nil {
 		return 0, err
 	}
 
-	err = c.writeDynamicBlockSectionWithChecksum(fullBlockWriter, diffsToMessages(rb.ContractStateDiffs))
+	err = binary.Write(w, binary.LittleEndian, fullBlockChecksum.Sum32()) // full block checksum
 	if err != nil {
 		return 0, err
 	}
 
-	err = c.writeDynamicBlockSectionWithChecksum(fullBlockWriter, transactionsToMessages(tb.SignedTransactions))
+	return fullBlockWriter.bytesWritten + checksumSize, nil
+}
+
+func (c *codec) writeDynamicBlockSections(w io.Writer, block *protocol.BlockPairContainer) error {
+	err := c.writeDynamicBlockSectionWithChecksum(w, transactionReceiptsToMessages(block.ResultsBlock.TransactionReceipts))
 	if err != nil {
-		return 0, err
+		return err
 	}
-
-	if fullBlockWriter.bytesWritten > c.maxBlockSize { // check if we exceeded budget
-		return 0, fmt.Errorf("block size exceeds max limit. wrote %d bytes", fullBlockWriter.bytesWritten)
+	err = c.writeDynamicBlockSectionWithChecksum(w, diffsToMessages(block.ResultsBlock.ContractStateDiffs))
+	if err != nil {
+		return err
 	}
+	return c.writeDynamicBlockSectionWithChecksum(w, transactionsToMessages(block.TransactionsBlock.SignedTransactions))
+}
 
-	err = binary.Write(w, binary.LittleEndian, fullBlockChecksum.Sum32()) // full block checksum
+// the dynamic sections are compressed together, along with their checksums
+func (c *codec) compressDynamicBlockSections(block *protocol.BlockPairContainer) (*bytes.Buffer, error) {
+	compressed := &bytes.Buffer{}
+	fw, err := flate.NewWriter(compressed, flate.DefaultCompression)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-
-	return blockHeaderSize + blockHeader.totalSize() + checksumSize*5, nil
+	err = c.writeDynamicBlockSections(fw, block)
+	if err == nil {
+		err = fw.Close()
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed compressing block")
+	}
+	return compressed, nil
 }
 
 func (c *codec) writeFixedBlockSectionWithChecksum(w io.Writer, block *protocol.BlockPairContainer) error {
@@ -292,6 +363,15 @@ func (c *codec) writeDynamicBlockSectionWithChecksum(w io.Writer, messages []mem
 
 // TODO V1 see https://tree.taiga.io/project/orbs-network/us/681
 func (c *codec) decode(r io.Reader) (*protocol.BlockPairContainer, int, error) {
+	return c.decodeBlock(r, false)
+}
+
+// the full block checksum already covers every section, section checksums are only verified when checking a blocks file offline
+func (c *codec) decodeVerifyingSections(r io.Reader) (*protocol.BlockPairContainer, int, error) {
+	return c.decodeBlock(r, true)
+}
+
+func (c *codec) decodeBlock(r io.Reader, verifySections bool) (*protocol.BlockPairContainer, int, error) {
 	checkSum := crc32.New(crc32.MakeTable(crc32.Castagnoli))
 	tr := io.TeeReader(r, checkSum)
 
@@ -301,6 +381,15 @@ func (c *codec) decode(r io.Reader) (*protocol.BlockPairContainer, int, error) {
 		return nil, 0, err
 	}
 
+	var compression *compressionHeader
+	if serializationHeader.Version == compressedBlockVersion {
+		compression = &compressionHeader{}
+		err = compression.read(tr)
+		if err != nil {
+			return nil, blockHeaderSize, err
+		}
+	}
+
 	budget := newReadingBudget(
 		int(serializationHeader.totalSize())+blockHeaderSize,
 		blockHeaderSize)
@@ -309,26 +398,61 @@ func (c *codec) decode(r io.Reader) (*protocol.BlockPairContainer, int, error) {
 		return nil, budget.bytesRead, fmt.Errorf("block size exceeds max limit. block header %#v", serializationHeader)
 	}
 
-	fixed, _, err := c.readFixedSection(tr, budget)
+	fixed, fixedChecksum, err := c.readFixedSection(tr, budget)
 	if err != nil {
 		return nil, budget.bytesRead, err
 	}
 
-	receipts, _, err := c.readReceiptsSection(tr, budget, fixed.resultsBlockHeader.NumTransactionReceipts())
+	dynamicReader := tr
+	var compressedReader *io.LimitedReader
+	if compression != nil {
+		if int(compression.CompressedSize) > c.maxBlockSize {
+			return nil, budget.bytesRead, fmt.Errorf("compressed block size exceeds max limit. compression header %#v", compression)
+		}
+		compressedReader = &io.LimitedReader{R: tr, N: int64(compression.CompressedSize)}
+		decompressor := flate.NewReader(compressedReader)
+		defer decompressor.Close()
+		dynamicReader = fullReader{decompressor}
+	}
+
+	receipts, receiptsChecksum, err := c.readReceiptsSection(dynamicReader, budget, fixed.resultsBlockHeader.NumTransactionReceipts())
 	if err != nil {
 		return nil, budget.bytesRead, err
 	}
 
-	stateDiffs, _, err := c.readStateDiffsSection(tr, budget, fixed.resultsBlockHeader.NumContractStateDiffs())
+	stateDiffs, stateDiffsChecksum, err := c.readStateDiffsSection(dynamicReader, budget, fixed.resultsBlockHeader.NumContractStateDiffs())
 	if err != nil {
 		return nil, budget.bytesRead, err
 	}
 
-	txs, _, err := c.readTransactionsSection(tr, budget, fixed.transactionsBlockHeader.NumSignedTransactions())
+	txs, txsChecksum, err := c.readTransactionsSection(dynamicReader, budget, fixed.transactionsBlockHeader.NumSignedTransactions())
 	if err != nil {
 		return nil, budget.bytesRead, err
 	}
 
+	if compressedReader != nil {
+		err = endCompressedSections(dynamicReader, compressedReader)
+		if err != nil {
+			return nil, budget.bytesRead, err
+		}
+	}
+
+	if verifySections {
+		err = verifySectionChecksum("fixed", fixedChecksum, fixed.transactionsBlockHeader, fixed.transactionsBlockMetadata, fixed.transactionsBlockProof, fixed.resultsBlockHeader, fixed.resultsBlockProof)
+		if err == nil {
+			err = verifySectionChecksum("receipts", receiptsChecksum, transactionReceiptsToMessages(receipts)...)
+		}
+		if err == nil {
+			err = verifySectionChecksum("state diffs", stateDiffsChecksum, diffsToMessages(stateDiffs)...)
+		}
+		if err == nil {
+			err = verifySectionChecksum("transactions", txsChecksum, transactionsToMessages(txs)...)
+		}
+		if err != nil {
+			return nil, budget.bytesRead, err
+		}
+	}
+
 	if budget.bytesRead != budget.limit {
 		return nil, budget.bytesRead, fmt.Errorf("block size mismatch. expected: %v read: %v", budget.limit, budget.bytesRead)
 	}
@@ -339,8 +463,13 @@ func (c *codec) decode(r io.Reader) (*protocol.BlockPairContainer, int, error) {
 		return nil, budget.bytesRead, err
 	}
 
+	blockSize := budget.bytesRead + checksumSize*5
+	if compression != nil {
+		blockSize = blockHeaderSize + compressionHeaderSize + int(serializationHeader.FixedSize) + checksumSize + int(compression.CompressedSize) + checksumSize
+	}
+
 	if checksum != checkSum.Sum32() {
-		return nil, budget.bytesRead + checksumSize, fmt.Errorf("block checksum mismatch. computed: %v recorded: %v", checkSum.Sum32(), checksum)
+		return nil, blockSize, fmt.Errorf("block checksum mismatch. computed: %v recorded: %v", checkSum.Sum32(), checksum)
 	}
 
 	blockPair := &protocol.BlockPairContainer{
@@ -358,7 +487,37 @@ func (c *codec) decode(r io.Reader) (*protocol.BlockPairContainer, int, error) {
 		},
 	}
 
-	return blockPair, budget.bytesRead + checksumSize*5, nil
+	return blockPair, blockSize, nil
+}
+
+// the decompressed sections must end where the compressed bytes end, which are all read so the full block checksum covers them
+func endCompressedSections(decompressed io.Reader, compressed *io.LimitedReader) error {
+	n, err := decompressed.Read(make([]byte, 1))
+	if n != 0 || err != io.EOF {
+		return fmt.Errorf("block has unexpected data following its compressed sections")
+	}
+	_, err = io.Copy(ioutil.Discard, compressed)
+	if err != nil {
+		return err
+	}
+	if compressed.N != 0 {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+func verifySectionChecksum(section string, recorded uint32, messages ...membuffers.Message) error {
+	checkSum := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	for _, message := range messages {
+		err := writeMessage(checkSum, message)
+		if err != nil {
+			return err
+		}
+	}
+	if recorded != checkSum.Sum32() {
+		return fmt.Errorf("block %s section checksum mismatch. computed: %v recorded: %v", section, checkSum.Sum32(), recorded)
+	}
+	return nil
 }
 
 type fixedSizeBlockSection struct {
@@ -19,7 +19,7 @@ import (
 
 func TestCodec_EnforcesBlockSizeLimit(t *testing.T) {
 	largeBlock := builders.BlockPair().WithHeight(1).WithTransactions(6).Build()
-	c := newCodec(5)
+	c := newCodec(5, false)
 	_, err := c.encode(largeBlock, new(bytes.Buffer))
 
 	require.Error(t, err, "expected to fail encoding a block larger than maxBlockSize")
@@ -29,7 +29,7 @@ func TestCodec_EncodesAndDecodes(t *testing.T) {
 	ctrlRand := rand.NewControlledRand(t)
 	block := builders.RandomizedBlock(1, ctrlRand, nil)
 	rw := new(bytes.Buffer)
-	c := newCodec(1024 * 1024)
+	c := newCodec(1024*1024, false)
 
 	bytesWritten, err := c.encode(block, rw)
 	require.NoError(t, err)
@@ -49,7 +49,7 @@ func TestCodec_DetectsDataCorruption(t *testing.T) {
 	block := builders.RandomizedBlock(1, ctrlRand, nil)
 
 	// serialize
-	c := newCodec(1024 * 1024)
+	c := newCodec(1024*1024, false)
 	encodedBlock := new(bytes.Buffer)
 	_, err := c.encode(block, encodedBlock)
 	blockBytes := encodedBlock.Bytes()
@@ -123,7 +123,7 @@ func TestBlockHeaderCodec_RejectDecodingWrongMagic(t *testing.T) {
 func TestBlockHeaderCodec_RejectDecodingWrongVersion(t *testing.T) {
 	header := newBlockHeader()
 
-	header.Version++ // fake wrong version
+	header.Version = compressedBlockVersion + 1 // fake wrong version
 
 	rw := new(bytes.Buffer)
 	err := header.write(rw)
@@ -206,7 +206,7 @@ func TestDynamicSectionChecksum(t *testing.T) {
 	ctrlRand := rand.NewControlledRand(t)
 
 	rw := new(bytes.Buffer)
-	codec := newCodec(10000000)
+	codec := newCodec(10000000, false)
 
 	block := builders.RandomizedBlock(1, ctrlRand, nil)
 	err := codec.writeDynamicBlockSectionWithChecksum(rw, transactionReceiptsToMessages(block.ResultsBlock.TransactionReceipts))
@@ -229,7 +229,7 @@ func TestFixedSectionChecksum(t *testing.T) {
 	ctrlRand := rand.NewControlledRand(t)
 
 	rw := new(bytes.Buffer)
-	codec := newCodec(10000)
+	codec := newCodec(10000, false)
 
 	block := builders.RandomizedBlock(1, ctrlRand, nil)
 	err := codec.writeFixedBlockSectionWithChecksum(rw, block)
@@ -248,3 +248,75 @@ func TestFixedSectionChecksum(t *testing.T) {
 	require.EqualValues(t, readChecksum, encodedChecksum, "expected read method to return encoded checksum")
 
 }
+
+func TestCodec_EncodesAndDecodesCompressedBlocks(t *testing.T) {
+	block := builders.BlockPair().WithHeight(1).WithTransactions(20).WithReceiptsForTransactions().Build()
+
+	uncompressed := new(bytes.Buffer)
+	_, err := newCodec(1024*1024, false).encode(block, uncompressed)
+	require.NoError(t, err)
+
+	rw := new(bytes.Buffer)
+	c := newCodec(1024*1024, true)
+	bytesWritten, err := c.encode(block, rw)
+	require.NoError(t, err)
+	require.EqualValues(t, compressedBlockVersion, binary.LittleEndian.Uint32(rw.Bytes()[4:8]), "expected block header to record compression")
+	require.True(t, rw.Len() < uncompressed.Len(), "expected compressed block to be smaller")
+
+	blockLen := rw.Len()
+
+	decodedBlock, readSize, err := c.decode(rw)
+	require.NoError(t, err, "expected to decode compressed block record successfully")
+	require.EqualValues(t, bytesWritten, readSize, "expected to read same number of bytes as written")
+	require.EqualValues(t, blockLen, readSize, "expected to read entire buffer")
+	test.RequireCmpEqual(t, block, decodedBlock, "expected to decode an identical block as encoded")
+}
+
+func TestCodec_DecodesUncompressedBlocksWhenCompressing(t *testing.T) {
+	ctrlRand := rand.NewControlledRand(t)
+	block := builders.RandomizedBlock(1, ctrlRand, nil)
+
+	rw := new(bytes.Buffer)
+	bytesWritten, err := newCodec(1024*1024, false).encode(block, rw)
+	require.NoError(t, err)
+
+	decodedBlock, readSize, err := newCodec(1024*1024, true).decode(rw)
+	require.NoError(t, err, "expected to decode uncompressed block record successfully")
+	require.EqualValues(t, bytesWritten, readSize, "expected to read same number of bytes as written")
+	test.RequireCmpEqual(t, block, decodedBlock, "expected to decode an identical block as encoded")
+}
+
+func TestCodec_EnforcesBlockSizeLimitOnDecompressedSize(t *testing.T) {
+	block := builders.BlockPair().WithHeight(1).WithTransactions(20).WithReceiptsForTransactions().Build()
+
+	rw := new(bytes.Buffer)
+	bytesWritten, err := newCodec(1024*1024, true).encode(block, rw)
+	require.NoError(t, err)
+
+	_, _, err = newCodec(uint32(bytesWritten), true).decode(rw)
+	require.Error(t, err, "expected to fail decoding a block larger than maxBlockSize once decompressed")
+}
+
+func TestCodec_DetectsDataCorruptionInCompressedBlocks(t *testing.T) {
+	ctrlRand := rand.NewControlledRand(t)
+	block := builders.BlockPair().WithHeight(1).WithTransactions(20).WithReceiptsForTransactions().Build()
+
+	c := newCodec(1024*1024, true)
+	encodedBlock := new(bytes.Buffer)
+	_, err := c.encode(block, encodedBlock)
+	require.NoError(t, err, "expected to encode block successfully")
+	blockBytes := encodedBlock.Bytes()
+
+	corruptBlock := new(bytes.Buffer)
+	for ri := 0; ri < len(blockBytes); ri += 1 + ctrlRand.Intn(len(blockBytes)/20) {
+		corruptBlock.Reset()
+		corruptBlock.Write(blockBytes)
+
+		bitFlip := byte(1) << uintptr(ctrlRand.Intn(8))
+		raw := corruptBlock.Bytes()
+		raw[ri] = raw[ri] ^ bitFlip
+
+		_, _, err = c.decode(corruptBlock)
+		require.Error(t, err, "expected codec to detect data corruption when flipping bit %08b in byte %v/%v", bitFlip, ri, len(blockBytes))
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/crypto/bloom"
 	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
 	"github.com/orbs-network/orbs-network-go/services/blockstorage/adapter"
@@ -22,7 +23,10 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 )
 
 type metrics struct {
@@ -50,43 +54,116 @@ type FilesystemBlockPersistence struct {
 	logger       log.Logger
 	blockWriter  *blockWriter
 	codec        blockCodec
+
+	// guarded by blockWriter, the first segment is kept open for its advisory lock until the context is done
+	activeSegment     int
+	activeSegmentFile *os.File
+	sidecar           *sidecarIndex
+	closed            bool
+
+	txIndex *txHashIndex
+	headers *blockHeaderStore
+	filters *blockFilterStore
 }
 
 func NewBlockPersistence(ctx context.Context, conf config.FilesystemBlockPersistenceConfig, parent log.Logger, metricFactory metric.Factory) (adapter.BlockPersistence, error) {
 	logger := parent.WithTags(log.String("adapter", "block-storage"))
 
-	codec := newCodec(conf.BlockStorageFileSystemMaxBlockSizeInBytes())
+	codec := newCodec(conf.BlockStorageFileSystemMaxBlockSizeInBytes(), conf.BlockStorageFileSystemCompression())
 
 	file, blocksOffset, err := openBlocksFile(ctx, conf, logger)
 	if err != nil {
 		return nil, err
 	}
 
-	bhIndex, err := buildIndex(file, blocksOffset, logger, codec)
+	sidecar, indexEntries, err := openSidecarIndex(conf, logger)
+	if err != nil {
+		closeSilently(file, logger)
+		return nil, err
+	}
+
+	bhIndex, activeSegment, activeSegmentFile, err := loadIndex(conf, file, blocksOffset, indexEntries, sidecar, logger, codec)
+	if err != nil {
+		sidecar.close()
+		closeSilently(file, logger)
+		return nil, err
+	}
+
+	newTip, err := newFileBlockWriter(activeSegmentFile, codec, bhIndex.fetchTopOffset())
+	if err != nil {
+		closeSegment(activeSegment, activeSegmentFile, logger)
+		sidecar.close()
+		closeSilently(file, logger)
+		return nil, err
+	}
+
+	headers, err := openBlockHeaderStore(conf, bhIndex.firstBlockHeight, bhIndex.topBlockHeight, codec, logger)
+	if err != nil {
+		closeSegment(activeSegment, activeSegmentFile, logger)
+		sidecar.close()
+		closeSilently(file, logger)
+		return nil, err
+	}
+
+	txIndex, err := openTxHashIndex(conf, bhIndex.firstBlockHeight, bhIndex.topBlockHeight, logger)
 	if err != nil {
+		closeSegment(activeSegment, activeSegmentFile, logger)
+		sidecar.close()
+		headers.close()
 		closeSilently(file, logger)
 		return nil, err
 	}
 
-	newTip, err := newFileBlockWriter(file, codec, bhIndex.fetchTopOffset())
+	filters, err := openBlockFilterStore(conf, bhIndex.firstBlockHeight, bhIndex.topBlockHeight, logger)
 	if err != nil {
+		closeSegment(activeSegment, activeSegmentFile, logger)
+		sidecar.close()
+		txIndex.close()
+		headers.close()
 		closeSilently(file, logger)
 		return nil, err
 	}
 
 	adapter := &FilesystemBlockPersistence{
-		bhIndex:      bhIndex,
-		config:       conf,
-		blockTracker: synchronization.NewBlockTracker(logger, uint64(bhIndex.topBlockHeight), 5),
-		metrics:      newMetrics(metricFactory),
-		logger:       logger,
-		blockWriter:  newTip,
-		codec:        codec,
+		bhIndex:           bhIndex,
+		config:            conf,
+		blockTracker:      synchronization.NewBlockTracker(logger, uint64(bhIndex.topBlockHeight), 5),
+		metrics:           newMetrics(metricFactory),
+		logger:            logger,
+		blockWriter:       newTip,
+		codec:             codec,
+		activeSegment:     activeSegment,
+		activeSegmentFile: activeSegmentFile,
+		sidecar:           sidecar,
+		txIndex:           txIndex,
+		headers:           headers,
+		filters:           filters,
+	}
+
+	err = adapter.catchUpHeaders()
+	if err == nil {
+		err = adapter.catchUpTxIndex()
 	}
+	if err == nil {
+		err = adapter.catchUpFilters()
+	}
+	if err != nil {
+		closeSegment(activeSegment, activeSegmentFile, logger)
+		sidecar.close()
+		txIndex.close()
+		headers.close()
+		filters.close()
+		closeSilently(file, logger)
+		return nil, err
+	}
+	adapter.pruneSegments()
+
+	adapter.closeFilesOnContextDone(ctx)
 
 	return adapter, nil
 }
 
+// the lock on the first segment guards the whole data dir, later segments and offline tools such as TruncateBlocksFile take it as well
 func openBlocksFile(ctx context.Context, conf config.FilesystemBlockPersistenceConfig, logger log.Logger) (*os.File, int64, error) {
 	dir := conf.BlockStorageFileSystemDataDir()
 	filename := blocksFileName(conf)
@@ -124,7 +201,7 @@ func validateFileHeader(file *os.File, conf config.FilesystemBlockPersistenceCon
 	}
 	if info.Size() == 0 { // write header
 		header := newBlocksFileHeader(0, uint32(conf.VirtualChainId()))
-		logger.Info("creating new blocks file", log.String("path", blocksFileName(conf)))
+		logger.Info("creating new blocks file", log.String("path", file.Name()))
 		err = header.write(file)
 		if err != nil {
 			return 0, errors.Wrapf(err, "error writing blocks file header")
@@ -180,6 +257,21 @@ func closeOnContextDone(ctx context.Context, file *os.File, logger log.Logger) {
 	}()
 }
 
+func (f *FilesystemBlockPersistence) closeFilesOnContextDone(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		f.blockWriter.Lock()
+		defer f.blockWriter.Unlock()
+
+		f.closed = true
+		closeSegment(f.activeSegment, f.activeSegmentFile, f.logger) // the first segment is closed along with its lock
+		f.sidecar.close()
+		f.txIndex.close()
+		f.headers.close()
+		f.filters.close()
+	}()
+}
+
 func advisoryLockExclusive(file *os.File) error {
 	return syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
 }
@@ -200,24 +292,224 @@ func newFileBlockWriter(file *os.File, codec blockCodec, nextBlockOffset int64)
 
 func buildIndex(r io.Reader, firstBlockOffset int64, logger log.Logger, c blockCodec) (*blockHeightIndex, error) {
 	bhIndex := newBlockHeightIndex(logger, firstBlockOffset)
+	_, err := indexSegment(bhIndex, r, firstBlockOffset, logger, c, func(int64, *protocol.BlockPairContainer) {})
+	if err != nil {
+		return nil, err
+	}
+	return bhIndex, nil
+}
+
+// restores the index from the index file when it is consistent with the blocks files, otherwise falls back to a full scan
+func loadIndex(conf config.FilesystemBlockPersistenceConfig, firstSegmentFile *os.File, firstBlockOffset int64, entries []sidecarIndexEntry, sidecar *sidecarIndex, logger log.Logger, c blockCodec) (*blockHeightIndex, int, *os.File, error) {
+	if len(entries) > 0 {
+		bhIndex, segment, file, err := restoreIndex(conf, firstSegmentFile, firstBlockOffset, entries, logger, c)
+		if err == nil {
+			logger.Info("restored index from block index file", logfields.BlockHeight(bhIndex.topBlockHeight))
+			return continueSegmentedIndex(conf, bhIndex, segment, file, sidecar, logger, c)
+		}
+		logger.Info("block index file is inconsistent with blocks file, rebuilding it", log.Error(err))
+		sidecar.reset()
+	}
+
+	_, err := firstSegmentFile.Seek(firstBlockOffset, io.SeekStart)
+	if err != nil {
+		return nil, 0, nil, errors.Wrapf(err, "failed to seek to first block offset %d", firstBlockOffset)
+	}
+	return continueSegmentedIndex(conf, newBlockHeightIndex(logger, firstBlockOffset), 0, firstSegmentFile, sidecar, logger, c)
+}
+
+// the top block listed in the index file is read back from its segment to confirm the index matches the blocks file tail
+func restoreIndex(conf config.FilesystemBlockPersistenceConfig, firstSegmentFile *os.File, firstBlockOffset int64, entries []sidecarIndexEntry, logger log.Logger, c blockCodec) (*blockHeightIndex, int, *os.File, error) {
+	bhIndex := newBlockHeightIndex(logger, firstBlockOffset)
+	err := restoreBlockLocations(bhIndex, entries, firstBlockOffset)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	err = verifyFirstBlock(conf, bhIndex, firstSegmentFile, logger, c)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	segment := bhIndex.numSegments() - 1
+	file := firstSegmentFile
+	if segment > 0 {
+		file, _, err = openSegmentFile(conf, segment, logger)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+	}
+
+	topOffset := bhIndex.fetchBlockOffset(bhIndex.topBlockHeight)
+	_, err = file.Seek(topOffset, io.SeekStart)
+	if err == nil {
+		var topBlock *protocol.BlockPairContainer
+		var topBlockSize int
+		topBlock, topBlockSize, err = c.decode(file)
+		if err == nil {
+			err = bhIndex.restoreTopBlock(topBlock, topOffset+int64(topBlockSize))
+		}
+	}
+	if err != nil {
+		closeSegment(segment, file, logger)
+		return nil, 0, nil, errors.Wrapf(err, "failed reading top block at segment %d offset %d", segment, topOffset)
+	}
+
+	return bhIndex, segment, file, nil
+}
+
+// an index file listing blocks which were since pruned was not rewritten before a crash
+func verifyFirstBlock(conf config.FilesystemBlockPersistenceConfig, bhIndex *blockHeightIndex, firstSegmentFile *os.File, logger log.Logger, c blockCodec) error {
+	first := bhIndex.getFirstBlockHeight()
+	segment := bhIndex.fetchBlockSegment(first)
+	offset := bhIndex.fetchBlockOffset(first)
+
+	file := firstSegmentFile
+	if segment > 0 {
+		var err error
+		file, _, err = openSegmentFile(conf, segment, logger)
+		if err != nil {
+			return err
+		}
+		defer closeSilently(file, logger)
+	}
+
+	_, err := file.Seek(offset, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	block, _, err := c.decode(file)
+	if err != nil {
+		return errors.Wrapf(err, "failed reading first block at segment %d offset %d", segment, offset)
+	}
+	if h := block.ResultsBlock.Header.BlockHeight(); h != first {
+		return fmt.Errorf("expected block height %d at first block offset, found block height %d", first, h)
+	}
+	return nil
+}
+
+// indexes the remainder of the given segment and every segment following it, returning the last segment opened for writing
+func continueSegmentedIndex(conf config.FilesystemBlockPersistenceConfig, bhIndex *blockHeightIndex, segment int, file *os.File, sidecar *sidecarIndex, logger log.Logger, c blockCodec) (*blockHeightIndex, int, *os.File, error) {
+	for {
+		currentSegment := segment
+		complete, err := indexSegment(bhIndex, file, bhIndex.fetchTopOffset(), logger, c, func(offset int64, block *protocol.BlockPairContainer) {
+			sidecar.add(currentSegment, offset, block.ResultsBlock.Header.BlockHeight())
+		})
+		if err != nil {
+			closeSegment(segment, file, logger)
+			return nil, 0, nil, err
+		}
+
+		nextFile, nextBlockOffset, err := openSegmentFile(conf, segment+1, logger)
+		if os.IsNotExist(errors.Cause(err)) {
+			err = requireNoSegmentsFollowing(conf, segment+1)
+			if err != nil {
+				closeSegment(segment, file, logger)
+				return nil, 0, nil, err
+			}
+			return bhIndex, segment, file, nil
+		}
+		closeSegment(segment, file, logger)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		if !complete { // only the tail of the last segment may be torn by a crash
+			closeSilently(nextFile, logger)
+			return nil, 0, nil, fmt.Errorf("blocks file segment %d has invalid block records but is followed by segment %d", segment, segment+1)
+		}
+
+		bhIndex.startSegment(nextBlockOffset)
+		segment, file = segment+1, nextFile
+	}
+}
+
+// segments are never removed, pruning only truncates them, so a missing segment means blocks were lost
+func requireNoSegmentsFollowing(conf config.FilesystemBlockPersistenceConfig, missingSegment int) error {
+	filenames, err := filepath.Glob(filepath.Join(conf.BlockStorageFileSystemDataDir(), blocksFilename+".*"))
+	if err != nil {
+		return errors.Wrap(err, "failed listing blocks file segments")
+	}
+	for _, filename := range filenames {
+		segment, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(filename), blocksFilename+"."))
+		if err != nil { // temporary files of segments being created and other files in the data dir
+			continue
+		}
+		if segment > missingSegment {
+			return errors.Errorf("blocks file segment %s is missing but segment %s exists", segmentFileName(conf, missingSegment), filename)
+		}
+	}
+	return nil
+}
+
+// returns false if an invalid block record was found before EOF
+func indexSegment(bhIndex *blockHeightIndex, r io.Reader, firstBlockOffset int64, logger log.Logger, c blockCodec, onBlock func(offset int64, block *protocol.BlockPairContainer)) (bool, error) {
 	offset := int64(firstBlockOffset)
 	for {
 		aBlock, blockSize, err := c.decode(r)
 		if err != nil {
 			if err == io.EOF {
 				logger.Info("built index", log.Int64("valid-block-bytes", offset), logfields.BlockHeight(bhIndex.topBlockHeight))
-			} else {
-				logger.Error("built index, found and ignoring invalid block records", log.Int64("valid-block-bytes", offset), log.Error(err), logfields.BlockHeight(bhIndex.topBlockHeight))
+				return true, nil
+			}
+			logger.Error("built index, found and ignoring invalid block records", log.Int64("valid-block-bytes", offset), log.Error(err), logfields.BlockHeight(bhIndex.topBlockHeight))
+			return false, nil // index up to EOF or first invalid record.
+		}
+		if h := aBlock.ResultsBlock.Header.BlockHeight(); h != bhIndex.getLastBlockHeight()+1 {
+			if bhIndex.getLastBlock() != nil {
+				return false, fmt.Errorf("failed building block height index, found block height %d following block height %d", h, bhIndex.getLastBlockHeight())
 			}
-			break // index up to EOF or first invalid record.
+			bhIndex.skipPrunedBlocks(h) // blocks before the first one found were pruned
 		}
 		err = bhIndex.appendBlock(offset, offset+int64(blockSize), aBlock)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed building block height index")
+			return false, errors.Wrap(err, "failed building block height index")
 		}
+		onBlock(offset, aBlock)
 		offset = offset + int64(blockSize)
 	}
-	return bhIndex, nil
+}
+
+func openSegmentFile(conf config.FilesystemBlockPersistenceConfig, segment int, logger log.Logger) (*os.File, int64, error) {
+	filename := segmentFileName(conf, segment)
+	file, err := os.OpenFile(filename, os.O_RDWR, 0600)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "failed to open blocks file segment %s", filename)
+	}
+
+	firstBlockOffset, err := validateFileHeader(file, conf, logger)
+	if err != nil {
+		closeSilently(file, logger)
+		return nil, 0, errors.Wrapf(err, "failed to validate blocks file segment header %s", filename)
+	}
+
+	return file, firstBlockOffset, nil
+}
+
+// the header is written to a temporary file first so a crash never leaves a segment without a valid header
+func createSegmentFile(conf config.FilesystemBlockPersistenceConfig, segment int, logger log.Logger) (*os.File, int64, error) {
+	filename := segmentFileName(conf, segment)
+	tempFilename := filename + ".tmp"
+	file, err := os.OpenFile(tempFilename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "failed to create blocks file segment %s", tempFilename)
+	}
+
+	firstBlockOffset, err := validateFileHeader(file, conf, logger)
+	if err == nil {
+		err = os.Rename(tempFilename, filename)
+	}
+	if err != nil {
+		closeSilently(file, logger)
+		return nil, 0, errors.Wrapf(err, "failed to create blocks file segment %s", filename)
+	}
+
+	return file, firstBlockOffset, nil
+}
+
+func closeSegment(segment int, file *os.File, logger log.Logger) {
+	if segment > 0 { // the first segment holds the advisory lock of the data dir
+		closeSilently(file, logger)
+	}
 }
 
 func (f *FilesystemBlockPersistence) WriteNextBlock(blockPair *protocol.BlockPairContainer) (bool, error) {
@@ -234,6 +526,18 @@ func (f *FilesystemBlockPersistence) WriteNextBlock(blockPair *protocol.BlockPai
 		return false, fmt.Errorf("attempt to write block %d out of order. current top height is %d", bh, currentTop)
 	}
 
+	if f.closed {
+		return false, fmt.Errorf("attempt to write block %d after blocks file was closed", bh)
+	}
+
+	rotated := f.shouldRotateSegment()
+	if rotated {
+		err := f.rotateSegment()
+		if err != nil {
+			return false, err
+		}
+	}
+
 	n, err := f.blockWriter.writeBlock(blockPair)
 	if err != nil {
 		return false, err
@@ -244,30 +548,159 @@ func (f *FilesystemBlockPersistence) WriteNextBlock(blockPair *protocol.BlockPai
 	if err != nil {
 		return false, errors.Wrap(err, "failed to update index after writing block")
 	}
+	f.sidecar.add(f.activeSegment, startPos, bh)
+	f.txIndex.add(blockPair)
+	f.headers.add(blockPair)
+	f.filters.add(blockPair)
 
 	f.blockTracker.IncrementTo(currentTop + 1)
 	f.metrics.size.Add(int64(n))
 
+	if rotated { // segments only become prunable once a newer one starts
+		f.pruneSegments()
+	}
+
 	return true, nil
 }
 
+func (f *FilesystemBlockPersistence) shouldRotateSegment() bool {
+	maxSegmentSize := f.config.BlockStorageFileSystemMaxSegmentSizeInBytes()
+	if maxSegmentSize == 0 {
+		return false
+	}
+	segmentHasBlocks := f.bhIndex.fetchSegmentFirstHeight(f.activeSegment) <= f.bhIndex.getLastBlockHeight()
+	return segmentHasBlocks && f.bhIndex.fetchTopOffset() >= int64(maxSegmentSize)
+}
+
+// callers must hold the blockWriter lock
+func (f *FilesystemBlockPersistence) rotateSegment() error {
+	segment := f.activeSegment + 1
+	file, firstBlockOffset, err := createSegmentFile(f.config, segment, f.logger)
+	if err != nil {
+		return err
+	}
+
+	closeSegment(f.activeSegment, f.activeSegmentFile, f.logger)
+	f.bhIndex.startSegment(firstBlockOffset)
+	f.blockWriter.switchTo(file)
+	f.activeSegment = segment
+	f.activeSegmentFile = file
+	f.metrics.size.Add(firstBlockOffset)
+
+	f.logger.Info("rotated blocks file segment", log.String("filename", file.Name()), logfields.BlockHeight(f.bhIndex.getLastBlockHeight()+1))
+	return nil
+}
+
+// Empties the oldest segments holding only blocks which neither pruning criteria keeps, the active segment is never pruned.
+// Segments are truncated to their file header rather than deleted so segment numbering stays contiguous. Callers must hold the blockWriter lock.
+func (f *FilesystemBlockPersistence) pruneSegments() {
+	keepBlocks := f.config.BlockStorageFileSystemPruningKeepBlocks()
+	keepWindow := f.config.BlockStorageFileSystemPruningKeepWindow()
+	if keepBlocks == 0 && keepWindow == 0 {
+		return
+	}
+
+	firstSegment := f.bhIndex.fetchBlockSegment(f.bhIndex.getFirstBlockHeight())
+	newFirst := f.bhIndex.getFirstBlockHeight()
+	segment := firstSegment
+	for ; segment < f.activeSegment; segment++ {
+		lastHeight := f.bhIndex.fetchSegmentFirstHeight(segment+1) - 1
+		if !f.isPrunable(lastHeight, keepBlocks, keepWindow) {
+			break
+		}
+		newFirst = lastHeight + 1
+	}
+	if segment == firstSegment {
+		return
+	}
+
+	err := f.headers.sync()
+	if err != nil {
+		f.logger.Info("not pruning blocks, block headers file could not be synced", log.Error(err))
+		return
+	}
+
+	offsets := make([]int64, segment-firstSegment)
+	for s := firstSegment; s < segment; s++ {
+		offsets[s-firstSegment] = f.bhIndex.fetchSegmentFirstOffset(s)
+	}
+
+	// readers are pointed away from the pruned blocks before they are removed
+	f.bhIndex.pruneBelow(newFirst)
+	f.txIndex.pruneBelow(newFirst)
+
+	prunedBytes := int64(0)
+	for s := firstSegment; s < segment; s++ { // oldest first, so blocks remaining after a crash are still contiguous
+		n, err := truncateSegment(f.config, s, offsets[s-firstSegment], f.logger)
+		if err != nil {
+			f.logger.Error("failed to prune blocks file segment", log.Error(err), log.String("filename", segmentFileName(f.config, s)))
+			break
+		}
+		prunedBytes += n
+	}
+	f.sidecar.rewrite(f.bhIndex)
+	f.metrics.size.Add(-prunedBytes)
+
+	f.logger.Info("pruned blocks", log.Uint64("first-block-height", uint64(newFirst)), log.Int64("pruned-bytes", prunedBytes))
+}
+
+// a block is kept if either configured criteria keeps it
+func (f *FilesystemBlockPersistence) isPrunable(height primitives.BlockHeight, keepBlocks uint32, keepWindow time.Duration) bool {
+	top := f.bhIndex.getLastBlockHeight()
+	if keepBlocks > 0 && height+primitives.BlockHeight(keepBlocks) > top {
+		return false
+	}
+	if keepWindow > 0 {
+		block, err := f.getBlockAtHeight(height)
+		if err != nil {
+			f.logger.Info("not pruning blocks, failed reading block timestamp", log.Error(err), logfields.BlockHeight(height))
+			return false
+		}
+		topTimestamp := f.bhIndex.getLastBlock().ResultsBlock.Header.Timestamp()
+		if block.ResultsBlock.Header.Timestamp()+primitives.TimestampNano(keepWindow.Nanoseconds()) >= topTimestamp {
+			return false
+		}
+	}
+	return true
+}
+
+// returns the number of bytes removed
+func truncateSegment(conf config.FilesystemBlockPersistenceConfig, segment int, firstBlockOffset int64, logger log.Logger) (int64, error) {
+	file, err := os.OpenFile(segmentFileName(conf, segment), os.O_RDWR, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer closeSilently(file, logger)
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	err = file.Truncate(firstBlockOffset)
+	if err == nil {
+		err = file.Sync()
+	}
+	if err != nil {
+		return 0, err
+	}
+	return info.Size() - firstBlockOffset, nil
+}
+
 func (f *FilesystemBlockPersistence) ScanBlocks(from primitives.BlockHeight, pageSize uint8, cursor adapter.CursorFunc) error {
 	currentTop := f.bhIndex.topBlockHeight
 	if currentTop < from {
 		return fmt.Errorf("requested unknown block height %d. current height is %d", from, currentTop)
 	}
-
-	file, err := os.Open(f.blockFileName())
-	if err != nil {
-		return errors.Wrap(err, "failed to open blocks file for reading")
+	if first := f.bhIndex.getFirstBlockHeight(); from > 0 && from < first {
+		return &adapter.BlockPrunedError{Height: from, FirstBlockHeight: first}
 	}
-	defer closeSilently(file, f.logger)
 
-	initialOffset := f.bhIndex.fetchBlockOffset(from)
-	newOffset, err := file.Seek(initialOffset, io.SeekStart)
-	if newOffset != initialOffset || err != nil {
-		return errors.Wrapf(err, "failed to seek in blocks file to position %v", initialOffset)
+	segment := f.bhIndex.fetchBlockSegment(from)
+	file, err := f.openSegmentForReading(segment, f.bhIndex.fetchBlockOffset(from))
+	if err != nil {
+		return err
 	}
+	defer func() { closeSilently(file, f.logger) }()
 
 	wantNext := true
 	eof := false
@@ -279,9 +712,21 @@ func (f *FilesystemBlockPersistence) ScanBlocks(from primitives.BlockHeight, pag
 			aBlock, _, err := f.codec.decode(file)
 			if err != nil {
 				if err == io.EOF {
+					if segment+1 < f.bhIndex.numSegments() { // continue reading from the following segment
+						nextFile, err := f.openSegmentForReading(segment+1, f.bhIndex.fetchSegmentFirstOffset(segment+1))
+						if err != nil {
+							return err
+						}
+						closeSilently(file, f.logger)
+						segment, file = segment+1, nextFile
+						continue
+					}
 					eof = true
 					break
 				}
+				if first := f.bhIndex.getFirstBlockHeight(); from < first { // pruned while reading
+					return &adapter.BlockPrunedError{Height: from, FirstBlockHeight: first}
+				}
 				return errors.Wrapf(err, "failed to decode block")
 			}
 			page = append(page, aBlock)
@@ -294,6 +739,24 @@ func (f *FilesystemBlockPersistence) ScanBlocks(from primitives.BlockHeight, pag
 	return nil
 }
 
+func (f *FilesystemBlockPersistence) openSegmentForReading(segment int, offset int64) (*os.File, error) {
+	file, err := os.Open(segmentFileName(f.config, segment))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open blocks file for reading")
+	}
+
+	newOffset, err := file.Seek(offset, io.SeekStart)
+	if newOffset != offset || err != nil {
+		closeSilently(file, f.logger)
+		return nil, errors.Wrapf(err, "failed to seek in blocks file to position %v", offset)
+	}
+	return file, nil
+}
+
+func (f *FilesystemBlockPersistence) GetFirstBlockHeight() (primitives.BlockHeight, error) {
+	return f.bhIndex.getFirstBlockHeight(), nil
+}
+
 func (f *FilesystemBlockPersistence) GetLastBlockHeight() (primitives.BlockHeight, error) {
 	return f.bhIndex.getLastBlockHeight(), nil
 }
@@ -303,7 +766,7 @@ func (f *FilesystemBlockPersistence) GetLastBlock() (*protocol.BlockPairContaine
 }
 
 func (f *FilesystemBlockPersistence) GetTransactionsBlock(height primitives.BlockHeight) (*protocol.TransactionsBlockContainer, error) {
-	bpc, err := f.getBlockAtHeight(height)
+	bpc, err := f.getBlockOrHeadersAtHeight(height)
 	if err != nil {
 		return nil, err
 	}
@@ -311,13 +774,22 @@ func (f *FilesystemBlockPersistence) GetTransactionsBlock(height primitives.Bloc
 }
 
 func (f *FilesystemBlockPersistence) GetResultsBlock(height primitives.BlockHeight) (*protocol.ResultsBlockContainer, error) {
-	bpc, err := f.getBlockAtHeight(height)
+	bpc, err := f.getBlockOrHeadersAtHeight(height)
 	if err != nil {
 		return nil, err
 	}
 	return bpc.ResultsBlock, nil
 }
 
+// only the headers of pruned blocks remain
+func (f *FilesystemBlockPersistence) getBlockOrHeadersAtHeight(height primitives.BlockHeight) (*protocol.BlockPairContainer, error) {
+	bpc, err := f.getBlockAtHeight(height)
+	if adapter.IsBlockPruned(err) {
+		return f.headers.read(height)
+	}
+	return bpc, err
+}
+
 func (f *FilesystemBlockPersistence) getBlockAtHeight(height primitives.BlockHeight) (*protocol.BlockPairContainer, error) {
 	var bpc *protocol.BlockPairContainer
 	err := f.ScanBlocks(height, 1, func(h primitives.BlockHeight, page []*protocol.BlockPairContainer) (wantsMore bool) {
@@ -327,49 +799,108 @@ func (f *FilesystemBlockPersistence) getBlockAtHeight(height primitives.BlockHei
 	return bpc, err
 }
 
-func (f *FilesystemBlockPersistence) GetBlockByTx(txHash primitives.Sha256, minBlockTs primitives.TimestampNano, maxBlockTs primitives.TimestampNano) (block *protocol.BlockPairContainer, txIndexInBlock int, err error) {
-	scanFrom, ok := f.bhIndex.getEarliestTxBlockInBucketForTsRange(minBlockTs, maxBlockTs)
+func (f *FilesystemBlockPersistence) GetBlockByTx(txHash primitives.Sha256) (block *protocol.BlockPairContainer, txIndexInBlock int, err error) {
+	location, ok := f.txIndex.lookup(txHash)
 	if !ok {
 		return nil, 0, nil
 	}
 
-	err = f.ScanBlocks(scanFrom, 1, func(h primitives.BlockHeight, page []*protocol.BlockPairContainer) (wantsMore bool) {
-		b := page[0]
-		if b.ResultsBlock.Header.Timestamp() > maxBlockTs {
-			return false
-		}
-		if b.ResultsBlock.Header.Timestamp() < minBlockTs {
-			return true
+	block, err = f.getBlockAtHeight(location.height)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to fetch block by txHash")
+	}
+
+	receipts := block.ResultsBlock.TransactionReceipts
+	if int(location.index) >= len(receipts) || !bytes.Equal(receipts[location.index].Txhash(), txHash) {
+		return nil, 0, fmt.Errorf("tx index lists txHash %s at block %d index %d but the block does not hold it there", txHash, location.height, location.index)
+	}
+	return block, int(location.index), nil
+}
+
+// appends headers of blocks the block headers file did not cover, the headers of pruned blocks can not be recovered
+func (f *FilesystemBlockPersistence) catchUpHeaders() error {
+	from := f.headers.getTopBlockHeight() + 1
+	if from > f.bhIndex.topBlockHeight {
+		return nil
+	}
+	if first := f.bhIndex.getFirstBlockHeight(); from < first {
+		return fmt.Errorf("block headers file is missing headers of pruned blocks from block height %d to %d", from, first-1)
+	}
+
+	f.logger.Info("adding headers missing from block headers file", log.Uint64("from-block-height", uint64(from)), log.Uint64("to-block-height", uint64(f.bhIndex.topBlockHeight)))
+	err := f.ScanBlocks(from, 100, func(h primitives.BlockHeight, page []*protocol.BlockPairContainer) (wantsMore bool) {
+		for _, block := range page {
+			f.headers.add(block)
 		}
+		return true
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to add headers of blocks missing from block headers file")
+	}
+	return nil
+}
 
-		for i, receipt := range b.ResultsBlock.TransactionReceipts {
-			if bytes.Equal(receipt.Txhash(), txHash) { // found requested transaction
-				block = b
-				txIndexInBlock = i
-				return false
-			}
+// indexes the transactions of blocks the tx index file did not cover, typically those written just before a crash
+func (f *FilesystemBlockPersistence) catchUpTxIndex() error {
+	from := f.txIndex.getTopBlockHeight() + 1
+	if from > f.bhIndex.topBlockHeight {
+		return nil
+	}
+
+	f.logger.Info("indexing transactions missing from tx index file", log.Uint64("from-block-height", uint64(from)), log.Uint64("to-block-height", uint64(f.bhIndex.topBlockHeight)))
+	err := f.ScanBlocks(from, 100, func(h primitives.BlockHeight, page []*protocol.BlockPairContainer) (wantsMore bool) {
+		for _, block := range page {
+			f.txIndex.add(block)
 		}
 		return true
 	})
+	if err != nil {
+		return errors.Wrap(err, "failed to index transactions of blocks missing from tx index file")
+	}
+	return nil
+}
+
+// computes the filters of blocks the block filters file did not cover
+func (f *FilesystemBlockPersistence) catchUpFilters() error {
+	from := f.filters.getTopBlockHeight() + 1
+	if from > f.bhIndex.topBlockHeight {
+		return nil
+	}
 
+	f.logger.Info("computing block filters missing from block filters file", log.Uint64("from-block-height", uint64(from)), log.Uint64("to-block-height", uint64(f.bhIndex.topBlockHeight)))
+	err := f.ScanBlocks(from, 100, func(h primitives.BlockHeight, page []*protocol.BlockPairContainer) (wantsMore bool) {
+		for _, block := range page {
+			f.filters.add(block)
+		}
+		return true
+	})
 	if err != nil {
-		return nil, 0, errors.Wrap(err, "failed to fetch block by txHash")
+		return errors.Wrap(err, "failed to compute filters of blocks missing from block filters file")
 	}
-	return block, txIndexInBlock, nil
+	return nil
 }
 
-func (f *FilesystemBlockPersistence) GetBlockTracker() *synchronization.BlockTracker {
-	return f.blockTracker
+// Not part of the BlockPersistence interface, see adapter.BlockFilterPersistence
+func (f *FilesystemBlockPersistence) GetBlockFilter(height primitives.BlockHeight) (*bloom.BlockFilter, error) {
+	return f.filters.read(height)
 }
 
-func (f *FilesystemBlockPersistence) blockFileName() string {
-	return blocksFileName(f.config)
+func (f *FilesystemBlockPersistence) GetBlockTracker() *synchronization.BlockTracker {
+	return f.blockTracker
 }
 
 func blocksFileName(config config.FilesystemBlockPersistenceConfig) string {
 	return filepath.Join(config.BlockStorageFileSystemDataDir(), blocksFilename)
 }
 
+// the first segment keeps the name of the original single blocks file so existing data dirs remain readable
+func segmentFileName(config config.FilesystemBlockPersistenceConfig, segment int) string {
+	if segment == 0 {
+		return blocksFileName(config)
+	}
+	return filepath.Join(config.BlockStorageFileSystemDataDir(), fmt.Sprintf("%s.%06d", blocksFilename, segment))
+}
+
 func closeSilently(file *os.File, logger log.Logger) {
 	err := file.Close()
 	if err != nil {
@@ -11,25 +11,28 @@ import (
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/protocol"
 	"github.com/orbs-network/scribe/log"
+	"sort"
 	"sync"
 )
 
 type blockHeightIndex struct {
 	sync.RWMutex
-	heightOffset         map[primitives.BlockHeight]int64
-	firstBlockInTsBucket map[uint32]primitives.BlockHeight
-	topBlock             *protocol.BlockPairContainer
-	topBlockHeight       primitives.BlockHeight
-	logger               log.Logger
+	heightOffset       map[primitives.BlockHeight]int64
+	segmentFirstHeight []primitives.BlockHeight
+	firstBlockHeight   primitives.BlockHeight
+	topBlock           *protocol.BlockPairContainer
+	topBlockHeight     primitives.BlockHeight
+	logger             log.Logger
 }
 
 func newBlockHeightIndex(logger log.Logger, firstBlockOffset int64) *blockHeightIndex {
 	return &blockHeightIndex{
-		logger:               logger,
-		heightOffset:         map[primitives.BlockHeight]int64{1: firstBlockOffset},
-		firstBlockInTsBucket: map[uint32]primitives.BlockHeight{},
-		topBlock:             nil,
-		topBlockHeight:       0,
+		logger:             logger,
+		heightOffset:       map[primitives.BlockHeight]int64{1: firstBlockOffset},
+		segmentFirstHeight: []primitives.BlockHeight{1},
+		firstBlockHeight:   1,
+		topBlock:           nil,
+		topBlockHeight:     0,
 	}
 }
 
@@ -55,20 +58,75 @@ func (i *blockHeightIndex) fetchBlockOffset(height primitives.BlockHeight) int64
 	return offset
 }
 
-func (i *blockHeightIndex) getEarliestTxBlockInBucketForTsRange(rangeStart primitives.TimestampNano, rangeEnd primitives.TimestampNano) (primitives.BlockHeight, bool) {
+// offsets in heightOffset are relative to the segment file holding the block
+func (i *blockHeightIndex) fetchBlockSegment(height primitives.BlockHeight) int {
 	i.RLock()
 	defer i.RUnlock()
 
-	fromBucket := blockTsBucketKey(rangeStart)
-	toBucket := blockTsBucketKey(rangeEnd)
-	for b := fromBucket; b <= toBucket; b++ {
-		result, exists := i.firstBlockInTsBucket[b]
-		if exists {
-			return result, true
-		}
+	return sort.Search(len(i.segmentFirstHeight), func(s int) bool {
+		return i.segmentFirstHeight[s] > height
+	}) - 1
+}
+
+func (i *blockHeightIndex) fetchSegmentFirstOffset(segment int) int64 {
+	i.RLock()
+	defer i.RUnlock()
+
+	if segment < 0 || segment >= len(i.segmentFirstHeight) {
+		panic(fmt.Sprintf("index missing segment %d", segment))
 	}
-	return 0, false
+	return i.heightOffset[i.segmentFirstHeight[segment]]
+}
 
+func (i *blockHeightIndex) fetchSegmentFirstHeight(segment int) primitives.BlockHeight {
+	i.RLock()
+	defer i.RUnlock()
+
+	if segment < 0 || segment >= len(i.segmentFirstHeight) {
+		panic(fmt.Sprintf("index missing segment %d", segment))
+	}
+	return i.segmentFirstHeight[segment]
+}
+
+func (i *blockHeightIndex) numSegments() int {
+	i.RLock()
+	defer i.RUnlock()
+	return len(i.segmentFirstHeight)
+}
+
+// the next block will be written to a new segment, right after its file header
+func (i *blockHeightIndex) startSegment(firstBlockOffset int64) {
+	i.Lock()
+	defer i.Unlock()
+
+	i.heightOffset[i.topBlockHeight+1] = firstBlockOffset
+	i.segmentFirstHeight = append(i.segmentFirstHeight, i.topBlockHeight+1)
+}
+
+// the first block found follows pruned blocks, segments before it were emptied by pruning
+func (i *blockHeightIndex) skipPrunedBlocks(firstBlockHeight primitives.BlockHeight) {
+	i.Lock()
+	defer i.Unlock()
+
+	offset := i.heightOffset[i.topBlockHeight+1]
+	delete(i.heightOffset, i.topBlockHeight+1)
+	i.heightOffset[firstBlockHeight] = offset
+	for s := range i.segmentFirstHeight {
+		i.segmentFirstHeight[s] = firstBlockHeight
+	}
+	i.firstBlockHeight = firstBlockHeight
+	i.topBlockHeight = firstBlockHeight - 1
+}
+
+// forgets the locations of blocks below firstBlockHeight once the segments holding them were pruned
+func (i *blockHeightIndex) pruneBelow(firstBlockHeight primitives.BlockHeight) {
+	i.Lock()
+	defer i.Unlock()
+
+	for h := i.firstBlockHeight; h < firstBlockHeight; h++ {
+		delete(i.heightOffset, h)
+	}
+	i.firstBlockHeight = firstBlockHeight
 }
 
 func (i *blockHeightIndex) appendBlock(prevTopOffset int64, newTopOffset int64, newBlock *protocol.BlockPairContainer) error {
@@ -76,8 +134,6 @@ func (i *blockHeightIndex) appendBlock(prevTopOffset int64, newTopOffset int64,
 	defer i.Unlock()
 
 	newBlockHeight := newBlock.ResultsBlock.Header.BlockHeight()
-	numTxReceipts := newBlock.ResultsBlock.Header.NumTransactionReceipts()
-	blockTs := newBlock.ResultsBlock.Header.Timestamp()
 
 	currentTopOffset, ok := i.heightOffset[i.topBlockHeight+1]
 	if !ok {
@@ -92,16 +148,39 @@ func (i *blockHeightIndex) appendBlock(prevTopOffset int64, newTopOffset int64,
 	i.topBlockHeight = newBlock.ResultsBlock.Header.BlockHeight()
 	i.heightOffset[newBlockHeight+1] = newTopOffset
 
-	if numTxReceipts > 0 {
-		_, exists := i.firstBlockInTsBucket[blockTsBucketKey(blockTs)]
-		if !exists {
-			i.firstBlockInTsBucket[blockTsBucketKey(blockTs)] = newBlockHeight
-		}
+	return nil
+}
+
+// restores a block location read from the index file, restoreTopBlock must follow once all locations were restored
+func (i *blockHeightIndex) restoreBlockLocation(height primitives.BlockHeight, offset int64, startsSegment bool) {
+	i.Lock()
+	defer i.Unlock()
+
+	i.heightOffset[height] = offset
+	i.topBlockHeight = height
+	if startsSegment {
+		i.segmentFirstHeight = append(i.segmentFirstHeight, height)
 	}
+}
+
+func (i *blockHeightIndex) restoreTopBlock(topBlock *protocol.BlockPairContainer, nextBlockOffset int64) error {
+	i.Lock()
+	defer i.Unlock()
 
+	if h := topBlock.ResultsBlock.Header.BlockHeight(); h != i.topBlockHeight {
+		return fmt.Errorf("expected block height %d at top block offset, found block height %d", i.topBlockHeight, h)
+	}
+	i.topBlock = topBlock
+	i.heightOffset[i.topBlockHeight+1] = nextBlockOffset
 	return nil
 }
 
+func (i *blockHeightIndex) getFirstBlockHeight() primitives.BlockHeight {
+	i.RLock()
+	defer i.RUnlock()
+	return i.firstBlockHeight
+}
+
 func (i *blockHeightIndex) getLastBlock() *protocol.BlockPairContainer {
 	i.RLock()
 	defer i.RUnlock()
@@ -113,9 +192,3 @@ func (i *blockHeightIndex) getLastBlockHeight() primitives.BlockHeight {
 	defer i.RUnlock()
 	return i.topBlockHeight
 }
-
-const minuteToNanoRatio = 60 * 1000 * 1000 * 1000
-
-func blockTsBucketKey(nano primitives.TimestampNano) uint32 {
-	return uint32(nano / minuteToNanoRatio)
-}
@@ -0,0 +1,294 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package filesystem
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const indexFilename = "block_index"
+
+const indexFormatMagic = uint32(0x58444942) // "BIDX"
+const sidecarFormatVersion = 0
+
+var sidecarIndexEntrySize = binary.Size(sidecarIndexEntry{})
+
+// The location of a single block, appended to the index file after the block itself was synced to its segment
+type sidecarIndexEntry struct {
+	Height  uint64
+	Offset  int64
+	Segment uint32
+}
+
+func newSidecarIndexEntry(segment int, offset int64, height primitives.BlockHeight) *sidecarIndexEntry {
+	return &sidecarIndexEntry{
+		Height:  uint64(height),
+		Offset:  offset,
+		Segment: uint32(segment),
+	}
+}
+
+func (e *sidecarIndexEntry) write(w io.Writer) error {
+	checkSum := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	err := binary.Write(io.MultiWriter(w, checkSum), binary.LittleEndian, e)
+	if err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, checkSum.Sum32())
+}
+
+func (e *sidecarIndexEntry) read(r io.Reader) error {
+	checkSum := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	err := binary.Read(io.TeeReader(r, checkSum), binary.LittleEndian, e)
+	if err != nil {
+		return err
+	}
+
+	var sum32 uint32
+	err = binary.Read(r, binary.LittleEndian, &sum32)
+	if err != nil {
+		return err
+	}
+	if sum32 != checkSum.Sum32() {
+		return fmt.Errorf("index entry checksum mismatch. computed: %v recorded: %v", checkSum.Sum32(), sum32)
+	}
+	return nil
+}
+
+// The index file is an optimization only. It is not synced to disk, and is never trusted beyond what the blocks files confirm.
+// Failing to write it disables it until the next startup, which then indexes the missing blocks from the blocks files.
+type sidecarIndex struct {
+	file   *os.File
+	header *blocksFileHeader
+	logger log.Logger
+}
+
+func openSidecarIndex(conf config.FilesystemBlockPersistenceConfig, logger log.Logger) (*sidecarIndex, []sidecarIndexEntry, error) {
+	filename := indexFileName(conf)
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to open block index file %s", filename)
+	}
+
+	index := &sidecarIndex{
+		file:   file,
+		header: newSidecarFileHeader(indexFormatMagic, 0, uint32(conf.VirtualChainId())),
+		logger: logger,
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		closeSilently(file, logger)
+		return nil, nil, errors.Wrapf(err, "failed to open block index file %s", filename)
+	}
+	if info.Size() == 0 {
+		logger.Info("creating new block index file", log.String("path", filename))
+		index.reset()
+		return index, nil, nil
+	}
+
+	entries, err := index.readEntries()
+	if err != nil {
+		logger.Info("block index file is invalid, recreating it", log.String("path", filename), log.Error(err))
+		index.reset()
+		return index, nil, nil
+	}
+
+	return index, entries, nil
+}
+
+// sidecar files share the layout of the blocks file header, each with its own magic number
+func newSidecarFileHeader(magic, networkId, vchainId uint32) *blocksFileHeader {
+	return &blocksFileHeader{
+		Magic:       magic,
+		FileVersion: sidecarFormatVersion,
+		NetworkId:   networkId,
+		ChainId:     vchainId,
+	}
+}
+
+// reads all valid entries, dropping whatever follows the last one
+func (s *sidecarIndex) readEntries() ([]sidecarIndexEntry, error) {
+	r := bufio.NewReader(s.file)
+
+	err := readSidecarFileHeader(r, s.header)
+	if err != nil {
+		return nil, err
+	}
+
+	validSize := int64(binary.Size(s.header) + checksumSize)
+	var entries []sidecarIndexEntry
+	for {
+		var entry sidecarIndexEntry
+		err = entry.read(r)
+		if err != nil {
+			if err != io.EOF {
+				s.logger.Info("block index file has an invalid tail, ignoring it", log.Int64("valid-bytes", validSize), log.Error(err))
+			}
+			break
+		}
+		entries = append(entries, entry)
+		validSize += int64(sidecarIndexEntrySize + checksumSize)
+	}
+
+	err = s.file.Truncate(validSize)
+	if err == nil {
+		_, err = s.file.Seek(validSize, io.SeekStart)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// validates the header read matches the expected one
+func readSidecarFileHeader(r io.Reader, expected *blocksFileHeader) error {
+	header := &blocksFileHeader{}
+	checkSum := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	err := binary.Read(io.TeeReader(r, checkSum), binary.LittleEndian, header)
+	if err != nil {
+		return err
+	}
+
+	var sum32 uint32
+	err = binary.Read(r, binary.LittleEndian, &sum32)
+	if err != nil {
+		return errors.Wrapf(err, "failed reading header checksum")
+	}
+	if sum32 != checkSum.Sum32() {
+		return fmt.Errorf("invalid header, bad checksum")
+	}
+
+	if header.Magic != expected.Magic {
+		return fmt.Errorf("invalid magic number %v", header.Magic)
+	}
+	if header.FileVersion != expected.FileVersion {
+		return fmt.Errorf("invalid version %d", header.FileVersion)
+	}
+	if header.ChainId != expected.ChainId {
+		return fmt.Errorf("virtual chain id mismatch. found vchain id %d expected %d", header.ChainId, expected.ChainId)
+	}
+	return nil
+}
+
+// drops all entries, used when the index file does not match the blocks files and is rebuilt from a full scan
+func (s *sidecarIndex) reset() {
+	if s.file == nil {
+		return
+	}
+
+	err := s.file.Truncate(0)
+	if err == nil {
+		_, err = s.file.Seek(0, io.SeekStart)
+	}
+	if err == nil {
+		err = s.header.write(s.file)
+	}
+	if err != nil {
+		s.disable(err)
+	}
+}
+
+func (s *sidecarIndex) add(segment int, offset int64, height primitives.BlockHeight) {
+	if s.file == nil {
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	err := newSidecarIndexEntry(segment, offset, height).write(buf)
+	if err == nil {
+		_, err = s.file.Write(buf.Bytes())
+	}
+	if err != nil {
+		s.disable(err)
+	}
+}
+
+// replaces all entries with the locations of the blocks still held, used once older blocks were pruned
+func (s *sidecarIndex) rewrite(bhIndex *blockHeightIndex) {
+	buf := &bytes.Buffer{}
+	for h := bhIndex.getFirstBlockHeight(); h <= bhIndex.getLastBlockHeight(); h++ {
+		_ = newSidecarIndexEntry(bhIndex.fetchBlockSegment(h), bhIndex.fetchBlockOffset(h), h).write(buf)
+	}
+
+	s.reset()
+	if s.file == nil {
+		return
+	}
+	_, err := s.file.Write(buf.Bytes())
+	if err != nil {
+		s.disable(err)
+	}
+}
+
+func (s *sidecarIndex) disable(err error) {
+	s.logger.Error("failed writing block index file, disabling it until restart", log.Error(err), log.String("filename", s.file.Name()))
+	s.close()
+}
+
+func (s *sidecarIndex) close() {
+	if s.file == nil {
+		return
+	}
+	closeSilently(s.file, s.logger)
+	s.file = nil
+}
+
+func indexFileName(config config.FilesystemBlockPersistenceConfig) string {
+	return filepath.Join(config.BlockStorageFileSystemDataDir(), indexFilename)
+}
+
+// rebuilds block locations from the index file, the top block itself is then read from its segment.
+// When blocks were pruned the first entry is the first block of the earliest segment still holding blocks.
+func restoreBlockLocations(bhIndex *blockHeightIndex, entries []sidecarIndexEntry, firstBlockOffset int64) error {
+	segment := uint32(0)
+	for i, entry := range entries {
+		height := primitives.BlockHeight(entry.Height)
+		if i == 0 {
+			if entry.Offset != firstBlockOffset {
+				return fmt.Errorf("block index file lists first block at offset %d", entry.Offset)
+			}
+			for ; segment < entry.Segment; segment++ {
+				bhIndex.startSegment(firstBlockOffset)
+			}
+			if height > 1 {
+				bhIndex.skipPrunedBlocks(height)
+			}
+			bhIndex.restoreBlockLocation(height, entry.Offset, false)
+			continue
+		}
+
+		if height != primitives.BlockHeight(entries[i-1].Height)+1 {
+			return fmt.Errorf("block index file lists block height %d at position %d", height, i)
+		}
+
+		startsSegment := entry.Segment != segment
+		if startsSegment {
+			if entry.Segment != segment+1 || entry.Offset != firstBlockOffset {
+				return fmt.Errorf("block index file lists block height %d at unexpected segment %d offset %d", height, entry.Segment, entry.Offset)
+			}
+			segment = entry.Segment
+		} else if entry.Offset <= entries[i-1].Offset {
+			return fmt.Errorf("block index file lists block height %d at offset %d before the previous block", height, entry.Offset)
+		}
+
+		bhIndex.restoreBlockLocation(height, entry.Offset, startsSegment)
+	}
+	return nil
+}
@@ -0,0 +1,314 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package filesystem
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/crypto/hash"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const txIndexFilename = "tx_index"
+
+const txIndexFormatMagic = uint32(0x58444954) // "TIDX"
+
+// every block has a record, even when it has no transactions, so the index file tells which blocks it covers
+type txIndexRecordHeader struct {
+	Height uint64
+	NumTxs uint32
+}
+
+var txIndexRecordHeaderSize = binary.Size(txIndexRecordHeader{})
+
+type txLocation struct {
+	height primitives.BlockHeight
+	index  uint32
+}
+
+// Maps transaction hashes to their receipt position. Like the block index file, the tx index file is
+// not synced to disk and blocks it is missing are indexed again from the blocks files on startup.
+type txHashIndex struct {
+	sync.RWMutex
+	locations      map[string]txLocation
+	topBlockHeight primitives.BlockHeight
+	maxNumTxs      uint32 // bounds the records read back, a block holds fewer receipts than it holds receipt hash sized chunks
+
+	file   *os.File
+	header *blocksFileHeader
+	logger log.Logger
+}
+
+// loads records from firstBlockHeight up to topBlockHeight, records beyond it belong to blocks which did not survive in the blocks files
+// and records below it belong to pruned blocks
+func openTxHashIndex(conf config.FilesystemBlockPersistenceConfig, firstBlockHeight, topBlockHeight primitives.BlockHeight, logger log.Logger) (*txHashIndex, error) {
+	filename := txIndexFileName(conf)
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open tx index file %s", filename)
+	}
+
+	index := &txHashIndex{
+		locations:      make(map[string]txLocation),
+		topBlockHeight: firstBlockHeight - 1,
+		maxNumTxs:      conf.BlockStorageFileSystemMaxBlockSizeInBytes() / hash.SHA256_HASH_SIZE_BYTES,
+		file:           file,
+		header:         newSidecarFileHeader(txIndexFormatMagic, 0, uint32(conf.VirtualChainId())),
+		logger:         logger,
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		closeSilently(file, logger)
+		return nil, errors.Wrapf(err, "failed to open tx index file %s", filename)
+	}
+	if info.Size() == 0 {
+		logger.Info("creating new tx index file", log.String("path", filename))
+		index.reset()
+		return index, nil
+	}
+
+	err = index.readRecords(firstBlockHeight, topBlockHeight)
+	if err != nil {
+		logger.Info("tx index file is invalid, recreating it", log.String("path", filename), log.Error(err))
+		index.locations = make(map[string]txLocation)
+		index.topBlockHeight = firstBlockHeight - 1
+		index.reset()
+	}
+
+	return index, nil
+}
+
+func (i *txHashIndex) readRecords(firstBlockHeight, topBlockHeight primitives.BlockHeight) error {
+	r := bufio.NewReader(i.file)
+
+	err := readSidecarFileHeader(r, i.header)
+	if err != nil {
+		return err
+	}
+
+	headerSize := int64(binary.Size(i.header) + checksumSize)
+	validSize, prunedSize := headerSize, int64(0)
+	prevHeight := primitives.BlockHeight(0)
+	for i.topBlockHeight < topBlockHeight {
+		height, txHashes, recordSize, err := readTxIndexRecord(r, i.maxNumTxs)
+		if err != nil {
+			if err != io.EOF {
+				i.logger.Info("tx index file has an invalid tail, ignoring it", log.Int64("valid-bytes", validSize), log.Error(err))
+			}
+			break
+		}
+		if (prevHeight > 0 && height != prevHeight+1) || (prevHeight == 0 && height > firstBlockHeight) {
+			i.logger.Info("tx index file has an out of order record, ignoring it and what follows", log.Int64("valid-bytes", validSize))
+			break
+		}
+
+		prevHeight = height
+		validSize += int64(recordSize)
+		if height < firstBlockHeight {
+			prunedSize += int64(recordSize)
+			continue
+		}
+		i.indexTxHashes(height, txHashes)
+	}
+
+	if validSize == prunedSize+headerSize { // nothing worth keeping
+		prunedSize, validSize = 0, headerSize
+	}
+	err = i.file.Truncate(validSize)
+	if err == nil {
+		_, err = i.file.Seek(validSize, io.SeekStart)
+	}
+	if err == nil && prunedSize > 0 {
+		err = i.dropPrunedRecords(headerSize+prunedSize, validSize)
+	}
+	return err
+}
+
+// rewrites the file without the records of pruned blocks, which start right after the file header
+func (i *txHashIndex) dropPrunedRecords(from int64, to int64) error {
+	filename := i.file.Name()
+	tempFilename := filename + ".tmp"
+	tempFile, err := os.OpenFile(tempFilename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	err = i.header.write(tempFile)
+	if err == nil {
+		_, err = io.Copy(tempFile, io.NewSectionReader(i.file, from, to-from))
+	}
+	if err == nil {
+		err = os.Rename(tempFilename, filename)
+	}
+	if err != nil {
+		closeSilently(tempFile, i.logger)
+		return err
+	}
+
+	closeSilently(i.file, i.logger)
+	i.file = tempFile
+	i.logger.Info("dropped records of pruned blocks from tx index file", log.Int64("dropped-bytes", from-int64(binary.Size(i.header)+checksumSize)))
+	return nil
+}
+
+// the record header is checked against maxNumTxs before allocating, since its checksum is only verified once the whole record is read
+func readTxIndexRecord(r io.Reader, maxNumTxs uint32) (primitives.BlockHeight, [][]byte, int, error) {
+	checkSum := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	tr := io.TeeReader(r, checkSum)
+
+	header := &txIndexRecordHeader{}
+	err := binary.Read(tr, binary.LittleEndian, header)
+	if err != nil {
+		return 0, nil, 0, err
+	}
+	if header.NumTxs > maxNumTxs {
+		return 0, nil, 0, fmt.Errorf("tx index record of block %d has %d transactions, more than a block can hold (%d)", header.Height, header.NumTxs, maxNumTxs)
+	}
+
+	hashes := make([]byte, int(header.NumTxs)*hash.SHA256_HASH_SIZE_BYTES)
+	_, err = io.ReadFull(tr, hashes)
+	if err != nil {
+		return 0, nil, 0, err
+	}
+
+	var sum32 uint32
+	err = binary.Read(r, binary.LittleEndian, &sum32)
+	if err != nil {
+		return 0, nil, 0, err
+	}
+	if sum32 != checkSum.Sum32() {
+		return 0, nil, 0, fmt.Errorf("tx index record checksum mismatch. computed: %v recorded: %v", checkSum.Sum32(), sum32)
+	}
+
+	txHashes := make([][]byte, header.NumTxs)
+	for j := range txHashes {
+		txHashes[j] = hashes[j*hash.SHA256_HASH_SIZE_BYTES : (j+1)*hash.SHA256_HASH_SIZE_BYTES]
+	}
+	return primitives.BlockHeight(header.Height), txHashes, txIndexRecordHeaderSize + len(hashes) + checksumSize, nil
+}
+
+func encodeTxIndexRecord(height primitives.BlockHeight, receipts []*protocol.TransactionReceipt) []byte {
+	buf := &bytes.Buffer{}
+	checkSum := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	cw := io.MultiWriter(buf, checkSum)
+
+	_ = binary.Write(cw, binary.LittleEndian, &txIndexRecordHeader{Height: uint64(height), NumTxs: uint32(len(receipts))})
+	for _, receipt := range receipts {
+		_, _ = cw.Write(receipt.Txhash())
+	}
+	_ = binary.Write(buf, binary.LittleEndian, checkSum.Sum32())
+	return buf.Bytes()
+}
+
+func (i *txHashIndex) indexTxHashes(height primitives.BlockHeight, txHashes [][]byte) {
+	for j, txHash := range txHashes {
+		if _, exists := i.locations[string(txHash)]; !exists {
+			i.locations[string(txHash)] = txLocation{height: height, index: uint32(j)}
+		}
+	}
+	i.topBlockHeight = height
+}
+
+// callers must add blocks in order, starting right after the height the index was loaded up to
+func (i *txHashIndex) add(block *protocol.BlockPairContainer) {
+	i.Lock()
+	defer i.Unlock()
+
+	height := block.ResultsBlock.Header.BlockHeight()
+	receipts := block.ResultsBlock.TransactionReceipts
+	txHashes := make([][]byte, len(receipts))
+	for j, receipt := range receipts {
+		txHashes[j] = receipt.Txhash()
+	}
+	i.indexTxHashes(height, txHashes)
+
+	if i.file == nil {
+		return
+	}
+	_, err := i.file.Write(encodeTxIndexRecord(height, receipts))
+	if err != nil {
+		i.disable(err)
+	}
+}
+
+// forgets transactions of pruned blocks, their records are dropped from the file on the next startup
+func (i *txHashIndex) pruneBelow(firstBlockHeight primitives.BlockHeight) {
+	i.Lock()
+	defer i.Unlock()
+
+	for txHash, location := range i.locations {
+		if location.height < firstBlockHeight {
+			delete(i.locations, txHash)
+		}
+	}
+}
+
+func (i *txHashIndex) lookup(txHash primitives.Sha256) (txLocation, bool) {
+	i.RLock()
+	defer i.RUnlock()
+
+	location, ok := i.locations[string(txHash)]
+	return location, ok
+}
+
+func (i *txHashIndex) getTopBlockHeight() primitives.BlockHeight {
+	i.RLock()
+	defer i.RUnlock()
+	return i.topBlockHeight
+}
+
+func (i *txHashIndex) reset() {
+	if i.file == nil {
+		return
+	}
+
+	err := i.file.Truncate(0)
+	if err == nil {
+		_, err = i.file.Seek(0, io.SeekStart)
+	}
+	if err == nil {
+		err = i.header.write(i.file)
+	}
+	if err != nil {
+		i.disable(err)
+	}
+}
+
+func (i *txHashIndex) disable(err error) {
+	i.logger.Error("failed writing tx index file, disabling it until restart", log.Error(err), log.String("filename", i.file.Name()))
+	i.closeFile()
+}
+
+func (i *txHashIndex) close() {
+	i.Lock()
+	defer i.Unlock()
+	i.closeFile()
+}
+
+func (i *txHashIndex) closeFile() {
+	if i.file == nil {
+		return
+	}
+	closeSilently(i.file, i.logger)
+	i.file = nil
+}
+
+func txIndexFileName(config config.FilesystemBlockPersistenceConfig) string {
+	return filepath.Join(config.BlockStorageFileSystemDataDir(), txIndexFilename)
+}
@@ -0,0 +1,197 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package filesystem
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"github.com/orbs-network/orbs-network-go/crypto/digest"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type VerifyOptions struct {
+	MaxBlockSizeInBytes uint32
+	VirtualChainId      primitives.VirtualChainId  // zero accepts the virtual chain id recorded in the file header
+	ProtocolVersion     primitives.ProtocolVersion // zero accepts any protocol version
+}
+
+type VerifyReport struct {
+	VirtualChainId      primitives.VirtualChainId
+	NumBlocks           int
+	FirstBlockHeight    primitives.BlockHeight
+	LastGoodBlockHeight primitives.BlockHeight
+	ValidBytes          int64 // the file holds only good blocks when truncated to this size
+	FileSize            int64
+	Err                 error // why the block following the last good one is bad, nil when the whole file is good
+}
+
+func (r *VerifyReport) FirstBadBlockHeight() primitives.BlockHeight {
+	if r.Err == nil {
+		return 0
+	}
+	return r.LastGoodBlockHeight + 1
+}
+
+// Verifies a blocks file, or a single segment of one, without taking its lock so it can also check the files of a running node.
+// Blocks are checked in order - their section checksums, their headers against the file header and options, and that each
+// block points at the hash of the one before it. A block file segment may start at any height, its first block is not linked.
+func VerifyBlocksFile(filename string, options *VerifyOptions) (*VerifyReport, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open blocks file %s", filename)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open blocks file %s", filename)
+	}
+
+	r := bufio.NewReader(file)
+	header := newBlocksFileHeader(0, 0)
+	err = header.read(r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading blocks file header")
+	}
+	vchainId := primitives.VirtualChainId(header.ChainId)
+	if options.VirtualChainId != 0 && options.VirtualChainId != vchainId {
+		return nil, fmt.Errorf("blocks file virtual chain id mismatch. found vchain id %d expected %d", vchainId, options.VirtualChainId)
+	}
+
+	report := &VerifyReport{
+		VirtualChainId: vchainId,
+		ValidBytes:     int64(fileHeaderSize()),
+		FileSize:       info.Size(),
+	}
+	c := newCodec(options.MaxBlockSizeInBytes, false)
+	var prev *protocol.BlockPairContainer
+	for {
+		block, blockSize, err := c.decodeVerifyingSections(fullReader{r})
+		if err == io.EOF {
+			return report, nil
+		}
+		if err == nil {
+			err = verifyBlock(block, prev, vchainId, options.ProtocolVersion)
+		}
+		if err != nil {
+			report.Err = err
+			return report, nil
+		}
+
+		if prev == nil {
+			report.FirstBlockHeight = block.ResultsBlock.Header.BlockHeight()
+		}
+		report.NumBlocks++
+		report.LastGoodBlockHeight = block.ResultsBlock.Header.BlockHeight()
+		report.ValidBytes += int64(blockSize)
+		prev = block
+	}
+}
+
+func verifyBlock(block, prev *protocol.BlockPairContainer, vchainId primitives.VirtualChainId, protocolVersion primitives.ProtocolVersion) error {
+	txHeader, rxHeader := block.TransactionsBlock.Header, block.ResultsBlock.Header
+	height := rxHeader.BlockHeight()
+	if txHeader.BlockHeight() != height {
+		return fmt.Errorf("transactions block height %d does not match results block height %d", txHeader.BlockHeight(), height)
+	}
+	if txHeader.VirtualChainId() != vchainId || rxHeader.VirtualChainId() != vchainId {
+		return fmt.Errorf("block %d virtual chain id mismatch. found vchain ids %d and %d expected %d", height, txHeader.VirtualChainId(), rxHeader.VirtualChainId(), vchainId)
+	}
+	if protocolVersion != 0 && (txHeader.ProtocolVersion() != protocolVersion || rxHeader.ProtocolVersion() != protocolVersion) {
+		return fmt.Errorf("block %d protocol version mismatch. found protocol versions %d and %d expected %d", height, txHeader.ProtocolVersion(), rxHeader.ProtocolVersion(), protocolVersion)
+	}
+
+	if prev == nil {
+		return nil
+	}
+	if prevHeight := prev.ResultsBlock.Header.BlockHeight(); height != prevHeight+1 {
+		return fmt.Errorf("found block height %d following block height %d", height, prevHeight)
+	}
+	if !txHeader.PrevBlockHashPtr().Equal(digest.CalcTransactionsBlockHash(prev.TransactionsBlock)) {
+		return fmt.Errorf("block %d transactions block prev block hash does not match the previous block", height)
+	}
+	if !rxHeader.PrevBlockHashPtr().Equal(digest.CalcResultsBlockHash(prev.ResultsBlock)) {
+		return fmt.Errorf("block %d results block prev block hash does not match the previous block", height)
+	}
+	return nil
+}
+
+// Drops every block following the last good one of a report. Only the last segment of a data dir may be truncated,
+// truncating an earlier one would leave a gap in the block heights. The data dir lock is taken first, a node must not be running on it.
+func TruncateBlocksFile(filename string, report *VerifyReport) error {
+	file, err := os.OpenFile(filename, os.O_RDWR, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open blocks file %s", filename)
+	}
+	defer file.Close()
+
+	dir := filepath.Dir(filename)
+	lockFile := file
+	if filepath.Base(filename) != blocksFilename { // flock locks of the same process conflict when taken through separate file descriptors
+		lockFile, err = os.Open(filepath.Join(dir, blocksFilename))
+		if err != nil {
+			return errors.Wrapf(err, "failed to open first blocks file segment of %s", dir)
+		}
+		defer lockFile.Close()
+	}
+	err = advisoryLockExclusive(lockFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to obtain exclusive lock for writing %s", dir)
+	}
+
+	isLast, err := isLastSegmentFile(filename)
+	if err != nil {
+		return err
+	}
+	if !isLast {
+		return fmt.Errorf("blocks file %s is followed by later segments, only the last segment of a data dir may be truncated", filename)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() != report.FileSize {
+		return fmt.Errorf("blocks file %s changed since it was verified", filename)
+	}
+
+	err = file.Truncate(report.ValidBytes)
+	if err != nil {
+		return errors.Wrapf(err, "failed to truncate blocks file %s", filename)
+	}
+	return file.Sync()
+}
+
+func isLastSegmentFile(filename string) (bool, error) {
+	segment := 0
+	if base := filepath.Base(filename); base != blocksFilename {
+		var err error
+		segment, err = strconv.Atoi(strings.TrimPrefix(base, blocksFilename+"."))
+		if err != nil || !strings.HasPrefix(base, blocksFilename+".") {
+			return false, fmt.Errorf("%s is not named as a blocks file segment", filename)
+		}
+	}
+
+	nextSegment := filepath.Join(filepath.Dir(filename), fmt.Sprintf("%s.%06d", blocksFilename, segment+1))
+	_, err := os.Stat(nextSegment)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	return false, err
+}
+
+func fileHeaderSize() int {
+	return binary.Size(blocksFileHeader{}) + checksumSize
+}
@@ -24,16 +24,19 @@ type memMetrics struct {
 	size *metric.Gauge
 }
 
+type txLocation struct {
+	height primitives.BlockHeight
+	index  int
+}
+
 type aChainOfBlocks struct {
 	sync.RWMutex
-	blocks []*protocol.BlockPairContainer
+	blocks  []*protocol.BlockPairContainer
+	txIndex map[string]txLocation
 }
 
 type InMemoryBlockPersistence struct {
-	blockChain struct {
-		sync.RWMutex
-		blocks []*protocol.BlockPairContainer
-	}
+	blockChain aChainOfBlocks
 
 	tracker *synchronization.BlockTracker
 	Logger  log.Logger
@@ -47,12 +50,26 @@ func NewBlockPersistence(parent log.Logger, metricFactory metric.Factory, preloa
 		Logger:     logger,
 		metrics:    &memMetrics{size: metricFactory.NewGauge("BlockStorage.InMemoryBlockPersistenceSize.Bytes")},
 		tracker:    synchronization.NewBlockTracker(logger, uint64(len(preloadedBlocks)), 5),
-		blockChain: aChainOfBlocks{blocks: preloadedBlocks},
+		blockChain: aChainOfBlocks{blocks: preloadedBlocks, txIndex: make(map[string]txLocation)},
+	}
+
+	for _, blockPair := range preloadedBlocks {
+		p.blockChain.indexTransactions(blockPair)
 	}
 
 	return p
 }
 
+// keeps the first occurrence of a txHash, matching the block a receipt query would have found first
+func (c *aChainOfBlocks) indexTransactions(blockPair *protocol.BlockPairContainer) {
+	height := blockPair.ResultsBlock.Header.BlockHeight()
+	for i, receipt := range blockPair.ResultsBlock.TransactionReceipts {
+		if _, exists := c.txIndex[string(receipt.Txhash())]; !exists {
+			c.txIndex[string(receipt.Txhash())] = txLocation{height: height, index: i}
+		}
+	}
+}
+
 func (bp *InMemoryBlockPersistence) GetBlockTracker() *synchronization.BlockTracker {
 	return bp.tracker
 }
@@ -69,6 +86,11 @@ func (bp *InMemoryBlockPersistence) GetLastBlock() (*protocol.BlockPairContainer
 	return bp.blockChain.blocks[count-1], nil
 }
 
+// the in memory adapter never prunes blocks
+func (bp *InMemoryBlockPersistence) GetFirstBlockHeight() (primitives.BlockHeight, error) {
+	return 1, nil
+}
+
 func (bp *InMemoryBlockPersistence) GetLastBlockHeight() (primitives.BlockHeight, error) {
 	bp.blockChain.RLock()
 	defer bp.blockChain.RUnlock()
@@ -106,36 +128,20 @@ func (bp *InMemoryBlockPersistence) validateAndAddNextBlock(blockPair *protocol.
 		return false, nil
 	}
 	bp.blockChain.blocks = append(bp.blockChain.blocks, blockPair)
+	bp.blockChain.indexTransactions(blockPair)
 	bp.tracker.IncrementTo(blockPair.ResultsBlock.Header.BlockHeight())
 	return true, nil
 }
 
-func (bp *InMemoryBlockPersistence) GetBlockByTx(txHash primitives.Sha256, minBlockTs primitives.TimestampNano, maxBlockTs primitives.TimestampNano) (*protocol.BlockPairContainer, int, error) {
-
+func (bp *InMemoryBlockPersistence) GetBlockByTx(txHash primitives.Sha256) (*protocol.BlockPairContainer, int, error) {
 	bp.blockChain.RLock()
 	defer bp.blockChain.RUnlock()
 
-	allBlocks := bp.blockChain.blocks
-	var candidateBlocks []*protocol.BlockPairContainer
-	for _, blockPair := range allBlocks {
-		bts := blockPair.TransactionsBlock.Header.Timestamp()
-		if maxBlockTs > bts && minBlockTs < bts {
-			candidateBlocks = append(candidateBlocks, blockPair)
-		}
-	}
-
-	if len(candidateBlocks) == 0 {
+	location, ok := bp.blockChain.txIndex[string(txHash)]
+	if !ok {
 		return nil, 0, nil
 	}
-
-	for _, b := range candidateBlocks {
-		for txi, txr := range b.ResultsBlock.TransactionReceipts {
-			if txr.Txhash().Equal(txHash) {
-				return b, txi, nil
-			}
-		}
-	}
-	return nil, 0, nil
+	return bp.blockChain.blocks[location.height-1], location.index, nil
 }
 
 func (bp *InMemoryBlockPersistence) getBlockPairAtHeight(height primitives.BlockHeight) (*protocol.BlockPairContainer, error) {
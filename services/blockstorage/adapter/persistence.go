@@ -7,9 +7,12 @@
 package adapter
 
 import (
+	"fmt"
+	"github.com/orbs-network/orbs-network-go/crypto/bloom"
 	"github.com/orbs-network/orbs-network-go/synchronization"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/pkg/errors"
 )
 
 // A Callback function provided by consumers of blocks from storage. Each invocation receives a single blocks page
@@ -22,12 +25,36 @@ type BlockPersistence interface {
 
 	ScanBlocks(from primitives.BlockHeight, pageSize uint8, f CursorFunc) error
 
+	GetFirstBlockHeight() (primitives.BlockHeight, error)
 	GetLastBlockHeight() (primitives.BlockHeight, error)
 	GetLastBlock() (*protocol.BlockPairContainer, error)
 
 	GetTransactionsBlock(height primitives.BlockHeight) (*protocol.TransactionsBlockContainer, error)
 	GetResultsBlock(height primitives.BlockHeight) (*protocol.ResultsBlockContainer, error)
-	GetBlockByTx(txHash primitives.Sha256, minBlockTs primitives.TimestampNano, maxBlockTs primitives.TimestampNano) (block *protocol.BlockPairContainer, txIndexInBlock int, err error)
+	GetBlockByTx(txHash primitives.Sha256) (block *protocol.BlockPairContainer, txIndexInBlock int, err error)
 
 	GetBlockTracker() *synchronization.BlockTracker
 }
+
+// Implemented by persistence adapters keeping a bloom filter of every block, which lets block range queries skip the blocks
+// a filter excludes without reading them. GetBlockFilter fails for blocks the adapter holds no filter of.
+type BlockFilterPersistence interface {
+	BlockPersistence
+	GetBlockFilter(height primitives.BlockHeight) (*bloom.BlockFilter, error)
+}
+
+// Returned when reading full blocks below the first block height a pruning node still holds. The headers of pruned
+// blocks remain available, GetTransactionsBlock and GetResultsBlock return them without transactions, receipts and state diffs.
+type BlockPrunedError struct {
+	Height           primitives.BlockHeight
+	FirstBlockHeight primitives.BlockHeight
+}
+
+func (e *BlockPrunedError) Error() string {
+	return fmt.Sprintf("block height %d was pruned, first available block height is %d", e.Height, e.FirstBlockHeight)
+}
+
+func IsBlockPruned(err error) bool {
+	_, ok := errors.Cause(err).(*BlockPrunedError)
+	return ok
+}
@@ -0,0 +1,81 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"github.com/orbs-network/orbs-network-go/crypto/bloom"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage/adapter"
+	"github.com/orbs-network/orbs-network-go/test/rand"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const blockFiltersFilename = "block_filters"
+
+func getBlockFiltersFileSize(t *testing.T, conf *localConfig) int64 {
+	info, err := os.Stat(filepath.Join(conf.BlockStorageFileSystemDataDir(), blockFiltersFilename))
+	require.NoError(t, err)
+	return info.Size()
+}
+
+func requireFiltersOfAllBlocks(t *testing.T, fsa adapter.BlockPersistence, blocks []*protocol.BlockPairContainer) {
+	filters, ok := fsa.(adapter.BlockFilterPersistence)
+	require.True(t, ok, "expected filesystem adapter to keep block filters")
+	for _, b := range blocks {
+		filter, err := filters.GetBlockFilter(b.ResultsBlock.Header.BlockHeight())
+		require.NoError(t, err)
+		require.Equal(t, bloom.CalcBlockFilter(b).Raw(), filter.Raw(), "got wrong filter of block %d", b.ResultsBlock.Header.BlockHeight())
+	}
+}
+
+func TestFileSystemBlockPersistence_RestoresBlockFiltersFromBlockFiltersFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	ctrlRand := rand.NewControlledRand(t)
+
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+
+	blocks := writeRandomBlocksToFile(t, conf, 10, ctrlRand)
+	filtersSize := getBlockFiltersFileSize(t, conf)
+
+	fsa, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+
+	requireFiltersOfAllBlocks(t, fsa, blocks)
+	closeAdapter()
+
+	require.EqualValues(t, filtersSize, getBlockFiltersFileSize(t, conf), "expected block filters file to be used as is")
+}
+
+func TestFileSystemBlockPersistence_ComputesFiltersMissingFromBlockFiltersFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	ctrlRand := rand.NewControlledRand(t)
+
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+
+	blocks := writeRandomBlocksToFile(t, conf, 10, ctrlRand)
+	originalFiltersSize := getBlockFiltersFileSize(t, conf)
+
+	require.NoError(t, os.Truncate(filepath.Join(conf.BlockStorageFileSystemDataDir(), blockFiltersFilename), originalFiltersSize/2)) // lose some records and tear another one
+
+	fsa, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+
+	requireFiltersOfAllBlocks(t, fsa, blocks)
+	closeAdapter()
+
+	require.EqualValues(t, originalFiltersSize, getBlockFiltersFileSize(t, conf), "expected missing filters to be appended to block filters file")
+}
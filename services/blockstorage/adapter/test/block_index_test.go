@@ -0,0 +1,160 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"github.com/orbs-network/orbs-network-go/test"
+	"github.com/orbs-network/orbs-network-go/test/rand"
+	"github.com/orbs-network/scribe/log"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const indexFilename = "block_index"
+const indexEntrySize = 24
+
+func getIndexFileSize(t *testing.T, conf *localConfig) int64 {
+	info, err := os.Stat(filepath.Join(conf.BlockStorageFileSystemDataDir(), indexFilename))
+	require.NoError(t, err)
+	return info.Size()
+}
+
+func truncateIndexFile(t *testing.T, conf *localConfig, size int64) {
+	require.NoError(t, os.Truncate(filepath.Join(conf.BlockStorageFileSystemDataDir(), indexFilename), size))
+}
+
+func TestFileSystemBlockPersistence_WritesIndexEntryForEveryBlock(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	ctrlRand := rand.NewControlledRand(t)
+
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+
+	emptyIndexSize := int64(0)
+	func() {
+		_, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+		require.NoError(t, err)
+		defer closeAdapter()
+		emptyIndexSize = getIndexFileSize(t, conf)
+	}()
+
+	blocks := writeRandomBlocksToFile(t, conf, 10, ctrlRand)
+
+	require.EqualValues(t, emptyIndexSize+int64(len(blocks)*indexEntrySize), getIndexFileSize(t, conf))
+}
+
+func TestFileSystemBlockPersistence_RestoresIndexFromIndexFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	ctrlRand := rand.NewControlledRand(t)
+
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+	conf.setMaxSegmentSize(64 * 1024)
+
+	blocks := writeRandomBlocksToFile(t, conf, 30, ctrlRand)
+
+	fsa, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+	defer closeAdapter()
+
+	lastBlock, err := fsa.GetLastBlock()
+	require.NoError(t, err)
+	test.RequireCmpEqual(t, blocks[len(blocks)-1], lastBlock, "expected top block to be restored")
+
+	requireCanReadAllBlocksInRandomOrder(t, fsa, blocks, ctrlRand)
+
+	for _, b := range blocks {
+		if len(b.ResultsBlock.TransactionReceipts) == 0 {
+			continue
+		}
+		block, _, err := fsa.GetBlockByTx(b.ResultsBlock.TransactionReceipts[0].Txhash())
+		require.NoError(t, err)
+		require.NotNil(t, block, "expected tx index to be restored")
+	}
+}
+
+func TestFileSystemBlockPersistence_IndexesBlocksMissingFromIndexFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	ctrlRand := rand.NewControlledRand(t)
+
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+	conf.setMaxSegmentSize(64 * 1024)
+
+	blocks := writeRandomBlocksToFile(t, conf, 20, ctrlRand)
+	originalIndexSize := getIndexFileSize(t, conf)
+
+	truncateIndexFile(t, conf, originalIndexSize-5*indexEntrySize-10) // lose a few entries and tear another one
+
+	fsa, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+
+	topBlockHeight, err := fsa.GetLastBlockHeight()
+	require.NoError(t, err)
+	require.EqualValues(t, len(blocks), topBlockHeight, "expected blocks missing from index file to be indexed from blocks file")
+	requireCanReadAllBlocksInRandomOrder(t, fsa, blocks, ctrlRand)
+	closeAdapter()
+
+	require.EqualValues(t, originalIndexSize, getIndexFileSize(t, conf), "expected missing entries to be appended to index file")
+}
+
+func TestFileSystemBlockPersistence_RebuildsIndexInconsistentWithBlocksFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	ctrlRand := rand.NewControlledRand(t)
+
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+
+	blocks := writeRandomBlocksToFile(t, conf, 5, ctrlRand)
+	truncateFile(t, conf, getFileSize(t, conf)-1) // the index now lists a block which is not in the blocks file
+
+	fsa, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLoggerAllowingErrors(t, "built index, found and ignoring invalid block records"), conf)
+	require.NoError(t, err)
+	defer closeAdapter()
+
+	topBlockHeight, err := fsa.GetLastBlockHeight()
+	require.NoError(t, err)
+	require.EqualValues(t, len(blocks)-1, topBlockHeight, "expected index to be rebuilt from blocks file")
+	requireCanReadAllBlocksInRandomOrder(t, fsa, blocks[:len(blocks)-1], ctrlRand)
+}
+
+func TestFileSystemBlockPersistence_RecreatesCorruptIndexFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	ctrlRand := rand.NewControlledRand(t)
+
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+
+	blocks := writeRandomBlocksToFile(t, conf, 5, ctrlRand)
+	originalIndexSize := getIndexFileSize(t, conf)
+
+	indexFile, err := os.OpenFile(filepath.Join(conf.BlockStorageFileSystemDataDir(), indexFilename), os.O_RDWR, 0666)
+	require.NoError(t, err)
+	_, err = indexFile.WriteAt([]byte{0xFF}, 1) // break the header
+	require.NoError(t, err)
+	require.NoError(t, indexFile.Close())
+
+	fsa, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+
+	requireCanReadAllBlocksInRandomOrder(t, fsa, blocks, ctrlRand)
+	closeAdapter()
+
+	require.EqualValues(t, originalIndexSize, getIndexFileSize(t, conf), "expected index file to be rebuilt")
+}
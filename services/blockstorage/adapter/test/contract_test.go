@@ -240,7 +240,7 @@ func TestBlockPersistenceContract_ReturnsBlockByTx(t *testing.T) {
 
 		tx := blocks[1].TransactionsBlock.SignedTransactions[6].Transaction()
 
-		readBlock, txIndex, err := adapter.GetBlockByTx(digest.CalcTxHash(tx), blocks[0].ResultsBlock.Header.Timestamp(), blocks[2].ResultsBlock.Header.Timestamp())
+		readBlock, txIndex, err := adapter.GetBlockByTx(digest.CalcTxHash(tx))
 		require.NoError(t, err)
 		require.EqualValues(t, 6, txIndex)
 		test.RequireCmpEqual(t, readBlock, blocks[1])
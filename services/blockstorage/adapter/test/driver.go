@@ -48,8 +48,12 @@ func NewFilesystemAdapterDriver(logger log.Logger, conf config.FilesystemBlockPe
 }
 
 type localConfig struct {
-	dir     string
-	chainId primitives.VirtualChainId
+	dir            string
+	chainId        primitives.VirtualChainId
+	maxSegmentSize uint32
+	keepBlocks     uint32
+	keepWindow     time.Duration
+	compression    bool
 }
 
 func newTempFileConfig() *localConfig {
@@ -70,6 +74,22 @@ func (l *localConfig) BlockStorageFileSystemMaxBlockSizeInBytes() uint32 {
 	return 64 * 1024 * 1024
 }
 
+func (l *localConfig) BlockStorageFileSystemMaxSegmentSizeInBytes() uint32 {
+	return l.maxSegmentSize
+}
+
+func (l *localConfig) BlockStorageFileSystemPruningKeepBlocks() uint32 {
+	return l.keepBlocks
+}
+
+func (l *localConfig) BlockStorageFileSystemPruningKeepWindow() time.Duration {
+	return l.keepWindow
+}
+
+func (l *localConfig) BlockStorageFileSystemCompression() bool {
+	return l.compression
+}
+
 func (l *localConfig) VirtualChainId() primitives.VirtualChainId {
 	return l.chainId
 }
@@ -82,6 +102,19 @@ func (l *localConfig) setVirtualChainId(id primitives.VirtualChainId) {
 	l.chainId = id
 }
 
+func (l *localConfig) setMaxSegmentSize(size uint32) {
+	l.maxSegmentSize = size
+}
+
+func (l *localConfig) setPruning(keepBlocks uint32, keepWindow time.Duration) {
+	l.keepBlocks = keepBlocks
+	l.keepWindow = keepWindow
+}
+
+func (l *localConfig) setCompression(compression bool) {
+	l.compression = compression
+}
+
 func getFileSize(t *testing.T, conf *localConfig) int64 {
 	blocksFile, err := os.Open(filepath.Join(conf.BlockStorageFileSystemDataDir(), blocksFilename))
 	require.NoError(t, err)
@@ -0,0 +1,109 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"bytes"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage/adapter/export"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage/adapter/memory"
+	"github.com/orbs-network/orbs-network-go/test"
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-network-go/test/rand"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestExport_ImportsExportedBlocks(t *testing.T) {
+	ctrlRand := rand.NewControlledRand(t)
+	blocks := builders.RandomizedBlockChain(20, ctrlRand)
+	source := memory.NewBlockPersistence(log.DefaultTestingLogger(t), metric.NewRegistry(), blocks...)
+	target := memory.NewBlockPersistence(log.DefaultTestingLogger(t), metric.NewRegistry(), copyBlocks(blocks[:4])...)
+
+	stream := &bytes.Buffer{}
+	manifest, err := export.Export(stream, source, builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID, 5, 15)
+	require.NoError(t, err)
+	require.EqualValues(t, 5, manifest.FirstBlockHeight)
+	require.EqualValues(t, 15, manifest.LastBlockHeight)
+
+	_, err = export.Import(bytes.NewReader(stream.Bytes()), target, builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID, true)
+	require.NoError(t, err)
+
+	top, err := target.GetLastBlockHeight()
+	require.NoError(t, err)
+	require.EqualValues(t, 15, top)
+	for _, b := range blocks[4:15] {
+		block, err := readOneBlock(target, b.ResultsBlock.Header.BlockHeight())
+		require.NoError(t, err)
+		test.RequireCmpEqual(t, b, block)
+	}
+}
+
+func TestExport_ImportRejectsCorruptStreamWithoutWritingBlocks(t *testing.T) {
+	ctrlRand := rand.NewControlledRand(t)
+	blocks := builders.RandomizedBlockChain(10, ctrlRand)
+	source := memory.NewBlockPersistence(log.DefaultTestingLogger(t), metric.NewRegistry(), blocks...)
+
+	stream := &bytes.Buffer{}
+	_, err := export.Export(stream, source, builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID, 0, 0)
+	require.NoError(t, err)
+
+	corrupt := append([]byte{}, stream.Bytes()...)
+	corrupt[len(corrupt)-100] ^= 0x01 // in the last block, so all blocks before it are readable
+
+	target := memory.NewBlockPersistence(log.DefaultTestingLogger(t), metric.NewRegistry())
+	_, err = export.Import(bytes.NewReader(corrupt), target, builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID, true)
+	require.Error(t, err)
+
+	top, err := target.GetLastBlockHeight()
+	require.NoError(t, err)
+	require.EqualValues(t, 0, top, "expected no blocks to be written from an invalid stream")
+}
+
+func TestExport_ImportRejectsStreamNotFollowingLastBlock(t *testing.T) {
+	ctrlRand := rand.NewControlledRand(t)
+	blocks := builders.RandomizedBlockChain(10, ctrlRand)
+	source := memory.NewBlockPersistence(log.DefaultTestingLogger(t), metric.NewRegistry(), blocks...)
+
+	stream := &bytes.Buffer{}
+	_, err := export.Export(stream, source, builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID, 6, 0)
+	require.NoError(t, err)
+
+	target := memory.NewBlockPersistence(log.DefaultTestingLogger(t), metric.NewRegistry(), copyBlocks(blocks[:3])...)
+	_, err = export.Import(bytes.NewReader(stream.Bytes()), target, builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID, true)
+	require.Error(t, err, "expected a gap between the last block and the stream to be rejected")
+
+	otherChain := builders.RandomizedBlockChain(5, ctrlRand) // same heights, different hashes
+	target = memory.NewBlockPersistence(log.DefaultTestingLogger(t), metric.NewRegistry(), otherChain...)
+	_, err = export.Import(bytes.NewReader(stream.Bytes()), target, builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID, true)
+	require.Error(t, err, "expected a stream not extending the chain held to be rejected")
+}
+
+func TestExport_ImportRequiresTrustedSource(t *testing.T) {
+	ctrlRand := rand.NewControlledRand(t)
+	blocks := builders.RandomizedBlockChain(5, ctrlRand)
+	source := memory.NewBlockPersistence(log.DefaultTestingLogger(t), metric.NewRegistry(), blocks...)
+
+	stream := &bytes.Buffer{}
+	_, err := export.Export(stream, source, builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID, 0, 0)
+	require.NoError(t, err)
+
+	target := memory.NewBlockPersistence(log.DefaultTestingLogger(t), metric.NewRegistry())
+	_, err = export.Import(bytes.NewReader(stream.Bytes()), target, builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID, false)
+	require.Error(t, err, "expected import to require the caller to trust the source of the stream, block proofs are not validated")
+
+	top, err := target.GetLastBlockHeight()
+	require.NoError(t, err)
+	require.EqualValues(t, 0, top)
+}
+
+// the in memory adapter appends to the slice of preloaded blocks
+func copyBlocks(blocks []*protocol.BlockPairContainer) []*protocol.BlockPairContainer {
+	return append([]*protocol.BlockPairContainer{}, blocks...)
+}
@@ -102,6 +102,22 @@ func (l *randomChainConfig) BlockStorageFileSystemMaxBlockSizeInBytes() uint32 {
 	return 1000000000
 }
 
+func (l *randomChainConfig) BlockStorageFileSystemMaxSegmentSizeInBytes() uint32 {
+	return 0
+}
+
+func (l *randomChainConfig) BlockStorageFileSystemPruningKeepBlocks() uint32 {
+	return 0
+}
+
+func (l *randomChainConfig) BlockStorageFileSystemPruningKeepWindow() time.Duration {
+	return 0
+}
+
+func (l *randomChainConfig) BlockStorageFileSystemCompression() bool {
+	return false
+}
+
 type adHocLogger string
 
 func (l *adHocLogger) Log(args ...interface{}) {
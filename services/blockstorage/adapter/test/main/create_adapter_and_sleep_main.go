@@ -41,3 +41,19 @@ func (l *localConfig) BlockStorageFileSystemDataDir() string {
 func (l *localConfig) BlockStorageFileSystemMaxBlockSizeInBytes() uint32 {
 	return 1000000000
 }
+
+func (l *localConfig) BlockStorageFileSystemMaxSegmentSizeInBytes() uint32 {
+	return 0
+}
+
+func (l *localConfig) BlockStorageFileSystemPruningKeepBlocks() uint32 {
+	return 0
+}
+
+func (l *localConfig) BlockStorageFileSystemPruningKeepWindow() time.Duration {
+	return 0
+}
+
+func (l *localConfig) BlockStorageFileSystemCompression() bool {
+	return false
+}
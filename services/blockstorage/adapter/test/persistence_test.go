@@ -46,6 +46,42 @@ func TestPersistenceAdapter_CanAccessBlocksOutOfOrder(t *testing.T) {
 	close2()
 }
 
+func TestPersistenceAdapter_ReadsFileMixingCompressedAndUncompressedBlocks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	ctrlRand := rand.NewControlledRand(t)
+	blocks := builders.RandomizedBlockChain(20, ctrlRand)
+
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+
+	adapter1, close1, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+	for _, block := range blocks[:10] {
+		_, err = adapter1.WriteNextBlock(block)
+		require.NoError(t, err)
+	}
+	close1()
+
+	conf.setCompression(true)
+	adapter2, close2, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+	for _, block := range blocks[10:] {
+		_, err = adapter2.WriteNextBlock(block)
+		require.NoError(t, err)
+	}
+	requireCanReadAllBlocksInRandomOrder(t, adapter2, blocks, ctrlRand)
+	close2()
+
+	conf.setCompression(false)
+	adapter3, close3, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+
+	requireCanReadAllBlocksInRandomOrder(t, adapter3, blocks, ctrlRand)
+	close3()
+}
+
 func requireCanReadAllBlocksInRandomOrder(t *testing.T, adapter adapter.BlockPersistence, blocks []*protocol.BlockPairContainer, ctrlRand *rand.ControlledRand) {
 	for _, i := range ctrlRand.Perm(len(blocks)) { // read each block out of order
 		h := primitives.BlockHeight(i + 1)
@@ -0,0 +1,133 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"github.com/orbs-network/orbs-network-go/services/blockstorage/adapter"
+	"github.com/orbs-network/orbs-network-go/test"
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-network-go/test/rand"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func requirePrunedBelow(t *testing.T, fsa adapter.BlockPersistence, blocks []*protocol.BlockPairContainer, firstBlockHeight primitives.BlockHeight) {
+	first, err := fsa.GetFirstBlockHeight()
+	require.NoError(t, err)
+	require.EqualValues(t, firstBlockHeight, first)
+
+	err = fsa.ScanBlocks(1, 1, func(primitives.BlockHeight, []*protocol.BlockPairContainer) bool { return true })
+	require.True(t, adapter.IsBlockPruned(err), "expected scanning pruned blocks to fail with a pruned block error, got %v", err)
+
+	for _, b := range blocks {
+		h := b.ResultsBlock.Header.BlockHeight()
+		txBlock, err := fsa.GetTransactionsBlock(h)
+		require.NoError(t, err, "expected headers of block %d to be kept", h)
+		require.EqualValues(t, b.TransactionsBlock.Header.Raw(), txBlock.Header.Raw())
+		rxBlock, err := fsa.GetResultsBlock(h)
+		require.NoError(t, err, "expected headers of block %d to be kept", h)
+		require.EqualValues(t, b.ResultsBlock.Header.Raw(), rxBlock.Header.Raw())
+		require.EqualValues(t, b.ResultsBlock.BlockProof.Raw(), rxBlock.BlockProof.Raw())
+
+		if h < firstBlockHeight {
+			continue
+		}
+		block, err := readOneBlock(fsa, h)
+		require.NoError(t, err)
+		test.RequireCmpEqual(t, b, block, "expected block %d to be kept", h)
+	}
+}
+
+func TestFileSystemBlockPersistence_PrunesSegmentsBeyondKeptBlocks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	ctrlRand := rand.NewControlledRand(t)
+
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+	conf.setMaxSegmentSize(1) // every segment holds a single block
+	conf.setPruning(3, 0)
+
+	blocks := writeRandomBlocksToFile(t, conf, 10, ctrlRand)
+
+	fsa, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+	defer closeAdapter()
+
+	requirePrunedBelow(t, fsa, blocks, 8)
+
+	for _, b := range blocks {
+		if len(b.ResultsBlock.TransactionReceipts) == 0 {
+			continue
+		}
+		block, _, err := fsa.GetBlockByTx(b.ResultsBlock.TransactionReceipts[0].Txhash())
+		require.NoError(t, err)
+		require.Equal(t, b.ResultsBlock.Header.BlockHeight() >= 8, block != nil, "expected only transactions of blocks kept to be found")
+	}
+}
+
+func TestFileSystemBlockPersistence_PrunesSegmentsOutsideKeptWindow(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+	conf.setMaxSegmentSize(1)
+	conf.setPruning(0, 3*time.Minute)
+
+	fsa, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+	defer closeAdapter()
+
+	now := time.Now()
+	var blocks []*protocol.BlockPairContainer
+	for h := 1; h <= 10; h++ {
+		block := builders.BlockPair().WithHeight(primitives.BlockHeight(h)).WithBlockCreated(now.Add(time.Duration(h) * time.Minute)).Build()
+		_, err = fsa.WriteNextBlock(block)
+		require.NoError(t, err)
+		blocks = append(blocks, block)
+	}
+
+	requirePrunedBelow(t, fsa, blocks, 7)
+}
+
+func TestFileSystemBlockPersistence_RebuildsIndexFileListingPrunedBlocks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	ctrlRand := rand.NewControlledRand(t)
+
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+	conf.setMaxSegmentSize(1)
+
+	blocks := writeRandomBlocksToFile(t, conf, 10, ctrlRand)
+	indexFile := filepath.Join(conf.BlockStorageFileSystemDataDir(), indexFilename)
+	unprunedIndex, err := ioutil.ReadFile(indexFile)
+	require.NoError(t, err)
+
+	conf.setPruning(3, 0)
+	_, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+	closeAdapter()
+
+	require.NoError(t, ioutil.WriteFile(indexFile, unprunedIndex, 0600)) // as if pruning crashed before rewriting the index file
+
+	fsa, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+	defer closeAdapter()
+
+	requirePrunedBelow(t, fsa, blocks, 8)
+}
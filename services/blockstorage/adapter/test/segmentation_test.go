@@ -0,0 +1,186 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"github.com/orbs-network/orbs-network-go/test"
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-network-go/test/rand"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileSystemBlockPersistence_RotatesToNewSegmentWhenSegmentIsFull(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	ctrlRand := rand.NewControlledRand(t)
+
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+	conf.setMaxSegmentSize(1) // every segment holds a single block
+
+	blocks := writeRandomBlocksToFile(t, conf, 5, ctrlRand)
+
+	segments, err := filepath.Glob(filepath.Join(conf.BlockStorageFileSystemDataDir(), blocksFilename+"*"))
+	require.NoError(t, err)
+	require.Len(t, segments, len(blocks), "expected a segment file per block")
+
+	fsa, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+	defer closeAdapter()
+
+	requireCanReadAllBlocksInRandomOrder(t, fsa, blocks, ctrlRand)
+}
+
+func TestFileSystemBlockPersistence_ScansBlocksAcrossSegments(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	ctrlRand := rand.NewControlledRand(t)
+
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+
+	blocks := builders.RandomizedBlockChain(30, ctrlRand)
+	conf.setMaxSegmentSize(64 * 1024)
+
+	fsa, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+	for _, block := range blocks[:20] {
+		_, err = fsa.WriteNextBlock(block)
+		require.NoError(t, err)
+	}
+	closeAdapter()
+
+	segments, err := filepath.Glob(filepath.Join(conf.BlockStorageFileSystemDataDir(), blocksFilename+"*"))
+	require.NoError(t, err)
+	require.True(t, len(segments) > 1, "expected blocks to be written to more than one segment")
+
+	fsa, closeAdapter, err = NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+	defer closeAdapter()
+
+	for _, block := range blocks[20:] { // resume writing to the last segment after reopening
+		_, err = fsa.WriteNextBlock(block)
+		require.NoError(t, err)
+	}
+
+	var scanned []*protocol.BlockPairContainer
+	err = fsa.ScanBlocks(1, 7, func(first primitives.BlockHeight, page []*protocol.BlockPairContainer) (wantsMore bool) {
+		require.EqualValues(t, len(scanned)+1, first, "expected pages to follow each other")
+		scanned = append(scanned, page...)
+		return true
+	})
+	require.NoError(t, err)
+	require.Len(t, scanned, len(blocks))
+	for i := range blocks {
+		test.RequireCmpEqual(t, blocks[i], scanned[i], "expected block %d to be scanned", i+1)
+	}
+
+	for _, b := range blocks {
+		if len(b.ResultsBlock.TransactionReceipts) == 0 {
+			continue
+		}
+		block, txIndex, err := fsa.GetBlockByTx(b.ResultsBlock.TransactionReceipts[0].Txhash())
+		require.NoError(t, err)
+		require.NotNil(t, block, "expected to find transaction of block %d", b.ResultsBlock.Header.BlockHeight())
+		require.EqualValues(t, b.ResultsBlock.Header.BlockHeight(), block.ResultsBlock.Header.BlockHeight())
+		require.EqualValues(t, 0, txIndex)
+	}
+}
+
+func TestFileSystemBlockPersistence_TakesExclusiveLockOnFirstSegment(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	ctrlRand := rand.NewControlledRand(t)
+
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+	conf.setMaxSegmentSize(1)
+
+	fsa, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+	defer closeAdapter()
+
+	for _, block := range builders.RandomizedBlockChain(3, ctrlRand) { // writes now go to the third segment
+		_, err = fsa.WriteNextBlock(block)
+		require.NoError(t, err)
+	}
+
+	_, _, err = NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.Error(t, err, "expected second adapter to fail locking the blocks files")
+}
+
+func TestFileSystemBlockPersistence_FailsOnCorruptSegmentFollowedByAnotherSegment(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	ctrlRand := rand.NewControlledRand(t)
+
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+	conf.setMaxSegmentSize(1)
+
+	writeRandomBlocksToFile(t, conf, 3, ctrlRand)
+
+	truncateFile(t, conf, getFileSize(t, conf)-1) // cut the single block of the first segment
+
+	_, _, err := NewFilesystemAdapterDriver(log.DefaultTestingLoggerAllowingErrors(t, "built index, found and ignoring invalid block records"), conf)
+	require.Error(t, err, "expected a gap between segments to fail opening the blocks files")
+}
+
+func TestFileSystemBlockPersistence_ReadsSegmentsMovedBehindSymlinks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	ctrlRand := rand.NewControlledRand(t)
+
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+	conf.setMaxSegmentSize(1)
+
+	blocks := writeRandomBlocksToFile(t, conf, 3, ctrlRand)
+
+	archive := newTempFileConfig()
+	defer archive.cleanDir()
+
+	segment := filepath.Join(conf.BlockStorageFileSystemDataDir(), blocksFilename+".000001")
+	archived := filepath.Join(archive.BlockStorageFileSystemDataDir(), blocksFilename+".000001")
+	require.NoError(t, os.Rename(segment, archived))
+	require.NoError(t, os.Symlink(archived, segment))
+
+	fsa, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+	defer closeAdapter()
+
+	requireCanReadAllBlocksInRandomOrder(t, fsa, blocks, ctrlRand)
+}
+
+func TestFileSystemBlockPersistence_FailsOnMissingMiddleSegment(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	ctrlRand := rand.NewControlledRand(t)
+
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+	conf.setMaxSegmentSize(1)
+
+	writeRandomBlocksToFile(t, conf, 4, ctrlRand)
+
+	require.NoError(t, os.Remove(filepath.Join(conf.BlockStorageFileSystemDataDir(), blocksFilename+".000002")))
+
+	_, _, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.Error(t, err, "expected a missing segment followed by another segment to fail opening the blocks files")
+}
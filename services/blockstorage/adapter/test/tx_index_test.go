@@ -0,0 +1,134 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"github.com/orbs-network/orbs-network-go/services/blockstorage/adapter"
+	"github.com/orbs-network/orbs-network-go/test/rand"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const txIndexFilename = "tx_index"
+const txIndexHeaderSize = 20
+
+func getTxIndexFileSize(t *testing.T, conf *localConfig) int64 {
+	info, err := os.Stat(filepath.Join(conf.BlockStorageFileSystemDataDir(), txIndexFilename))
+	require.NoError(t, err)
+	return info.Size()
+}
+
+func requireCanFindAllTransactions(t *testing.T, fsa adapter.BlockPersistence, blocks []*protocol.BlockPairContainer) {
+	for _, b := range blocks {
+		for i, receipt := range b.ResultsBlock.TransactionReceipts {
+			block, txIndex, err := fsa.GetBlockByTx(receipt.Txhash())
+			require.NoError(t, err)
+			require.NotNil(t, block, "expected to find transaction %d of block %d", i, b.ResultsBlock.Header.BlockHeight())
+			require.EqualValues(t, b.ResultsBlock.Header.BlockHeight(), block.ResultsBlock.Header.BlockHeight())
+			require.EqualValues(t, i, txIndex)
+		}
+	}
+}
+
+func TestFileSystemBlockPersistence_RestoresTxIndexFromTxIndexFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	ctrlRand := rand.NewControlledRand(t)
+
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+
+	blocks := writeRandomBlocksToFile(t, conf, 10, ctrlRand)
+	txIndexSize := getTxIndexFileSize(t, conf)
+
+	fsa, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+
+	requireCanFindAllTransactions(t, fsa, blocks)
+	closeAdapter()
+
+	require.EqualValues(t, txIndexSize, getTxIndexFileSize(t, conf), "expected tx index file to be used as is")
+}
+
+func TestFileSystemBlockPersistence_IndexesTransactionsMissingFromTxIndexFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	ctrlRand := rand.NewControlledRand(t)
+
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+
+	blocks := writeRandomBlocksToFile(t, conf, 10, ctrlRand)
+	originalTxIndexSize := getTxIndexFileSize(t, conf)
+
+	require.NoError(t, os.Truncate(filepath.Join(conf.BlockStorageFileSystemDataDir(), txIndexFilename), originalTxIndexSize/2)) // lose some records and tear another one
+
+	fsa, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+
+	requireCanFindAllTransactions(t, fsa, blocks)
+	closeAdapter()
+
+	require.EqualValues(t, originalTxIndexSize, getTxIndexFileSize(t, conf), "expected missing records to be appended to tx index file")
+}
+
+func TestFileSystemBlockPersistence_RecreatesCorruptTxIndexFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	ctrlRand := rand.NewControlledRand(t)
+
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+
+	blocks := writeRandomBlocksToFile(t, conf, 5, ctrlRand)
+	originalTxIndexSize := getTxIndexFileSize(t, conf)
+
+	txIndexFile, err := os.OpenFile(filepath.Join(conf.BlockStorageFileSystemDataDir(), txIndexFilename), os.O_RDWR, 0666)
+	require.NoError(t, err)
+	_, err = txIndexFile.WriteAt([]byte{0xFF}, txIndexHeaderSize+1) // break the first record
+	require.NoError(t, err)
+	require.NoError(t, txIndexFile.Close())
+
+	fsa, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+
+	requireCanFindAllTransactions(t, fsa, blocks)
+	closeAdapter()
+
+	require.EqualValues(t, originalTxIndexSize, getTxIndexFileSize(t, conf), "expected tx index file to be rebuilt")
+}
+
+func TestFileSystemBlockPersistence_RecreatesTxIndexFileWithOversizedRecord(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	ctrlRand := rand.NewControlledRand(t)
+
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+
+	blocks := writeRandomBlocksToFile(t, conf, 5, ctrlRand)
+
+	txIndexFile, err := os.OpenFile(filepath.Join(conf.BlockStorageFileSystemDataDir(), txIndexFilename), os.O_RDWR, 0666)
+	require.NoError(t, err)
+	_, err = txIndexFile.WriteAt([]byte{0xFF, 0xFF, 0xFF, 0xFF}, txIndexHeaderSize+8) // number of transactions of the first record
+	require.NoError(t, err)
+	require.NoError(t, txIndexFile.Close())
+
+	fsa, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err, "expected a record claiming more transactions than a block holds to be rejected before reading it")
+
+	requireCanFindAllTransactions(t, fsa, blocks)
+	closeAdapter()
+}
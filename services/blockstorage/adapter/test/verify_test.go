@@ -0,0 +1,141 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"github.com/orbs-network/orbs-network-go/services/blockstorage/adapter"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage/adapter/filesystem"
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-network-go/test/rand"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+)
+
+func verifyOptions(conf *localConfig) *filesystem.VerifyOptions {
+	return &filesystem.VerifyOptions{
+		MaxBlockSizeInBytes: conf.BlockStorageFileSystemMaxBlockSizeInBytes(),
+		VirtualChainId:      conf.VirtualChainId(),
+	}
+}
+
+func TestVerifyBlocksFile_ReportsValidFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	ctrlRand := rand.NewControlledRand(t)
+
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+	conf.setVirtualChainId(builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID)
+
+	writeRandomBlocksToFile(t, conf, 10, ctrlRand)
+
+	report, err := filesystem.VerifyBlocksFile(filepath.Join(conf.BlockStorageFileSystemDataDir(), blocksFilename), verifyOptions(conf))
+	require.NoError(t, err)
+	require.NoError(t, report.Err)
+	require.EqualValues(t, 10, report.NumBlocks)
+	require.EqualValues(t, 10, report.LastGoodBlockHeight)
+	require.EqualValues(t, getFileSize(t, conf), report.ValidBytes)
+}
+
+func TestVerifyBlocksFile_ReportsFirstCorruptBlockAndTruncatesFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	ctrlRand := rand.NewControlledRand(t)
+
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+	conf.setVirtualChainId(builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID)
+
+	blocks := writeRandomBlocksToFile(t, conf, 10, ctrlRand)
+	filename := filepath.Join(conf.BlockStorageFileSystemDataDir(), blocksFilename)
+
+	report, err := filesystem.VerifyBlocksFile(filename, verifyOptions(conf))
+	require.NoError(t, err)
+	flipBitInFile(t, conf, report.ValidBytes*2/3, 0x08)
+
+	report, err = filesystem.VerifyBlocksFile(filename, verifyOptions(conf))
+	require.NoError(t, err)
+	require.Error(t, report.Err, "expected a corrupt block to be found")
+	badHeight := report.FirstBadBlockHeight()
+	require.True(t, badHeight > 1 && badHeight <= 10, "expected a block after the first one to be corrupt")
+
+	require.NoError(t, filesystem.TruncateBlocksFile(filename, report))
+
+	report, err = filesystem.VerifyBlocksFile(filename, verifyOptions(conf))
+	require.NoError(t, err)
+	require.NoError(t, report.Err, "expected only good blocks to remain after truncating")
+	require.EqualValues(t, badHeight-1, report.LastGoodBlockHeight)
+
+	fsa, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+	defer closeAdapter()
+	requireCanReadAllBlocksInRandomOrder(t, fsa, blocks[:badHeight-1], ctrlRand)
+}
+
+func TestVerifyBlocksFile_ReportsBrokenHashChain(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	ctrlRand := rand.NewControlledRand(t)
+
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+	conf.setVirtualChainId(builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID)
+
+	blocks := builders.RandomizedBlockChain(3, ctrlRand)
+	blocks = append(blocks, builders.RandomizedBlock(4, ctrlRand, blocks[1])) // points at block 2 rather than block 3
+
+	fsa, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+	writeBlocks(t, fsa, blocks)
+	closeAdapter()
+
+	report, err := filesystem.VerifyBlocksFile(filepath.Join(conf.BlockStorageFileSystemDataDir(), blocksFilename), verifyOptions(conf))
+	require.NoError(t, err)
+	require.Error(t, report.Err)
+	require.EqualValues(t, 4, report.FirstBadBlockHeight())
+}
+
+func TestVerifyBlocksFile_TruncatesOnlyLastSegmentOfDataDirNotInUse(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	ctrlRand := rand.NewControlledRand(t)
+
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+	conf.setVirtualChainId(builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID)
+	conf.setMaxSegmentSize(1) // every segment holds a single block
+
+	writeRandomBlocksToFile(t, conf, 3, ctrlRand)
+	firstSegment := filepath.Join(conf.BlockStorageFileSystemDataDir(), blocksFilename)
+	lastSegment := filepath.Join(conf.BlockStorageFileSystemDataDir(), blocksFilename+".000002")
+
+	report, err := filesystem.VerifyBlocksFile(firstSegment, verifyOptions(conf))
+	require.NoError(t, err)
+	require.Error(t, filesystem.TruncateBlocksFile(firstSegment, report), "truncating a segment followed by later ones should leave a gap in block heights")
+
+	_, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+	defer closeAdapter()
+
+	report, err = filesystem.VerifyBlocksFile(lastSegment, verifyOptions(conf))
+	require.NoError(t, err)
+	require.Error(t, filesystem.TruncateBlocksFile(lastSegment, report), "truncating a segment of a data dir in use by the node should fail")
+}
+
+func writeBlocks(t *testing.T, fsa adapter.BlockPersistence, blocks []*protocol.BlockPairContainer) {
+	for _, block := range blocks {
+		_, err := fsa.WriteNextBlock(block)
+		require.NoError(t, err)
+	}
+}
@@ -20,12 +20,14 @@ import (
 type TamperingInMemoryBlockPersistence interface {
 	adapter.BlockPersistence
 	FailNextBlocks()
+	SimulatePruning(firstBlockHeight primitives.BlockHeight)
 	WaitForTransaction(ctx context.Context, txHash primitives.Sha256) primitives.BlockHeight
 }
 
 type tamperingBlockPersistence struct {
 	memory.InMemoryBlockPersistence
 	failNextBlocks bool
+	prunedBelow    primitives.BlockHeight
 
 	txTracker *txTracker
 }
@@ -46,6 +48,25 @@ func (bp *tamperingBlockPersistence) FailNextBlocks() {
 	bp.failNextBlocks = true
 }
 
+// blocks below firstBlockHeight are reported as pruned, as a pruning filesystem adapter would
+func (bp *tamperingBlockPersistence) SimulatePruning(firstBlockHeight primitives.BlockHeight) {
+	bp.prunedBelow = firstBlockHeight
+}
+
+func (bp *tamperingBlockPersistence) GetFirstBlockHeight() (primitives.BlockHeight, error) {
+	if bp.prunedBelow > 1 {
+		return bp.prunedBelow, nil
+	}
+	return bp.InMemoryBlockPersistence.GetFirstBlockHeight()
+}
+
+func (bp *tamperingBlockPersistence) ScanBlocks(from primitives.BlockHeight, pageSize uint8, f adapter.CursorFunc) error {
+	if from > 0 && from < bp.prunedBelow {
+		return &adapter.BlockPrunedError{Height: from, FirstBlockHeight: bp.prunedBelow}
+	}
+	return bp.InMemoryBlockPersistence.ScanBlocks(from, pageSize, f)
+}
+
 func (bp *tamperingBlockPersistence) WriteNextBlock(blockPair *protocol.BlockPairContainer) (bool, error) {
 	if bp.failNextBlocks {
 		return false, errors.New("could not write a block")
@@ -46,7 +46,18 @@ func (s *service) sourceHandleBlockAvailabilityRequest(ctx context.Context, mess
 		return nil
 	}
 
-	firstAvailableBlockHeight := primitives.BlockHeight(1)
+	firstAvailableBlockHeight, err := s.persistence.GetFirstBlockHeight()
+	if err != nil {
+		return err
+	}
+
+	if message.SignedBatchRange.FirstBlockHeight() < firstAvailableBlockHeight {
+		logger.Info("cannot serve availability request, requested blocks were pruned",
+			log.Stringable("petitioner", message.Sender.SenderNodeAddress()),
+			log.Uint64("first-available-block-height", uint64(firstAvailableBlockHeight)))
+		return nil
+	}
+
 	blockType := message.SignedBatchRange.BlockType()
 
 	response := &gossiptopics.BlockAvailabilityResponseInput{
@@ -0,0 +1,87 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package blockstorage
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/crypto/bloom"
+	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage/adapter"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+)
+
+// Scans the blocks from one height to another in order, skipping those whose block filter excludes the query when the
+// persistence adapter keeps block filters. Blocks without a filter are read and searched
+func (s *service) FindEvents(ctx context.Context, fromHeight primitives.BlockHeight, toHeight primitives.BlockHeight, query *bloom.EventsQuery) ([]*bloom.FoundEvent, error) {
+	if query.EventName == "" {
+		return nil, errors.New("event name is required")
+	}
+	if fromHeight == 0 || fromHeight > toHeight {
+		return nil, errors.Errorf("invalid block range %d to %d", fromHeight, toHeight)
+	}
+	firstHeight, err := s.persistence.GetFirstBlockHeight()
+	if err != nil {
+		return nil, err
+	}
+	if fromHeight < firstHeight {
+		return nil, &adapter.BlockPrunedError{Height: fromHeight, FirstBlockHeight: firstHeight}
+	}
+	lastHeight, err := s.persistence.GetLastBlockHeight()
+	if err != nil {
+		return nil, err
+	}
+	if toHeight > lastHeight {
+		return nil, errors.Errorf("block height %d is not committed, last committed block is %d", toHeight, lastHeight)
+	}
+
+	filters, _ := s.persistence.(adapter.BlockFilterPersistence)
+	var found []*bloom.FoundEvent
+	for height := fromHeight; height <= toHeight; height++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if filters != nil && s.blockFilterExcludes(filters, height, query) {
+			continue
+		}
+
+		block, err := s.readBlockPair(height)
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, query.FindInBlock(block)...)
+	}
+	return found, nil
+}
+
+func (s *service) blockFilterExcludes(filters adapter.BlockFilterPersistence, height primitives.BlockHeight, query *bloom.EventsQuery) bool {
+	filter, err := filters.GetBlockFilter(height)
+	if err != nil {
+		s.logger.Info("no block filter, searching the block", logfields.BlockHeight(height), log.Error(err))
+		return false
+	}
+	return query.ExcludedBy(filter)
+}
+
+func (s *service) readBlockPair(height primitives.BlockHeight) (*protocol.BlockPairContainer, error) {
+	var block *protocol.BlockPairContainer
+	err := s.persistence.ScanBlocks(height, 1, func(first primitives.BlockHeight, page []*protocol.BlockPairContainer) bool {
+		if len(page) > 0 && first == height {
+			block = page[0]
+		}
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, errors.Errorf("could not read block %d", height)
+	}
+	return block, nil
+}
@@ -22,27 +22,69 @@ func (s *service) GenerateReceiptProof(ctx context.Context, input *services.Gene
 		return nil, err
 	}
 
-	for i, txr := range block.TransactionReceipts {
-		if txr.Txhash().Equal(input.Txhash) {
-
-			proof, err := generateProof(block.TransactionReceipts, i)
-			if err != nil {
-				return nil, err
-			}
-
-			result := &services.GenerateReceiptProofOutput{
-				Proof: (&protocol.ReceiptProofBuilder{
-					Header:       protocol.ResultsBlockHeaderBuilderFromRaw(block.Header.Raw()),
-					BlockProof:   protocol.ResultsBlockProofBuilderFromRaw(block.BlockProof.Raw()),
-					ReceiptProof: proof,
-				}).Build(),
-			}
-			return result, nil
+	txIndex := indexOfReceipt(block.TransactionReceipts, input.Txhash)
+	if txIndex < 0 {
+		return nil, errors.Errorf("could not find transaction inside block %d, txHash %x", input.BlockHeight, input.Txhash)
+	}
+
+	proof, err := generateProof(block.TransactionReceipts, txIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	return &services.GenerateReceiptProofOutput{
+		Proof: (&protocol.ReceiptProofBuilder{
+			Header:       protocol.ResultsBlockHeaderBuilderFromRaw(block.Header.Raw()),
+			BlockProof:   protocol.ResultsBlockProofBuilderFromRaw(block.BlockProof.Raw()),
+			ReceiptProof: proof,
+		}).Build(),
+	}, nil
+}
+
+// the same transaction hash may appear in more than one block, so the receipt is looked for in the requested block only
+func indexOfReceipt(receipts []*protocol.TransactionReceipt, txHash primitives.Sha256) int {
+	for i, receipt := range receipts {
+		if receipt.Txhash().Equal(txHash) {
+			return i
 		}
 	}
+	return -1
+}
 
-	return nil, errors.Errorf("could not find transaction inside block %x", input.Txhash)
+// Proves the receipts of all the given transactions of the block at the given height at once, building the block's receipts tree a single time.
+// The proof's indices are those of the receipts in the returned block
+func (s *service) GenerateReceiptsProof(ctx context.Context, blockHeight primitives.BlockHeight, txHashes []primitives.Sha256) (*protocol.ResultsBlockContainer, *merkle.OrderedTreeMultiProof, error) {
+	lastHeight, err := s.persistence.GetLastBlockHeight()
+	if err != nil {
+		return nil, nil, err
+	}
+	if blockHeight == 0 || blockHeight > lastHeight {
+		return nil, nil, errors.Errorf("block height %d is not committed, last committed block is %d", blockHeight, lastHeight)
+	}
+
+	block, err := s.persistence.GetResultsBlock(blockHeight)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	indexByTxHash := make(map[string]int, len(block.TransactionReceipts))
+	for i, receipt := range block.TransactionReceipts {
+		indexByTxHash[string(receipt.Txhash())] = i
+	}
+	indices := make([]int, 0, len(txHashes))
+	for _, txHash := range txHashes {
+		index, ok := indexByTxHash[string(txHash)]
+		if !ok {
+			return nil, nil, errors.Errorf("could not find transaction inside block %d, txHash %x", blockHeight, txHash)
+		}
+		indices = append(indices, index)
+	}
 
+	proof, err := merkle.GetOrderedTreeMultiProof(digest.CalcReceiptHashes(block.TransactionReceipts), indices)
+	if err != nil {
+		return nil, nil, err
+	}
+	return block, proof, nil
 }
 
 func generateProof(receipts []*protocol.TransactionReceipt, index int) (primitives.MerkleTreeProof, error) {
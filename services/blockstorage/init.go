@@ -9,6 +9,8 @@ package blockstorage
 import (
 	"context"
 	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/crypto/bloom"
+	"github.com/orbs-network/orbs-network-go/crypto/merkle"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
 	"github.com/orbs-network/orbs-network-go/services/blockstorage/adapter"
 	"github.com/orbs-network/orbs-network-go/services/blockstorage/internodesync"
@@ -28,6 +30,12 @@ const (
 
 var LogTag = log.Service("block-storage")
 
+// Searches of committed blocks served besides services.BlockStorage, for the public api
+type BlockQueries interface {
+	GenerateReceiptsProof(ctx context.Context, blockHeight primitives.BlockHeight, txHashes []primitives.Sha256) (*protocol.ResultsBlockContainer, *merkle.OrderedTreeMultiProof, error)
+	FindEvents(ctx context.Context, fromHeight primitives.BlockHeight, toHeight primitives.BlockHeight, query *bloom.EventsQuery) ([]*bloom.FoundEvent, error)
+}
+
 type service struct {
 	persistence  adapter.BlockPersistence
 	stateStorage services.StateStorage
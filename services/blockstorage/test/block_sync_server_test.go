@@ -75,6 +75,49 @@ func TestSourceDoesNotRespondToAvailabilityRequestIfSourceIsBehindPetitioner(t *
 	})
 }
 
+func TestSourceAdvertisesOnlyBlocksNotPrunedInAvailabilityResponse(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		harness := newBlockStorageHarness(t).withSyncBroadcast(1).start(ctx)
+		harness.commitSomeBlocks(ctx, 10)
+		harness.storageAdapter.SimulatePruning(5)
+
+		harness.gossip.When("SendBlockAvailabilityResponse", mock.Any, mock.AnyIf("first block height is the first block not pruned", func(i interface{}) bool {
+			response := i.(*gossiptopics.BlockAvailabilityResponseInput)
+			return response.Message.SignedBatchRange.FirstBlockHeight() == 5
+		})).Return(nil, nil).Times(1)
+
+		msg := builders.BlockAvailabilityRequestInput().
+			WithFirstBlockHeight(7).
+			WithLastCommittedBlockHeight(primitives.BlockHeight(6)).
+			WithLastBlockHeight(primitives.BlockHeight(8)).
+			Build()
+		_, err := harness.blockStorage.HandleBlockAvailabilityRequest(ctx, msg)
+
+		require.NoError(t, err, "expecting a happy flow")
+		harness.verifyMocks(t, 1)
+	})
+}
+
+func TestSourceDoesNotRespondToAvailabilityRequestForPrunedBlocks(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		harness := newBlockStorageHarness(t).withSyncBroadcast(1).start(ctx)
+		harness.commitSomeBlocks(ctx, 10)
+		harness.storageAdapter.SimulatePruning(5)
+
+		harness.gossip.Never("SendBlockAvailabilityResponse", mock.Any, mock.Any)
+
+		msg := builders.BlockAvailabilityRequestInput().
+			WithFirstBlockHeight(3).
+			WithLastCommittedBlockHeight(primitives.BlockHeight(2)).
+			WithLastBlockHeight(primitives.BlockHeight(4)).
+			Build()
+		_, err := harness.blockStorage.HandleBlockAvailabilityRequest(ctx, msg)
+
+		require.NoError(t, err, "expecting a happy flow (without sending the response)")
+		harness.verifyMocks(t, 1)
+	})
+}
+
 func TestSourceIgnoresSendBlockAvailabilityRequestsIfFailedToRespond(t *testing.T) {
 	test.WithContext(func(ctx context.Context) {
 		harness := newBlockStorageHarness(t).withSyncBroadcast(1).start(ctx)
@@ -0,0 +1,149 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/crypto/bloom"
+	"github.com/orbs-network/orbs-network-go/crypto/digest"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage/adapter"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage/adapter/testkit"
+	"github.com/orbs-network/orbs-network-go/test"
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+)
+
+// computes block filters as the filesystem adapter keeps them, and records the blocks read
+type filteringPersistence struct {
+	testkit.TamperingInMemoryBlockPersistence
+	mutex   sync.Mutex
+	scanned []primitives.BlockHeight
+}
+
+func (p *filteringPersistence) GetBlockFilter(height primitives.BlockHeight) (*bloom.BlockFilter, error) {
+	var filter *bloom.BlockFilter
+	err := p.TamperingInMemoryBlockPersistence.ScanBlocks(height, 1, func(first primitives.BlockHeight, page []*protocol.BlockPairContainer) bool {
+		filter = bloom.CalcBlockFilter(page[0])
+		return false
+	})
+	return filter, err
+}
+
+func (p *filteringPersistence) ScanBlocks(from primitives.BlockHeight, pageSize uint8, f adapter.CursorFunc) error {
+	p.mutex.Lock()
+	p.scanned = append(p.scanned, from)
+	p.mutex.Unlock()
+	return p.TamperingInMemoryBlockPersistence.ScanBlocks(from, pageSize, f)
+}
+
+func (p *filteringPersistence) scannedBlocks() []primitives.BlockHeight {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.scanned
+}
+
+func (d *harness) withBlockFilters() *filteringPersistence {
+	filtering := &filteringPersistence{TamperingInMemoryBlockPersistence: d.storageAdapter}
+	d.storageAdapter = filtering
+	return filtering
+}
+
+func blockWithEvent(height primitives.BlockHeight, eventName primitives.EventName, address primitives.ClientAddress) *protocol.BlockPairContainer {
+	tx := builders.TransferTransaction().Build()
+	receipt := builders.TransactionReceipt().WithTransaction(tx.Transaction()).WithEvents(&protocol.EventBuilder{
+		ContractName:        "MyToken",
+		EventName:           eventName,
+		OutputArgumentArray: builders.PackedArgumentArrayEncode([]byte(address), uint64(10)),
+	}).Build()
+	return builders.BlockPair().WithHeight(height).WithTransaction(tx).WithReceipt(receipt).Build()
+}
+
+func commitBlocksWithEvents(ctx context.Context, t *testing.T, harness *harness) []*protocol.BlockPairContainer {
+	blocks := []*protocol.BlockPairContainer{
+		blockWithEvent(1, "Transfer", builders.ClientAddressForEd25519SignerForTests(5)),
+		blockWithEvent(2, "Approval", builders.ClientAddressForEd25519SignerForTests(5)),
+		blockWithEvent(3, "Transfer", builders.ClientAddressForEd25519SignerForTests(6)),
+		blockWithEvent(4, "Transfer", builders.ClientAddressForEd25519SignerForTests(5)),
+	}
+	for _, block := range blocks {
+		_, err := harness.commitBlock(ctx, block)
+		require.NoError(t, err)
+	}
+	return blocks
+}
+
+func TestFindEvents_MatchesEventAndAddress(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		harness := newBlockStorageHarness(t).withSyncBroadcast(1).start(ctx)
+		blocks := commitBlocksWithEvents(ctx, t, harness)
+
+		found, err := harness.blockStorage.(blockstorage.BlockQueries).FindEvents(ctx, 1, 4, &bloom.EventsQuery{
+			ContractName: "MyToken",
+			EventName:    "Transfer",
+			Address:      builders.ClientAddressForEd25519SignerForTests(5),
+		})
+		require.NoError(t, err)
+
+		require.Len(t, found, 2, "expected the transfers to the address only")
+		require.EqualValues(t, 1, found[0].BlockHeight)
+		require.EqualValues(t, 4, found[1].BlockHeight)
+		require.Equal(t, blocks[3].ResultsBlock.TransactionReceipts[0].Txhash(), found[1].Txhash, "got wrong txhash")
+		require.EqualValues(t, "Transfer", found[1].Event.EventName(), "got wrong event")
+	})
+}
+
+func TestFindEvents_MatchesSignerAddress(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		harness := newBlockStorageHarness(t).withSyncBroadcast(1).start(ctx)
+		blocks := commitBlocksWithEvents(ctx, t, harness)
+
+		signer, err := digest.CalcClientAddressOfEd25519Signer(blocks[2].TransactionsBlock.SignedTransactions[0].Transaction().Signer())
+		require.NoError(t, err)
+
+		found, err := harness.blockStorage.(blockstorage.BlockQueries).FindEvents(ctx, 3, 3, &bloom.EventsQuery{
+			EventName: "Transfer",
+			Address:   signer,
+		})
+		require.NoError(t, err)
+		require.Len(t, found, 1, "expected the transfer signed by the address")
+	})
+}
+
+func TestFindEvents_SkipsBlocksExcludedByBlockFilter(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		harness := newBlockStorageHarness(t).withSyncBroadcast(1)
+		persistence := harness.withBlockFilters()
+		harness.start(ctx)
+		commitBlocksWithEvents(ctx, t, harness)
+		scannedBeforeQuery := len(persistence.scannedBlocks())
+
+		found, err := harness.blockStorage.(blockstorage.BlockQueries).FindEvents(ctx, 1, 4, &bloom.EventsQuery{
+			EventName: "Approval",
+		})
+		require.NoError(t, err)
+
+		require.Len(t, found, 1, "expected the single approval")
+		require.Equal(t, []primitives.BlockHeight{2}, persistence.scannedBlocks()[scannedBeforeQuery:], "expected only the block holding the event to be read")
+	})
+}
+
+func TestFindEvents_RejectsUncommittedRange(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		harness := newBlockStorageHarness(t).withSyncBroadcast(1).start(ctx)
+		commitBlocksWithEvents(ctx, t, harness)
+
+		_, err := harness.blockStorage.(blockstorage.BlockQueries).FindEvents(ctx, 3, 5, &bloom.EventsQuery{
+			EventName: "Transfer",
+		})
+		require.Error(t, err, "expected blocks above the last committed to be rejected")
+	})
+}
@@ -11,6 +11,8 @@ import (
 	"context"
 	"github.com/orbs-network/orbs-network-go/crypto/digest"
 	"github.com/orbs-network/orbs-network-go/crypto/hash"
+	"github.com/orbs-network/orbs-network-go/crypto/merkle"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage"
 	"github.com/orbs-network/orbs-network-go/test"
 	"github.com/orbs-network/orbs-network-go/test/builders"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
@@ -77,6 +79,76 @@ func TestGenerateProof_WrongTxHash(t *testing.T) {
 	})
 }
 
+func TestGenerateProof_TxHashAlsoInEarlierBlock(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		harness := newBlockStorageHarness(t).withSyncBroadcast(1).start(ctx)
+
+		block1 := builders.BlockPair().WithHeight(1).WithTransactions(2).WithReceiptsForTransactions().Build()
+		_, err := harness.commitBlock(ctx, block1)
+		require.NoError(t, err)
+		repeatedTx := block1.TransactionsBlock.SignedTransactions[1]
+		block2 := builders.BlockPair().WithHeight(2).WithPrevBlock(block1).WithTransaction(repeatedTx).WithReceiptsForTransactions().Build()
+		_, err = harness.commitBlock(ctx, block2)
+		require.NoError(t, err)
+
+		proof, err := harness.blockStorage.GenerateReceiptProof(ctx, &services.GenerateReceiptProofInput{
+			Txhash:      digest.CalcTxHash(repeatedTx.Transaction()),
+			BlockHeight: 2,
+		})
+		require.NoError(t, err, "receipt should be found in the requested block even when the tx hash appears in an earlier one")
+		require.EqualValues(t, 2, proof.Proof.Header().BlockHeight(), "wrong height")
+	})
+}
+
+func TestGenerateReceiptsProof(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		harness := newBlockStorageHarness(t).withSyncBroadcast(1).start(ctx)
+
+		blockHeight := primitives.BlockHeight(1)
+		block := builders.BlockPair().WithHeight(blockHeight).WithTransactions(7).WithReceiptsForTransactions().Build()
+		_, err := harness.commitBlock(ctx, block)
+		require.NoError(t, err)
+
+		receipts := block.ResultsBlock.TransactionReceipts
+		resultsBlock, proof, err := harness.blockStorage.(blockstorage.BlockQueries).GenerateReceiptsProof(ctx, blockHeight,
+			[]primitives.Sha256{receipts[5].Txhash(), receipts[1].Txhash(), receipts[2].Txhash()})
+		require.NoError(t, err)
+
+		require.EqualValues(t, blockHeight, resultsBlock.Header.BlockHeight(), "wrong height")
+		require.Equal(t, []int{1, 2, 5}, proof.Indices, "proof should be of the receipts' indices in the block")
+		require.EqualValues(t, len(receipts), proof.NumValues, "proof should be of all receipts in the block")
+
+		root := merkle.CalculateOrderedTreeRoot(digest.CalcReceiptHashes(receipts))
+		proven := []primitives.Sha256{digest.CalcReceiptHash(receipts[1]), digest.CalcReceiptHash(receipts[2]), digest.CalcReceiptHash(receipts[5])}
+		require.NoError(t, merkle.VerifyMultiProof(proven, proof, root), "proof should verify against the receipts root")
+	})
+}
+
+func TestGenerateReceiptsProof_WrongTxHash(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		harness := newBlockStorageHarness(t).withSyncBroadcast(1).start(ctx)
+
+		blockHeight := primitives.BlockHeight(1)
+		block := builders.BlockPair().WithHeight(blockHeight).WithTransactions(3).WithReceiptsForTransactions().Build()
+		_, err := harness.commitBlock(ctx, block)
+		require.NoError(t, err)
+
+		fakeTxHash := hash.CalcSha256([]byte("any text"))
+		_, proof, err := harness.blockStorage.(blockstorage.BlockQueries).GenerateReceiptsProof(ctx, blockHeight,
+			[]primitives.Sha256{block.ResultsBlock.TransactionReceipts[0].Txhash(), fakeTxHash})
+
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "could not find transaction inside block", "expected not found err")
+		require.Nil(t, proof, "proof should have been nil")
+
+		_, proof, err = harness.blockStorage.(blockstorage.BlockQueries).GenerateReceiptsProof(ctx, blockHeight+1,
+			[]primitives.Sha256{block.ResultsBlock.TransactionReceipts[0].Txhash()})
+
+		require.Error(t, err, "block which is not committed should fail")
+		require.Nil(t, proof, "proof should have been nil")
+	})
+}
+
 func hashTwoRecpeits(list []*protocol.TransactionReceipt, index0, index1 int) primitives.Sha256 {
 	l0 := digest.CalcReceiptHash(list[index0])
 	l1 := digest.CalcReceiptHash(list[index1])
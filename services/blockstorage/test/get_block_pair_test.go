@@ -8,6 +8,7 @@ package test
 
 import (
 	"context"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage/adapter"
 	"github.com/orbs-network/orbs-network-go/test"
 	"github.com/orbs-network/orbs-network-go/test/builders"
 	"github.com/orbs-network/orbs-spec/types/go/protocol"
@@ -61,6 +62,18 @@ func TestReturnNilWhenBlockHeightInTrackerGraceButTimesOut(t *testing.T) {
 	})
 }
 
+func TestReturnPrunedErrorWhenBlockWasPruned(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		harness, _ := generateAndCommitOneBlock(ctx, t)
+		harness.storageAdapter.SimulatePruning(2)
+
+		_, err := harness.blockStorage.GetBlockPair(ctx, &services.GetBlockPairInput{BlockHeight: 1})
+
+		require.Error(t, err, "a pruned block can not be returned")
+		require.True(t, adapter.IsBlockPruned(err), "expected a pruned block error")
+	})
+}
+
 func generateAndCommitOneBlock(ctx context.Context, t *testing.T) (*harness, *protocol.BlockPairContainer) {
 	harness := newBlockStorageHarness(t).
 		withSyncBroadcast(1).
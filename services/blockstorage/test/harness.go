@@ -29,15 +29,12 @@ import (
 )
 
 type configForBlockStorageTests struct {
-	nodeAddress           primitives.NodeAddress
-	syncBatchSize         uint32
-	syncNoCommit          time.Duration
-	syncCollectResponses  time.Duration
-	syncCollectChunks     time.Duration
-	queryGraceStart       time.Duration
-	queryGraceEnd         time.Duration
-	queryExpirationWindow time.Duration
-	blockTrackerGrace     time.Duration
+	nodeAddress          primitives.NodeAddress
+	syncBatchSize        uint32
+	syncNoCommit         time.Duration
+	syncCollectResponses time.Duration
+	syncCollectChunks    time.Duration
+	blockTrackerGrace    time.Duration
 }
 
 func (c *configForBlockStorageTests) NodeAddress() primitives.NodeAddress {
@@ -60,14 +57,6 @@ func (c *configForBlockStorageTests) BlockSyncCollectChunksTimeout() time.Durati
 	return c.syncCollectChunks
 }
 
-func (c *configForBlockStorageTests) BlockStorageTransactionReceiptQueryTimestampGrace() time.Duration {
-	return c.queryGraceStart
-}
-
-func (c *configForBlockStorageTests) TransactionExpirationWindow() time.Duration {
-	return c.queryExpirationWindow
-}
-
 func (c *configForBlockStorageTests) BlockTrackerGraceTimeout() time.Duration {
 	return c.blockTrackerGrace
 }
@@ -211,9 +200,6 @@ func createConfig(nodeAddress primitives.NodeAddress) config.BlockStorageConfig
 	cfg.syncCollectResponses = 5 * time.Millisecond
 	cfg.syncCollectChunks = 20 * time.Millisecond
 
-	cfg.queryGraceStart = 5 * time.Second
-	cfg.queryGraceEnd = 5 * time.Second
-	cfg.queryExpirationWindow = 30 * time.Minute
 	cfg.blockTrackerGrace = 1 * time.Hour
 
 	return cfg
@@ -41,8 +41,6 @@ func TestReturnTransactionReceiptIfTransactionNotFound(t *testing.T) {
 	})
 }
 
-// TODO(v1) return transaction receipt while the transaction timestamp is in the future (and too far ahead to be in the grace
-
 func TestReturnTransactionReceipt(t *testing.T) {
 	test.WithContext(func(ctx context.Context) {
 		harness := newBlockStorageHarness(t).
@@ -62,7 +60,6 @@ func TestReturnTransactionReceipt(t *testing.T) {
 		tx := block.TransactionsBlock.SignedTransactions[3].Transaction()
 		txHash := digest.CalcTxHash(tx)
 
-		// the block timestamp is just a couple of nanos ahead of the transactions, which is inside the grace
 		out, err := harness.blockStorage.GetTransactionReceipt(ctx, &services.GetTransactionReceiptInput{
 			Txhash:               txHash,
 			TransactionTimestamp: tx.Timestamp(),
@@ -76,6 +73,29 @@ func TestReturnTransactionReceipt(t *testing.T) {
 	})
 }
 
-// TODO(v1) return transaction receipt while the transaction timestamp is outside the grace (regular)
-// TODO(v1) return transaction receipt while the transaction timestamp is at the expire window
-// TODO(v1) return transaction receipt while the transaction timestamp is at the expire window and within the grace
+func TestReturnTransactionReceiptRegardlessOfTransactionTimestamp(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		harness := newBlockStorageHarness(t).
+			withSyncBroadcast(1).
+			withCommitStateDiff(1).
+			withValidateConsensusAlgos(1).
+			start(ctx)
+
+		block := builders.BlockPair().WithHeight(1).WithTransactions(10).WithReceiptsForTransactions().WithTimestampNow().Build()
+		harness.commitBlock(ctx, block)
+
+		tx := block.TransactionsBlock.SignedTransactions[3].Transaction()
+		txHash := digest.CalcTxHash(tx)
+
+		// receipts are looked up by txHash alone, a timestamp far from the block's does not hide them
+		out, err := harness.blockStorage.GetTransactionReceipt(ctx, &services.GetTransactionReceiptInput{
+			Txhash:               txHash,
+			TransactionTimestamp: tx.Timestamp() + primitives.TimestampNano(24*time.Hour),
+		})
+
+		require.NoError(t, err, "receipt should be found in this flow")
+		require.NotNil(t, out.TransactionReceipt, "receipt should be found in this flow")
+		require.EqualValues(t, txHash, out.TransactionReceipt.Txhash(), "receipt should have the tx hash we looked for")
+		require.EqualValues(t, 1, out.BlockHeight, "receipt should have the block height of the block containing the transaction")
+	})
+}
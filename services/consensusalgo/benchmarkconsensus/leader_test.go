@@ -7,8 +7,10 @@
 package benchmarkconsensus
 
 import (
+	"context"
 	"fmt"
 	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/crypto/keys"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/stretchr/testify/require"
 	"testing"
@@ -39,6 +41,14 @@ func (f *fakeFed) NodeAddress() primitives.NodeAddress {
 	return []byte("bbbabababab")
 }
 
+func (f *fakeFed) BlsPublicKey() keys.BlsBn256PublicKey {
+	return nil
+}
+
+func (f *fakeFed) BlsShareIndex() uint32 {
+	return 0
+}
+
 func TestLeaderBadKey(t *testing.T) {
 	nodes := make(map[string]config.ValidatorNode)
 
@@ -56,6 +66,6 @@ func TestLeaderBadKey(t *testing.T) {
 	}
 
 	require.Panics(t, func() {
-		s.leaderGenerateGenesisBlock()
+		s.leaderGenerateGenesisBlock(context.Background())
 	}, "should panic")
 }
@@ -8,7 +8,6 @@ package benchmarkconsensus
 
 import (
 	"context"
-	"github.com/orbs-network/orbs-network-go/crypto/digest"
 	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
 	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
@@ -84,7 +83,7 @@ func (s *service) nonLeaderCommitAndReply(ctx context.Context, blockPair *protoc
 	status := (&gossipmessages.BenchmarkConsensusStatusBuilder{
 		LastCommittedBlockHeight: lastCommittedBlockHeight,
 	}).Build()
-	sig, err := digest.SignAsNode(s.config.NodePrivateKey(), status.Raw())
+	sig, err := s.signer.SignAsNode(ctx, lastCommittedBlockHeight, status.Raw())
 	if err != nil {
 		return err
 	}
@@ -10,6 +10,7 @@ import (
 	"context"
 	"github.com/orbs-network/go-mock"
 	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/crypto/signer"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
 	"github.com/orbs-network/orbs-network-go/services/consensusalgo/benchmarkconsensus"
 	testKeys "github.com/orbs-network/orbs-network-go/test/crypto/keys"
@@ -24,6 +25,7 @@ import (
 const NETWORK_SIZE = 5
 
 type harness struct {
+	signer           signer.Signer
 	gossip           *gossiptopics.MockBenchmarkConsensus
 	blockStorage     *services.MockBlockStorage
 	consensusContext *services.MockConsensusContext
@@ -71,6 +73,7 @@ func newHarness(tb testing.TB, isLeader bool) *harness {
 	consensusContext := &services.MockConsensusContext{}
 
 	return &harness{
+		signer:           signer.NewLocalSigner(nodeKeyPair.PrivateKey(), log),
 		gossip:           gossip,
 		blockStorage:     blockStorage,
 		consensusContext: consensusContext,
@@ -87,6 +90,7 @@ func (h *harness) createService(ctx context.Context) {
 		h.gossip,
 		h.blockStorage,
 		h.consensusContext,
+		h.signer,
 		h.reporting,
 		h.config,
 		h.registry,
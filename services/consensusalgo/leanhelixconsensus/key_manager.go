@@ -8,37 +8,94 @@ package leanhelixconsensus
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	lhprimitives "github.com/orbs-network/lean-helix-go/spec/types/go/primitives"
 	lhprotocol "github.com/orbs-network/lean-helix-go/spec/types/go/protocol"
+	"github.com/orbs-network/orbs-network-go/config"
 	"github.com/orbs-network/orbs-network-go/crypto/digest"
 	"github.com/orbs-network/orbs-network-go/crypto/hash"
+	"github.com/orbs-network/orbs-network-go/crypto/keys"
+	"github.com/orbs-network/orbs-network-go/crypto/signature"
+	"github.com/orbs-network/orbs-network-go/crypto/signer"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/scribe/log"
 	"github.com/pkg/errors"
+	"sync"
 )
 
 type keyManager struct {
-	privateKey primitives.EcdsaSecp256K1PrivateKey
-	logger     log.Logger
+	signer signer.Signer
+	bls    *blsRandomSeedKeys // nil unless random seeds are signed with BLS
+	logger log.Logger
+}
+
+// heights whose random seed content is remembered for verifying the aggregated signature, shares may arrive for the next heights
+// before the current one is aggregated
+const blsRandomSeedRetainedHeights = 4
+
+// Random seeds are signed with the node's share of the committee's BLS group key, and any threshold of the shares
+// aggregates to a single group signature which verifies with the group public key, whatever the size of the committee.
+// TODO(v1) aggregate the commit signatures of block proofs as well. The lean-helix library assembles and validates block
+// proofs with a signature per committee member, so until it does so through the key manager block proofs grow with the committee
+type blsRandomSeedKeys struct {
+	privateKey     keys.BlsBn256PrivateKey
+	groupPublicKey keys.BlsBn256PublicKey
+	validators     map[string]config.ValidatorNode
+
+	mutex       sync.Mutex
+	seedContent map[lhprimitives.BlockHeight][]byte // the content signed by the shares of each height, to verify their aggregate
 }
 
 // TODO Fix according to branch lh-outline, see https://tree.taiga.io/project/orbs-network/us/566
 
-func NewKeyManager(logger log.Logger, privateKey primitives.EcdsaSecp256K1PrivateKey) *keyManager {
+func NewKeyManager(logger log.Logger, signer signer.Signer) *keyManager {
 	return &keyManager{
-		logger:     logger,
-		privateKey: privateKey,
+		logger: logger,
+		signer: signer,
+	}
+}
+
+func NewBlsKeyManager(logger log.Logger, signer signer.Signer, blsPrivateKey keys.BlsBn256PrivateKey, blsGroupPublicKey keys.BlsBn256PublicKey, validators map[string]config.ValidatorNode) *keyManager {
+	km := NewKeyManager(logger, signer)
+	km.bls = &blsRandomSeedKeys{
+		privateKey:     blsPrivateKey,
+		groupPublicKey: blsGroupPublicKey,
+		validators:     validators,
+		seedContent:    make(map[lhprimitives.BlockHeight][]byte),
+	}
+	return km
+}
+
+func newKeyManagerFromConfig(logger log.Logger, signer signer.Signer, config config.LeanHelixConsensusConfig) *keyManager {
+	if config.LeanHelixBlsSignatures() {
+		return NewBlsKeyManager(logger, signer, config.NodeBlsPrivateKey(), config.LeanHelixBlsGroupPublicKey(), config.GenesisValidatorNodes())
 	}
+	return NewKeyManager(logger, signer)
 }
 
 func (km *keyManager) SignConsensusMessage(blockHeight lhprimitives.BlockHeight, content []byte) lhprimitives.Signature {
-	sig, _ := digest.SignAsNode(km.privateKey, content) // TODO(v1): handle error (log) https://tree.taiga.io/project/orbs-network/us/603
+	sig, err := km.signer.SignAsNode(context.Background(), primitives.BlockHeight(blockHeight), content)
+	if err != nil {
+		km.logger.Error("failed to sign consensus message", log.Error(err), log.Uint64("block-height", uint64(blockHeight)))
+	}
 	return lhprimitives.Signature(sig)
 }
 
 func (km *keyManager) SignRandomSeed(blockHeight lhprimitives.BlockHeight, content []byte) lhprimitives.RandomSeedSignature {
-	sig, _ := digest.SignAsNode(km.privateKey, content) // TODO(v1): handle error (log) https://tree.taiga.io/project/orbs-network/us/603
+	if km.bls != nil {
+		km.bls.rememberSeedContent(blockHeight, content)
+		sig, err := signature.SignBlsBn256(km.bls.privateKey, content)
+		if err != nil {
+			km.logger.Error("failed to sign random seed", log.Error(err), log.Uint64("block-height", uint64(blockHeight)))
+		}
+		return lhprimitives.RandomSeedSignature(sig)
+	}
+
+	sig, err := km.signer.SignAsNode(context.Background(), primitives.BlockHeight(blockHeight), content)
+	if err != nil {
+		km.logger.Error("failed to sign random seed", log.Error(err), log.Uint64("block-height", uint64(blockHeight)))
+	}
 	return lhprimitives.RandomSeedSignature(sig)
 }
 
@@ -47,6 +104,9 @@ func (km *keyManager) VerifyConsensusMessage(blockHeight lhprimitives.BlockHeigh
 }
 
 func (km *keyManager) VerifyRandomSeed(blockHeight lhprimitives.BlockHeight, content []byte, sender *lhprotocol.SenderSignature) error {
+	if km.bls != nil {
+		return km.verifyBlsRandomSeed(blockHeight, content, sender)
+	}
 
 	// This is hack in v1 because BLS signatures / thresholds are not supported so master memberId is nil
 	// See https://tree.taiga.io/project/orbs-network/us/565
@@ -66,9 +126,90 @@ func (km *keyManager) VerifyRandomSeed(blockHeight lhprimitives.BlockHeight, con
 	return nil
 }
 
+// an empty member id stands for the committee, whose aggregated signature verifies with the group public key
+func (km *keyManager) verifyBlsRandomSeed(blockHeight lhprimitives.BlockHeight, content []byte, sender *lhprotocol.SenderSignature) error {
+	publicKey := km.bls.groupPublicKey
+	if len(sender.MemberId()) != 0 {
+		validator, found := km.bls.validators[primitives.NodeAddress(sender.MemberId()).KeyForMap()]
+		if !found {
+			return errors.Errorf("no bls public key of member %s", primitives.NodeAddress(sender.MemberId()))
+		}
+		publicKey = validator.BlsPublicKey()
+	}
+
+	if !signature.VerifyBlsBn256(publicKey, content, sender.Signature()) {
+		return errors.Errorf("bls random seed signature of member %s on blockHeight %s is invalid", primitives.NodeAddress(sender.MemberId()), blockHeight)
+	}
+	if len(sender.MemberId()) != 0 {
+		km.bls.rememberSeedContent(blockHeight, content)
+	}
+	return nil
+}
+
+func (k *blsRandomSeedKeys) rememberSeedContent(blockHeight lhprimitives.BlockHeight, content []byte) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	k.seedContent[blockHeight] = content
+	for h := range k.seedContent {
+		if h+blsRandomSeedRetainedHeights <= blockHeight {
+			delete(k.seedContent, h)
+		}
+	}
+}
+
+func (k *blsRandomSeedKeys) getSeedContent(blockHeight lhprimitives.BlockHeight) ([]byte, bool) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	content, found := k.seedContent[blockHeight]
+	return content, found
+}
+
 // This is hack in v1 - see https://tree.taiga.io/project/orbs-network/us/565
 func (km *keyManager) AggregateRandomSeed(blockHeight lhprimitives.BlockHeight, randomSeedShares []*lhprotocol.SenderSignature) lhprimitives.RandomSeedSignature {
+	if km.bls != nil {
+		return km.aggregateBlsRandomSeed(blockHeight, randomSeedShares)
+	}
+
 	heightAsByteArray := make([]byte, 8)
 	binary.LittleEndian.PutUint64(heightAsByteArray, uint64(blockHeight))
 	return lhprimitives.RandomSeedSignature(hash.CalcSha256(heightAsByteArray))
 }
+
+// shares were verified by VerifyRandomSeed when they arrived, shares of members without a share index are skipped.
+// The recovered signature is verified with the group public key against the content the shares signed, so too few or
+// inconsistent shares never make it into a block proof
+func (km *keyManager) aggregateBlsRandomSeed(blockHeight lhprimitives.BlockHeight, randomSeedShares []*lhprotocol.SenderSignature) lhprimitives.RandomSeedSignature {
+	var shareIndices []uint32
+	var sigShares [][]byte
+	for _, share := range randomSeedShares {
+		validator, found := km.bls.validators[primitives.NodeAddress(share.MemberId()).KeyForMap()]
+		if !found || validator.BlsShareIndex() == 0 {
+			continue
+		}
+		shareIndices = append(shareIndices, validator.BlsShareIndex())
+		sigShares = append(sigShares, share.Signature())
+	}
+
+	sig, err := signature.RecoverBlsBn256ThresholdSignature(shareIndices, sigShares)
+	if err == nil {
+		err = km.verifyBlsGroupSignature(blockHeight, sig)
+	}
+	if err != nil {
+		km.logger.Error("failed to aggregate random seed", log.Error(err), log.Uint64("block-height", uint64(blockHeight)), log.Int("num-shares", len(sigShares)))
+		return nil
+	}
+	return lhprimitives.RandomSeedSignature(sig)
+}
+
+func (km *keyManager) verifyBlsGroupSignature(blockHeight lhprimitives.BlockHeight, sig []byte) error {
+	content, found := km.bls.getSeedContent(blockHeight)
+	if !found {
+		return errors.Errorf("no random seed share of blockHeight %s was signed or verified, the aggregated signature cannot be verified", blockHeight)
+	}
+	if !signature.VerifyBlsBn256(km.bls.groupPublicKey, content, sig) {
+		return errors.Errorf("aggregated random seed signature of blockHeight %s does not verify with the group public key", blockHeight)
+	}
+	return nil
+}
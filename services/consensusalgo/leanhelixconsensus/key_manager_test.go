@@ -9,6 +9,9 @@ package leanhelixconsensus
 import (
 	lhprimitives "github.com/orbs-network/lean-helix-go/spec/types/go/primitives"
 	lhprotocol "github.com/orbs-network/lean-helix-go/spec/types/go/protocol"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/crypto/signature"
+	"github.com/orbs-network/orbs-network-go/crypto/signer"
 	testKeys "github.com/orbs-network/orbs-network-go/test/crypto/keys"
 	"github.com/orbs-network/scribe/log"
 	"github.com/stretchr/testify/require"
@@ -18,7 +21,8 @@ import (
 func TestSignAndVerifyConsensusMessage(t *testing.T) {
 
 	keyPair := testKeys.EcdsaSecp256K1KeyPairForTests(0)
-	mgr := NewKeyManager(log.DefaultTestingLogger(t), keyPair.PrivateKey())
+	logger := log.DefaultTestingLogger(t)
+	mgr := NewKeyManager(logger, signer.NewLocalSigner(keyPair.PrivateKey(), logger))
 	content := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
 
 	contentSig := mgr.SignConsensusMessage(1, content)
@@ -33,7 +37,8 @@ func TestSignAndVerifyConsensusMessage(t *testing.T) {
 func TestSignAndVerifyConsensusMessageOfMismatchedHeight(t *testing.T) {
 	t.Skip("Remove the skip when block height is actually verified by VerifyConsensusMessage()")
 	keyPair := testKeys.EcdsaSecp256K1KeyPairForTests(0)
-	mgr := NewKeyManager(log.DefaultTestingLogger(t), keyPair.PrivateKey())
+	logger := log.DefaultTestingLogger(t)
+	mgr := NewKeyManager(logger, signer.NewLocalSigner(keyPair.PrivateKey(), logger))
 	content := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
 	contentSig := mgr.SignConsensusMessage(1, content)
 	senderSignature := lhprotocol.SenderSignatureBuilder{
@@ -48,7 +53,8 @@ func TestSignAndVerifyConsensusMessageOfMismatchedHeight(t *testing.T) {
 func TestSignAndVerifyTaintedConsensusMessage(t *testing.T) {
 
 	keyPair := testKeys.EcdsaSecp256K1KeyPairForTests(0)
-	mgr := NewKeyManager(log.DefaultTestingLogger(t), keyPair.PrivateKey())
+	logger := log.DefaultTestingLogger(t)
+	mgr := NewKeyManager(logger, signer.NewLocalSigner(keyPair.PrivateKey(), logger))
 	content := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
 	tamperedMessage := []byte{0, 2, 3, 4, 5, 6, 7, 8, 9, 10}
 
@@ -64,7 +70,8 @@ func TestSignAndVerifyTaintedConsensusMessage(t *testing.T) {
 func TestSignAndVerifyRandomSeed(t *testing.T) {
 
 	keyPair := testKeys.EcdsaSecp256K1KeyPairForTests(0)
-	mgr := NewKeyManager(log.DefaultTestingLogger(t), keyPair.PrivateKey())
+	logger := log.DefaultTestingLogger(t)
+	mgr := NewKeyManager(logger, signer.NewLocalSigner(keyPair.PrivateKey(), logger))
 	randomSeed := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
 
 	randomSeedSig := mgr.SignRandomSeed(1, randomSeed)
@@ -79,7 +86,8 @@ func TestSignAndVerifyRandomSeed(t *testing.T) {
 func TestSignAndVerifyTaintedRandomSeed(t *testing.T) {
 
 	keyPair := testKeys.EcdsaSecp256K1KeyPairForTests(0)
-	mgr := NewKeyManager(log.DefaultTestingLogger(t), keyPair.PrivateKey())
+	logger := log.DefaultTestingLogger(t)
+	mgr := NewKeyManager(logger, signer.NewLocalSigner(keyPair.PrivateKey(), logger))
 	randomSeed := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
 	tamperedRandomSeed := []byte{0, 2, 3, 4, 5, 6, 7, 8, 9, 10}
 
@@ -95,7 +103,8 @@ func TestSignAndVerifyTaintedRandomSeed(t *testing.T) {
 func TestSignAndVerifyRandomSeedOfMismatchedHeight(t *testing.T) {
 	t.Skip("Remove the skip when block height is actually verified by VerifyRandomSeed()")
 	keyPair := testKeys.EcdsaSecp256K1KeyPairForTests(0)
-	mgr := NewKeyManager(log.DefaultTestingLogger(t), keyPair.PrivateKey())
+	logger := log.DefaultTestingLogger(t)
+	mgr := NewKeyManager(logger, signer.NewLocalSigner(keyPair.PrivateKey(), logger))
 	randomSeed := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
 
 	randomSeedSig := mgr.SignRandomSeed(1, randomSeed)
@@ -107,3 +116,83 @@ func TestSignAndVerifyRandomSeedOfMismatchedHeight(t *testing.T) {
 	require.Error(t, err, "Verification of random seed that was signed for another block height should fail")
 
 }
+
+func blsKeyManagersForTests(t *testing.T, threshold int, numNodes int) ([]*keyManager, []lhprimitives.MemberId) {
+	thresholdKeys := testKeys.BlsBn256ThresholdKeysForTests(threshold, numNodes)
+	validators := make(map[string]config.ValidatorNode)
+	var memberIds []lhprimitives.MemberId
+	for i := 0; i < numNodes; i++ {
+		nodeAddress := testKeys.EcdsaSecp256K1KeyPairForTests(i).NodeAddress()
+		validators[nodeAddress.KeyForMap()] = config.NewHardCodedValidatorNodeWithBlsKey(nodeAddress, thresholdKeys.Shares[i].PublicKey(), uint32(i+1))
+		memberIds = append(memberIds, lhprimitives.MemberId(nodeAddress))
+	}
+
+	var mgrs []*keyManager
+	for i := 0; i < numNodes; i++ {
+		logger := log.DefaultTestingLogger(t)
+		mgrs = append(mgrs, NewBlsKeyManager(logger, signer.NewLocalSigner(testKeys.EcdsaSecp256K1KeyPairForTests(i).PrivateKey(), logger), thresholdKeys.Shares[i].PrivateKey(), thresholdKeys.GroupPublicKey, validators))
+	}
+	return mgrs, memberIds
+}
+
+func TestSignAndVerifyBlsRandomSeed(t *testing.T) {
+	mgrs, memberIds := blsKeyManagersForTests(t, 3, 4)
+	randomSeed := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	tamperedRandomSeed := []byte{0, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	senderSignature := (&lhprotocol.SenderSignatureBuilder{
+		MemberId:  memberIds[1],
+		Signature: lhprimitives.Signature(mgrs[1].SignRandomSeed(1, randomSeed)),
+	}).Build()
+
+	require.NoError(t, mgrs[0].VerifyRandomSeed(1, randomSeed, senderSignature), "Verification of original random seed share should succeed")
+	require.Error(t, mgrs[0].VerifyRandomSeed(1, tamperedRandomSeed, senderSignature), "Verification of a tampered random seed share should fail")
+
+	senderSignature = (&lhprotocol.SenderSignatureBuilder{
+		MemberId:  memberIds[2],
+		Signature: senderSignature.Signature(),
+	}).Build()
+	require.Error(t, mgrs[0].VerifyRandomSeed(1, randomSeed, senderSignature), "Verification of a random seed share of another member should fail")
+}
+
+func TestAggregateBlsRandomSeedIsConstantSizeAndVerifiesWithGroupKey(t *testing.T) {
+	mgrs, memberIds := blsKeyManagersForTests(t, 3, 4)
+	randomSeed := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	var shares []*lhprotocol.SenderSignature
+	for _, i := range []int{3, 0, 2} {
+		share := (&lhprotocol.SenderSignatureBuilder{
+			MemberId:  memberIds[i],
+			Signature: lhprimitives.Signature(mgrs[i].SignRandomSeed(1, randomSeed)),
+		}).Build()
+		require.NoError(t, mgrs[1].VerifyRandomSeed(1, randomSeed, share), "shares are verified as they arrive, before they are aggregated")
+		shares = append(shares, share)
+	}
+
+	aggregated := mgrs[1].AggregateRandomSeed(1, shares)
+	require.Len(t, aggregated, signature.BLS_BN256_SIGNATURE_SIZE_BYTES, "aggregated random seed should be of a single signature")
+
+	groupSignature := (&lhprotocol.SenderSignatureBuilder{
+		Signature: lhprimitives.Signature(aggregated),
+	}).Build()
+	require.NoError(t, mgrs[0].VerifyRandomSeed(1, randomSeed, groupSignature), "Verification of aggregated random seed should succeed")
+}
+
+func TestAggregateBlsRandomSeedFailsWhenGroupSignatureDoesNotVerify(t *testing.T) {
+	mgrs, memberIds := blsKeyManagersForTests(t, 3, 4)
+	mgrs[1].logger = log.DefaultTestingLoggerAllowingErrors(t, "failed to aggregate random seed")
+	randomSeed := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	var shares []*lhprotocol.SenderSignature
+	for _, i := range []int{3, 0} {
+		share := (&lhprotocol.SenderSignatureBuilder{
+			MemberId:  memberIds[i],
+			Signature: lhprimitives.Signature(mgrs[i].SignRandomSeed(1, randomSeed)),
+		}).Build()
+		require.NoError(t, mgrs[1].VerifyRandomSeed(1, randomSeed, share))
+		shares = append(shares, share)
+	}
+	require.Nil(t, mgrs[1].AggregateRandomSeed(1, shares), "random seed aggregated below the threshold should not verify with the group key")
+
+	require.Nil(t, mgrs[1].AggregateRandomSeed(2, shares), "random seed of a height whose content is unknown cannot be verified")
+}
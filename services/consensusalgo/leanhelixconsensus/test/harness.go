@@ -11,6 +11,7 @@ import (
 	"github.com/orbs-network/go-mock"
 	lhprimitives "github.com/orbs-network/lean-helix-go/spec/types/go/primitives"
 	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/crypto/signer"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
 	"github.com/orbs-network/orbs-network-go/services/consensusalgo/leanhelixconsensus"
 	testKeys "github.com/orbs-network/orbs-network-go/test/crypto/keys"
@@ -53,10 +54,11 @@ func (h *harness) start(tb testing.TB, ctx context.Context) *harness {
 	logger := log.GetLogger().WithOutput(logOutput)
 	registry := metric.NewRegistry()
 
-	cfg := config.ForLeanHelixConsensusTests(testKeys.EcdsaSecp256K1KeyPairForTests(0))
+	keyPair := testKeys.EcdsaSecp256K1KeyPairForTests(0)
+	cfg := config.ForLeanHelixConsensusTests(keyPair)
 	h.instanceId = leanhelixconsensus.CalcInstanceId(cfg.NetworkType(), cfg.VirtualChainId())
 
-	h.consensus = leanhelixconsensus.NewLeanHelixConsensusAlgo(ctx, h.gossip, h.blockStorage, h.consensusContext, logger, cfg, registry)
+	h.consensus = leanhelixconsensus.NewLeanHelixConsensusAlgo(ctx, h.gossip, h.blockStorage, h.consensusContext, signer.NewLocalSigner(keyPair.PrivateKey(), logger), logger, cfg, registry)
 	return h
 }
 
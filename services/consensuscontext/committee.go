@@ -19,16 +19,28 @@ import (
 	"sort"
 )
 
+// lean helix requests the ordering committee of past blocks as well when it validates their proofs during block sync,
+// the listener is notified only of the elected validators of the block following the latest committed one
 func (s *service) RequestOrderingCommittee(ctx context.Context, input *services.RequestCommitteeInput) (*services.RequestCommitteeOutput, error) {
-	return s.RequestValidationCommittee(ctx, input)
+	electedValidatorsAddresses, err := s.queryElectedValidators(ctx, input.CurrentBlockHeight)
+	if err != nil {
+		return nil, err
+	}
+	s.notifyElectedValidatorsChanged(ctx, input.CurrentBlockHeight, electedValidatorsAddresses)
+	return s.chooseCommittee(ctx, input, electedValidatorsAddresses)
 }
 
+// validation may be of past blocks, so its elected validators are never passed to the listener
 func (s *service) RequestValidationCommittee(ctx context.Context, input *services.RequestCommitteeInput) (*services.RequestCommitteeOutput, error) {
-	logger := s.logger.WithTags(trace.LogFieldFrom(ctx))
-	electedValidatorsAddresses, err := s.getElectedValidators(ctx, input.CurrentBlockHeight)
+	electedValidatorsAddresses, err := s.queryElectedValidators(ctx, input.CurrentBlockHeight)
 	if err != nil {
 		return nil, err
 	}
+	return s.chooseCommittee(ctx, input, electedValidatorsAddresses)
+}
+
+func (s *service) chooseCommittee(ctx context.Context, input *services.RequestCommitteeInput, electedValidatorsAddresses []primitives.NodeAddress) (*services.RequestCommitteeOutput, error) {
+	logger := s.logger.WithTags(trace.LogFieldFrom(ctx))
 
 	committeeSize := calculateCommitteeSize(input.MaxCommitteeSize, s.config.LeanHelixConsensusMinimumCommitteeSize(), uint32(len(electedValidatorsAddresses)))
 	logger.Info("Calculated committee size", logfields.BlockHeight(input.CurrentBlockHeight), log.Uint32("committee-size", committeeSize), log.Int("elected-validators-count", len(electedValidatorsAddresses)), log.Uint32("max-committee-size", input.MaxCommitteeSize))
@@ -23,7 +23,7 @@ import (
 
 const CALL_ELECTIONS_CONTRACT_INTERVAL = 200 * time.Millisecond
 
-func (s *service) getElectedValidators(ctx context.Context, currentBlockHeight primitives.BlockHeight) ([]primitives.NodeAddress, error) {
+func (s *service) queryElectedValidators(ctx context.Context, currentBlockHeight primitives.BlockHeight) ([]primitives.NodeAddress, error) {
 	logger := s.logger.WithTags(trace.LogFieldFrom(ctx))
 
 	lastCommittedBlockHeight := currentBlockHeight - 1
@@ -51,6 +51,39 @@ func (s *service) getElectedValidators(ctx context.Context, currentBlockHeight p
 	return electedValidatorsAddresses, nil
 }
 
+// the elected validators of any block but the one following the latest committed block are stale
+func (s *service) notifyElectedValidatorsChanged(ctx context.Context, currentBlockHeight primitives.BlockHeight, electedValidatorsAddresses []primitives.NodeAddress) {
+	if s.electedValidatorsListener == nil {
+		return
+	}
+
+	output, err := s.stateStorage.GetStateStorageBlockHeight(ctx, &services.GetStateStorageBlockHeightInput{})
+	if err != nil {
+		s.logger.Info("failed to get the last committed block height, not notifying elected validators", log.Error(err), trace.LogFieldFrom(ctx))
+		return
+	}
+	if currentBlockHeight != output.LastCommittedBlockHeight+1 {
+		return
+	}
+
+	// a block may be committed between a slower call reading the last committed height and taking the mutex, so calls may
+	// notify out of order once the mutex is released, the listener orders them by the block height
+	s.electedValidatorsMutex.Lock()
+	if currentBlockHeight < s.lastElectedValidatorsHeight {
+		s.electedValidatorsMutex.Unlock()
+		return
+	}
+	s.lastElectedValidatorsHeight = currentBlockHeight
+	if sameNodeAddresses(s.lastElectedValidators, electedValidatorsAddresses) {
+		s.electedValidatorsMutex.Unlock()
+		return
+	}
+	s.lastElectedValidators = electedValidatorsAddresses
+	s.electedValidatorsMutex.Unlock()
+
+	s.electedValidatorsListener.OnElectedValidatorsChanged(ctx, currentBlockHeight, electedValidatorsAddresses)
+}
+
 func (s *service) callElectionsSystemContractUntilSuccess(ctx context.Context, blockHeight primitives.BlockHeight) ([]primitives.NodeAddress, error) {
 	attempts := 1
 	for {
@@ -125,3 +158,20 @@ func toNodeAddresses(nodes map[string]config.ValidatorNode) []primitives.NodeAdd
 	}
 	return nodeAddresses
 }
+
+// compares the sets of addresses, ignoring their order
+func sameNodeAddresses(a []primitives.NodeAddress, b []primitives.NodeAddress) bool {
+	if a == nil || len(a) != len(b) {
+		return false
+	}
+	keys := make(map[string]bool)
+	for _, address := range a {
+		keys[address.KeyForMap()] = true
+	}
+	for _, address := range b {
+		if !keys[address.KeyForMap()] {
+			return false
+		}
+	}
+	return true
+}
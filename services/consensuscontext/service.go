@@ -13,9 +13,11 @@ import (
 	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
 	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/protocol"
 	"github.com/orbs-network/orbs-spec/types/go/services"
 	"github.com/orbs-network/scribe/log"
+	"sync"
 	"time"
 )
 
@@ -37,32 +39,46 @@ func newMetrics(factory metric.Factory) *metrics {
 	}
 }
 
+// notified whenever the elected validators of the latest committed block change, so the gossip transport can follow the committee.
+// Notifications may arrive out of order, the listener keeps the elected validators of the highest block height. The listener
+// is called on the consensus flow and must not block
+type ElectedValidatorsListener interface {
+	OnElectedValidatorsChanged(ctx context.Context, blockHeight primitives.BlockHeight, electedValidators []primitives.NodeAddress)
+}
+
 type service struct {
-	transactionPool services.TransactionPool
-	virtualMachine  services.VirtualMachine
-	stateStorage    services.StateStorage
-	config          config.ConsensusContextConfig
-	logger          log.Logger
+	transactionPool           services.TransactionPool
+	virtualMachine            services.VirtualMachine
+	stateStorage              services.StateStorage
+	electedValidatorsListener ElectedValidatorsListener
+	config                    config.ConsensusContextConfig
+	logger                    log.Logger
 
 	metrics *metrics
+
+	electedValidatorsMutex      sync.Mutex
+	lastElectedValidators       []primitives.NodeAddress
+	lastElectedValidatorsHeight primitives.BlockHeight
 }
 
 func NewConsensusContext(
 	transactionPool services.TransactionPool,
 	virtualMachine services.VirtualMachine,
 	stateStorage services.StateStorage,
+	electedValidatorsListener ElectedValidatorsListener, // optional
 	config config.ConsensusContextConfig,
 	logger log.Logger,
 	metricFactory metric.Factory,
 ) services.ConsensusContext {
 
 	return &service{
-		transactionPool: transactionPool,
-		virtualMachine:  virtualMachine,
-		stateStorage:    stateStorage,
-		config:          config,
-		logger:          logger.WithTags(LogTag),
-		metrics:         newMetrics(metricFactory),
+		transactionPool:           transactionPool,
+		virtualMachine:            virtualMachine,
+		stateStorage:              stateStorage,
+		electedValidatorsListener: electedValidatorsListener,
+		config:                    config,
+		logger:                    logger.WithTags(LogTag),
+		metrics:                   newMetrics(metricFactory),
 	}
 }
 
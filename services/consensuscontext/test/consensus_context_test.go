@@ -7,6 +7,7 @@
 package test
 
 import (
+	"bytes"
 	"context"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/services"
@@ -16,6 +17,7 @@ import (
 
 func TestRequestOrderingCommittee(t *testing.T) {
 	h := newHarness(t)
+	h.expectLastCommittedBlockHeight(0)
 	blockHeight := primitives.BlockHeight(1)
 	genesisValidatorsSize := len(h.config.GenesisValidatorNodes())
 
@@ -46,3 +48,56 @@ func TestRequestOrderingCommittee(t *testing.T) {
 		require.Equal(t, genesisValidatorsSize, actualNumberOfValidators, "expected committee size is %d but got %d", genesisValidatorsSize, actualNumberOfValidators)
 	})
 }
+
+func TestRequestOrderingCommittee_NotifiesElectedValidatorsListenerOnlyOnChange(t *testing.T) {
+	h := newHarness(t)
+	h.expectLastCommittedBlockHeight(0)
+	input := &services.RequestCommitteeInput{
+		CurrentBlockHeight: 1,
+		RandomSeed:         0,
+		MaxCommitteeSize:   uint32(len(h.config.GenesisValidatorNodes())),
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := h.service.RequestOrderingCommittee(context.Background(), input)
+		require.NoError(t, err)
+	}
+
+	notifications := h.electedValidatorsListener.received()
+	require.Len(t, notifications, 1, "listener should be notified once while the elected validators do not change")
+	require.ElementsMatch(t, validatorNodeAddressesForTest, notifications[0], "listener should be notified of the genesis validators")
+}
+
+func TestRequestValidationCommittee_DoesNotNotifyElectedValidatorsListener(t *testing.T) {
+	h := newHarness(t)
+	input := &services.RequestCommitteeInput{
+		CurrentBlockHeight: 1,
+		RandomSeed:         0,
+		MaxCommitteeSize:   uint32(len(h.config.GenesisValidatorNodes())),
+	}
+
+	_, err := h.service.RequestValidationCommittee(context.Background(), input)
+	require.NoError(t, err)
+
+	require.Empty(t, h.electedValidatorsListener.received(), "validation may be of a past block and should not notify the listener")
+}
+
+func TestRequestOrderingCommittee_DoesNotNotifyElectedValidatorsOfAPastBlock(t *testing.T) {
+	h := newHarness(t)
+	electedValidators := []primitives.NodeAddress{bytes.Repeat([]byte{0x01}, 20), bytes.Repeat([]byte{0x02}, 20)}
+	h.expectElectedValidatorsQueryToReturn(electedValidators)
+	h.expectLastCommittedBlockHeight(2)
+
+	for _, blockHeight := range []primitives.BlockHeight{3, 1} {
+		_, err := h.service.RequestOrderingCommittee(context.Background(), &services.RequestCommitteeInput{
+			CurrentBlockHeight: blockHeight,
+			RandomSeed:         0,
+			MaxCommitteeSize:   uint32(len(electedValidators)),
+		})
+		require.NoError(t, err)
+	}
+
+	notifications := h.electedValidatorsListener.received()
+	require.Len(t, notifications, 1, "listener should be notified only of the elected validators following the last committed block")
+	require.ElementsMatch(t, electedValidators, notifications[0])
+}
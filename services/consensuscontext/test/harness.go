@@ -19,6 +19,7 @@ import (
 	"github.com/orbs-network/orbs-spec/types/go/services"
 	"github.com/orbs-network/scribe/log"
 	"github.com/stretchr/testify/require"
+	"sync"
 	"testing"
 	"time"
 )
@@ -37,12 +38,30 @@ var validatorNodeAddressesForTest = []primitives.NodeAddress{
 }
 
 type harness struct {
-	transactionPool *services.MockTransactionPool
-	virtualMachine  *services.MockVirtualMachine
-	stateStorage    *services.MockStateStorage
-	reporting       log.Logger
-	service         services.ConsensusContext
-	config          config.ConsensusContextConfig
+	transactionPool           *services.MockTransactionPool
+	virtualMachine            *services.MockVirtualMachine
+	stateStorage              *services.MockStateStorage
+	electedValidatorsListener *recordingElectedValidatorsListener
+	reporting                 log.Logger
+	service                   services.ConsensusContext
+	config                    config.ConsensusContextConfig
+}
+
+type recordingElectedValidatorsListener struct {
+	sync.Mutex
+	notifications [][]primitives.NodeAddress
+}
+
+func (l *recordingElectedValidatorsListener) OnElectedValidatorsChanged(ctx context.Context, blockHeight primitives.BlockHeight, electedValidators []primitives.NodeAddress) {
+	l.Lock()
+	defer l.Unlock()
+	l.notifications = append(l.notifications, electedValidators)
+}
+
+func (l *recordingElectedValidatorsListener) received() [][]primitives.NodeAddress {
+	l.Lock()
+	defer l.Unlock()
+	return l.notifications
 }
 
 func (h *harness) requestTransactionsBlock(ctx context.Context) (*protocol.TransactionsBlockContainer, error) {
@@ -123,6 +142,25 @@ func (h *harness) expectStateHashToReturn(hash []byte) {
 
 }
 
+func (h *harness) expectLastCommittedBlockHeight(blockHeight primitives.BlockHeight) {
+	output := &services.GetStateStorageBlockHeightOutput{
+		LastCommittedBlockHeight: blockHeight,
+	}
+	h.stateStorage.When("GetStateStorageBlockHeight", mock.Any, mock.Any).Return(output, nil)
+}
+
+func (h *harness) expectElectedValidatorsQueryToReturn(electedValidators []primitives.NodeAddress) {
+	var joinedAddresses []byte
+	for _, nodeAddress := range electedValidators {
+		joinedAddresses = append(joinedAddresses, nodeAddress...)
+	}
+	output := &services.CallSystemContractOutput{
+		OutputArgumentArray: builders.ArgumentsArray(joinedAddresses),
+		CallResult:          protocol.EXECUTION_RESULT_SUCCESS,
+	}
+	h.virtualMachine.When("CallSystemContract", mock.Any, mock.Any).Return(output, nil)
+}
+
 func newHarness(tb testing.TB) *harness {
 	log := log.DefaultTestingLogger(tb)
 
@@ -137,19 +175,22 @@ func newHarness(tb testing.TB) *harness {
 	cfg := config.ForConsensusContextTests(genesisValidatorNodes)
 
 	metricFactory := metric.NewRegistry()
+	electedValidatorsListener := &recordingElectedValidatorsListener{}
 
 	service := consensuscontext.NewConsensusContext(
 		txPool,
 		machine,
 		state,
+		electedValidatorsListener,
 		cfg, log, metricFactory)
 
 	return &harness{
-		transactionPool: txPool,
-		virtualMachine:  machine,
-		stateStorage:    state,
-		reporting:       log,
-		service:         service,
-		config:          cfg,
+		transactionPool:           txPool,
+		virtualMachine:            machine,
+		stateStorage:              state,
+		electedValidatorsListener: electedValidatorsListener,
+		reporting:                 log,
+		service:                   service,
+		config:                    cfg,
 	}
 }
@@ -71,6 +71,7 @@ func TestValidateTransactionsBlockOnValidBlock(t *testing.T) {
 		txPool,
 		&services.MockVirtualMachine{},
 		&services.MockStateStorage{},
+		nil,
 		cfg,
 		log,
 		metricFactory)
@@ -154,6 +155,7 @@ func TestValidateResultsBlockOnValidBlock(t *testing.T) {
 		txPool,
 		vm,
 		stateStorage,
+		nil,
 		cfg,
 		log,
 		metricFactory)
@@ -0,0 +1,135 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package relay
+
+import (
+	"crypto/rand"
+	"github.com/orbs-network/membuffers/go"
+	"github.com/orbs-network/orbs-network-go/crypto/digest"
+	"github.com/orbs-network/orbs-network-go/crypto/hash"
+	"github.com/orbs-network/orbs-network-go/crypto/signature"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol/gossipmessages"
+	"github.com/pkg/errors"
+)
+
+const MESSAGE_ID_SIZE_BYTES = 16
+
+// the relay header travels as the last payload, after the payloads sent by the gossip service:
+// message id, origin address, origin signature, relayer address, hops left, recipient mode, number of recipients, recipient addresses
+const relayHeaderFixedSizeBytes = MESSAGE_ID_SIZE_BYTES + 2*digest.NODE_ADDRESS_SIZE_BYTES + signature.ECDSA_SECP256K1_SIGNATURE_SIZE_BYTES + 3*4
+
+const originSignatureLabel = "orbs-gossip-relay-origin"
+
+type relayHeader struct {
+	messageId       []byte
+	origin          primitives.NodeAddress
+	originSignature primitives.EcdsaSecp256K1Sig
+	relayer         primitives.NodeAddress
+	hopsLeft        uint32
+	mode            gossipmessages.RecipientsListMode
+	recipients      []primitives.NodeAddress
+}
+
+func newMessageId() ([]byte, error) {
+	messageId := make([]byte, MESSAGE_ID_SIZE_BYTES)
+	if _, err := rand.Read(messageId); err != nil {
+		return nil, errors.Wrap(err, "failed to generate relay message id")
+	}
+	return messageId, nil
+}
+
+func (h *relayHeader) encode() []byte {
+	buffer := make([]byte, relayHeaderFixedSizeBytes+len(h.recipients)*digest.NODE_ADDRESS_SIZE_BYTES)
+	offset := copy(buffer, h.messageId)
+	offset += copy(buffer[offset:], h.origin)
+	copy(buffer[offset:offset+signature.ECDSA_SECP256K1_SIGNATURE_SIZE_BYTES], h.originSignature)
+	offset += signature.ECDSA_SECP256K1_SIGNATURE_SIZE_BYTES
+	offset += copy(buffer[offset:], h.relayer)
+	membuffers.WriteUint32(buffer[offset:], h.hopsLeft)
+	membuffers.WriteUint32(buffer[offset+4:], uint32(h.mode))
+	membuffers.WriteUint32(buffer[offset+8:], uint32(len(h.recipients)))
+	offset += 12
+	for _, recipient := range h.recipients {
+		offset += copy(buffer[offset:], recipient)
+	}
+	return buffer
+}
+
+func decodeRelayHeader(buffer []byte) (*relayHeader, error) {
+	if len(buffer) < relayHeaderFixedSizeBytes {
+		return nil, errors.Errorf("relay header is too short: %d bytes", len(buffer))
+	}
+	h := &relayHeader{}
+	offset := 0
+	h.messageId, offset = buffer[offset:offset+MESSAGE_ID_SIZE_BYTES], offset+MESSAGE_ID_SIZE_BYTES
+	h.origin, offset = primitives.NodeAddress(buffer[offset:offset+digest.NODE_ADDRESS_SIZE_BYTES]), offset+digest.NODE_ADDRESS_SIZE_BYTES
+	h.originSignature, offset = primitives.EcdsaSecp256K1Sig(buffer[offset:offset+signature.ECDSA_SECP256K1_SIGNATURE_SIZE_BYTES]), offset+signature.ECDSA_SECP256K1_SIGNATURE_SIZE_BYTES
+	h.relayer, offset = primitives.NodeAddress(buffer[offset:offset+digest.NODE_ADDRESS_SIZE_BYTES]), offset+digest.NODE_ADDRESS_SIZE_BYTES
+	h.hopsLeft = membuffers.GetUint32(buffer[offset:])
+	h.mode = gossipmessages.RecipientsListMode(membuffers.GetUint32(buffer[offset+4:]))
+	numRecipients := membuffers.GetUint32(buffer[offset+8:])
+	offset += 12
+
+	if uint64(len(buffer)-offset) != uint64(numRecipients)*digest.NODE_ADDRESS_SIZE_BYTES {
+		return nil, errors.Errorf("relay header of %d bytes does not match %d recipients", len(buffer), numRecipients)
+	}
+	for i := uint32(0); i < numRecipients; i++ {
+		h.recipients = append(h.recipients, primitives.NodeAddress(buffer[offset:offset+digest.NODE_ADDRESS_SIZE_BYTES]))
+		offset += digest.NODE_ADDRESS_SIZE_BYTES
+	}
+	return h, nil
+}
+
+// the origin signs everything about the message but the relayer and the hops left, which change at every hop, so a relayer
+// can neither forge the origin of a message nor change where it goes or what it carries
+func (h *relayHeader) signedData(payloads [][]byte) []byte {
+	data := make([]byte, 0, len(originSignatureLabel)+MESSAGE_ID_SIZE_BYTES+(len(h.recipients)+1)*digest.NODE_ADDRESS_SIZE_BYTES+8+hash.SHA256_HASH_SIZE_BYTES)
+	data = append(data, originSignatureLabel...)
+	data = append(data, h.messageId...)
+	data = append(data, h.origin...)
+	data = append(data, encodeUint32(uint32(h.mode))...)
+	data = append(data, encodeUint32(uint32(len(h.recipients)))...)
+	for _, recipient := range h.recipients {
+		data = append(data, recipient...)
+	}
+	return append(data, payloadsHash(payloads)...)
+}
+
+func (h *relayHeader) verifyOrigin(payloads [][]byte) error {
+	if err := digest.VerifyNodeSignature(h.origin, h.signedData(payloads), h.originSignature); err != nil {
+		return errors.Wrapf(err, "relay message is not signed by its origin %s", h.origin)
+	}
+	return nil
+}
+
+// every payload is prefixed by its size, so payloads split differently do not hash the same
+func payloadsHash(payloads [][]byte) []byte {
+	var sizedPayloads [][]byte
+	for _, payload := range payloads {
+		sizedPayloads = append(sizedPayloads, encodeUint32(uint32(len(payload))), payload)
+	}
+	return hash.CalcSha256(sizedPayloads...)
+}
+
+func encodeUint32(value uint32) []byte {
+	buffer := make([]byte, 4)
+	membuffers.WriteUint32(buffer, value)
+	return buffer
+}
+
+func (h *relayHeader) isRecipient(nodeAddress primitives.NodeAddress) bool {
+	if h.mode == gossipmessages.RECIPIENT_LIST_MODE_BROADCAST {
+		return !nodeAddress.Equal(h.origin)
+	}
+	for _, recipient := range h.recipients {
+		if recipient.Equal(nodeAddress) {
+			return true
+		}
+	}
+	return false
+}
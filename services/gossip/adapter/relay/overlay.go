@@ -0,0 +1,141 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package relay
+
+import (
+	"bytes"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"math"
+	"sort"
+)
+
+// MembersOf returns the node itself together with all of its gossip peers, which are the members of the overlay
+func MembersOf(nodeAddress primitives.NodeAddress, gossipPeers map[string]config.GossipPeer) []primitives.NodeAddress {
+	members := []primitives.NodeAddress{nodeAddress}
+	for key := range gossipPeers {
+		if key != nodeAddress.KeyForMap() {
+			members = append(members, primitives.NodeAddress(key))
+		}
+	}
+	return members
+}
+
+// Neighbors returns the members a node is linked to in the overlay. Members are placed on a ring ordered by address and
+// every node links to the members at the same set of distances in both directions, so the graph is symmetric and connected.
+// The distances grow geometrically up to the size of the ring, so a message spans the overlay in a few hops while no node
+// has more than maxDegree links
+func Neighbors(nodeAddress primitives.NodeAddress, members []primitives.NodeAddress, maxDegree uint32) []primitives.NodeAddress {
+	ring := sortedUniqueMembers(members)
+	self := -1
+	for i, member := range ring {
+		if member.Equal(nodeAddress) {
+			self = i
+		}
+	}
+	if self == -1 {
+		return nil
+	}
+
+	size := len(ring)
+	var neighbors []primitives.NodeAddress
+	added := map[int]bool{self: true}
+	for _, distance := range ringDistances(size, int(maxDegree/2)) {
+		for _, index := range []int{(self + distance) % size, (self - distance + size) % size} {
+			if !added[index] {
+				added[index] = true
+				neighbors = append(neighbors, ring[index])
+			}
+		}
+	}
+	return neighbors
+}
+
+// the neighbor of the node on a shortest route to every other member of the overlay. Every member derives the same overlay,
+// so a message passed along the routes of the nodes it reaches gets one hop closer to its recipient at every hop
+func routesFrom(nodeAddress primitives.NodeAddress, members []primitives.NodeAddress, maxDegree uint32) map[string]primitives.NodeAddress {
+	routes := map[string]primitives.NodeAddress{nodeAddress.KeyForMap(): nil}
+	var frontier []primitives.NodeAddress
+	for _, neighbor := range Neighbors(nodeAddress, members, maxDegree) {
+		routes[neighbor.KeyForMap()] = neighbor
+		frontier = append(frontier, neighbor)
+	}
+
+	for len(frontier) > 0 {
+		var next []primitives.NodeAddress
+		for _, member := range frontier {
+			for _, neighbor := range Neighbors(member, members, maxDegree) {
+				if _, found := routes[neighbor.KeyForMap()]; !found {
+					routes[neighbor.KeyForMap()] = routes[member.KeyForMap()]
+					next = append(next, neighbor)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	delete(routes, nodeAddress.KeyForMap())
+	return routes
+}
+
+func ringDistances(size int, count int) []int {
+	if count < 1 {
+		count = 1
+	}
+	if size/2 <= count { // small enough for every member to link to all others
+		count = size / 2
+	}
+
+	base := math.Pow(float64(size), 1/float64(count))
+	var distances []int
+	for i := 0; i < count; i++ {
+		distance := int(math.Round(math.Pow(base, float64(i))))
+		if len(distances) > 0 && distance <= distances[len(distances)-1] {
+			distance = distances[len(distances)-1] + 1
+		}
+		if distance > size/2 {
+			break
+		}
+		distances = append(distances, distance)
+	}
+	return distances
+}
+
+func sortedUniqueMembers(members []primitives.NodeAddress) []primitives.NodeAddress {
+	var ring []primitives.NodeAddress
+	seen := make(map[string]bool)
+	for _, member := range members {
+		if !seen[member.KeyForMap()] {
+			seen[member.KeyForMap()] = true
+			ring = append(ring, member)
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool {
+		return bytes.Compare(ring[i], ring[j]) < 0
+	})
+	return ring
+}
+
+type neighborsOnlyConfig struct {
+	config.GossipTransportConfig
+	neighbors map[string]config.GossipPeer
+}
+
+// NeighborsOnly narrows the gossip peers of a transport config to the neighbors of the node in the overlay, so a
+// transport created with it links the node only to its neighbors
+func NeighborsOnly(transportConfig config.GossipTransportConfig, maxDegree uint32) config.GossipTransportConfig {
+	gossipPeers := transportConfig.GossipPeers()
+	neighbors := make(map[string]config.GossipPeer)
+	for _, neighbor := range Neighbors(transportConfig.NodeAddress(), MembersOf(transportConfig.NodeAddress(), gossipPeers), maxDegree) {
+		neighbors[neighbor.KeyForMap()] = gossipPeers[neighbor.KeyForMap()]
+	}
+	return &neighborsOnlyConfig{GossipTransportConfig: transportConfig, neighbors: neighbors}
+}
+
+func (c *neighborsOnlyConfig) GossipPeers() map[string]config.GossipPeer {
+	return c.neighbors
+}
@@ -0,0 +1,122 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package relay
+
+import (
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func membersForTests(count int) []primitives.NodeAddress {
+	var members []primitives.NodeAddress
+	for i := 0; i < count; i++ {
+		address := make([]byte, 20)
+		address[0], address[1] = byte(i>>8), byte(i)
+		members = append(members, primitives.NodeAddress(address))
+	}
+	return members
+}
+
+// the number of hops it takes a flooded message to reach every member from the first member
+func hopsToReachAll(members []primitives.NodeAddress, maxDegree uint32) (hops int, reached int) {
+	reachedMembers := map[string]bool{members[0].KeyForMap(): true}
+	frontier := []primitives.NodeAddress{members[0]}
+	for len(frontier) > 0 && len(reachedMembers) < len(members) {
+		var next []primitives.NodeAddress
+		for _, member := range frontier {
+			for _, neighbor := range Neighbors(member, members, maxDegree) {
+				if !reachedMembers[neighbor.KeyForMap()] {
+					reachedMembers[neighbor.KeyForMap()] = true
+					next = append(next, neighbor)
+				}
+			}
+		}
+		frontier = next
+		hops++
+	}
+	return hops, len(reachedMembers)
+}
+
+func TestNeighbors_LinksAllMembersOfASmallNetwork(t *testing.T) {
+	members := membersForTests(5)
+
+	neighbors := Neighbors(members[2], members, 8)
+
+	require.Len(t, neighbors, 4, "every other member should be a neighbor")
+	require.NotContains(t, neighbors, members[2], "a member should not be its own neighbor")
+}
+
+func TestNeighbors_AreSymmetricAndBoundedInDegree(t *testing.T) {
+	for _, maxDegree := range []uint32{2, 3, 8} {
+		members := membersForTests(100)
+		for _, member := range members {
+			neighbors := Neighbors(member, members, maxDegree)
+			require.True(t, len(neighbors) <= int(maxDegree), "member should have at most %d neighbors, has %d", maxDegree, len(neighbors))
+			for _, neighbor := range neighbors {
+				require.Contains(t, Neighbors(neighbor, members, maxDegree), member, "neighbor should link back to the member")
+			}
+		}
+	}
+}
+
+func TestNeighbors_SpanALargeNetworkInFewHops(t *testing.T) {
+	members := membersForTests(1000)
+
+	hops, reached := hopsToReachAll(members, 8)
+
+	require.Equal(t, len(members), reached, "overlay should be connected")
+	require.True(t, hops <= 16, "overlay of 1000 members should be spanned in the default max hops, took %d", hops)
+}
+
+func TestNeighbors_DoNotDependOnTheOrderOfMembers(t *testing.T) {
+	members := membersForTests(20)
+	reversed := make([]primitives.NodeAddress, len(members))
+	for i, member := range members {
+		reversed[len(members)-1-i] = member
+	}
+
+	require.ElementsMatch(t, Neighbors(members[7], members, 4), Neighbors(members[7], reversed, 4))
+}
+
+func TestNeighbors_OfANonMemberAreEmpty(t *testing.T) {
+	require.Empty(t, Neighbors(primitives.NodeAddress{0xff}, membersForTests(10), 4))
+}
+
+func TestRoutes_LeadOneHopCloserToEveryMember(t *testing.T) {
+	members := membersForTests(30)
+	for _, member := range members {
+		routes := routesFrom(member, members, 4)
+		require.Len(t, routes, len(members)-1, "member should have a route to every other member")
+		for key, nextHop := range routes {
+			require.Contains(t, Neighbors(member, members, 4), nextHop, "the next hop should be a neighbor")
+			destination := primitives.NodeAddress(key)
+			require.Equal(t, hopsBetween(member, destination, members, 4)-1, hopsBetween(nextHop, destination, members, 4), "the next hop should be one hop closer to %s", destination)
+		}
+	}
+}
+
+func hopsBetween(from primitives.NodeAddress, to primitives.NodeAddress, members []primitives.NodeAddress, maxDegree uint32) int {
+	reached := map[string]bool{from.KeyForMap(): true}
+	frontier := []primitives.NodeAddress{from}
+	for hops := 0; len(frontier) > 0; hops++ {
+		var next []primitives.NodeAddress
+		for _, member := range frontier {
+			if member.Equal(to) {
+				return hops
+			}
+			for _, neighbor := range Neighbors(member, members, maxDegree) {
+				if !reached[neighbor.KeyForMap()] {
+					reached[neighbor.KeyForMap()] = true
+					next = append(next, neighbor)
+				}
+			}
+		}
+		frontier = next
+	}
+	return -1
+}
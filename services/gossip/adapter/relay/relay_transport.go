@@ -0,0 +1,352 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+/*
+Package relay provides a multi-hop implementation of the Gossip Transport adapter. Instead of linking every node to every
+other node, each node is linked only to its neighbors in a bounded-degree overlay, and messages travel across the overlay
+with a message id so every node delivers and forwards each message once. Broadcasts are flooded across the overlay while
+messages for a list of recipients follow the shortest routes to them. The origin of a message signs it as the node and
+every node verifies that signature before passing the message on, so listeners are told the origin as the authenticated
+sender. The links between neighbors are provided by another Transport, such as the TCP-based adapter in production or the
+in-memory adapter in tests
+*/
+package relay
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/crypto/signature"
+	"github.com/orbs-network/orbs-network-go/crypto/signer"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol/gossipmessages"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	"sync"
+)
+
+const SEEN_MESSAGES_MAX_MESSAGES = 100000
+
+var LogTag = log.String("adapter", "gossip-relay")
+
+type metrics struct {
+	deliveredMessages *metric.Gauge
+	forwardedMessages *metric.Gauge
+	duplicateMessages *metric.Gauge
+	corruptMessages   *metric.Gauge
+	sendErrors        *metric.Gauge
+}
+
+func newMetrics(registry metric.Registry) *metrics {
+	return &metrics{
+		deliveredMessages: registry.NewGauge("Gossip.Relay.DeliveredMessages.Count"),
+		forwardedMessages: registry.NewGauge("Gossip.Relay.ForwardedMessages.Count"),
+		duplicateMessages: registry.NewGauge("Gossip.Relay.DuplicateMessages.Count"),
+		corruptMessages:   registry.NewGauge("Gossip.Relay.CorruptMessages.Count"),
+		sendErrors:        registry.NewGauge("Gossip.Relay.SendErrors.Count"),
+	}
+}
+
+type RelayTransport struct {
+	link              adapter.Transport
+	configuredMembers []primitives.NodeAddress
+	signers           map[string]signer.Signer
+	config            config.GossipRelayConfig
+	logger            log.Logger
+	metrics           *metrics
+
+	mutex                   sync.RWMutex
+	nodes                   map[string]*relayNode
+	members                 []primitives.NodeAddress // the configured members and the elected validators
+	electedValidatorsHeight primitives.BlockHeight
+}
+
+// the link is told the neighbors of the nodes as its elected validators, so it connects to neighbors joining the overlay
+type electedValidatorsListener interface {
+	OnElectedValidatorsChanged(ctx context.Context, blockHeight primitives.BlockHeight, electedValidators []primitives.NodeAddress)
+}
+
+type relayNode struct {
+	nodeAddress primitives.NodeAddress
+	listener    adapter.TransportListener
+	signer      signer.Signer
+	seen        *seenMessages
+	transport   *RelayTransport
+	logger      log.Logger
+
+	overlayMutex sync.RWMutex
+	neighbors    []primitives.NodeAddress
+	routes       map[string]primitives.NodeAddress // the next hop towards each other member
+}
+
+// link carries the messages between neighbors and must be able to reach every neighbor of the nodes registered on this transport.
+// signers holds the signer of every node which registers on this transport, keyed by node address, to sign the messages it sends
+func NewTransport(link adapter.Transport, members []primitives.NodeAddress, signers map[string]signer.Signer, config config.GossipRelayConfig, logger log.Logger, registry metric.Registry) *RelayTransport {
+	return &RelayTransport{
+		link:              link,
+		configuredMembers: members,
+		signers:           signers,
+		config:            config,
+		logger:            logger.WithTags(LogTag),
+		metrics:           newMetrics(registry),
+		nodes:             make(map[string]*relayNode),
+		members:           members,
+	}
+}
+
+func (t *RelayTransport) RegisterListener(listener adapter.TransportListener, listenerNodeAddress primitives.NodeAddress) {
+	n := &relayNode{
+		nodeAddress: listenerNodeAddress,
+		listener:    listener,
+		signer:      t.signers[listenerNodeAddress.KeyForMap()],
+		seen:        newSeenMessages(SEEN_MESSAGES_MAX_MESSAGES),
+		transport:   t,
+		logger:      t.logger.WithTags(log.Stringable("node", listenerNodeAddress)),
+	}
+
+	t.mutex.Lock()
+	neighbors := n.joinOverlay(t.members)
+	t.nodes[listenerNodeAddress.KeyForMap()] = n
+	t.mutex.Unlock()
+	n.logger.Info("joined gossip relay overlay", log.StringableSlice("neighbors", neighbors))
+
+	t.link.RegisterListener(n, listenerNodeAddress)
+}
+
+// the elected validators join the overlay in addition to the configured members, and the link is told the new neighbors
+// of the nodes. The elected validators of a block lower than that of elected validators already received are ignored
+func (t *RelayTransport) OnElectedValidatorsChanged(ctx context.Context, blockHeight primitives.BlockHeight, electedValidators []primitives.NodeAddress) {
+	t.mutex.Lock()
+	if blockHeight < t.electedValidatorsHeight {
+		t.mutex.Unlock()
+		return
+	}
+	t.electedValidatorsHeight = blockHeight
+	t.members = append(append([]primitives.NodeAddress{}, t.configuredMembers...), electedValidators...)
+
+	var allNeighbors []primitives.NodeAddress
+	for _, n := range t.nodes {
+		neighbors := n.joinOverlay(t.members)
+		n.logger.Info("elected validators changed, updated gossip relay overlay", log.StringableSlice("neighbors", neighbors), trace.LogFieldFrom(ctx))
+		allNeighbors = append(allNeighbors, neighbors...)
+	}
+	t.mutex.Unlock()
+
+	if listener, ok := t.link.(electedValidatorsListener); ok {
+		listener.OnElectedValidatorsChanged(ctx, blockHeight, sortedUniqueMembers(allNeighbors))
+	}
+}
+
+// places the node in the overlay of the members, returns its neighbors
+func (n *relayNode) joinOverlay(members []primitives.NodeAddress) []primitives.NodeAddress {
+	neighbors := Neighbors(n.nodeAddress, members, n.transport.config.GossipRelayMaxDegree())
+	routes := routesFrom(n.nodeAddress, members, n.transport.config.GossipRelayMaxDegree())
+
+	n.overlayMutex.Lock()
+	defer n.overlayMutex.Unlock()
+	n.neighbors = neighbors
+	n.routes = routes
+	return neighbors
+}
+
+func (t *RelayTransport) Send(ctx context.Context, data *adapter.TransportData) error {
+	t.mutex.RLock()
+	n, found := t.nodes[data.SenderNodeAddress.KeyForMap()]
+	t.mutex.RUnlock()
+	if !found {
+		return errors.Errorf("sender %s did not register a listener on the relay transport", data.SenderNodeAddress)
+	}
+	if n.signer == nil {
+		return errors.Errorf("sender %s has no signer on the relay transport", data.SenderNodeAddress)
+	}
+
+	switch data.RecipientMode {
+	case gossipmessages.RECIPIENT_LIST_MODE_BROADCAST, gossipmessages.RECIPIENT_LIST_MODE_LIST:
+	case gossipmessages.RECIPIENT_LIST_MODE_ALL_BUT_LIST:
+		panic("Not implemented")
+	default:
+		return errors.Errorf("unknown recipient mode: %s", data.RecipientMode.String())
+	}
+
+	messageId, err := newMessageId()
+	if err != nil {
+		return err
+	}
+
+	header := &relayHeader{
+		messageId:  messageId,
+		origin:     n.nodeAddress,
+		relayer:    n.nodeAddress,
+		hopsLeft:   t.config.GossipRelayMaxHops(),
+		mode:       data.RecipientMode,
+		recipients: data.RecipientNodeAddresses,
+	}
+	header.originSignature, err = n.signer.SignAsNode(ctx, 0, header.signedData(data.Payloads))
+	if err != nil {
+		return errors.Wrap(err, "failed signing relay message")
+	}
+	if len(header.originSignature) != signature.ECDSA_SECP256K1_SIGNATURE_SIZE_BYTES {
+		return errors.Errorf("relay message signature is %d bytes, expected %d", len(header.originSignature), signature.ECDSA_SECP256K1_SIGNATURE_SIZE_BYTES)
+	}
+
+	n.seen.add(messageId)
+	return n.relay(ctx, header, data.Payloads)
+}
+
+func (n *relayNode) OnTransportMessageReceived(ctx context.Context, payloads [][]byte) {
+	if len(payloads) == 0 {
+		n.transport.metrics.corruptMessages.Inc()
+		n.logger.Info("dropping relay message without a relay header", trace.LogFieldFrom(ctx))
+		return
+	}
+
+	header, err := decodeRelayHeader(payloads[len(payloads)-1])
+	if err != nil {
+		n.transport.metrics.corruptMessages.Inc()
+		n.logger.Info("dropping corrupt relay message", log.Error(err), trace.LogFieldFrom(ctx))
+		return
+	}
+
+	// a link which authenticates its peers only vouches for the relayer, the origin is vouched for by its signature
+	if linkSender, ok := adapter.AuthenticatedSender(ctx); ok && !linkSender.Equal(header.relayer) {
+		n.transport.metrics.corruptMessages.Inc()
+		n.logger.Info("dropping relay message from a relayer other than the peer", log.Stringable("relayer", header.relayer), log.Stringable("peer", linkSender), trace.LogFieldFrom(ctx))
+		return
+	}
+
+	// the signature is verified before the message id is marked as seen, so a forged copy cannot suppress the genuine message
+	payloads = payloads[:len(payloads)-1]
+	if n.seen.contains(header.messageId) {
+		n.transport.metrics.duplicateMessages.Inc()
+		return
+	}
+	if err := header.verifyOrigin(payloads); err != nil {
+		n.transport.metrics.corruptMessages.Inc()
+		n.logger.Info("dropping relay message with a forged origin", log.Error(err), log.Stringable("relayer", header.relayer), trace.LogFieldFrom(ctx))
+		return
+	}
+	if !n.seen.add(header.messageId) {
+		n.transport.metrics.duplicateMessages.Inc()
+		return
+	}
+
+	// the relayer sets the hops left, a message may not travel further than this node would send it
+	if maxHops := n.transport.config.GossipRelayMaxHops(); header.hopsLeft > maxHops {
+		header.hopsLeft = maxHops
+	}
+
+	// forwarding first, so a slow listener does not hold the message back from the rest of the overlay
+	if header.hopsLeft > 1 {
+		header.hopsLeft--
+		if err := n.relay(ctx, header, payloads); err != nil {
+			n.logger.Info("failed forwarding relay message", log.Error(err), log.Stringable("origin", header.origin), trace.LogFieldFrom(ctx))
+		}
+	}
+
+	if header.isRecipient(n.nodeAddress) {
+		n.transport.metrics.deliveredMessages.Inc()
+		n.listener.OnTransportMessageReceived(adapter.ContextWithAuthenticatedSender(ctx, header.origin), payloads)
+	}
+}
+
+func (n *relayNode) relay(ctx context.Context, header *relayHeader, payloads [][]byte) error {
+	nextHops := n.nextHops(header)
+	if len(nextHops) == 0 {
+		return nil
+	}
+
+	// the relay header goes last, so the link still finds the gossip header first and can prioritize the message
+	header.relayer = n.nodeAddress
+	err := n.transport.link.Send(ctx, &adapter.TransportData{
+		SenderNodeAddress:      n.nodeAddress,
+		RecipientMode:          gossipmessages.RECIPIENT_LIST_MODE_LIST,
+		RecipientNodeAddresses: nextHops,
+		Payloads:               append(payloads[:len(payloads):len(payloads)], header.encode()),
+	})
+	if err != nil {
+		n.transport.metrics.sendErrors.Inc()
+		return err
+	}
+	if !header.origin.Equal(n.nodeAddress) {
+		n.transport.metrics.forwardedMessages.Inc()
+	}
+	return nil
+}
+
+// a message for a list of recipients is passed only to the neighbors on the routes to the recipients, a broadcast is
+// flooded to the neighbors which did not already see it from the origin or from the relayer it came through
+func (n *relayNode) nextHops(header *relayHeader) []primitives.NodeAddress {
+	n.overlayMutex.RLock()
+	defer n.overlayMutex.RUnlock()
+
+	if header.mode == gossipmessages.RECIPIENT_LIST_MODE_LIST {
+		return n.nextHopsTowards(header.recipients)
+	}
+
+	var nextHops []primitives.NodeAddress
+	for _, neighbor := range n.neighbors {
+		if !neighbor.Equal(header.origin) && !neighbor.Equal(header.relayer) {
+			nextHops = append(nextHops, neighbor)
+		}
+	}
+	return nextHops
+}
+
+// recipients which are not members of the overlay, as well as the node itself, have no route and are skipped. Must be
+// called under the overlay mutex
+func (n *relayNode) nextHopsTowards(recipients []primitives.NodeAddress) []primitives.NodeAddress {
+	var nextHops []primitives.NodeAddress
+	added := make(map[string]bool)
+	for _, recipient := range recipients {
+		nextHop, found := n.routes[recipient.KeyForMap()]
+		if !found || added[nextHop.KeyForMap()] {
+			continue
+		}
+		added[nextHop.KeyForMap()] = true
+		nextHops = append(nextHops, nextHop)
+	}
+	return nextHops
+}
+
+// remembers the ids of the most recent messages, the oldest id is forgotten once the capacity is reached
+type seenMessages struct {
+	sync.Mutex
+	ids   map[string]bool
+	order []string
+	next  int
+}
+
+func newSeenMessages(capacity int) *seenMessages {
+	return &seenMessages{
+		ids:   make(map[string]bool),
+		order: make([]string, capacity),
+	}
+}
+
+func (s *seenMessages) contains(messageId []byte) bool {
+	s.Lock()
+	defer s.Unlock()
+	return s.ids[string(messageId)]
+}
+
+func (s *seenMessages) add(messageId []byte) (added bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	key := string(messageId)
+	if s.ids[key] {
+		return false
+	}
+	if oldest := s.order[s.next]; oldest != "" {
+		delete(s.ids, oldest)
+	}
+	s.order[s.next] = key
+	s.ids[key] = true
+	s.next = (s.next + 1) % len(s.order)
+	return true
+}
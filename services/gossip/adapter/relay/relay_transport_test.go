@@ -0,0 +1,396 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package relay
+
+import (
+	"bytes"
+	"context"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/crypto/digest"
+	"github.com/orbs-network/orbs-network-go/crypto/signature"
+	"github.com/orbs-network/orbs-network-go/crypto/signer"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter/memory"
+	"github.com/orbs-network/orbs-network-go/test"
+	"github.com/orbs-network/orbs-network-go/test/crypto/keys"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol/gossipmessages"
+	"github.com/orbs-network/scribe/log"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+type countingListener struct {
+	sync.Mutex
+	received             [][][]byte
+	authenticatedSenders []primitives.NodeAddress
+}
+
+func (l *countingListener) OnTransportMessageReceived(ctx context.Context, payloads [][]byte) {
+	l.Lock()
+	defer l.Unlock()
+	l.received = append(l.received, payloads)
+	sender, _ := adapter.AuthenticatedSender(ctx)
+	l.authenticatedSenders = append(l.authenticatedSenders, sender)
+}
+
+func (l *countingListener) count() int {
+	l.Lock()
+	defer l.Unlock()
+	return len(l.received)
+}
+
+type relayNetwork struct {
+	members     []primitives.NodeAddress
+	privateKeys map[string]primitives.EcdsaSecp256K1PrivateKey
+	link        adapter.Transport
+	transport   *RelayTransport
+	listeners   []*countingListener
+}
+
+// members are ordered by address, so the ring of the overlay follows the member indices
+func newRelayNetwork(ctx context.Context, tb testing.TB, numNodes int, maxDegree uint32, maxHops uint32) *relayNetwork {
+	logger := log.DefaultTestingLogger(tb)
+	n := &relayNetwork{privateKeys: make(map[string]primitives.EcdsaSecp256K1PrivateKey)}
+
+	validators := make(map[string]config.ValidatorNode)
+	signers := make(map[string]signer.Signer)
+	for i := 0; i < numNodes; i++ {
+		keyPair := keys.EcdsaSecp256K1KeyPairForTests(i)
+		n.members = append(n.members, keyPair.NodeAddress())
+		n.privateKeys[keyPair.NodeAddress().KeyForMap()] = keyPair.PrivateKey()
+		validators[keyPair.NodeAddress().KeyForMap()] = config.NewHardCodedValidatorNode(keyPair.NodeAddress())
+		signers[keyPair.NodeAddress().KeyForMap()] = signer.NewLocalSigner(keyPair.PrivateKey(), logger)
+	}
+	n.members = sortedUniqueMembers(n.members)
+
+	n.link = memory.NewTransport(ctx, logger, validators)
+	n.transport = NewTransport(n.link, n.members, signers, config.ForGossipRelayTests(maxDegree, maxHops), logger, metric.NewRegistry())
+
+	for _, member := range n.members {
+		listener := &countingListener{}
+		n.transport.RegisterListener(listener, member)
+		n.listeners = append(n.listeners, listener)
+	}
+	return n
+}
+
+// signs the header as signedBy, which is the origin of the message unless the origin is forged
+func (n *relayNetwork) sign(tb testing.TB, header *relayHeader, payloads [][]byte, signedBy primitives.NodeAddress) []byte {
+	sig, err := digest.SignAsNode(n.privateKeys[signedBy.KeyForMap()], header.signedData(payloads))
+	require.NoError(tb, err)
+	header.originSignature = sig
+	return header.encode()
+}
+
+func (n *relayNetwork) eventuallyReceived(indices ...int) bool {
+	return test.Eventually(1*time.Second, func() bool {
+		for _, i := range indices {
+			if n.listeners[i].count() == 0 {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func TestRelayTransport_BroadcastReachesEveryNodeOnce(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		n := newRelayNetwork(ctx, t, 30, 4, 16)
+		payloads := [][]byte{{0x71, 0x72}, {0x73}}
+
+		err := n.transport.Send(ctx, &adapter.TransportData{
+			SenderNodeAddress: n.members[0],
+			RecipientMode:     gossipmessages.RECIPIENT_LIST_MODE_BROADCAST,
+			Payloads:          payloads,
+		})
+		require.NoError(t, err)
+
+		var others []int
+		for i := 1; i < len(n.members); i++ {
+			others = append(others, i)
+		}
+		require.True(t, n.eventuallyReceived(others...), "every other node should receive the broadcast")
+
+		require.True(t, test.Eventually(1*time.Second, func() bool {
+			return n.transport.metrics.duplicateMessages.Value() > 0
+		}), "copies arriving over other paths should be suppressed")
+		for _, i := range others {
+			require.Equal(t, 1, n.listeners[i].count(), "node %d should receive the broadcast exactly once", i)
+			require.Equal(t, payloads, n.listeners[i].received[0], "node %d should receive the payloads without the relay header", i)
+		}
+		require.Zero(t, n.listeners[0].count(), "sender should not receive its own broadcast")
+	})
+}
+
+func TestRelayTransport_SendToListReachesOnlyTheRecipients(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		n := newRelayNetwork(ctx, t, 12, 2, 12)
+
+		err := n.transport.Send(ctx, &adapter.TransportData{
+			SenderNodeAddress:      n.members[0],
+			RecipientMode:          gossipmessages.RECIPIENT_LIST_MODE_LIST,
+			RecipientNodeAddresses: []primitives.NodeAddress{n.members[1], n.members[6]},
+			Payloads:               [][]byte{{0x81}},
+		})
+		require.NoError(t, err)
+
+		require.True(t, n.eventuallyReceived(1, 6), "recipients should receive the message, the far one over several hops")
+		for i := range n.members {
+			if i != 1 && i != 6 {
+				require.Zero(t, n.listeners[i].count(), "node %d is not a recipient and should not receive the message", i)
+			}
+		}
+	})
+}
+
+func TestRelayTransport_SendToListIsForwardedOnlyAlongTheRoute(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		n := newRelayNetwork(ctx, t, 10, 2, 10) // a ring
+
+		err := n.transport.Send(ctx, &adapter.TransportData{
+			SenderNodeAddress:      n.members[0],
+			RecipientMode:          gossipmessages.RECIPIENT_LIST_MODE_LIST,
+			RecipientNodeAddresses: []primitives.NodeAddress{n.members[3]},
+			Payloads:               [][]byte{{0x85}},
+		})
+		require.NoError(t, err)
+
+		require.True(t, n.eventuallyReceived(3), "recipient should receive the message")
+		require.EqualValues(t, 2, n.transport.metrics.forwardedMessages.Value(), "only the two nodes on the route to the recipient should forward the message")
+		require.Zero(t, n.transport.metrics.duplicateMessages.Value(), "no node should receive the message twice")
+	})
+}
+
+func TestRelayTransport_DoesNotForwardBeyondItsOwnMaxHops(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		n := newRelayNetwork(ctx, t, 10, 2, 2) // a ring
+		header := &relayHeader{
+			messageId: []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+			origin:    n.members[0],
+			relayer:   n.members[0],
+			hopsLeft:  1000,
+			mode:      gossipmessages.RECIPIENT_LIST_MODE_BROADCAST,
+		}
+
+		n.transport.nodes[n.members[1].KeyForMap()].OnTransportMessageReceived(ctx, [][]byte{{0x93}, n.sign(t, header, [][]byte{{0x93}}, header.origin)})
+
+		require.True(t, n.eventuallyReceived(1, 2), "nodes within max hops should receive the broadcast")
+		for _, i := range []int{3, 4, 5, 6, 7, 8, 9} {
+			require.Zero(t, n.listeners[i].count(), "node %d is beyond max hops and should not receive the broadcast", i)
+		}
+	})
+}
+
+func TestRelayTransport_DoesNotForwardBeyondMaxHops(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		n := newRelayNetwork(ctx, t, 10, 2, 2) // a ring
+
+		err := n.transport.Send(ctx, &adapter.TransportData{
+			SenderNodeAddress: n.members[0],
+			RecipientMode:     gossipmessages.RECIPIENT_LIST_MODE_BROADCAST,
+			Payloads:          [][]byte{{0x91}},
+		})
+		require.NoError(t, err)
+
+		require.True(t, n.eventuallyReceived(1, 2, 8, 9), "nodes up to 2 hops away should receive the broadcast")
+		for _, i := range []int{3, 4, 5, 6, 7} {
+			require.Zero(t, n.listeners[i].count(), "node %d is more than 2 hops away and should not receive the broadcast", i)
+		}
+	})
+}
+
+func TestRelayTransport_DropsMessageWithCorruptRelayHeader(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		n := newRelayNetwork(ctx, t, 3, 2, 2)
+
+		err := n.link.Send(ctx, &adapter.TransportData{
+			SenderNodeAddress:      n.members[0],
+			RecipientMode:          gossipmessages.RECIPIENT_LIST_MODE_LIST,
+			RecipientNodeAddresses: []primitives.NodeAddress{n.members[1]},
+			Payloads:               [][]byte{{0x01, 0x02}, {0xa1}},
+		})
+		require.NoError(t, err)
+
+		require.True(t, test.Eventually(1*time.Second, func() bool {
+			return n.transport.metrics.corruptMessages.Value() == 1
+		}), "message should be counted as corrupt")
+		require.Zero(t, n.listeners[1].count(), "corrupt message should not be delivered")
+	})
+}
+
+func TestRelayTransport_DropsMessageFromRelayerOtherThanTheAuthenticatedPeer(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		n := newRelayNetwork(ctx, t, 3, 2, 1)
+		header := &relayHeader{
+			messageId: []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+			origin:    n.members[2],
+			relayer:   n.members[2],
+			hopsLeft:  1,
+			mode:      gossipmessages.RECIPIENT_LIST_MODE_BROADCAST,
+		}
+
+		peerCtx := adapter.ContextWithAuthenticatedSender(ctx, n.members[0])
+		n.transport.nodes[n.members[1].KeyForMap()].OnTransportMessageReceived(peerCtx, [][]byte{{0x01}, n.sign(t, header, [][]byte{{0x01}}, header.origin)})
+
+		require.EqualValues(t, 1, n.transport.metrics.corruptMessages.Value(), "message should be counted as corrupt")
+		require.Zero(t, n.listeners[1].count(), "message claiming another relayer should not be delivered")
+	})
+}
+
+func TestRelayTransport_DeliversTheOriginAsTheAuthenticatedSender(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		n := newRelayNetwork(ctx, t, 3, 2, 1)
+		node := n.transport.nodes[n.members[1].KeyForMap()]
+		peerCtx := adapter.ContextWithAuthenticatedSender(ctx, n.members[0])
+
+		direct := &relayHeader{
+			messageId: []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+			origin:    n.members[0],
+			relayer:   n.members[0],
+			hopsLeft:  1,
+			mode:      gossipmessages.RECIPIENT_LIST_MODE_BROADCAST,
+		}
+		node.OnTransportMessageReceived(peerCtx, [][]byte{{0x01}, n.sign(t, direct, [][]byte{{0x01}}, direct.origin)})
+
+		forwarded := &relayHeader{
+			messageId: []byte{16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1},
+			origin:    n.members[2],
+			relayer:   n.members[0],
+			hopsLeft:  1,
+			mode:      gossipmessages.RECIPIENT_LIST_MODE_BROADCAST,
+		}
+		node.OnTransportMessageReceived(peerCtx, [][]byte{{0x02}, n.sign(t, forwarded, [][]byte{{0x02}}, forwarded.origin)})
+
+		require.Equal(t, []primitives.NodeAddress{n.members[0], n.members[2]}, n.listeners[1].authenticatedSenders, "the origin of a message should be authenticated whichever peer relayed it")
+	})
+}
+
+func TestRelayTransport_DropsMessageRelayedWithAForgedOrigin(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		n := newRelayNetwork(ctx, t, 3, 2, 1)
+		node := n.transport.nodes[n.members[1].KeyForMap()]
+		peerCtx := adapter.ContextWithAuthenticatedSender(ctx, n.members[0])
+		header := &relayHeader{
+			messageId: []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+			origin:    n.members[2],
+			relayer:   n.members[0],
+			hopsLeft:  1,
+			mode:      gossipmessages.RECIPIENT_LIST_MODE_BROADCAST,
+		}
+
+		node.OnTransportMessageReceived(peerCtx, [][]byte{{0x01}, n.sign(t, header, [][]byte{{0x01}}, n.members[0])})
+		require.EqualValues(t, 1, n.transport.metrics.corruptMessages.Value(), "message with a forged origin should be counted as corrupt")
+		require.Zero(t, n.listeners[1].count(), "message with a forged origin should not be delivered")
+
+		signed := n.sign(t, header, [][]byte{{0x01}}, header.origin)
+		node.OnTransportMessageReceived(peerCtx, [][]byte{{0x02}, signed})
+		require.EqualValues(t, 2, n.transport.metrics.corruptMessages.Value(), "message with payloads other than the origin signed should be counted as corrupt")
+		require.Zero(t, n.listeners[1].count(), "message with payloads other than the origin signed should not be delivered")
+
+		node.OnTransportMessageReceived(peerCtx, [][]byte{{0x01}, signed})
+		require.Equal(t, []primitives.NodeAddress{n.members[2]}, n.listeners[1].authenticatedSenders, "forged copies should not keep the genuine message from being delivered")
+	})
+}
+
+type recordingLink struct {
+	sync.Mutex
+	sentTo            [][]primitives.NodeAddress
+	electedValidators [][]primitives.NodeAddress
+}
+
+func (l *recordingLink) RegisterListener(listener adapter.TransportListener, listenerNodeAddress primitives.NodeAddress) {
+}
+
+func (l *recordingLink) Send(ctx context.Context, data *adapter.TransportData) error {
+	l.Lock()
+	defer l.Unlock()
+	l.sentTo = append(l.sentTo, data.RecipientNodeAddresses)
+	return nil
+}
+
+func (l *recordingLink) OnElectedValidatorsChanged(ctx context.Context, blockHeight primitives.BlockHeight, electedValidators []primitives.NodeAddress) {
+	l.Lock()
+	defer l.Unlock()
+	l.electedValidators = append(l.electedValidators, electedValidators)
+}
+
+func TestRelayTransport_ElectedValidatorsJoinTheOverlay(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		logger := log.DefaultTestingLogger(t)
+		var addresses []primitives.NodeAddress
+		privateKeys := make(map[string]primitives.EcdsaSecp256K1PrivateKey)
+		for i := 0; i < 4; i++ {
+			keyPair := keys.EcdsaSecp256K1KeyPairForTests(i)
+			addresses = append(addresses, keyPair.NodeAddress())
+			privateKeys[keyPair.NodeAddress().KeyForMap()] = keyPair.PrivateKey()
+		}
+		addresses = sortedUniqueMembers(addresses) // a ring following the indices
+		self, configured, elected := addresses[0], addresses[:3], addresses[3]
+
+		link := &recordingLink{}
+		signers := map[string]signer.Signer{self.KeyForMap(): signer.NewLocalSigner(privateKeys[self.KeyForMap()], logger)}
+		transport := NewTransport(link, configured, signers, config.ForGossipRelayTests(2, 4), logger, metric.NewRegistry())
+		transport.RegisterListener(&countingListener{}, self)
+		sendToElected := func() {
+			require.NoError(t, transport.Send(ctx, &adapter.TransportData{
+				SenderNodeAddress:      self,
+				RecipientMode:          gossipmessages.RECIPIENT_LIST_MODE_LIST,
+				RecipientNodeAddresses: []primitives.NodeAddress{elected},
+				Payloads:               [][]byte{{0x01}},
+			}))
+		}
+
+		sendToElected()
+		require.Empty(t, link.sentTo, "a node which is not a member should have no route")
+
+		transport.OnElectedValidatorsChanged(ctx, 5, []primitives.NodeAddress{elected})
+		require.Equal(t, [][]primitives.NodeAddress{{addresses[1], elected}}, link.electedValidators, "link should be told the neighbors of the node in the ring including the elected validator")
+		sendToElected()
+		require.Equal(t, [][]primitives.NodeAddress{{elected}}, link.sentTo, "elected validator should be a neighbor of the node")
+
+		transport.OnElectedValidatorsChanged(ctx, 4, nil)
+		require.Len(t, link.electedValidators, 1, "elected validators of a lower block should be ignored")
+		sendToElected()
+		require.Len(t, link.sentTo, 2, "elected validator should remain a member")
+	})
+}
+
+func TestRelayTransport_FailsToSendFromUnregisteredNode(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		n := newRelayNetwork(ctx, t, 3, 2, 2)
+
+		err := n.transport.Send(ctx, &adapter.TransportData{
+			SenderNodeAddress: primitives.NodeAddress{0xff},
+			RecipientMode:     gossipmessages.RECIPIENT_LIST_MODE_BROADCAST,
+		})
+		require.Error(t, err)
+	})
+}
+
+func TestRelayHeader_EncodeDecode(t *testing.T) {
+	members := membersForTests(4)
+	header := &relayHeader{
+		messageId:       []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		origin:          members[0],
+		originSignature: primitives.EcdsaSecp256K1Sig(bytes.Repeat([]byte{0x5a}, signature.ECDSA_SECP256K1_SIGNATURE_SIZE_BYTES)),
+		relayer:         members[1],
+		hopsLeft:        7,
+		mode:            gossipmessages.RECIPIENT_LIST_MODE_LIST,
+		recipients:      []primitives.NodeAddress{members[2], members[3]},
+	}
+
+	decoded, err := decodeRelayHeader(header.encode())
+	require.NoError(t, err)
+	require.Equal(t, header, decoded)
+
+	_, err = decodeRelayHeader(header.encode()[:relayHeaderFixedSizeBytes+5])
+	require.Error(t, err, "header cut in the middle of a recipient should fail to decode")
+}
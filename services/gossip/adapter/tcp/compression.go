@@ -0,0 +1,185 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package tcp
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"github.com/orbs-network/membuffers/go"
+	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
+	"github.com/orbs-network/orbs-network-go/synchronization/supervised"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"sync"
+	"sync/atomic"
+)
+
+// the payloads of a compressed frame are replaced by their compressed size followed by the compressed payloads.
+// Peers which do not support compression reject the flag, so it is only sent to peers which offered compression
+const TRANSPORT_FRAME_FLAG_COMPRESSED = uint32(1) << 30
+
+// bounds the memory a small compressed frame may expand to
+const MAX_DECOMPRESSED_FRAME_SIZE_BYTES = 64 * 1024 * 1024
+
+// a peer accepting incoming connections offers compression by sending a compressed frame without payloads right after
+// the handshake. Peers which do not support compression never offer it, and never read the offer
+type compressionOffer struct {
+	accepted int32
+}
+
+func (o *compressionOffer) isAccepted() bool {
+	return atomic.LoadInt32(&o.accepted) == 1
+}
+
+func encodeCompressionOffer() ([]byte, error) {
+	return compressTransportFrame(encodeTransportFrame(0, nil))
+}
+
+// the offer is awaited in the background since older peers never send one, messages are sent uncompressed until it arrives
+func (t *DirectTransport) waitForCompressionOffer(ctx context.Context, conn net.Conn, networkAddress string) *compressionOffer {
+	offer := &compressionOffer{}
+	if t.config.GossipCompressionMinSizeBytes() == 0 {
+		return offer
+	}
+
+	supervised.GoOnce(t.logger, func() {
+		_, flags, err := receiveTransportFrame(ctx, conn, t.config.GossipNetworkTimeout())
+		if err != nil || flags&TRANSPORT_FRAME_FLAG_COMPRESSED == 0 {
+			t.logger.Info("gossip peer did not offer compression", log.String("peer", networkAddress), trace.LogFieldFrom(ctx))
+			return
+		}
+		atomic.StoreInt32(&offer.accepted, 1)
+	})
+	return offer
+}
+
+// a message queued to the peers it is sent to. It is compressed the first time it is sent to a peer which offered
+// compression, and the compressed frame is kept so a broadcast is compressed once rather than once per peer
+type outgoingMessage struct {
+	*adapter.TransportData
+	compressionMinSizeBytes uint32
+
+	compressOnce    sync.Once
+	compressedFrame []byte // nil when the message is not worth compressing
+	frameSize       int    // of the frame before compression, set along with the compressed frame
+}
+
+func (t *DirectTransport) newOutgoingMessage(data *adapter.TransportData) *outgoingMessage {
+	return &outgoingMessage{TransportData: data, compressionMinSizeBytes: t.config.GossipCompressionMinSizeBytes()}
+}
+
+// the compressed frame is sent only to peers which offered compression, the others get the frame as it is. Returns the
+// size of the frame before compression along with the frame to send
+func (m *outgoingMessage) frameFor(offer *compressionOffer) (frame []byte, uncompressedSize int) {
+	if m.compressionMinSizeBytes > 0 && offer.isAccepted() {
+		m.compressOnce.Do(m.compressIfWorthwhile)
+		if m.compressedFrame != nil {
+			return m.compressedFrame, m.frameSize
+		}
+	}
+	frame = encodeTransportData(m.Payloads)
+	return frame, len(frame)
+}
+
+// leaves the compressed frame nil for frames below the configured size, or which do not shrink
+func (m *outgoingMessage) compressIfWorthwhile() {
+	frame := encodeTransportData(m.Payloads)
+	if len(frame) < int(m.compressionMinSizeBytes) || len(frame) > MAX_DECOMPRESSED_FRAME_SIZE_BYTES {
+		return
+	}
+
+	compressed, err := compressTransportFrame(frame)
+	if err != nil || len(compressed) >= len(frame) {
+		return
+	}
+	m.compressedFrame = compressed
+	m.frameSize = len(frame)
+}
+
+// keeps the flags and number of payloads of the frame, and compresses everything following them
+func compressTransportFrame(frame []byte) ([]byte, error) {
+	buffer := bytes.NewBuffer(make([]byte, 8))
+	fw, err := flate.NewWriter(buffer, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	_, err = fw.Write(frame[4:])
+	if err == nil {
+		err = fw.Close()
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed compressing transport frame")
+	}
+
+	compressedSize := uint32(buffer.Len() - 8)
+	if compressedSize > MAX_PAYLOAD_SIZE_BYTES {
+		return nil, errors.Errorf("compressed transport frame is too big: %d bytes", compressedSize)
+	}
+	buffer.Write(make([]byte, calcPaddingSize(compressedSize)))
+
+	res := buffer.Bytes()
+	membuffers.WriteUint32(res, membuffers.GetUint32(frame)|TRANSPORT_FRAME_FLAG_COMPRESSED)
+	membuffers.WriteUint32(res[4:], compressedSize)
+	return res, nil
+}
+
+// reads the compressed payloads of a frame and returns them as they were before compression. The decompressed bytes
+// must hold exactly the payloads, anything left over means the frame is corrupt
+func readCompressedPayloads(numPayloads uint32, read func(size uint32) ([]byte, error)) ([][]byte, error) {
+	sizeBuffer, err := read(4)
+	if err != nil {
+		return nil, err
+	}
+	compressedSize := membuffers.GetUint32(sizeBuffer)
+	if compressedSize > MAX_PAYLOAD_SIZE_BYTES {
+		return nil, errors.Errorf("received compressed message too big: %d bytes", compressedSize)
+	}
+
+	compressed, err := read(compressedSize)
+	if err != nil {
+		return nil, err
+	}
+	paddingSize := calcPaddingSize(compressedSize)
+	if paddingSize > 0 {
+		_, err := read(paddingSize)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	decompressor := flate.NewReader(bytes.NewReader(compressed))
+	defer decompressor.Close()
+	decompressed, err := ioutil.ReadAll(io.LimitReader(decompressor, MAX_DECOMPRESSED_FRAME_SIZE_BYTES+1))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed decompressing message")
+	}
+	if len(decompressed) > MAX_DECOMPRESSED_FRAME_SIZE_BYTES {
+		return nil, errors.Errorf("received compressed message which decompresses to more than %d bytes", MAX_DECOMPRESSED_FRAME_SIZE_BYTES)
+	}
+
+	reader := bytes.NewReader(decompressed)
+	res, err := readPayloads(numPayloads, func(size uint32) ([]byte, error) {
+		if int64(size) > int64(reader.Len()) {
+			return nil, errors.Errorf("compressed message is missing %d bytes", int64(size)-int64(reader.Len()))
+		}
+		buffer := make([]byte, size)
+		_, err := io.ReadFull(reader, buffer)
+		return buffer, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reader.Len() > 0 {
+		return nil, errors.Errorf("compressed message has %d bytes left over after its payloads", reader.Len())
+	}
+	return res, nil
+}
@@ -0,0 +1,169 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package tcp
+
+import (
+	"bytes"
+	"context"
+	"github.com/orbs-network/membuffers/go"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
+	"github.com/orbs-network/orbs-network-go/test"
+	testKeys "github.com/orbs-network/orbs-network-go/test/crypto/keys"
+	"github.com/stretchr/testify/require"
+	"net"
+	"testing"
+)
+
+func compressiblePayloads() [][]byte {
+	return [][]byte{{0x11}, bytes.Repeat([]byte{0x22, 0x33, 0x44}, 1000)}
+}
+
+func transportWithCompression(ctx context.Context, t *testing.T) *DirectTransport {
+	cfg := config.ForGossipAdapterTests(testKeys.EcdsaSecp256K1KeyPairForTests(0).NodeAddress(), 0, make(map[string]config.GossipPeer))
+	return makeTransport(ctx, t, cfg)
+}
+
+func TestCompression_CompressedFrameIsReceivedAsOriginalPayloads(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		frame, err := compressTransportFrame(encodeTransportFrame(TRANSPORT_FRAME_FLAG_PEER_EXCHANGE, compressiblePayloads()))
+		require.NoError(t, err)
+		require.Equal(t, TRANSPORT_FRAME_FLAG_COMPRESSED|TRANSPORT_FRAME_FLAG_PEER_EXCHANGE|2, membuffers.GetUint32(frame), "frame should keep its flags and number of payloads")
+		require.Zero(t, len(frame)%4, "frame should be padded")
+
+		sender, receiver := net.Pipe()
+		defer sender.Close()
+		go sender.Write(frame)
+
+		payloads, flags, err := receiveTransportFrame(ctx, receiver, TEST_NETWORK_TIMEOUT)
+		require.NoError(t, err)
+		require.Equal(t, TRANSPORT_FRAME_FLAG_COMPRESSED|TRANSPORT_FRAME_FLAG_PEER_EXCHANGE, flags)
+		require.Equal(t, compressiblePayloads(), payloads)
+	})
+}
+
+func TestCompression_CorruptCompressedFrameIsRejected(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		frame, err := compressTransportFrame(encodeTransportData(compressiblePayloads()))
+		require.NoError(t, err)
+		membuffers.WriteUint32(frame, TRANSPORT_FRAME_FLAG_COMPRESSED|3)
+
+		sender, receiver := net.Pipe()
+		defer sender.Close()
+		go sender.Write(frame)
+
+		_, _, err = receiveTransportFrame(ctx, receiver, TEST_NETWORK_TIMEOUT)
+		require.Error(t, err, "frame claiming more payloads than it holds should be rejected")
+	})
+}
+
+func TestCompression_CompressedFrameWithBytesLeftOverIsRejected(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		frame, err := compressTransportFrame(encodeTransportData(compressiblePayloads()))
+		require.NoError(t, err)
+		membuffers.WriteUint32(frame, TRANSPORT_FRAME_FLAG_COMPRESSED|1)
+
+		sender, receiver := net.Pipe()
+		defer sender.Close()
+		go sender.Write(frame)
+
+		_, _, err = receiveTransportFrame(ctx, receiver, TEST_NETWORK_TIMEOUT)
+		require.Error(t, err, "frame holding more payloads than it claims should be rejected")
+	})
+}
+
+func TestCompression_OnlyLargeFramesToPeersWhichOfferedCompressionAreCompressed(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		transport := transportWithCompression(ctx, t)
+		largeFrame := encodeTransportData(compressiblePayloads())
+		large := transport.newOutgoingMessage(&adapter.TransportData{Payloads: compressiblePayloads()})
+		small := transport.newOutgoingMessage(&adapter.TransportData{Payloads: [][]byte{{0x11}}})
+
+		frame, uncompressedSize := large.frameFor(&compressionOffer{})
+		require.Equal(t, largeFrame, frame, "peer which did not offer compression should get uncompressed frames")
+		require.Equal(t, len(largeFrame), uncompressedSize)
+		require.Nil(t, large.compressedFrame, "message should not be compressed before it is sent to a peer which offered compression")
+
+		accepted := &compressionOffer{accepted: 1}
+		frame, _ = small.frameFor(accepted)
+		require.Equal(t, encodeTransportData([][]byte{{0x11}}), frame, "frame below the threshold should not be compressed")
+
+		compressed, uncompressedSize := large.frameFor(accepted)
+		require.NotZero(t, membuffers.GetUint32(compressed)&TRANSPORT_FRAME_FLAG_COMPRESSED, "large frame should be compressed")
+		require.Equal(t, len(largeFrame), uncompressedSize, "size before compression should be that of the uncompressed frame")
+	})
+}
+
+func TestCompression_MessageIsCompressedOnceHoweverManyPeersItIsSentTo(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		transport := transportWithCompression(ctx, t)
+		message := transport.newOutgoingMessage(&adapter.TransportData{Payloads: compressiblePayloads()})
+
+		first, _ := message.frameFor(&compressionOffer{accepted: 1})
+		second, _ := message.frameFor(&compressionOffer{accepted: 1})
+		require.True(t, &first[0] == &second[0], "peers should be sent the same compressed frame")
+	})
+}
+
+func TestCompression_MetricsCountTheFramesSent(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		transport := transportWithCompression(ctx, t)
+		largeFrame := encodeTransportData(compressiblePayloads())
+		message := transport.newOutgoingMessage(&adapter.TransportData{Payloads: compressiblePayloads()})
+
+		sendAndRead := func(offer *compressionOffer) {
+			sender, receiver := net.Pipe()
+			defer sender.Close()
+			go func() {
+				_, _, _ = receiveTransportFrame(ctx, receiver, TEST_NETWORK_TIMEOUT)
+				receiver.Close()
+			}()
+			require.NoError(t, transport.sendTransportData(ctx, sender, message, offer))
+		}
+
+		sendAndRead(&compressionOffer{accepted: 1})
+		compressed, _ := message.frameFor(&compressionOffer{accepted: 1})
+		require.EqualValues(t, len(largeFrame), transport.metrics.outgoingBytesBeforeCompression.Value())
+		require.EqualValues(t, len(compressed), transport.metrics.outgoingBytesAfterCompression.Value())
+
+		sendAndRead(&compressionOffer{})
+		require.EqualValues(t, 2*len(largeFrame), transport.metrics.outgoingBytesBeforeCompression.Value())
+		require.EqualValues(t, len(compressed)+len(largeFrame), transport.metrics.outgoingBytesAfterCompression.Value(), "frame sent uncompressed to a peer which did not offer compression should count as is")
+	})
+}
+
+func TestCompression_ClientAcceptsOfferOfServer(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		transport := transportWithCompression(ctx, t)
+		server, client := net.Pipe()
+		defer server.Close()
+
+		offer := transport.waitForCompressionOffer(ctx, client, "peer")
+		encodedOffer, err := encodeCompressionOffer()
+		require.NoError(t, err)
+		_, err = server.Write(encodedOffer)
+		require.NoError(t, err)
+
+		require.True(t, test.Eventually(test.EVENTUALLY_ADAPTER_TIMEOUT, offer.isAccepted), "client should accept compression offered by the server")
+	})
+}
+
+func TestCompression_ClientDoesNotCompressForServerWithoutCompression(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		transport := transportWithCompression(ctx, t)
+		server, client := net.Pipe()
+
+		offer := transport.waitForCompressionOffer(ctx, client, "peer")
+		_, err := server.Write(exampleWireProtocolEncoding_KeepAlive())
+		require.NoError(t, err)
+		server.Close()
+
+		message := transport.newOutgoingMessage(&adapter.TransportData{Payloads: compressiblePayloads()})
+		frame, _ := message.frameFor(offer)
+		require.Equal(t, encodeTransportData(compressiblePayloads()), frame, "frames to a server which did not offer compression should be sent as they are")
+	})
+}
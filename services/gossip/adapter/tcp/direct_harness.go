@@ -11,12 +11,14 @@ import (
 	"fmt"
 	"github.com/orbs-network/go-mock"
 	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/crypto/signer"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
 	"github.com/orbs-network/orbs-network-go/services/gossip/adapter/testkit"
 	"github.com/orbs-network/orbs-network-go/test"
 	testKeys "github.com/orbs-network/orbs-network-go/test/crypto/keys"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 	"net"
 	"testing"
@@ -60,8 +62,9 @@ func newDirectHarnessWithConnectedPeersWithTimeouts(t *testing.T, ctx context.Co
 	transport := makeTransport(ctx, t, cfg)                                               // step 3: create the transport; it will attempt to establish connections with the peer servers repeatedly until they start accepting connections
 	// end of section where order matters
 
-	peerTalkerConnection := establishPeerClient(t, transport.serverPort)           // establish connection from test to server port ( test harness ==> SUT )
+	peerTalkerConnection := establishPeerClient(t, transport.Port())               // establish connection from test to server port ( test harness ==> SUT )
 	peersListenersConnections := establishPeerServerConnections(t, peersListeners) // establish connection from transport clients to peer servers ( SUT ==> test harness)
+	// all of the connections above passed the handshake, so test peers read and write plaintext
 
 	h := &directHarness{
 		config:                    cfg,
@@ -83,7 +86,7 @@ func newDirectHarnessWithConnectedPeersWithTimeouts(t *testing.T, ctx context.Co
 func makeTransport(ctx context.Context, tb testing.TB, cfg config.GossipTransportConfig) *DirectTransport {
 	log := log.DefaultTestingLogger(tb)
 	registry := metric.NewRegistry()
-	transport := NewDirectTransport(ctx, cfg, log, registry)
+	transport := NewDirectTransport(ctx, cfg, signerForTests(tb, 0), log, registry)
 	// to synchronize tests, wait until server is ready
 	test.Eventually(test.EVENTUALLY_ADAPTER_TIMEOUT, func() bool {
 		return transport.IsServerListening()
@@ -91,16 +94,35 @@ func makeTransport(ctx context.Context, tb testing.TB, cfg config.GossipTranspor
 	return transport
 }
 
+func signerForTests(tb testing.TB, keyIndex int) signer.Signer {
+	return signer.NewLocalSigner(testKeys.EcdsaSecp256K1KeyPairForTests(keyIndex).PrivateKey(), log.DefaultTestingLogger(tb))
+}
+
+// the identity of the test peer at index, the transport itself holds key 0
+func peerIdentityForTests(tb testing.TB, peerIndex int) *handshakeIdentity {
+	return &handshakeIdentity{
+		nodeAddress: testKeys.EcdsaSecp256K1KeyPairForTests(peerIndex + 1).NodeAddress(),
+		signer:      signerForTests(tb, peerIndex+1),
+	}
+}
+
+func isTransportUnderTest(address primitives.NodeAddress) bool {
+	return address.Equal(testKeys.EcdsaSecp256K1KeyPairForTests(0).NodeAddress())
+}
+
+// connects to the transport as the first test peer
 func establishPeerClient(t *testing.T, serverPort int) net.Conn {
-	peerTalkerConnection, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", serverPort))
+	rawConn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", serverPort))
 	require.NoError(t, err, "test should be able connect to local transport")
+	peerTalkerConnection, err := clientHandshake(context.Background(), rawConn, TEST_NETWORK_TIMEOUT, peerIdentityForTests(t, 0), testKeys.EcdsaSecp256K1KeyPairForTests(0).NodeAddress())
+	require.NoError(t, err, "test peer should pass the handshake with local transport")
 	return peerTalkerConnection
 }
 
 func establishPeerServerConnections(t *testing.T, peersListeners []net.Listener) []net.Conn {
 	peersListenersConnections := make([]net.Conn, NETWORK_SIZE-1)
 	for i := 0; i < NETWORK_SIZE-1; i++ {
-		conn, err := peersListeners[i].Accept()
+		conn, err := acceptPeerConnection(t, peersListeners[i], i)
 		require.NoError(t, err, "test peer server could not accept connection from local transport")
 
 		peersListenersConnections[i] = conn
@@ -108,6 +130,28 @@ func establishPeerServerConnections(t *testing.T, peersListeners []net.Listener)
 	return peersListenersConnections
 }
 
+func acceptPeerConnection(tb testing.TB, peerListener net.Listener, peerIndex int) (net.Conn, error) {
+	rawConn, err := peerListener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := serverHandshake(context.Background(), rawConn, TEST_NETWORK_TIMEOUT, peerIdentityForTests(tb, peerIndex), isTransportUnderTest)
+	if err != nil {
+		rawConn.Close()
+		return nil, err
+	}
+	// the transport advertises its peers right after connecting
+	_, flags, err := receiveTransportFrame(context.Background(), conn, TEST_NETWORK_TIMEOUT)
+	if err == nil && flags != TRANSPORT_FRAME_FLAG_PEER_EXCHANGE {
+		err = errors.Errorf("expected a peer exchange frame, got flags %x", flags)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
 func makePeers(t *testing.T) (map[string]config.GossipPeer, []net.Listener) {
 	gossipPeers := make(map[string]config.GossipPeer)
 	peersListeners := make([]net.Listener, NETWORK_SIZE-1)
@@ -151,9 +195,9 @@ func (h *directHarness) cleanupConnectedPeers() {
 	}
 }
 
-func (h *directHarness) reconnect(listenerIndex int) error {
-	h.peersListenersConnections[listenerIndex].Close()    // disconnect transport forcefully
-	conn, err := h.peersListeners[listenerIndex].Accept() // reconnect transport forcefully
+func (h *directHarness) reconnect(tb testing.TB, listenerIndex int) error {
+	h.peersListenersConnections[listenerIndex].Close()                                    // disconnect transport forcefully
+	conn, err := acceptPeerConnection(tb, h.peersListeners[listenerIndex], listenerIndex) // reconnect transport forcefully
 	h.peersListenersConnections[listenerIndex] = conn
 
 	return err
@@ -187,8 +231,11 @@ func (h *directHarness) verifyTransportListenerNotCalled(t *testing.T) {
 }
 
 func (h *directHarness) allOutgoingQueuesEnabled() bool {
+	h.transport.mutex.RLock()
+	defer h.transport.mutex.RUnlock()
+
 	for _, queue := range h.transport.outgoingPeerQueues {
-		if queue.disabled {
+		if queue.isDisabled() {
 			return false
 		}
 	}
@@ -203,7 +250,7 @@ func concatSlices(slices ...[]byte) []byte {
 	return tmp
 }
 
-// encoded examples of the gossip wire protocol spec:
+// encoded examples of the gossip wire protocol spec, which test peers read and write as the plaintext of a secure connection:
 // https://github.com/orbs-network/orbs-spec/blob/master/encoding/gossip/membuffers-over-tcp.md
 
 func exampleWireProtocolEncoding_Payloads_0x11_0x2233() []byte {
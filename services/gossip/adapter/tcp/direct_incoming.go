@@ -38,6 +38,7 @@ func (t *DirectTransport) serverListenForIncomingConnections(ctx context.Context
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
 	t.serverListeningUnderMutex = true
+	t.serverPort = listener.Addr().(*net.TCPAddr).Port
 
 	return listener, err
 }
@@ -55,8 +56,7 @@ func (t *DirectTransport) serverMainLoop(parentCtx context.Context, listenPort u
 		panic(fmt.Sprintf("gossip transport failed to listen on port %d: %s", listenPort, err.Error()))
 	}
 
-	t.serverPort = listener.Addr().(*net.TCPAddr).Port
-	t.logger.Info("gossip transport server listening", log.Uint32("port", uint32(t.serverPort)))
+	t.logger.Info("gossip transport server listening", log.Uint32("port", uint32(listener.Addr().(*net.TCPAddr).Port)))
 
 	for {
 		if parentCtx.Err() != nil {
@@ -82,15 +82,35 @@ func (t *DirectTransport) serverMainLoop(parentCtx context.Context, listenPort u
 	}
 }
 
-func (t *DirectTransport) serverHandleIncomingConnection(ctx context.Context, conn net.Conn) {
-	t.logger.Info("successful incoming gossip transport connection", log.String("peer", conn.RemoteAddr().String()), trace.LogFieldFrom(ctx))
-	// TODO(https://github.com/orbs-network/orbs-network-go/issues/182): add a white list for IPs we're willing to accept connections from
-	// TODO(https://github.com/orbs-network/orbs-network-go/issues/182): make sure each IP from the white list connects only once
+func (t *DirectTransport) serverHandleIncomingConnection(ctx context.Context, rawConn net.Conn) {
+	// TODO(https://github.com/orbs-network/orbs-network-go/issues/182): make sure each peer connects only once
+	conn, err := serverHandshake(ctx, rawConn, t.config.GossipNetworkTimeout(), t.identity, t.isKnownPeer)
+	if err != nil {
+		t.metrics.incomingConnectionHandshakeErrors.Inc()
+		t.logger.Info("incoming gossip transport connection failed handshake, disconnecting", log.Error(err), log.String("peer", rawConn.RemoteAddr().String()), trace.LogFieldFrom(ctx))
+		rawConn.Close()
+		return
+	}
+
+	t.logger.Info("successful incoming gossip transport connection", log.String("peer", conn.RemoteAddr().String()), log.Stringable("peer-node-address", conn.peerNodeAddress), trace.LogFieldFrom(ctx))
 	t.metrics.activeIncomingConnections.Inc()
 	defer t.metrics.activeIncomingConnections.Dec()
 
+	if t.config.GossipCompressionMinSizeBytes() > 0 {
+		offer, err := encodeCompressionOffer()
+		if err == nil {
+			err = write(ctx, conn, offer, t.config.GossipNetworkTimeout())
+		}
+		if err != nil {
+			t.metrics.incomingConnectionTransportErrors.Inc()
+			t.logger.Info("failed offering compression, disconnecting", log.Error(err), log.String("peer", conn.RemoteAddr().String()), trace.LogFieldFrom(ctx))
+			conn.Close()
+			return
+		}
+	}
+
 	for {
-		payloads, err := t.receiveTransportData(ctx, conn)
+		payloads, flags, err := t.receiveTransportData(ctx, conn)
 		if err != nil {
 			t.metrics.incomingConnectionTransportErrors.Inc()
 			t.logger.Info("failed receiving transport data, disconnecting", log.Error(err), log.String("peer", conn.RemoteAddr().String()), trace.LogFieldFrom(ctx))
@@ -99,33 +119,61 @@ func (t *DirectTransport) serverHandleIncomingConnection(ctx context.Context, co
 			return
 		}
 
-		// notify if not keepalive
+		if flags&TRANSPORT_FRAME_FLAG_PEER_EXCHANGE != 0 {
+			t.onPeerExchange(ctx, conn, payloads)
+			continue
+		}
+
+		// notify if not keepalive, the listener drops messages claiming a sender other than the peer the handshake authenticated
 		if len(payloads) > 0 {
-			ctxWithPeer := context.WithValue(ctx, "peer-ip", conn.RemoteAddr().String())
+			ctxWithPeer := adapter.ContextWithAuthenticatedSender(context.WithValue(ctx, "peer-ip", conn.RemoteAddr().String()), conn.peerNodeAddress)
 			t.notifyListener(ctxWithPeer, payloads)
 		}
 	}
 }
 
-func (t *DirectTransport) receiveTransportData(ctx context.Context, conn net.Conn) ([][]byte, error) {
+func (t *DirectTransport) receiveTransportData(ctx context.Context, conn net.Conn) ([][]byte, uint32, error) {
 	// TODO(https://github.com/orbs-network/orbs-network-go/issues/182): think about timeout policy on receive, we might not want it
-	timeout := t.config.GossipNetworkTimeout()
-	res := [][]byte{}
+	return receiveTransportFrame(ctx, conn, t.config.GossipNetworkTimeout())
+}
 
+func receiveTransportFrame(ctx context.Context, conn net.Conn, timeout time.Duration) ([][]byte, uint32, error) {
 	// receive num payloads
 	sizeBuffer, err := readTotal(ctx, conn, 4, timeout)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	numPayloads := membuffers.GetUint32(sizeBuffer)
+	flags := membuffers.GetUint32(sizeBuffer) & TRANSPORT_FRAME_FLAGS_MASK
+	numPayloads := membuffers.GetUint32(sizeBuffer) &^ TRANSPORT_FRAME_FLAGS_MASK
 
+	if flags&^(TRANSPORT_FRAME_FLAG_PEER_EXCHANGE|TRANSPORT_FRAME_FLAG_COMPRESSED) != 0 {
+		return nil, 0, errors.Errorf("received message with unknown flags: %x", flags)
+	}
 	if numPayloads > MAX_PAYLOADS_IN_MESSAGE {
-		return nil, errors.Errorf("received message with too many payloads: %d", numPayloads)
+		return nil, 0, errors.Errorf("received message with too many payloads: %d", numPayloads)
+	}
+
+	read := func(size uint32) ([]byte, error) {
+		return readTotal(ctx, conn, size, timeout)
+	}
+	var res [][]byte
+	if flags&TRANSPORT_FRAME_FLAG_COMPRESSED != 0 {
+		res, err = readCompressedPayloads(numPayloads, read)
+	} else {
+		res, err = readPayloads(numPayloads, read)
+	}
+	if err != nil {
+		return nil, 0, err
 	}
+	return res, flags, nil
+}
+
+func readPayloads(numPayloads uint32, read func(size uint32) ([]byte, error)) ([][]byte, error) {
+	res := [][]byte{}
 
 	for i := uint32(0); i < numPayloads; i++ {
 		// receive payload size
-		sizeBuffer, err := readTotal(ctx, conn, 4, timeout)
+		sizeBuffer, err := read(4)
 		if err != nil {
 			return nil, err
 		}
@@ -135,7 +183,7 @@ func (t *DirectTransport) receiveTransportData(ctx context.Context, conn net.Con
 		}
 
 		// receive payload data
-		payload, err := readTotal(ctx, conn, payloadSize, timeout)
+		payload, err := read(payloadSize)
 		if err != nil {
 			return nil, err
 		}
@@ -144,7 +192,7 @@ func (t *DirectTransport) receiveTransportData(ctx context.Context, conn net.Con
 		// receive padding
 		paddingSize := calcPaddingSize(uint32(len(payload)))
 		if paddingSize > 0 {
-			_, err := readTotal(ctx, conn, paddingSize, timeout)
+			_, err := read(paddingSize)
 			if err != nil {
 				return nil, err
 			}
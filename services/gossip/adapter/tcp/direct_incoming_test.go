@@ -162,3 +162,48 @@ func TestDirectIncoming_TimeoutDuringReceiveCausesDisconnect(t *testing.T) {
 		require.Error(t, err, "test peer should be disconnected from local transport")
 	})
 }
+
+func TestDirectIncoming_RejectsConnectionFromUnknownPeer(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+
+		h := newDirectHarnessWithConnectedPeers(t, ctx)
+		defer h.cleanupConnectedPeers()
+
+		h.transport.RegisterListener(h.listenerMock, nil)
+		h.expectTransportListenerNotCalled()
+
+		rawConn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", h.transport.serverPort))
+		require.NoError(t, err, "test should be able connect to local transport")
+		defer rawConn.Close()
+
+		unknownPeer := peerIdentityForTests(t, NETWORK_SIZE+1)
+		_, err = clientHandshake(ctx, rawConn, TEST_NETWORK_TIMEOUT, unknownPeer, h.config.NodeAddress())
+		require.Error(t, err, "local transport should reject a node address which is not a gossip peer")
+
+		h.verifyTransportListenerNotCalled(t)
+	})
+}
+
+func TestDirectIncoming_RejectsPeerNotHoldingKeyOfItsAddress(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+
+		h := newDirectHarnessWithConnectedPeers(t, ctx)
+		defer h.cleanupConnectedPeers()
+
+		h.transport.RegisterListener(h.listenerMock, nil)
+		h.expectTransportListenerNotCalled()
+
+		rawConn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", h.transport.serverPort))
+		require.NoError(t, err, "test should be able connect to local transport")
+		defer rawConn.Close()
+
+		impostor := &handshakeIdentity{
+			nodeAddress: h.nodeAddressForPeer(0),
+			signer:      signerForTests(t, NETWORK_SIZE+1),
+		}
+		_, err = clientHandshake(ctx, rawConn, TEST_NETWORK_TIMEOUT, impostor, h.config.NodeAddress())
+		require.Error(t, err, "impostor should be disconnected before the local transport proves its address")
+
+		h.verifyTransportListenerNotCalled(t)
+	})
+}
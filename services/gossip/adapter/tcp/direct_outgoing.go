@@ -11,22 +11,31 @@ import (
 	"fmt"
 	"github.com/orbs-network/membuffers/go"
 	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
-	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
 	"github.com/orbs-network/scribe/log"
 	"github.com/pkg/errors"
 	"net"
 	"time"
 )
 
-func (t *DirectTransport) clientMainLoop(parentCtx context.Context, queue *transportQueue) {
-	for {
+func (t *DirectTransport) clientMainLoop(parentCtx context.Context, queue *peerQueue) {
+	dialer := &net.Dialer{Timeout: t.config.GossipNetworkTimeout()}
+	for parentCtx.Err() == nil { // the context ends on system shutdown or when the peer is removed
 		ctx := trace.NewContext(parentCtx, fmt.Sprintf("Gossip.Transport.TCP.Client.%s", queue.networkAddress))
 		t.logger.Info("attempting outgoing transport connection", log.String("peer", queue.networkAddress), trace.LogFieldFrom(ctx))
-		conn, err := net.DialTimeout("tcp", queue.networkAddress, t.config.GossipNetworkTimeout())
+		rawConn, err := dialer.DialContext(ctx, "tcp", queue.networkAddress)
 
 		if err != nil {
 			t.logger.Info("cannot connect to gossip peer endpoint", log.String("peer", queue.networkAddress), trace.LogFieldFrom(ctx))
-			time.Sleep(t.config.GossipReconnectInterval())
+			sleepOrShutdown(ctx, t.config.GossipReconnectInterval())
+			continue
+		}
+
+		conn, err := clientHandshake(ctx, rawConn, t.config.GossipNetworkTimeout(), t.identity, queue.nodeAddress)
+		if err != nil {
+			t.metrics.outgoingConnectionHandshakeErrors.Inc()
+			t.logger.Info("gossip peer failed handshake", log.Error(err), log.String("peer", queue.networkAddress), trace.LogFieldFrom(ctx))
+			rawConn.Close()
+			sleepOrShutdown(ctx, t.config.GossipReconnectInterval())
 			continue
 		}
 
@@ -36,11 +45,30 @@ func (t *DirectTransport) clientMainLoop(parentCtx context.Context, queue *trans
 	}
 }
 
+func sleepOrShutdown(ctx context.Context, duration time.Duration) {
+	select {
+	case <-time.After(duration):
+	case <-ctx.Done():
+	}
+}
+
 // returns true if should attempt reconnect on error
-func (t *DirectTransport) clientHandleOutgoingConnection(ctx context.Context, conn net.Conn, queue *transportQueue) bool {
+func (t *DirectTransport) clientHandleOutgoingConnection(ctx context.Context, conn net.Conn, queue *peerQueue) bool {
 	t.logger.Info("successful outgoing gossip transport connection", log.String("peer", queue.networkAddress), trace.LogFieldFrom(ctx))
 	t.metrics.activeOutgoingConnections.Inc()
 	defer t.metrics.activeOutgoingConnections.Dec()
+
+	// the peer learns the endpoints this node knows of, including its own
+	err := write(ctx, conn, t.encodePeerExchange(), t.config.GossipNetworkTimeout())
+	if err != nil {
+		t.metrics.outgoingConnectionSendErrors.Inc()
+		t.logger.Info("failed sending peer exchange, reconnecting", log.Error(err), log.String("peer", queue.networkAddress), trace.LogFieldFrom(ctx))
+		conn.Close()
+		return true
+	}
+
+	compression := t.waitForCompressionOffer(ctx, conn, queue.networkAddress)
+
 	queue.Clear(ctx)
 	queue.Enable()
 	defer queue.Disable()
@@ -48,14 +76,14 @@ func (t *DirectTransport) clientHandleOutgoingConnection(ctx context.Context, co
 	for {
 
 		ctxWithKeepAliveTimeout, cancelCtxWithKeepAliveTimeout := context.WithTimeout(ctx, t.config.GossipConnectionKeepAliveInterval())
-		data := queue.Pop(ctxWithKeepAliveTimeout)
+		message := queue.Pop(ctxWithKeepAliveTimeout)
 		cancelCtxWithKeepAliveTimeout()
 
-		if data != nil {
+		if message != nil {
 
 			// ctxWithKeepAliveTimeout not closed, so no keep alive timeout nor system shutdown
 			// meaning do a regular send (we have data)
-			err := t.sendTransportData(ctx, conn, data)
+			err := t.sendTransportData(ctx, conn, message, compression)
 			if err != nil {
 				t.metrics.outgoingConnectionSendErrors.Inc()
 				t.logger.Info("failed sending transport data, reconnecting", log.Error(err), log.String("peer", queue.networkAddress), trace.LogFieldFrom(ctx))
@@ -79,9 +107,9 @@ func (t *DirectTransport) clientHandleOutgoingConnection(ctx context.Context, co
 
 			} else {
 
-				// parent ctx is closed, so system shutdown
+				// parent ctx is closed, so system shutdown or peer removed
 				// meaning cleanup and exit
-				t.logger.Info("client loop stopped since server is shutting down", trace.LogFieldFrom(ctx))
+				t.logger.Info("client loop stopped since server is shutting down or peer was removed", log.String("peer", queue.networkAddress), trace.LogFieldFrom(ctx))
 				conn.Close()
 				return false
 
@@ -90,52 +118,55 @@ func (t *DirectTransport) clientHandleOutgoingConnection(ctx context.Context, co
 	}
 }
 
-func (t *DirectTransport) addDataToOutgoingPeerQueue(data *adapter.TransportData, outgoingQueue *transportQueue) {
-	err := outgoingQueue.Push(data)
+func (t *DirectTransport) addDataToOutgoingPeerQueue(message *outgoingMessage, outgoingQueue *peerQueue) {
+	err := outgoingQueue.Push(message)
 	if err != nil {
 		t.metrics.outgoingConnectionSendQueueErrors.Inc()
 		t.logger.Info("direct transport send queue error", log.Error(err), log.String("peer", outgoingQueue.networkAddress))
 	}
-	t.metrics.outgoingMessageSize.Record(int64(data.TotalSize()))
+	t.metrics.outgoingMessageSize.Record(int64(message.TotalSize()))
 }
 
-func (t *DirectTransport) sendTransportData(ctx context.Context, conn net.Conn, data *adapter.TransportData) error {
-	timeout := t.config.GossipNetworkTimeout()
-	zeroBuffer := make([]byte, 4)
-	sizeBuffer := make([]byte, 4)
-
-	// send num payloads
-	membuffers.WriteUint32(sizeBuffer, uint32(len(data.Payloads)))
-	err := write(ctx, conn, sizeBuffer, timeout)
+// the message is written at once, so it is sealed in as few records as possible. The compression metrics count the
+// frames sent, so the bytes after compression are those written to the peer
+func (t *DirectTransport) sendTransportData(ctx context.Context, conn net.Conn, message *outgoingMessage, compression *compressionOffer) error {
+	frame, uncompressedSize := message.frameFor(compression)
+	err := write(ctx, conn, frame, t.config.GossipNetworkTimeout())
 	if err != nil {
 		return err
 	}
+	t.metrics.outgoingBytesBeforeCompression.Add(int64(uncompressedSize))
+	t.metrics.outgoingBytesAfterCompression.Add(int64(len(frame)))
+	return nil
+}
 
-	for _, payload := range data.Payloads {
-		// send payload size
-		membuffers.WriteUint32(sizeBuffer, uint32(len(payload)))
-		err := write(ctx, conn, sizeBuffer, timeout)
-		if err != nil {
-			return err
-		}
+func encodeTransportData(payloads [][]byte) []byte {
+	return encodeTransportFrame(0, payloads)
+}
 
-		// send payload data
-		err = write(ctx, conn, payload, timeout)
-		if err != nil {
-			return err
-		}
+// flags are set in the high bits of the word holding the number of payloads
+func encodeTransportFrame(flags uint32, payloads [][]byte) []byte {
+	size := 4
+	for _, payload := range payloads {
+		size += 4 + len(payload) + int(calcPaddingSize(uint32(len(payload))))
+	}
+	buffer := make([]byte, size)
 
-		// send padding
-		paddingSize := calcPaddingSize(uint32(len(payload)))
-		if paddingSize > 0 {
-			err = write(ctx, conn, zeroBuffer[:paddingSize], timeout)
-			if err != nil {
-				return err
-			}
-		}
+	// num payloads
+	membuffers.WriteUint32(buffer, flags|uint32(len(payloads)))
+	offset := 4
+
+	for _, payload := range payloads {
+		// payload size
+		membuffers.WriteUint32(buffer[offset:], uint32(len(payload)))
+		offset += 4
+
+		// payload data, followed by zero padding
+		offset += copy(buffer[offset:], payload)
+		offset += int(calcPaddingSize(uint32(len(payload))))
 	}
 
-	return nil
+	return buffer
 }
 
 func (t *DirectTransport) sendKeepAlive(ctx context.Context, conn net.Conn) error {
@@ -41,7 +41,7 @@ func TestDirectOutgoing_ConnectionReconnectsOnFailure(t *testing.T) {
 		defer h.cleanupConnectedPeers()
 
 		for numForcefulDisconnect := 0; numForcefulDisconnect < 2; numForcefulDisconnect++ {
-			err := h.reconnect(numForcefulDisconnect % NETWORK_SIZE)
+			err := h.reconnect(t, numForcefulDisconnect%NETWORK_SIZE)
 
 			require.NoError(t, err, "test peer server could not accept connection from local transport")
 		}
@@ -115,7 +115,7 @@ func TestDirectOutgoing_ErrorDuringSendCausesReconnect(t *testing.T) {
 		err := h.peersListenersConnections[1].Close()
 		require.NoError(t, err, "expected the connection to successfully close")
 
-		h.peersListenersConnections[1], err = h.peersListeners[1].Accept()
+		h.peersListenersConnections[1], err = acceptPeerConnection(t, h.peersListeners[1], 1)
 		require.NoError(t, err, "client loop did not reconnect immediately after connection closed")
 
 		data, err := h.peerListenerReadTotal(1, 4)
@@ -143,7 +143,7 @@ func TestDirectOutgoing_OutgoingMessageQueueDisabledWhenConnectionClosed(t *test
 		// remote peer comes back online
 		h.peersListeners[1], err = net.Listen("tcp", h.peersListeners[1].Addr().String()) // recover listener
 		require.NoError(t, err, "test peer server could not listen")
-		h.peersListenersConnections[1], err = h.peersListeners[1].Accept() // obtain recovered connection
+		h.peersListenersConnections[1], err = acceptPeerConnection(t, h.peersListeners[1], 1) // obtain recovered connection
 		require.NoError(t, err, "test peer server did not accept new connection from local transport")
 
 		require.True(t, test.Eventually(3*time.Second, func() bool {
@@ -151,3 +151,21 @@ func TestDirectOutgoing_OutgoingMessageQueueDisabledWhenConnectionClosed(t *test
 		}), "expected all queues to return to enabled state once connection is recovered")
 	})
 }
+
+func TestDirectOutgoing_DoesNotSendToPeerWithUnexpectedAddress(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+
+		h := newDirectHarnessWithConnectedPeers(t, ctx)
+		defer h.cleanupConnectedPeers()
+
+		// a different node answers at the endpoint of peer 1
+		err := h.peersListenersConnections[1].Close()
+		require.NoError(t, err, "expected the connection to successfully close")
+		rawConn, err := h.peersListeners[1].Accept()
+		require.NoError(t, err, "client loop did not reconnect after connection closed")
+		defer rawConn.Close()
+
+		_, err = serverHandshake(ctx, rawConn, TEST_NETWORK_TIMEOUT, peerIdentityForTests(t, NETWORK_SIZE+1), isTransportUnderTest)
+		require.Error(t, err, "local transport should reject a peer which does not hold the key of the address it dialed")
+	})
+}
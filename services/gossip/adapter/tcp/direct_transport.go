@@ -10,6 +10,8 @@ import (
 	"context"
 	"fmt"
 	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/crypto/signer"
+	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
 	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
 	"github.com/orbs-network/orbs-network-go/synchronization/supervised"
@@ -22,8 +24,12 @@ import (
 
 const MAX_PAYLOADS_IN_MESSAGE = 100000
 const MAX_PAYLOAD_SIZE_BYTES = 20 * 1024 * 1024
-const SEND_QUEUE_MAX_MESSAGES = 1000
-const SEND_QUEUE_MAX_BYTES = 20 * 1024 * 1024
+const CONSENSUS_SEND_QUEUE_MAX_MESSAGES = 1000
+const CONSENSUS_SEND_QUEUE_MAX_BYTES = 20 * 1024 * 1024
+const BLOCK_SYNC_SEND_QUEUE_MAX_MESSAGES = 500
+const BLOCK_SYNC_SEND_QUEUE_MAX_BYTES = 20 * 1024 * 1024
+const TRANSACTION_RELAY_SEND_QUEUE_MAX_MESSAGES = 1000
+const TRANSACTION_RELAY_SEND_QUEUE_MAX_BYTES = 10 * 1024 * 1024
 
 var LogTag = log.String("adapter", "gossip")
 
@@ -34,26 +40,42 @@ type metrics struct {
 	outgoingConnectionSendErrors      *metric.Gauge
 	outgoingConnectionKeepaliveErrors *metric.Gauge
 	outgoingConnectionSendQueueErrors *metric.Gauge
+	incomingConnectionHandshakeErrors *metric.Gauge
+	outgoingConnectionHandshakeErrors *metric.Gauge
 
 	activeIncomingConnections *metric.Gauge
 	activeOutgoingConnections *metric.Gauge
+	outgoingPeers             *metric.Gauge
+	learnedPeers              *metric.Gauge
+
+	outgoingBytesBeforeCompression *metric.Gauge
+	outgoingBytesAfterCompression  *metric.Gauge
 
 	outgoingMessageSize *metric.Histogram
 }
 
 type DirectTransport struct {
-	config config.GossipTransportConfig
-	logger log.Logger
+	config   config.GossipTransportConfig
+	logger   log.Logger
+	identity *handshakeIdentity
+
+	bgCtx              context.Context // long-running, peers connected at runtime live as long as the transport
+	outgoingPeerQueues map[string]*peerQueue
+	learnedPeers       map[string]config.GossipPeer // endpoints advertised by peers, for nodes missing from the configured peers
+	electedValidators  map[string]bool
 
-	outgoingPeerQueues map[string]*transportQueue
+	// the latest elected validators not applied yet, the transport goroutine applying them is signaled on the channel
+	pendingElectedValidators       []primitives.NodeAddress
+	pendingElectedValidatorsHeight primitives.BlockHeight
+	electedValidatorsChanged       chan struct{}
 
 	mutex                       *sync.RWMutex
 	transportListenerUnderMutex adapter.TransportListener
 	serverListeningUnderMutex   bool
 	serverPort                  int
 
-	metrics        *metrics
-	metricRegistry metric.Registry
+	metrics      *metrics
+	queueMetrics *peerQueueMetrics // shared by the queues of all peers
 }
 
 func getMetrics(registry metric.Registry) *metrics {
@@ -64,19 +86,32 @@ func getMetrics(registry metric.Registry) *metrics {
 		outgoingConnectionSendErrors:      registry.NewGauge("Gossip.OutgoingConnection.SendErrors.Count"),
 		outgoingConnectionKeepaliveErrors: registry.NewGauge("Gossip.OutgoingConnection.KeepaliveErrors.Count"),
 		outgoingConnectionSendQueueErrors: registry.NewGauge("Gossip.OutgoingConnection.SendQueueErrors.Count"),
+		incomingConnectionHandshakeErrors: registry.NewGauge("Gossip.IncomingConnection.HandshakeErrors.Count"),
+		outgoingConnectionHandshakeErrors: registry.NewGauge("Gossip.OutgoingConnection.HandshakeErrors.Count"),
 		activeIncomingConnections:         registry.NewGauge("Gossip.IncomingConnection.Active.Count"),
 		activeOutgoingConnections:         registry.NewGauge("Gossip.OutgoingConnection.Active.Count"),
+		outgoingPeers:                     registry.NewGauge("Gossip.OutgoingConnection.Peers.Count"),
+		learnedPeers:                      registry.NewGauge("Gossip.PeerExchange.LearnedPeers.Count"),
+		outgoingBytesBeforeCompression:    registry.NewGauge("Gossip.OutgoingConnection.Compression.BytesBefore.Count"),
+		outgoingBytesAfterCompression:     registry.NewGauge("Gossip.OutgoingConnection.Compression.BytesAfter.Count"),
 		outgoingMessageSize:               registry.NewHistogram("Gossip.OutgoingConnection.MessageSize.Bytes", MAX_PAYLOAD_SIZE_BYTES),
 	}
 }
 
-func NewDirectTransport(ctx context.Context, config config.GossipTransportConfig, logger log.Logger, registry metric.Registry) *DirectTransport {
+// nodeSigner proves to peers that the transport holds the key of the node address
+func NewDirectTransport(ctx context.Context, transportConfig config.GossipTransportConfig, nodeSigner signer.Signer, logger log.Logger, registry metric.Registry) *DirectTransport {
 	t := &DirectTransport{
-		config:         config,
-		logger:         logger.WithTags(LogTag),
-		metricRegistry: registry,
+		config:       transportConfig,
+		logger:       logger.WithTags(LogTag),
+		identity:     &handshakeIdentity{nodeAddress: transportConfig.NodeAddress(), signer: nodeSigner},
+		queueMetrics: newPeerQueueMetrics(registry),
+
+		bgCtx:              ctx,
+		outgoingPeerQueues: make(map[string]*peerQueue),
+		learnedPeers:       make(map[string]config.GossipPeer),
+		electedValidators:  make(map[string]bool),
 
-		outgoingPeerQueues: make(map[string]*transportQueue),
+		electedValidatorsChanged: make(chan struct{}, 1),
 
 		mutex:   &sync.RWMutex{},
 		metrics: getMetrics(registry),
@@ -87,6 +122,11 @@ func NewDirectTransport(ctx context.Context, config config.GossipTransportConfig
 		t.serverMainLoop(ctx, t.config.GossipListenPort())
 	})
 
+	// elected validators goroutine
+	supervised.GoForever(ctx, t.logger, func() {
+		t.electedValidatorsMainLoop(ctx)
+	})
+
 	// client goroutines
 	t.mutex.Lock()
 	for peerNodeAddress, peer := range t.config.GossipPeers() {
@@ -101,24 +141,162 @@ func NewDirectTransport(ctx context.Context, config config.GossipTransportConfig
 // the context is done
 func (t *DirectTransport) connect(bgCtx context.Context, peerNodeAddress string, peer config.GossipPeer) {
 	if peerNodeAddress != t.config.NodeAddress().KeyForMap() {
-		t.outgoingPeerQueues[peerNodeAddress] = NewTransportQueue(SEND_QUEUE_MAX_BYTES, SEND_QUEUE_MAX_MESSAGES, t.metricRegistry)
-		t.outgoingPeerQueues[peerNodeAddress].Disable() // until connection is established
+		queue := newPeerQueue(t.queueMetrics)
+		queue.Disable() // until connection is established
 
-		peerAddress := fmt.Sprintf("%s:%d", peer.GossipEndpoint(), peer.GossipPort())
-		t.outgoingPeerQueues[peerNodeAddress].networkAddress = peerAddress
+		queue.networkAddress = networkAddressOf(peer)
+		queue.nodeAddress = primitives.NodeAddress(peerNodeAddress)
 
-		supervised.GoForever(bgCtx, t.logger, func() {
-			t.clientMainLoop(bgCtx, t.outgoingPeerQueues[peerNodeAddress])
+		peerCtx, cancel := context.WithCancel(bgCtx)
+		queue.disconnect = cancel
+		queue.disconnected = supervised.GoForever(peerCtx, t.logger, func() {
+			t.clientMainLoop(peerCtx, queue)
 		})
+
+		t.outgoingPeerQueues[peerNodeAddress] = queue
+		t.metrics.outgoingPeers.Update(int64(len(t.outgoingPeerQueues)))
 	}
 }
 
+// stops the client goroutine of the peer, which closes its connection and disables the queue. Returns the queue of the
+// peer to pass to waitUntilDisconnected once the mutex is released, or nil when the peer is not connected
+func (t *DirectTransport) disconnect(peerNodeAddress string) *peerQueue {
+	queue, found := t.outgoingPeerQueues[peerNodeAddress]
+	if !found {
+		return nil
+	}
+
+	t.logger.Info("disconnecting from gossip peer", log.String("peer", queue.networkAddress), log.Stringable("peer-node-address", queue.nodeAddress))
+	queue.disconnect()
+	delete(t.outgoingPeerQueues, peerNodeAddress)
+	t.metrics.outgoingPeers.Update(int64(len(t.outgoingPeerQueues)))
+	return queue
+}
+
+// waits for the client goroutines of the disconnected peers to end and drops the messages left in their queues. Must not
+// be called under mutex, the client goroutines take it
+func waitUntilDisconnected(queues []*peerQueue) {
+	for _, queue := range queues {
+		<-queue.disconnected
+		queue.Clear(context.Background())
+	}
+}
+
+// like waitUntilDisconnected but without waiting, for callers which may not be held up by a peer slow to disconnect
+func (t *DirectTransport) finishDisconnectingInBackground(queues []*peerQueue) {
+	if len(queues) == 0 {
+		return
+	}
+	supervised.GoOnce(t.logger, func() {
+		waitUntilDisconnected(queues)
+	})
+}
+
 func (t *DirectTransport) AddPeer(bgCtx context.Context, address primitives.NodeAddress, peer config.GossipPeer) {
-	t.mutex.Lock()
-	defer t.mutex.Unlock()
+	var disconnected []*peerQueue
 
+	t.mutex.Lock()
 	t.config.GossipPeers()[address.KeyForMap()] = peer
-	t.connect(bgCtx, address.KeyForMap(), peer)
+	if queue, connected := t.outgoingPeerQueues[address.KeyForMap()]; !connected || queue.networkAddress != networkAddressOf(peer) {
+		if queue := t.disconnect(address.KeyForMap()); queue != nil { // the peer moved to another endpoint
+			disconnected = append(disconnected, queue)
+		}
+		t.connect(bgCtx, address.KeyForMap(), peer)
+	}
+	t.mutex.Unlock()
+
+	waitUntilDisconnected(disconnected)
+}
+
+// the peer remains connected while it is an elected validator
+func (t *DirectTransport) RemovePeer(address primitives.NodeAddress) {
+	t.mutex.Lock()
+	delete(t.config.GossipPeers(), address.KeyForMap())
+	disconnected := t.updatePeers()
+	t.mutex.Unlock()
+
+	waitUntilDisconnected(disconnected)
+}
+
+// connects to the elected validators in addition to the configured peers, a validator whose endpoint is not configured
+// is connected once a peer advertises it. Returns without waiting, the transport goroutine applies the latest elected
+// validators, and the elected validators of a block lower than that of elected validators already received are ignored
+func (t *DirectTransport) OnElectedValidatorsChanged(ctx context.Context, blockHeight primitives.BlockHeight, electedValidators []primitives.NodeAddress) {
+	t.mutex.Lock()
+	if blockHeight < t.pendingElectedValidatorsHeight {
+		t.mutex.Unlock()
+		return
+	}
+	t.pendingElectedValidators = electedValidators
+	t.pendingElectedValidatorsHeight = blockHeight
+	t.mutex.Unlock()
+
+	select {
+	case t.electedValidatorsChanged <- struct{}{}:
+	default: // the transport goroutine is already signaled and will apply these elected validators
+	}
+}
+
+func (t *DirectTransport) electedValidatorsMainLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.electedValidatorsChanged:
+			t.applyElectedValidators()
+		}
+	}
+}
+
+func (t *DirectTransport) applyElectedValidators() {
+	t.mutex.Lock()
+	t.electedValidators = make(map[string]bool)
+	for _, validator := range t.pendingElectedValidators {
+		t.electedValidators[validator.KeyForMap()] = true
+	}
+	t.logger.Info("elected validators changed, updating gossip peers", log.StringableSlice("elected-validators", t.pendingElectedValidators), logfields.BlockHeight(t.pendingElectedValidatorsHeight))
+	disconnected := t.updatePeers()
+	t.mutex.Unlock()
+
+	t.finishDisconnectingInBackground(disconnected)
+}
+
+// connects to the configured peers and to the elected validators with a known endpoint, and disconnects from any other
+// node, must be called under mutex. Returns the queues of the disconnected peers to wait on once the mutex is released
+func (t *DirectTransport) updatePeers() (disconnected []*peerQueue) {
+	wanted := make(map[string]config.GossipPeer)
+	for key := range t.electedValidators {
+		if peer, found := t.learnedPeers[key]; found {
+			wanted[key] = peer
+		}
+	}
+	for key, peer := range t.config.GossipPeers() {
+		wanted[key] = peer
+	}
+
+	for key, queue := range t.outgoingPeerQueues {
+		if peer, found := wanted[key]; !found || queue.networkAddress != networkAddressOf(peer) {
+			disconnected = append(disconnected, t.disconnect(key))
+		}
+	}
+	for key, peer := range wanted {
+		if _, connected := t.outgoingPeerQueues[key]; !connected {
+			t.connect(t.bgCtx, key, peer)
+		}
+	}
+	return disconnected
+}
+
+func networkAddressOf(peer config.GossipPeer) string {
+	return fmt.Sprintf("%s:%d", peer.GossipEndpoint(), peer.GossipPort())
+}
+
+func (t *DirectTransport) isKnownPeer(address primitives.NodeAddress) bool {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	_, found := t.config.GossipPeers()[address.KeyForMap()]
+	return found || t.electedValidators[address.KeyForMap()]
 }
 
 func (t *DirectTransport) RegisterListener(listener adapter.TransportListener, listenerNodeAddress primitives.NodeAddress) {
@@ -130,16 +308,21 @@ func (t *DirectTransport) RegisterListener(listener adapter.TransportListener, l
 
 // TODO(https://github.com/orbs-network/orbs-network-go/issues/182): we are not currently respecting any intents given in ctx (added in context refactor)
 func (t *DirectTransport) Send(ctx context.Context, data *adapter.TransportData) error {
+	message := t.newOutgoingMessage(data) // shared by all the peers it is queued to, so it is compressed at most once
+
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
 	switch data.RecipientMode {
 	case gossipmessages.RECIPIENT_LIST_MODE_BROADCAST:
 		for _, peerQueue := range t.outgoingPeerQueues {
-			t.addDataToOutgoingPeerQueue(data, peerQueue)
+			t.addDataToOutgoingPeerQueue(message, peerQueue)
 		}
 		return nil
 	case gossipmessages.RECIPIENT_LIST_MODE_LIST:
 		for _, recipientPublicKey := range data.RecipientNodeAddresses {
 			if peerQueue, found := t.outgoingPeerQueues[recipientPublicKey.KeyForMap()]; found {
-				t.addDataToOutgoingPeerQueue(data, peerQueue)
+				t.addDataToOutgoingPeerQueue(message, peerQueue)
 			} else {
 				return errors.Errorf("unknown recipient public key: %s", recipientPublicKey.String())
 			}
@@ -152,6 +335,9 @@ func (t *DirectTransport) Send(ctx context.Context, data *adapter.TransportData)
 }
 
 func (t *DirectTransport) Port() int {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
 	return t.serverPort
 }
 
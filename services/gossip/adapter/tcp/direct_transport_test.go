@@ -2,6 +2,7 @@ package tcp
 
 import (
 	"context"
+	"fmt"
 	"github.com/orbs-network/orbs-network-go/config"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
 	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
@@ -21,7 +22,7 @@ func TestDirectTransport_HandlesStartupWithEmptyPeerList(t *testing.T) {
 
 	cfg := config.ForDirectTransportTests(make(map[string]config.GossipPeer), 20*time.Hour, 1*time.Second)
 	test.WithContext(func(ctx context.Context) {
-		transport := NewDirectTransport(ctx, cfg, log.DefaultTestingLogger(t), metric.NewRegistry())
+		transport := NewDirectTransport(ctx, cfg, signerForTests(t, 0), log.DefaultTestingLogger(t), metric.NewRegistry())
 		require.True(t, test.Eventually(test.EVENTUALLY_ADAPTER_TIMEOUT, func() bool {
 			return transport.IsServerListening()
 		}), "server did not start")
@@ -29,14 +30,13 @@ func TestDirectTransport_HandlesStartupWithEmptyPeerList(t *testing.T) {
 }
 
 func TestDirectTransport_SupportsAddingPeersInRuntime(t *testing.T) {
-	// High value to disable keep alive
-
-	cfg := config.ForDirectTransportTests(make(map[string]config.GossipPeer), 20*time.Hour, 1*time.Second)
+	address1 := keys.EcdsaSecp256K1KeyPairForTests(1).NodeAddress()
+	address2 := keys.EcdsaSecp256K1KeyPairForTests(2).NodeAddress()
+	cfg1 := config.ForGossipAdapterTests(address1, 0, make(map[string]config.GossipPeer))
+	cfg2 := config.ForGossipAdapterTests(address2, 0, make(map[string]config.GossipPeer))
 	test.WithContext(func(ctx context.Context) {
-		node1 := NewDirectTransport(ctx, cfg, log.DefaultTestingLogger(t), metric.NewRegistry())
-		node2 := NewDirectTransport(ctx, cfg, log.DefaultTestingLogger(t), metric.NewRegistry())
-		address1 := keys.EcdsaSecp256K1KeyPairForTests(1).NodeAddress()
-		address2 := keys.EcdsaSecp256K1KeyPairForTests(2).NodeAddress()
+		node1 := NewDirectTransport(ctx, cfg1, signerForTests(t, 1), log.DefaultTestingLogger(t), metric.NewRegistry())
+		node2 := NewDirectTransport(ctx, cfg2, signerForTests(t, 2), log.DefaultTestingLogger(t), metric.NewRegistry())
 		l1 := &testkit.MockTransportListener{}
 		l2 := &testkit.MockTransportListener{}
 		node1.RegisterListener(l1, address1)
@@ -81,3 +81,93 @@ func TestDirectTransport_SupportsAddingPeersInRuntime(t *testing.T) {
 		}))
 	})
 }
+
+func outgoingQueueOf(transport *DirectTransport, address primitives.NodeAddress) *peerQueue {
+	transport.mutex.RLock()
+	defer transport.mutex.RUnlock()
+
+	return transport.outgoingPeerQueues[address.KeyForMap()]
+}
+
+func requireDisconnected(t *testing.T, queue *peerQueue, message string) {
+	select {
+	case <-queue.disconnected:
+	default:
+		require.Fail(t, message)
+	}
+}
+
+func TestDirectTransport_SupportsRemovingPeersInRuntime(t *testing.T) {
+	address1 := keys.EcdsaSecp256K1KeyPairForTests(1).NodeAddress()
+	address2 := keys.EcdsaSecp256K1KeyPairForTests(2).NodeAddress()
+	cfg1 := config.ForGossipAdapterTests(address1, 0, make(map[string]config.GossipPeer))
+	cfg2 := config.ForGossipAdapterTests(address2, 0, make(map[string]config.GossipPeer))
+	test.WithContext(func(ctx context.Context) {
+		node1 := NewDirectTransport(ctx, cfg1, signerForTests(t, 1), log.DefaultTestingLogger(t), metric.NewRegistry())
+		node2 := NewDirectTransport(ctx, cfg2, signerForTests(t, 2), log.DefaultTestingLogger(t), metric.NewRegistry())
+
+		require.True(t, test.Eventually(test.EVENTUALLY_ADAPTER_TIMEOUT, func() bool {
+			return node1.IsServerListening() && node2.IsServerListening()
+		}), "server did not start")
+
+		node1.AddPeer(ctx, address2, config.NewHardCodedGossipPeer(node2.Port(), "127.0.0.1"))
+		node2.AddPeer(ctx, address1, config.NewHardCodedGossipPeer(node1.Port(), "127.0.0.1"))
+
+		queue := outgoingQueueOf(node1, address2)
+		require.NotNil(t, queue, "expected an outgoing queue for the added peer")
+
+		node1.RemovePeer(address2)
+
+		require.Nil(t, outgoingQueueOf(node1, address2), "expected the outgoing queue of the removed peer to be gone")
+		requireDisconnected(t, queue, "expected the client goroutine of the removed peer to end before RemovePeer returns")
+		require.Error(t, node1.Send(ctx, &adapter.TransportData{
+			SenderNodeAddress:      address1,
+			RecipientMode:          gossipmessages.RECIPIENT_LIST_MODE_LIST,
+			RecipientNodeAddresses: []primitives.NodeAddress{address2},
+			Payloads:               [][]byte{{0x11}},
+		}), "expected sending to a removed peer to fail")
+	})
+}
+
+func TestDirectTransport_ConnectsToElectedValidatorLearnedThroughPeerExchange(t *testing.T) {
+	address1 := keys.EcdsaSecp256K1KeyPairForTests(1).NodeAddress()
+	address2 := keys.EcdsaSecp256K1KeyPairForTests(2).NodeAddress()
+	cfg1 := config.ForGossipAdapterTests(address1, 0, make(map[string]config.GossipPeer))
+	cfg2 := config.ForGossipAdapterTests(address2, 0, make(map[string]config.GossipPeer))
+	test.WithContext(func(ctx context.Context) {
+		node1 := NewDirectTransport(ctx, cfg1, signerForTests(t, 1), log.DefaultTestingLogger(t), metric.NewRegistry())
+		node2 := NewDirectTransport(ctx, cfg2, signerForTests(t, 2), log.DefaultTestingLogger(t), metric.NewRegistry())
+
+		require.True(t, test.Eventually(test.EVENTUALLY_ADAPTER_TIMEOUT, func() bool {
+			return node1.IsServerListening() && node2.IsServerListening()
+		}), "server did not start")
+
+		// node1 does not know the endpoint of node2, it learns it once node2 connects as an elected validator
+		node1.OnElectedValidatorsChanged(ctx, 1, []primitives.NodeAddress{address2})
+		node2.AddPeer(ctx, address1, config.NewHardCodedGossipPeer(node1.Port(), "127.0.0.1"))
+
+		require.True(t, test.Eventually(HARNESS_OUTGOING_CONNECTIONS_INIT_TIMEOUT, func() bool {
+			queue := outgoingQueueOf(node1, address2)
+			return queue != nil && queue.networkAddress == fmt.Sprintf("127.0.0.1:%d", node2.Port())
+		}), "expected node1 to connect to the elected validator at the endpoint it advertised")
+
+		queue := outgoingQueueOf(node1, address2)
+		node1.OnElectedValidatorsChanged(ctx, 3, nil)
+		node1.OnElectedValidatorsChanged(ctx, 2, []primitives.NodeAddress{address2}) // stale, of a lower block
+
+		require.True(t, test.Eventually(HARNESS_OUTGOING_CONNECTIONS_INIT_TIMEOUT, func() bool {
+			return outgoingQueueOf(node1, address2) == nil
+		}), "expected node1 to disconnect from a validator which is no longer elected")
+		require.True(t, test.Eventually(HARNESS_OUTGOING_CONNECTIONS_INIT_TIMEOUT, func() bool {
+			select {
+			case <-queue.disconnected:
+				return true
+			default:
+				return false
+			}
+		}), "expected the client goroutine of the validator to end")
+		require.True(t, test.Consistently(test.CONSISTENTLY_ADAPTER_TIMEOUT, func() bool {
+			return outgoingQueueOf(node1, address2) == nil
+		}), "expected the elected validators of a lower block to be ignored")
+	})
+}
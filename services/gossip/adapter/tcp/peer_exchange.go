@@ -0,0 +1,106 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package tcp
+
+import (
+	"context"
+	"github.com/orbs-network/membuffers/go"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	"net"
+)
+
+// the high bits of the word holding the number of payloads carry frame flags, MAX_PAYLOADS_IN_MESSAGE fits in the rest
+const TRANSPORT_FRAME_FLAGS_MASK = uint32(0xff000000)
+
+// a peer exchange frame is handled by the transport itself and is not passed to the listener
+const TRANSPORT_FRAME_FLAG_PEER_EXCHANGE = uint32(1) << 31
+
+const MAX_LEARNED_PEERS = 10000
+
+const peerEntryFixedSizeBytes = 20 + 4 // node address and port, followed by the endpoint
+
+// every payload of a peer exchange frame is one node the sender knows of, the entry of the sender itself has an empty
+// endpoint which stands for the remote address of the connection the frame arrives on
+func (t *DirectTransport) encodePeerExchange() []byte {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	payloads := [][]byte{encodePeerEntry(t.config.NodeAddress(), uint32(t.serverPort), "")}
+	for key, peer := range t.config.GossipPeers() {
+		payloads = append(payloads, encodePeerEntry(primitives.NodeAddress(key), uint32(peer.GossipPort()), peer.GossipEndpoint()))
+	}
+	for key, peer := range t.learnedPeers {
+		if _, configured := t.config.GossipPeers()[key]; !configured {
+			payloads = append(payloads, encodePeerEntry(primitives.NodeAddress(key), uint32(peer.GossipPort()), peer.GossipEndpoint()))
+		}
+	}
+	return encodeTransportFrame(TRANSPORT_FRAME_FLAG_PEER_EXCHANGE, payloads)
+}
+
+func encodePeerEntry(nodeAddress primitives.NodeAddress, port uint32, endpoint string) []byte {
+	buffer := make([]byte, peerEntryFixedSizeBytes+len(endpoint))
+	copy(buffer, nodeAddress)
+	membuffers.WriteUint32(buffer[20:], port)
+	copy(buffer[peerEntryFixedSizeBytes:], endpoint)
+	return buffer
+}
+
+func decodePeerEntry(payload []byte) (nodeAddress primitives.NodeAddress, port uint32, endpoint string, err error) {
+	if len(payload) < peerEntryFixedSizeBytes {
+		return nil, 0, "", errors.Errorf("peer exchange entry is too short: %d bytes", len(payload))
+	}
+	return primitives.NodeAddress(payload[:20]), membuffers.GetUint32(payload[20:]), string(payload[peerEntryFixedSizeBytes:]), nil
+}
+
+// learns the endpoints of nodes missing from the configured peers. The sender is trusted about its own endpoint, while
+// endpoints it advertises for other nodes are only learned when they are not known yet
+func (t *DirectTransport) onPeerExchange(ctx context.Context, conn *secureConnection, payloads [][]byte) {
+	remoteHost, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil {
+		t.logger.Info("failed parsing remote address of peer exchange", log.Error(err), log.String("peer", conn.RemoteAddr().String()), trace.LogFieldFrom(ctx))
+		return
+	}
+
+	t.mutex.Lock()
+	for _, payload := range payloads {
+		nodeAddress, port, endpoint, err := decodePeerEntry(payload)
+		if err != nil {
+			t.logger.Info("ignoring corrupt peer exchange entry", log.Error(err), log.Stringable("peer-node-address", conn.peerNodeAddress), trace.LogFieldFrom(ctx))
+			continue
+		}
+
+		key := nodeAddress.KeyForMap()
+		isSender := nodeAddress.Equal(conn.peerNodeAddress)
+		if port == 0 || nodeAddress.Equal(t.config.NodeAddress()) {
+			continue
+		}
+		if _, configured := t.config.GossipPeers()[key]; configured {
+			continue
+		}
+		if endpoint == "" {
+			if !isSender {
+				continue
+			}
+			endpoint = remoteHost
+		}
+		if _, learned := t.learnedPeers[key]; !isSender && (learned || len(t.learnedPeers) >= MAX_LEARNED_PEERS) {
+			continue
+		}
+
+		t.learnedPeers[key] = config.NewHardCodedGossipPeer(int(port), endpoint)
+	}
+
+	t.metrics.learnedPeers.Update(int64(len(t.learnedPeers)))
+	disconnected := t.updatePeers()
+	t.mutex.Unlock()
+
+	t.finishDisconnectingInBackground(disconnected)
+}
@@ -0,0 +1,119 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package tcp
+
+import (
+	"context"
+	"github.com/orbs-network/membuffers/go"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/test"
+	testKeys "github.com/orbs-network/orbs-network-go/test/crypto/keys"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/stretchr/testify/require"
+	"net"
+	"testing"
+	"time"
+)
+
+// a connection from a peer at a given remote address, nothing is read or written
+type remoteAddressConnection struct {
+	net.Conn
+	remoteAddress net.Addr
+}
+
+func (c *remoteAddressConnection) RemoteAddr() net.Addr {
+	return c.remoteAddress
+}
+
+func connectionFromPeer(peerNodeAddress primitives.NodeAddress, remoteIp string) *secureConnection {
+	return &secureConnection{
+		Conn:            &remoteAddressConnection{remoteAddress: &net.TCPAddr{IP: net.ParseIP(remoteIp), Port: 51000}},
+		peerNodeAddress: peerNodeAddress,
+	}
+}
+
+func learnedEndpointOf(transport *DirectTransport, address primitives.NodeAddress) string {
+	transport.mutex.RLock()
+	defer transport.mutex.RUnlock()
+
+	peer, found := transport.learnedPeers[address.KeyForMap()]
+	if !found {
+		return ""
+	}
+	return networkAddressOf(peer)
+}
+
+func TestPeerExchange_EncodeDecodeEntry(t *testing.T) {
+	address := testKeys.EcdsaSecp256K1KeyPairForTests(3).NodeAddress()
+
+	decodedAddress, port, endpoint, err := decodePeerEntry(encodePeerEntry(address, 4400, "10.0.0.7"))
+	require.NoError(t, err)
+	require.Equal(t, address, decodedAddress)
+	require.EqualValues(t, 4400, port)
+	require.Equal(t, "10.0.0.7", endpoint)
+
+	_, _, _, err = decodePeerEntry(encodePeerEntry(address, 4400, "")[:10])
+	require.Error(t, err, "entry cut in the middle of the node address should fail to decode")
+}
+
+func TestPeerExchange_EncodesOwnEntryAndKnownPeers(t *testing.T) {
+	configuredPeer := testKeys.EcdsaSecp256K1KeyPairForTests(1).NodeAddress()
+	cfg := config.ForDirectTransportTests(map[string]config.GossipPeer{
+		configuredPeer.KeyForMap(): config.NewHardCodedGossipPeer(4401, "10.0.0.1"),
+	}, 20*time.Hour, TEST_NETWORK_TIMEOUT)
+	test.WithContext(func(ctx context.Context) {
+		transport := makeTransport(ctx, t, cfg)
+
+		frame := transport.encodePeerExchange()
+		require.Equal(t, TRANSPORT_FRAME_FLAG_PEER_EXCHANGE|2, membuffers.GetUint32(frame), "frame should be flagged and hold two entries")
+
+		_, port, endpoint, err := decodePeerEntry(frame[8 : 8+peerEntryFixedSizeBytes])
+		require.NoError(t, err)
+		require.EqualValues(t, transport.Port(), port, "first entry should advertise the listening port of the transport")
+		require.Empty(t, endpoint, "first entry should leave the endpoint to the remote address of the connection")
+	})
+}
+
+func TestPeerExchange_LearnsEndpointOfSenderFromConnection(t *testing.T) {
+	sender := testKeys.EcdsaSecp256K1KeyPairForTests(1).NodeAddress()
+	cfg := config.ForDirectTransportTests(make(map[string]config.GossipPeer), 20*time.Hour, TEST_NETWORK_TIMEOUT)
+	test.WithContext(func(ctx context.Context) {
+		transport := makeTransport(ctx, t, cfg)
+
+		transport.onPeerExchange(ctx, connectionFromPeer(sender, "10.0.0.1"), [][]byte{encodePeerEntry(sender, 4401, "")})
+		require.Equal(t, "10.0.0.1:4401", learnedEndpointOf(transport, sender))
+
+		transport.onPeerExchange(ctx, connectionFromPeer(sender, "10.0.0.2"), [][]byte{encodePeerEntry(sender, 4402, "")})
+		require.Equal(t, "10.0.0.2:4402", learnedEndpointOf(transport, sender), "sender should be trusted to move to another endpoint")
+	})
+}
+
+func TestPeerExchange_DoesNotOverrideKnownEndpointsAdvertisedByOthers(t *testing.T) {
+	configuredPeer := testKeys.EcdsaSecp256K1KeyPairForTests(1).NodeAddress()
+	sender := testKeys.EcdsaSecp256K1KeyPairForTests(2).NodeAddress()
+	otherSender := testKeys.EcdsaSecp256K1KeyPairForTests(3).NodeAddress()
+	advertisedPeer := testKeys.EcdsaSecp256K1KeyPairForTests(4).NodeAddress()
+	cfg := config.ForDirectTransportTests(map[string]config.GossipPeer{
+		configuredPeer.KeyForMap(): config.NewHardCodedGossipPeer(4401, "10.0.0.1"),
+	}, 20*time.Hour, TEST_NETWORK_TIMEOUT)
+	test.WithContext(func(ctx context.Context) {
+		transport := makeTransport(ctx, t, cfg)
+
+		transport.onPeerExchange(ctx, connectionFromPeer(sender, "10.0.0.2"), [][]byte{
+			encodePeerEntry(configuredPeer, 5401, "10.6.6.6"),
+			encodePeerEntry(advertisedPeer, 4404, "10.0.0.4"),
+			encodePeerEntry(otherSender, 4403, ""),
+		})
+		transport.onPeerExchange(ctx, connectionFromPeer(otherSender, "10.0.0.3"), [][]byte{
+			encodePeerEntry(advertisedPeer, 5404, "10.6.6.6"),
+		})
+
+		require.Empty(t, learnedEndpointOf(transport, configuredPeer), "configured peers should not be learned")
+		require.Equal(t, "10.0.0.4:4404", learnedEndpointOf(transport, advertisedPeer), "endpoint advertised first should be kept")
+		require.Empty(t, learnedEndpointOf(transport, otherSender), "empty endpoint should only stand for the sender itself")
+	})
+}
@@ -0,0 +1,138 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package tcp
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
+	"github.com/orbs-network/orbs-network-go/synchronization/supervised"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol/gossipmessages"
+)
+
+// messages are sent to a peer in the order of these priorities, so a burst of block sync or transaction relay messages
+// does not hold back consensus
+const (
+	PRIORITY_CONSENSUS = iota
+	PRIORITY_BLOCK_SYNC
+	PRIORITY_TRANSACTION_RELAY
+	NUM_PRIORITIES
+)
+
+type priorityQueueLimits struct {
+	name        string
+	maxBytes    int
+	maxMessages int
+}
+
+var peerQueueLimits = [NUM_PRIORITIES]priorityQueueLimits{
+	PRIORITY_CONSENSUS:         {name: "Consensus", maxBytes: CONSENSUS_SEND_QUEUE_MAX_BYTES, maxMessages: CONSENSUS_SEND_QUEUE_MAX_MESSAGES},
+	PRIORITY_BLOCK_SYNC:        {name: "BlockSync", maxBytes: BLOCK_SYNC_SEND_QUEUE_MAX_BYTES, maxMessages: BLOCK_SYNC_SEND_QUEUE_MAX_MESSAGES},
+	PRIORITY_TRANSACTION_RELAY: {name: "TransactionRelay", maxBytes: TRANSACTION_RELAY_SEND_QUEUE_MAX_BYTES, maxMessages: TRANSACTION_RELAY_SEND_QUEUE_MAX_MESSAGES},
+}
+
+// the outgoing messages to a single peer, held in a queue per priority
+type peerQueue struct {
+	queues         [NUM_PRIORITIES]*transportQueue
+	pushed         chan struct{} // signals Pop that a message may be waiting
+	networkAddress string
+	nodeAddress    primitives.NodeAddress
+	disconnect     context.CancelFunc          // stops the client goroutine of the peer
+	disconnected   supervised.ContextEndedChan // closed once the client goroutine of the peer has ended
+}
+
+// the metrics of the queues of each priority, registered once by the transport so connecting to a peer registers none
+type peerQueueMetrics [NUM_PRIORITIES]*TransportQueueMetrics
+
+func newPeerQueueMetrics(metricFactory metric.Factory) *peerQueueMetrics {
+	m := &peerQueueMetrics{}
+	for priority, limits := range peerQueueLimits {
+		m[priority] = NewTransportQueueMetrics(limits.name, metricFactory)
+	}
+	return m
+}
+
+func newPeerQueue(metrics *peerQueueMetrics) *peerQueue {
+	q := &peerQueue{pushed: make(chan struct{}, 1)}
+	for priority, limits := range peerQueueLimits {
+		q.queues[priority] = NewTransportQueue(limits.maxBytes, limits.maxMessages, metrics[priority])
+	}
+	return q
+}
+
+// messages without a valid gossip header, such as the ones of tests, get the lowest priority
+func priorityOf(data *adapter.TransportData) int {
+	if len(data.Payloads) == 0 {
+		return PRIORITY_TRANSACTION_RELAY
+	}
+	header := gossipmessages.HeaderReader(data.Payloads[0])
+	if !header.IsValid() {
+		return PRIORITY_TRANSACTION_RELAY
+	}
+
+	switch {
+	case header.IsTopicLeanHelix(), header.IsTopicBenchmarkConsensus():
+		return PRIORITY_CONSENSUS
+	case header.IsTopicBlockSync():
+		return PRIORITY_BLOCK_SYNC
+	default:
+		return PRIORITY_TRANSACTION_RELAY
+	}
+}
+
+func (q *peerQueue) Push(message *outgoingMessage) error {
+	err := q.queues[priorityOf(message.TransportData)].Push(message)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case q.pushed <- struct{}{}:
+	default: // Pop is signaled already
+	}
+	return nil
+}
+
+// returns the oldest message of the highest priority queue which is not empty, waiting for one if all are empty
+func (q *peerQueue) Pop(ctx context.Context) *outgoingMessage {
+	for {
+		for _, queue := range q.queues {
+			if res := queue.TryPop(); res != nil {
+				return res
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-q.pushed:
+		}
+	}
+}
+
+func (q *peerQueue) Clear(ctx context.Context) {
+	for _, queue := range q.queues {
+		queue.Clear(ctx)
+	}
+}
+
+func (q *peerQueue) Disable() {
+	for _, queue := range q.queues {
+		queue.Disable()
+	}
+}
+
+func (q *peerQueue) Enable() {
+	for _, queue := range q.queues {
+		queue.Enable()
+	}
+}
+
+func (q *peerQueue) isDisabled() bool {
+	return q.queues[PRIORITY_CONSENSUS].disabled
+}
@@ -0,0 +1,110 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package tcp
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
+	"github.com/orbs-network/orbs-network-go/test"
+	"github.com/orbs-network/orbs-spec/types/go/protocol/gossipmessages"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func dataWithTopic(topic gossipmessages.HeaderTopic, sender byte) *outgoingMessage {
+	header := (&gossipmessages.HeaderBuilder{
+		Topic:         topic,
+		RecipientMode: gossipmessages.RECIPIENT_LIST_MODE_BROADCAST,
+	}).Build()
+	return &outgoingMessage{TransportData: &adapter.TransportData{SenderNodeAddress: []byte{sender}, Payloads: [][]byte{header.Raw()}}}
+}
+
+func TestPeerQueue_PopsConsensusFirstThenBlockSyncThenTransactionRelay(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		q := newPeerQueue(newPeerQueueMetrics(metric.NewRegistry()))
+
+		require.NoError(t, q.Push(dataWithTopic(gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY, 0x01)))
+		require.NoError(t, q.Push(dataWithTopic(gossipmessages.HEADER_TOPIC_BLOCK_SYNC, 0x02)))
+		require.NoError(t, q.Push(dataWithTopic(gossipmessages.HEADER_TOPIC_LEAN_HELIX, 0x03)))
+		require.NoError(t, q.Push(dataWithTopic(gossipmessages.HEADER_TOPIC_BENCHMARK_CONSENSUS, 0x04)))
+		require.NoError(t, q.Push(&outgoingMessage{TransportData: &adapter.TransportData{SenderNodeAddress: []byte{0x05}, Payloads: [][]byte{{0x11}}}}))
+
+		for _, expectedSender := range []byte{0x03, 0x04, 0x02, 0x01, 0x05} {
+			require.EqualValues(t, []byte{expectedSender}, q.Pop(ctx).SenderNodeAddress)
+		}
+	})
+}
+
+func TestPeerQueue_FullQueueDoesNotDropMessagesOfOtherTopics(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		q := newPeerQueue(newPeerQueueMetrics(metric.NewRegistry()))
+
+		for i := 0; i < TRANSACTION_RELAY_SEND_QUEUE_MAX_MESSAGES; i++ {
+			require.NoError(t, q.Push(dataWithTopic(gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY, 0x01)))
+		}
+		require.Error(t, q.Push(dataWithTopic(gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY, 0x01)), "transaction relay queue should be full")
+		require.NoError(t, q.Push(dataWithTopic(gossipmessages.HEADER_TOPIC_LEAN_HELIX, 0x02)), "consensus queue should not be affected")
+
+		require.EqualValues(t, 1, q.queues[PRIORITY_TRANSACTION_RELAY].droppedMessagesMetric.Value())
+		require.Zero(t, q.queues[PRIORITY_CONSENSUS].droppedMessagesMetric.Value())
+		require.EqualValues(t, []byte{0x02}, q.Pop(ctx).SenderNodeAddress)
+	})
+}
+
+func TestPeerQueue_PopWhenEmptyWaitsUntilPush(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		q := newPeerQueue(newPeerQueueMetrics(metric.NewRegistry()))
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			require.NoError(t, q.Push(dataWithTopic(gossipmessages.HEADER_TOPIC_BLOCK_SYNC, 0x01)))
+		}()
+
+		require.EqualValues(t, []byte{0x01}, q.Pop(ctx).SenderNodeAddress)
+	})
+}
+
+func TestPeerQueue_QueuesOfAllPeersShareTheirMetrics(t *testing.T) {
+	metrics := newPeerQueueMetrics(metric.NewRegistry())
+	q1 := newPeerQueue(metrics)
+	q2 := newPeerQueue(metrics)
+
+	for priority := range q1.queues {
+		require.True(t, q1.queues[priority].droppedMessagesMetric == q2.queues[priority].droppedMessagesMetric, "peers should share the dropped messages metric of priority %d", priority)
+		require.True(t, q1.queues[priority].usageBytesMetric == q2.queues[priority].usageBytesMetric, "peers should share the usage metric of priority %d", priority)
+	}
+}
+
+func TestPeerQueue_MetricsSumUpTheQueuesOfAllPeers(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		registry := metric.NewRegistry()
+		metrics := newPeerQueueMetrics(registry)
+		q1 := newPeerQueue(metrics)
+		q2 := newPeerQueue(metrics)
+
+		data := dataWithTopic(gossipmessages.HEADER_TOPIC_BLOCK_SYNC, 0x01)
+		require.NoError(t, q1.Push(data))
+		require.NoError(t, q2.Push(data))
+		require.EqualValues(t, 2*data.TotalSize(), metrics[PRIORITY_BLOCK_SYNC].usageBytes.Value(), "usage should sum up the bytes queued to both peers")
+
+		q1.Pop(ctx)
+		require.EqualValues(t, data.TotalSize(), metrics[PRIORITY_BLOCK_SYNC].usageBytes.Value(), "usage should drop by the bytes popped")
+
+		for _, q := range []*peerQueue{q1, q2} {
+			for i := 0; i <= TRANSACTION_RELAY_SEND_QUEUE_MAX_MESSAGES; i++ {
+				q.Push(dataWithTopic(gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY, 0x01))
+			}
+		}
+		require.EqualValues(t, 2, metrics[PRIORITY_TRANSACTION_RELAY].droppedMessages.Value(), "dropped messages should sum up both peers")
+
+		exported := registry.ExportAll()
+		require.Contains(t, exported, "Gossip.OutgoingConnection.Queue.BlockSync.Usage.Bytes")
+		require.Contains(t, exported, "Gossip.OutgoingConnection.Queue.TransactionRelay.DroppedMessages.Count")
+	})
+}
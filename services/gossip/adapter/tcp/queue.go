@@ -8,46 +8,62 @@ package tcp
 
 import (
 	"context"
+	"fmt"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
-	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
 	"github.com/pkg/errors"
 	"sync"
 )
 
 type transportQueue struct {
-	channel        chan *adapter.TransportData // replace this buffered channel with github.com/phf/go-queue if we don't want maxSizeMessages (and its pre allocation)
-	networkAddress string
-	maxBytes       int
-	maxMessages    int
-	disabled       bool // not under mutex on purpose
+	channel     chan *outgoingMessage // replace this buffered channel with github.com/phf/go-queue if we don't want maxSizeMessages (and its pre allocation)
+	maxBytes    int
+	maxMessages int
+	disabled    bool // not under mutex on purpose
 
 	protected struct {
 		sync.Mutex
 		bytesLeft int
 	}
-	usagePercentageMetric *metric.Gauge
+	usageBytesMetric      *metric.Gauge
+	droppedMessagesMetric *metric.Gauge
 }
 
-func NewTransportQueue(maxSizeBytes int, maxSizeMessages int, metricFactory metric.Factory) *transportQueue {
+// the metrics of the queues holding one kind of messages, registered once and shared by the queues of all peers, so
+// each sums up the queues of all peers
+type TransportQueueMetrics struct {
+	usageBytes      *metric.Gauge
+	droppedMessages *metric.Gauge
+}
+
+// name tells apart the metrics of queues holding different kinds of messages
+func NewTransportQueueMetrics(name string, metricFactory metric.Factory) *TransportQueueMetrics {
+	return &TransportQueueMetrics{
+		usageBytes:      metricFactory.NewGauge(fmt.Sprintf("Gossip.OutgoingConnection.Queue.%s.Usage.Bytes", name)),
+		droppedMessages: metricFactory.NewGauge(fmt.Sprintf("Gossip.OutgoingConnection.Queue.%s.DroppedMessages.Count", name)),
+	}
+}
+
+func NewTransportQueue(maxSizeBytes int, maxSizeMessages int, metrics *TransportQueueMetrics) *transportQueue {
 	q := &transportQueue{
-		channel:     make(chan *adapter.TransportData, maxSizeMessages),
-		maxBytes:    maxSizeBytes,
-		maxMessages: maxSizeMessages,
+		channel:               make(chan *outgoingMessage, maxSizeMessages),
+		maxBytes:              maxSizeBytes,
+		maxMessages:           maxSizeMessages,
+		usageBytesMetric:      metrics.usageBytes,
+		droppedMessagesMetric: metrics.droppedMessages,
 	}
 	q.protected.bytesLeft = maxSizeBytes
 
-	q.usagePercentageMetric = metricFactory.NewGauge("Gossip.OutgoingConnection.Queue.Usage.Percent")
-
 	return q
 }
 
-func (q *transportQueue) Push(data *adapter.TransportData) error {
+func (q *transportQueue) Push(data *outgoingMessage) error {
 	if q.disabled {
 		return nil
 	}
 
 	err := q.consumeBytes(data)
 	if err != nil {
+		q.droppedMessagesMetric.Inc()
 		return err
 	}
 
@@ -55,11 +71,13 @@ func (q *transportQueue) Push(data *adapter.TransportData) error {
 	case q.channel <- data:
 		return nil
 	default:
+		q.releaseBytes(data)
+		q.droppedMessagesMetric.Inc()
 		return errors.Errorf("failed to push to queue - full with %d messages", q.maxMessages)
 	}
 }
 
-func (q *transportQueue) Pop(ctx context.Context) *adapter.TransportData {
+func (q *transportQueue) Pop(ctx context.Context) *outgoingMessage {
 	select {
 	case <-ctx.Done():
 		return nil
@@ -69,6 +87,17 @@ func (q *transportQueue) Pop(ctx context.Context) *adapter.TransportData {
 	}
 }
 
+// returns nil right away when the queue is empty
+func (q *transportQueue) TryPop() *outgoingMessage {
+	select {
+	case res := <-q.channel:
+		q.releaseBytes(res)
+		return res
+	default:
+		return nil
+	}
+}
+
 func (q *transportQueue) Clear(ctx context.Context) {
 	for {
 		select {
@@ -90,7 +119,7 @@ func (q *transportQueue) Enable() {
 	q.disabled = false
 }
 
-func (q *transportQueue) consumeBytes(data *adapter.TransportData) error {
+func (q *transportQueue) consumeBytes(data *outgoingMessage) error {
 	q.protected.Lock()
 	defer q.protected.Unlock()
 
@@ -100,19 +129,15 @@ func (q *transportQueue) consumeBytes(data *adapter.TransportData) error {
 	}
 
 	q.protected.bytesLeft -= dataSize
-	q.updateUsageMetric()
+	q.usageBytesMetric.Add(int64(dataSize))
 	return nil
 }
 
-func (q *transportQueue) releaseBytes(data *adapter.TransportData) {
+func (q *transportQueue) releaseBytes(data *outgoingMessage) {
 	q.protected.Lock()
 	defer q.protected.Unlock()
 
-	q.protected.bytesLeft += data.TotalSize()
-	q.updateUsageMetric()
-}
-
-func (q *transportQueue) updateUsageMetric() {
-	bytesUsed := q.maxBytes - q.protected.bytesLeft
-	q.usagePercentageMetric.Update(int64(bytesUsed * 100 / q.maxBytes))
+	dataSize := data.TotalSize()
+	q.protected.bytesLeft += dataSize
+	q.usageBytesMetric.Add(-int64(dataSize))
 }
@@ -18,18 +18,18 @@ import (
 
 func TestQueue_PushAndPopMultiple(t *testing.T) {
 	test.WithContext(func(ctx context.Context) {
-		q := NewTransportQueue(1000, 1000, metric.NewRegistry())
+		q := NewTransportQueue(1000, 1000, NewTransportQueueMetrics("Test", metric.NewRegistry()))
 
-		err := q.Push(&adapter.TransportData{SenderNodeAddress: []byte{0x01}})
+		err := q.Push(&outgoingMessage{TransportData: &adapter.TransportData{SenderNodeAddress: []byte{0x01}}})
 		require.NoError(t, err)
 
-		err = q.Push(&adapter.TransportData{SenderNodeAddress: []byte{0x02}})
+		err = q.Push(&outgoingMessage{TransportData: &adapter.TransportData{SenderNodeAddress: []byte{0x02}}})
 		require.NoError(t, err)
 
 		d1 := q.Pop(ctx)
 		require.EqualValues(t, []byte{0x01}, d1.SenderNodeAddress)
 
-		err = q.Push(&adapter.TransportData{SenderNodeAddress: []byte{0x03}})
+		err = q.Push(&outgoingMessage{TransportData: &adapter.TransportData{SenderNodeAddress: []byte{0x03}}})
 		require.NoError(t, err)
 
 		d2 := q.Pop(ctx)
@@ -42,35 +42,35 @@ func TestQueue_PushAndPopMultiple(t *testing.T) {
 
 func TestQueue_CannotPushMoreThanMaxMessages(t *testing.T) {
 	test.WithContext(func(ctx context.Context) {
-		q := NewTransportQueue(1000, 2, metric.NewRegistry())
+		q := NewTransportQueue(1000, 2, NewTransportQueueMetrics("Test", metric.NewRegistry()))
 
-		err := q.Push(&adapter.TransportData{SenderNodeAddress: []byte{0x01}})
+		err := q.Push(&outgoingMessage{TransportData: &adapter.TransportData{SenderNodeAddress: []byte{0x01}}})
 		require.NoError(t, err)
 
-		err = q.Push(&adapter.TransportData{SenderNodeAddress: []byte{0x02}})
+		err = q.Push(&outgoingMessage{TransportData: &adapter.TransportData{SenderNodeAddress: []byte{0x02}}})
 		require.NoError(t, err)
 
-		err = q.Push(&adapter.TransportData{SenderNodeAddress: []byte{0x03}})
+		err = q.Push(&outgoingMessage{TransportData: &adapter.TransportData{SenderNodeAddress: []byte{0x03}}})
 		require.Error(t, err, "queue should be full")
 
-		err = q.Push(&adapter.TransportData{SenderNodeAddress: []byte{0x04}})
+		err = q.Push(&outgoingMessage{TransportData: &adapter.TransportData{SenderNodeAddress: []byte{0x04}}})
 		require.Error(t, err, "queue should be full")
 
 		d1 := q.Pop(ctx)
 		require.EqualValues(t, []byte{0x01}, d1.SenderNodeAddress)
 
-		err = q.Push(&adapter.TransportData{SenderNodeAddress: []byte{0x03}})
+		err = q.Push(&outgoingMessage{TransportData: &adapter.TransportData{SenderNodeAddress: []byte{0x03}}})
 		require.NoError(t, err)
 	})
 }
 
 func TestQueue_PopWhenEmptyWaitsUntilPush(t *testing.T) {
 	test.WithContext(func(ctx context.Context) {
-		q := NewTransportQueue(1000, 1000, metric.NewRegistry())
+		q := NewTransportQueue(1000, 1000, NewTransportQueueMetrics("Test", metric.NewRegistry()))
 
 		go func() {
 			time.Sleep(10 * time.Millisecond)
-			err := q.Push(&adapter.TransportData{SenderNodeAddress: []byte{0x01}})
+			err := q.Push(&outgoingMessage{TransportData: &adapter.TransportData{SenderNodeAddress: []byte{0x01}}})
 			require.NoError(t, err)
 		}()
 
@@ -81,7 +81,7 @@ func TestQueue_PopWhenEmptyWaitsUntilPush(t *testing.T) {
 
 func TestQueue_PopWhenEmptyCancelsWithContext(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
-	q := NewTransportQueue(1000, 1000, metric.NewRegistry())
+	q := NewTransportQueue(1000, 1000, NewTransportQueueMetrics("Test", metric.NewRegistry()))
 
 	go func() {
 		time.Sleep(10 * time.Millisecond)
@@ -94,76 +94,76 @@ func TestQueue_PopWhenEmptyCancelsWithContext(t *testing.T) {
 
 func TestQueue_CannotPushMoreThanMaxBytes(t *testing.T) {
 	test.WithContext(func(ctx context.Context) {
-		q := NewTransportQueue(10, 1000, metric.NewRegistry())
+		q := NewTransportQueue(10, 1000, NewTransportQueueMetrics("Test", metric.NewRegistry()))
 
-		err := q.Push(&adapter.TransportData{SenderNodeAddress: []byte{0x01}, Payloads: [][]byte{buf(3), buf(4)}})
+		err := q.Push(&outgoingMessage{TransportData: &adapter.TransportData{SenderNodeAddress: []byte{0x01}, Payloads: [][]byte{buf(3), buf(4)}}})
 		require.NoError(t, err)
 
-		err = q.Push(&adapter.TransportData{SenderNodeAddress: []byte{0x02}, Payloads: [][]byte{buf(1), buf(6)}})
+		err = q.Push(&outgoingMessage{TransportData: &adapter.TransportData{SenderNodeAddress: []byte{0x02}, Payloads: [][]byte{buf(1), buf(6)}}})
 		require.Error(t, err, "queue should be full")
 
-		err = q.Push(&adapter.TransportData{SenderNodeAddress: []byte{0x03}, Payloads: [][]byte{buf(4)}})
+		err = q.Push(&outgoingMessage{TransportData: &adapter.TransportData{SenderNodeAddress: []byte{0x03}, Payloads: [][]byte{buf(4)}}})
 		require.Error(t, err, "queue should be full")
 
-		err = q.Push(&adapter.TransportData{SenderNodeAddress: []byte{0x04}, Payloads: [][]byte{buf(3)}})
+		err = q.Push(&outgoingMessage{TransportData: &adapter.TransportData{SenderNodeAddress: []byte{0x04}, Payloads: [][]byte{buf(3)}}})
 		require.NoError(t, err)
 
 		d1 := q.Pop(ctx)
 		require.EqualValues(t, []byte{0x01}, d1.SenderNodeAddress)
 
-		err = q.Push(&adapter.TransportData{SenderNodeAddress: []byte{0x05}, Payloads: [][]byte{buf(1), buf(6)}})
+		err = q.Push(&outgoingMessage{TransportData: &adapter.TransportData{SenderNodeAddress: []byte{0x05}, Payloads: [][]byte{buf(1), buf(6)}}})
 		require.NoError(t, err)
 	})
 }
 
 func TestQueue_ClearEmptiesTheQueue(t *testing.T) {
 	test.WithContext(func(ctx context.Context) {
-		q := NewTransportQueue(1000, 3, metric.NewRegistry())
+		q := NewTransportQueue(1000, 3, NewTransportQueueMetrics("Test", metric.NewRegistry()))
 
 		q.Clear(ctx)
 
-		err := q.Push(&adapter.TransportData{SenderNodeAddress: []byte{0x01}})
+		err := q.Push(&outgoingMessage{TransportData: &adapter.TransportData{SenderNodeAddress: []byte{0x01}}})
 		require.NoError(t, err)
 
-		err = q.Push(&adapter.TransportData{SenderNodeAddress: []byte{0x02}})
+		err = q.Push(&outgoingMessage{TransportData: &adapter.TransportData{SenderNodeAddress: []byte{0x02}}})
 		require.NoError(t, err)
 
-		err = q.Push(&adapter.TransportData{SenderNodeAddress: []byte{0x03}})
+		err = q.Push(&outgoingMessage{TransportData: &adapter.TransportData{SenderNodeAddress: []byte{0x03}}})
 		require.NoError(t, err)
 
 		q.Clear(ctx)
 
-		err = q.Push(&adapter.TransportData{SenderNodeAddress: []byte{0x01}})
+		err = q.Push(&outgoingMessage{TransportData: &adapter.TransportData{SenderNodeAddress: []byte{0x01}}})
 		require.NoError(t, err)
 
-		err = q.Push(&adapter.TransportData{SenderNodeAddress: []byte{0x02}})
+		err = q.Push(&outgoingMessage{TransportData: &adapter.TransportData{SenderNodeAddress: []byte{0x02}}})
 		require.NoError(t, err)
 
-		err = q.Push(&adapter.TransportData{SenderNodeAddress: []byte{0x03}})
+		err = q.Push(&outgoingMessage{TransportData: &adapter.TransportData{SenderNodeAddress: []byte{0x03}}})
 		require.NoError(t, err)
 	})
 }
 
 func TestQueue_DisableThenEnable(t *testing.T) {
 	test.WithContext(func(ctx context.Context) {
-		q := NewTransportQueue(1000, 2, metric.NewRegistry())
+		q := NewTransportQueue(1000, 2, NewTransportQueueMetrics("Test", metric.NewRegistry()))
 
 		q.Disable()
 
-		err := q.Push(&adapter.TransportData{SenderNodeAddress: []byte{0x01}})
+		err := q.Push(&outgoingMessage{TransportData: &adapter.TransportData{SenderNodeAddress: []byte{0x01}}})
 		require.NoError(t, err)
 
 		q.Enable()
 
-		err = q.Push(&adapter.TransportData{SenderNodeAddress: []byte{0x02}})
+		err = q.Push(&outgoingMessage{TransportData: &adapter.TransportData{SenderNodeAddress: []byte{0x02}}})
 		require.NoError(t, err)
 
-		err = q.Push(&adapter.TransportData{SenderNodeAddress: []byte{0x03}})
+		err = q.Push(&outgoingMessage{TransportData: &adapter.TransportData{SenderNodeAddress: []byte{0x03}}})
 		require.NoError(t, err)
 
 		q.Disable()
 
-		err = q.Push(&adapter.TransportData{SenderNodeAddress: []byte{0x04}})
+		err = q.Push(&outgoingMessage{TransportData: &adapter.TransportData{SenderNodeAddress: []byte{0x04}}})
 		require.NoError(t, err)
 	})
 }
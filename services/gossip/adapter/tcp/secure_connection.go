@@ -0,0 +1,295 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package tcp
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	cryptorand "crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"github.com/orbs-network/membuffers/go"
+	"github.com/orbs-network/orbs-network-go/crypto/digest"
+	"github.com/orbs-network/orbs-network-go/crypto/hash"
+	"github.com/orbs-network/orbs-network-go/crypto/signer"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/curve25519"
+	"io"
+	"net"
+	"time"
+)
+
+// Every connection starts with a handshake in which both nodes prove they hold the key of their node address:
+//   client -> server: hello (protocol version, ephemeral X25519 public key, node address)
+//   server -> client: hello
+//   client -> server: signature as node over the transcript of both hellos
+//   server -> client: signature as node over the transcript of both hellos
+// The server only accepts node addresses of its gossip peers, the client only the address of the peer it dialed.
+// The client proves its address first, so the server never signs for a client which merely claims the address of a peer.
+// Both derive session keys from the X25519 shared secret and the transcript, and from then on all data is sent
+// in records of AES-256-GCM ciphertext, each prefixed by its size. Record nonces count the records sent in each direction.
+
+const HANDSHAKE_PROTOCOL_VERSION = 1
+const SECURE_RECORD_MAX_PLAINTEXT_BYTES = 64 * 1024
+
+const (
+	handshakeHelloSizeBytes        = 4 + 32 + digest.NODE_ADDRESS_SIZE_BYTES
+	handshakeLabel                 = "orbs gossip handshake"
+	clientSignatureLabel           = "client"
+	serverSignatureLabel           = "server"
+	clientToServerKeyLabel         = "client to server"
+	serverToClientKeyLabel         = "server to client"
+	maxHandshakeSignatureSizeBytes = 128
+)
+
+type handshakeHello struct {
+	version      uint32
+	ephemeralKey [32]byte
+	nodeAddress  primitives.NodeAddress
+}
+
+type handshakeIdentity struct {
+	nodeAddress primitives.NodeAddress
+	signer      signer.Signer
+}
+
+// a net.Conn reading and writing records of a session, the other methods are the ones of the underlying connection
+type secureConnection struct {
+	net.Conn
+	peerNodeAddress primitives.NodeAddress
+
+	sendCipher    cipher.AEAD
+	sendNonce     uint64
+	receiveCipher cipher.AEAD
+	receiveNonce  uint64
+
+	received []byte // plaintext of the last record not read yet
+}
+
+func clientHandshake(ctx context.Context, conn net.Conn, timeout time.Duration, identity *handshakeIdentity, expectedPeerNodeAddress primitives.NodeAddress) (*secureConnection, error) {
+	ephemeralPrivateKey, clientHello, err := newHandshakeHello(identity.nodeAddress)
+	if err != nil {
+		return nil, err
+	}
+	if err := write(ctx, conn, clientHello.encode(), timeout); err != nil {
+		return nil, errors.Wrap(err, "failed sending handshake hello")
+	}
+
+	serverHello, err := readHandshakeHello(ctx, conn, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if !serverHello.nodeAddress.Equal(expectedPeerNodeAddress) {
+		return nil, errors.Errorf("gossip peer presented node address %s, expected %s", serverHello.nodeAddress, expectedPeerNodeAddress)
+	}
+
+	transcript := handshakeTranscript(clientHello, serverHello)
+	if err := signAndSendHandshakeSignature(ctx, conn, timeout, identity.signer, transcript, clientSignatureLabel); err != nil {
+		return nil, err
+	}
+	if err := readAndVerifyHandshakeSignature(ctx, conn, timeout, serverHello.nodeAddress, transcript, serverSignatureLabel); err != nil {
+		return nil, err
+	}
+
+	return newSecureConnection(conn, serverHello.nodeAddress, ephemeralPrivateKey, serverHello.ephemeralKey, transcript, clientToServerKeyLabel, serverToClientKeyLabel)
+}
+
+func serverHandshake(ctx context.Context, conn net.Conn, timeout time.Duration, identity *handshakeIdentity, isKnownPeer func(primitives.NodeAddress) bool) (*secureConnection, error) {
+	clientHello, err := readHandshakeHello(ctx, conn, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if !isKnownPeer(clientHello.nodeAddress) {
+		return nil, errors.Errorf("node address %s is not a gossip peer", clientHello.nodeAddress)
+	}
+
+	ephemeralPrivateKey, serverHello, err := newHandshakeHello(identity.nodeAddress)
+	if err != nil {
+		return nil, err
+	}
+	if err := write(ctx, conn, serverHello.encode(), timeout); err != nil {
+		return nil, errors.Wrap(err, "failed sending handshake hello")
+	}
+
+	transcript := handshakeTranscript(clientHello, serverHello)
+	if err := readAndVerifyHandshakeSignature(ctx, conn, timeout, clientHello.nodeAddress, transcript, clientSignatureLabel); err != nil {
+		return nil, err
+	}
+	if err := signAndSendHandshakeSignature(ctx, conn, timeout, identity.signer, transcript, serverSignatureLabel); err != nil {
+		return nil, err
+	}
+
+	return newSecureConnection(conn, clientHello.nodeAddress, ephemeralPrivateKey, clientHello.ephemeralKey, transcript, serverToClientKeyLabel, clientToServerKeyLabel)
+}
+
+func newHandshakeHello(nodeAddress primitives.NodeAddress) (ephemeralPrivateKey *[32]byte, hello *handshakeHello, err error) {
+	ephemeralPrivateKey = &[32]byte{}
+	if _, err := cryptorand.Read(ephemeralPrivateKey[:]); err != nil {
+		return nil, nil, errors.Wrap(err, "failed generating handshake ephemeral key")
+	}
+	hello = &handshakeHello{version: HANDSHAKE_PROTOCOL_VERSION, nodeAddress: nodeAddress}
+	curve25519.ScalarBaseMult(&hello.ephemeralKey, ephemeralPrivateKey)
+	return
+}
+
+func (h *handshakeHello) encode() []byte {
+	buffer := make([]byte, handshakeHelloSizeBytes)
+	membuffers.WriteUint32(buffer, h.version)
+	copy(buffer[4:36], h.ephemeralKey[:])
+	copy(buffer[36:], h.nodeAddress)
+	return buffer
+}
+
+func readHandshakeHello(ctx context.Context, conn net.Conn, timeout time.Duration) (*handshakeHello, error) {
+	buffer, err := readTotal(ctx, conn, handshakeHelloSizeBytes, timeout)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed receiving handshake hello")
+	}
+	hello := &handshakeHello{
+		version:     membuffers.GetUint32(buffer),
+		nodeAddress: primitives.NodeAddress(buffer[36:]),
+	}
+	copy(hello.ephemeralKey[:], buffer[4:36])
+	if hello.version != HANDSHAKE_PROTOCOL_VERSION {
+		return nil, errors.Errorf("gossip peer handshake version is %d, expected %d", hello.version, HANDSHAKE_PROTOCOL_VERSION)
+	}
+	return hello, nil
+}
+
+func handshakeTranscript(clientHello *handshakeHello, serverHello *handshakeHello) []byte {
+	return hash.CalcSha256([]byte(handshakeLabel), clientHello.encode(), serverHello.encode())
+}
+
+func signAndSendHandshakeSignature(ctx context.Context, conn net.Conn, timeout time.Duration, s signer.Signer, transcript []byte, label string) error {
+	sig, err := s.SignAsNode(ctx, 0, append([]byte(label), transcript...))
+	if err != nil {
+		return errors.Wrap(err, "failed signing handshake")
+	}
+	sizeBuffer := make([]byte, 4)
+	membuffers.WriteUint32(sizeBuffer, uint32(len(sig)))
+	return write(ctx, conn, append(sizeBuffer, sig...), timeout)
+}
+
+func readAndVerifyHandshakeSignature(ctx context.Context, conn net.Conn, timeout time.Duration, nodeAddress primitives.NodeAddress, transcript []byte, label string) error {
+	sizeBuffer, err := readTotal(ctx, conn, 4, timeout)
+	if err != nil {
+		return errors.Wrap(err, "failed receiving handshake signature")
+	}
+	size := membuffers.GetUint32(sizeBuffer)
+	if size > maxHandshakeSignatureSizeBytes {
+		return errors.Errorf("received handshake signature too big: %d bytes", size)
+	}
+	sig, err := readTotal(ctx, conn, size, timeout)
+	if err != nil {
+		return errors.Wrap(err, "failed receiving handshake signature")
+	}
+	if err := digest.VerifyNodeSignature(nodeAddress, append([]byte(label), transcript...), sig); err != nil {
+		return errors.Wrapf(err, "gossip peer failed proving node address %s", nodeAddress)
+	}
+	return nil
+}
+
+func newSecureConnection(conn net.Conn, peerNodeAddress primitives.NodeAddress, ephemeralPrivateKey *[32]byte, peerEphemeralKey [32]byte, transcript []byte, sendLabel string, receiveLabel string) (*secureConnection, error) {
+	var sharedSecret [32]byte
+	curve25519.ScalarMult(&sharedSecret, ephemeralPrivateKey, &peerEphemeralKey)
+	if subtle.ConstantTimeCompare(sharedSecret[:], make([]byte, 32)) == 1 {
+		return nil, errors.New("gossip peer sent an invalid handshake ephemeral key")
+	}
+
+	sendCipher, err := newSessionCipher(sharedSecret[:], transcript, sendLabel)
+	if err != nil {
+		return nil, err
+	}
+	receiveCipher, err := newSessionCipher(sharedSecret[:], transcript, receiveLabel)
+	if err != nil {
+		return nil, err
+	}
+
+	return &secureConnection{
+		Conn:            conn,
+		peerNodeAddress: peerNodeAddress,
+		sendCipher:      sendCipher,
+		receiveCipher:   receiveCipher,
+	}, nil
+}
+
+func newSessionCipher(sharedSecret []byte, transcript []byte, label string) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(hash.CalcSha256([]byte(label), sharedSecret, transcript))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (c *secureConnection) Write(buffer []byte) (int, error) {
+	written := 0
+	for written < len(buffer) {
+		end := written + SECURE_RECORD_MAX_PLAINTEXT_BYTES
+		if end > len(buffer) {
+			end = len(buffer)
+		}
+		if err := c.writeRecord(buffer[written:end]); err != nil {
+			return written, err
+		}
+		written = end
+	}
+	return written, nil
+}
+
+func (c *secureConnection) writeRecord(plaintext []byte) error {
+	record := make([]byte, 4, 4+len(plaintext)+c.sendCipher.Overhead())
+	record = c.sendCipher.Seal(record, recordNonce(c.sendNonce), plaintext, nil)
+	c.sendNonce++
+	membuffers.WriteUint32(record, uint32(len(record)-4))
+
+	_, err := c.Conn.Write(record)
+	return err
+}
+
+// reads the plaintext of the records in order, a record which fails authentication fails the connection
+func (c *secureConnection) Read(buffer []byte) (int, error) {
+	if len(c.received) == 0 {
+		plaintext, err := c.readRecord()
+		if err != nil {
+			return 0, err
+		}
+		c.received = plaintext
+	}
+	read := copy(buffer, c.received)
+	c.received = c.received[read:]
+	return read, nil
+}
+
+func (c *secureConnection) readRecord() ([]byte, error) {
+	sizeBuffer := make([]byte, 4)
+	if _, err := io.ReadFull(c.Conn, sizeBuffer); err != nil {
+		return nil, err
+	}
+	size := membuffers.GetUint32(sizeBuffer)
+	if size > SECURE_RECORD_MAX_PLAINTEXT_BYTES+uint32(c.receiveCipher.Overhead()) {
+		return nil, errors.Errorf("received record too big: %d bytes", size)
+	}
+	ciphertext := make([]byte, size)
+	if _, err := io.ReadFull(c.Conn, ciphertext); err != nil {
+		return nil, err
+	}
+
+	plaintext, err := c.receiveCipher.Open(ciphertext[:0], recordNonce(c.receiveNonce), ciphertext, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "received record failed authentication")
+	}
+	c.receiveNonce++
+	return plaintext, nil
+}
+
+func recordNonce(counter uint64) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint64(nonce[4:], counter)
+	return nonce
+}
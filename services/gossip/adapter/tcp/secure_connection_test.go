@@ -0,0 +1,187 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package tcp
+
+import (
+	"bytes"
+	"context"
+	"github.com/orbs-network/membuffers/go"
+	"github.com/orbs-network/orbs-network-go/crypto/signer"
+	testKeys "github.com/orbs-network/orbs-network-go/test/crypto/keys"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net"
+	"testing"
+)
+
+type handshakeResult struct {
+	conn *secureConnection
+	err  error
+}
+
+// replays recorded bytes as what the peer sent
+type replayedConnection struct {
+	net.Conn
+	reader io.Reader
+}
+
+func (c *replayedConnection) Read(buffer []byte) (int, error) {
+	return c.reader.Read(buffer)
+}
+
+type countingSigner struct {
+	signer.Signer
+	calls int
+}
+
+func (s *countingSigner) SignAsNode(ctx context.Context, blockHeight primitives.BlockHeight, data []byte) (primitives.EcdsaSecp256K1Sig, error) {
+	s.calls++
+	return s.Signer.SignAsNode(ctx, blockHeight, data)
+}
+
+func identityForTests(tb testing.TB, keyIndex int) *handshakeIdentity {
+	return &handshakeIdentity{
+		nodeAddress: testKeys.EcdsaSecp256K1KeyPairForTests(keyIndex).NodeAddress(),
+		signer:      signerForTests(tb, keyIndex),
+	}
+}
+
+func knownPeersForTests(keyIndices ...int) func(primitives.NodeAddress) bool {
+	return func(address primitives.NodeAddress) bool {
+		for _, keyIndex := range keyIndices {
+			if address.Equal(testKeys.EcdsaSecp256K1KeyPairForTests(keyIndex).NodeAddress()) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func handshakeOverPipe(client *handshakeIdentity, expectedServerAddress primitives.NodeAddress, server *handshakeIdentity, isKnownPeer func(primitives.NodeAddress) bool) (clientResult handshakeResult, serverResult handshakeResult) {
+	clientPipe, serverPipe := net.Pipe()
+	serverDone := make(chan handshakeResult)
+	go func() {
+		conn, err := serverHandshake(context.Background(), serverPipe, TEST_NETWORK_TIMEOUT, server, isKnownPeer)
+		if err != nil {
+			serverPipe.Close()
+		}
+		serverDone <- handshakeResult{conn, err}
+	}()
+
+	conn, err := clientHandshake(context.Background(), clientPipe, TEST_NETWORK_TIMEOUT, client, expectedServerAddress)
+	if err != nil {
+		clientPipe.Close()
+	}
+	return handshakeResult{conn, err}, <-serverDone
+}
+
+// reads the raw record the client sends for the plaintext
+func sendAndCaptureRecord(t *testing.T, client *secureConnection, server *secureConnection, plaintext []byte) []byte {
+	go client.Write(plaintext)
+
+	sizeBuffer := make([]byte, 4)
+	_, err := io.ReadFull(server.Conn, sizeBuffer)
+	require.NoError(t, err, "server should receive record size")
+	ciphertext := make([]byte, membuffers.GetUint32(sizeBuffer))
+	_, err = io.ReadFull(server.Conn, ciphertext)
+	require.NoError(t, err, "server should receive record")
+	return append(sizeBuffer, ciphertext...)
+}
+
+func TestSecureConnection_HandshakeAuthenticatesBothNodes(t *testing.T) {
+	clientResult, serverResult := handshakeOverPipe(identityForTests(t, 1), testKeys.EcdsaSecp256K1KeyPairForTests(0).NodeAddress(), identityForTests(t, 0), knownPeersForTests(1))
+	require.NoError(t, clientResult.err, "client handshake should succeed")
+	require.NoError(t, serverResult.err, "server handshake should succeed")
+	defer clientResult.conn.Close()
+	defer serverResult.conn.Close()
+
+	require.Equal(t, testKeys.EcdsaSecp256K1KeyPairForTests(0).NodeAddress(), clientResult.conn.peerNodeAddress, "client should know the address of the server")
+	require.Equal(t, testKeys.EcdsaSecp256K1KeyPairForTests(1).NodeAddress(), serverResult.conn.peerNodeAddress, "server should know the address of the client")
+
+	plaintext := exampleWireProtocolEncoding_Payloads_0x11_0x2233()
+	go clientResult.conn.Write(plaintext)
+	received, err := readTotal(context.Background(), serverResult.conn, uint32(len(plaintext)), TEST_NETWORK_TIMEOUT)
+	require.NoError(t, err, "server should read what the client wrote")
+	require.Equal(t, plaintext, received)
+}
+
+func TestSecureConnection_ServerRejectsUnknownPeer(t *testing.T) {
+	clientResult, serverResult := handshakeOverPipe(identityForTests(t, 5), testKeys.EcdsaSecp256K1KeyPairForTests(0).NodeAddress(), identityForTests(t, 0), knownPeersForTests(1, 2))
+	require.Error(t, serverResult.err, "server should reject a node address which is not a gossip peer")
+	require.Error(t, clientResult.err, "client handshake should fail when rejected")
+}
+
+func TestSecureConnection_ServerRejectsPeerNotHoldingKeyOfItsAddress(t *testing.T) {
+	impostor := &handshakeIdentity{
+		nodeAddress: testKeys.EcdsaSecp256K1KeyPairForTests(1).NodeAddress(),
+		signer:      signerForTests(t, 5),
+	}
+	server := identityForTests(t, 0)
+	serverSigner := &countingSigner{Signer: server.signer}
+	server.signer = serverSigner
+	clientResult, serverResult := handshakeOverPipe(impostor, testKeys.EcdsaSecp256K1KeyPairForTests(0).NodeAddress(), server, knownPeersForTests(1))
+	require.Error(t, serverResult.err, "server should reject a peer signing with a key of another address")
+	require.Error(t, clientResult.err, "impostor should not receive the signature of the server")
+	require.Zero(t, serverSigner.calls, "server should not sign for a peer which did not prove its address")
+}
+
+func TestSecureConnection_ClientRejectsServerWithUnexpectedAddress(t *testing.T) {
+	clientResult, serverResult := handshakeOverPipe(identityForTests(t, 1), testKeys.EcdsaSecp256K1KeyPairForTests(2).NodeAddress(), identityForTests(t, 0), knownPeersForTests(1))
+	require.Error(t, clientResult.err, "client should reject a server which is not the peer it dialed")
+	require.Error(t, serverResult.err, "server handshake should fail when rejected")
+}
+
+func TestSecureConnection_RecordsAreEncryptedAndAuthenticated(t *testing.T) {
+	clientResult, serverResult := handshakeOverPipe(identityForTests(t, 1), testKeys.EcdsaSecp256K1KeyPairForTests(0).NodeAddress(), identityForTests(t, 0), knownPeersForTests(1))
+	require.NoError(t, clientResult.err)
+	require.NoError(t, serverResult.err)
+	defer clientResult.conn.Close()
+	defer serverResult.conn.Close()
+	server := serverResult.conn
+
+	plaintext := []byte("consensus message which should not be readable on the wire")
+	record := sendAndCaptureRecord(t, clientResult.conn, server, plaintext)
+	require.False(t, bytes.Contains(record, plaintext), "record should not hold the plaintext")
+
+	tampered := append([]byte{}, record...)
+	tampered[len(tampered)-1] ^= 0x01
+	underlying := server.Conn
+
+	server.Conn = &replayedConnection{Conn: underlying, reader: bytes.NewReader(tampered)}
+	_, err := server.Read(make([]byte, len(plaintext)))
+	require.Error(t, err, "tampered record should fail authentication")
+
+	server.Conn = &replayedConnection{Conn: underlying, reader: bytes.NewReader(record)}
+	received := make([]byte, len(plaintext))
+	read, err := server.Read(received)
+	require.NoError(t, err, "original record should be read")
+	require.Equal(t, plaintext, received[:read])
+
+	server.Conn = &replayedConnection{Conn: underlying, reader: bytes.NewReader(record)}
+	_, err = server.Read(make([]byte, len(plaintext)))
+	require.Error(t, err, "replayed record should fail authentication")
+}
+
+func TestSecureConnection_SplitsLargeWritesIntoRecords(t *testing.T) {
+	clientResult, serverResult := handshakeOverPipe(identityForTests(t, 1), testKeys.EcdsaSecp256K1KeyPairForTests(0).NodeAddress(), identityForTests(t, 0), knownPeersForTests(1))
+	require.NoError(t, clientResult.err)
+	require.NoError(t, serverResult.err)
+	defer clientResult.conn.Close()
+	defer serverResult.conn.Close()
+
+	plaintext := make([]byte, 2*SECURE_RECORD_MAX_PLAINTEXT_BYTES+10)
+	for i := range plaintext {
+		plaintext[i] = byte(i)
+	}
+	go clientResult.conn.Write(plaintext)
+
+	received, err := readTotal(context.Background(), serverResult.conn, uint32(len(plaintext)), TEST_NETWORK_TIMEOUT)
+	require.NoError(t, err, "server should read all records of the write")
+	require.Equal(t, plaintext, received)
+	require.EqualValues(t, 3, serverResult.conn.receiveNonce, "write should be split into 3 records")
+}
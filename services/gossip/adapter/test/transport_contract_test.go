@@ -9,9 +9,11 @@ package test
 import (
 	"context"
 	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/crypto/signer"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
 	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
 	"github.com/orbs-network/orbs-network-go/services/gossip/adapter/memory"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter/relay"
 	"github.com/orbs-network/orbs-network-go/services/gossip/adapter/tcp"
 	"github.com/orbs-network/orbs-network-go/services/gossip/adapter/testkit"
 	"github.com/orbs-network/orbs-network-go/test"
@@ -27,11 +29,13 @@ import (
 func TestContract_SendBroadcast(t *testing.T) {
 	t.Run("TCP_DirectTransport", broadcastTest(aDirectTransport))
 	t.Run("MemoryTransport", broadcastTest(aMemoryTransport))
+	t.Run("RelayTransport", broadcastTest(aRelayTransport))
 }
 
 func TestContract_SendToList(t *testing.T) {
 	t.Run("TCP_DirectTransport", sendToListTest(aDirectTransport))
 	t.Run("MemoryTransport", sendToListTest(aMemoryTransport))
+	t.Run("RelayTransport", sendToListTest(aRelayTransport))
 }
 
 func TestContract_SendToAllButList(t *testing.T) {
@@ -110,10 +114,36 @@ func aMemoryTransport(ctx context.Context, tb testing.TB) *transportContractCont
 	return res
 }
 
-func aDirectTransport(ctx context.Context, tb testing.TB) *transportContractContext {
+// every node is linked to 2 neighbors over a memory transport, so a message reaches the opposite node on the ring over 2 hops
+func aRelayTransport(ctx context.Context, tb testing.TB) *transportContractContext {
 	res := &transportContractContext{}
 
-	gossipPeers := make(map[string]config.GossipPeer)
+	logger := log.DefaultTestingLogger(tb).WithTags(log.String("adapter", "transport"))
+
+	genesisValidatorNodes := make(map[string]config.ValidatorNode)
+	signers := make(map[string]signer.Signer)
+	for i := 0; i < 4; i++ {
+		nodeAddress := keys.EcdsaSecp256K1KeyPairForTests(i).NodeAddress()
+		res.nodeAddresses = append(res.nodeAddresses, nodeAddress)
+		genesisValidatorNodes[nodeAddress.KeyForMap()] = config.NewHardCodedValidatorNode(nodeAddress)
+		signers[nodeAddress.KeyForMap()] = signer.NewLocalSigner(keys.EcdsaSecp256K1KeyPairForTests(i).PrivateKey(), logger)
+	}
+
+	link := memory.NewTransport(ctx, logger, genesisValidatorNodes)
+	transport := relay.NewTransport(link, res.nodeAddresses, signers, config.ForGossipRelayTests(2, 4), logger, metric.NewRegistry())
+	res.transports = []adapter.Transport{transport, transport, transport, transport}
+	res.listeners = []*testkit.MockTransportListener{
+		testkit.ListenTo(res.transports[0], res.nodeAddresses[0]),
+		testkit.ListenTo(res.transports[1], res.nodeAddresses[1]),
+		testkit.ListenTo(res.transports[2], res.nodeAddresses[2]),
+		testkit.ListenTo(res.transports[3], res.nodeAddresses[3]),
+	}
+
+	return res
+}
+
+func aDirectTransport(ctx context.Context, tb testing.TB) *transportContractContext {
+	res := &transportContractContext{}
 
 	for i := 0; i < 4; i++ {
 		nodeAddress := keys.EcdsaSecp256K1KeyPairForTests(i).NodeAddress()
@@ -121,20 +151,20 @@ func aDirectTransport(ctx context.Context, tb testing.TB) *transportContractCont
 	}
 
 	configs := []config.GossipTransportConfig{
-		config.ForGossipAdapterTests(res.nodeAddresses[0], 0, gossipPeers),
-		config.ForGossipAdapterTests(res.nodeAddresses[1], 0, gossipPeers),
-		config.ForGossipAdapterTests(res.nodeAddresses[2], 0, gossipPeers),
-		config.ForGossipAdapterTests(res.nodeAddresses[3], 0, gossipPeers),
+		config.ForGossipAdapterTests(res.nodeAddresses[0], 0, make(map[string]config.GossipPeer)),
+		config.ForGossipAdapterTests(res.nodeAddresses[1], 0, make(map[string]config.GossipPeer)),
+		config.ForGossipAdapterTests(res.nodeAddresses[2], 0, make(map[string]config.GossipPeer)),
+		config.ForGossipAdapterTests(res.nodeAddresses[3], 0, make(map[string]config.GossipPeer)),
 	}
 
 	logger := log.DefaultTestingLogger(tb)
 	registry := metric.NewRegistry()
 
 	transports := []*tcp.DirectTransport{
-		tcp.NewDirectTransport(ctx, configs[0], logger, registry),
-		tcp.NewDirectTransport(ctx, configs[1], logger, registry),
-		tcp.NewDirectTransport(ctx, configs[2], logger, registry),
-		tcp.NewDirectTransport(ctx, configs[3], logger, registry),
+		tcp.NewDirectTransport(ctx, configs[0], signer.NewLocalSigner(keys.EcdsaSecp256K1KeyPairForTests(0).PrivateKey(), logger), logger, registry),
+		tcp.NewDirectTransport(ctx, configs[1], signer.NewLocalSigner(keys.EcdsaSecp256K1KeyPairForTests(1).PrivateKey(), logger), logger, registry),
+		tcp.NewDirectTransport(ctx, configs[2], signer.NewLocalSigner(keys.EcdsaSecp256K1KeyPairForTests(2).PrivateKey(), logger), logger, registry),
+		tcp.NewDirectTransport(ctx, configs[3], signer.NewLocalSigner(keys.EcdsaSecp256K1KeyPairForTests(3).PrivateKey(), logger), logger, registry),
 	}
 
 	test.Eventually(1*time.Second, func() bool {
@@ -29,6 +29,20 @@ type TransportListener interface {
 	OnTransportMessageReceived(ctx context.Context, payloads [][]byte)
 }
 
+type authenticatedSenderKey struct{}
+
+// Marks the context of messages received from a peer whose node address the transport authenticated, such as the TCP
+// transport after its handshake. A nil node address marks messages whose sender the transport cannot tell
+func ContextWithAuthenticatedSender(ctx context.Context, nodeAddress primitives.NodeAddress) context.Context {
+	return context.WithValue(ctx, authenticatedSenderKey{}, nodeAddress)
+}
+
+// the node address the transport authenticated as the sender of a received message, false when the transport does not authenticate senders
+func AuthenticatedSender(ctx context.Context) (primitives.NodeAddress, bool) {
+	nodeAddress, ok := ctx.Value(authenticatedSenderKey{}).(primitives.NodeAddress)
+	return nodeAddress, ok && len(nodeAddress) > 0
+}
+
 type ErrCorruptData struct {
 }
 
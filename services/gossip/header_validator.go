@@ -7,6 +7,8 @@
 package gossip
 
 import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/protocol/gossipmessages"
 	"github.com/orbs-network/scribe/log"
@@ -43,6 +45,20 @@ func (v *headerValidator) validateMessageHeader(header *gossipmessages.Header) e
 	return nil
 }
 
+// a transport which authenticates its peers tells which node sent the message, the sender the message claims must be that node
+func (v *headerValidator) validateMessageSender(ctx context.Context, sender *gossipmessages.SenderSignature) error {
+	authenticatedSender, ok := adapter.AuthenticatedSender(ctx)
+	if !ok {
+		return nil
+	}
+
+	if !sender.SenderNodeAddress().Equal(authenticatedSender) {
+		return errors.Errorf("message claims sender %s but was received from %s", sender.SenderNodeAddress(), authenticatedSender)
+	}
+
+	return nil
+}
+
 func isInRecipientList(me primitives.NodeAddress, recipientIterator *gossipmessages.HeaderRecipientNodeAddressesIterator) bool {
 	for recipientIterator.HasNext() {
 		if me.Equal(recipientIterator.NextRecipientNodeAddresses()) {
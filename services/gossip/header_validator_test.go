@@ -7,6 +7,8 @@
 package gossip
 
 import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/protocol/gossipmessages"
 	"github.com/orbs-network/scribe/log"
@@ -61,6 +63,31 @@ func TestValidateMessageHeader_NodeIsARecipient_AllButListMode(t *testing.T) {
 	require.Error(t, v.validateMessageHeader(header))
 }
 
+func TestValidateMessageSender_SenderIsTheAuthenticatedPeer(t *testing.T) {
+	v := newValidator(t)
+	ctx := adapter.ContextWithAuthenticatedSender(context.Background(), primitives.NodeAddress{0x2})
+
+	require.NoError(t, v.validateMessageSender(ctx, senderSignature(primitives.NodeAddress{0x2})))
+}
+
+func TestValidateMessageSender_SenderIsNotTheAuthenticatedPeer(t *testing.T) {
+	v := newValidator(t)
+	ctx := adapter.ContextWithAuthenticatedSender(context.Background(), primitives.NodeAddress{0x2})
+
+	require.Error(t, v.validateMessageSender(ctx, senderSignature(primitives.NodeAddress{0x3})))
+}
+
+func TestValidateMessageSender_TransportDoesNotAuthenticateSenders(t *testing.T) {
+	v := newValidator(t)
+
+	require.NoError(t, v.validateMessageSender(context.Background(), senderSignature(primitives.NodeAddress{0x3})))
+	require.NoError(t, v.validateMessageSender(adapter.ContextWithAuthenticatedSender(context.Background(), nil), senderSignature(primitives.NodeAddress{0x3})))
+}
+
+func senderSignature(nodeAddress primitives.NodeAddress) *gossipmessages.SenderSignature {
+	return (&gossipmessages.SenderSignatureBuilder{SenderNodeAddress: nodeAddress}).Build()
+}
+
 func newValidator(t *testing.T) *headerValidator {
 	cfg := &hardcodedValidatorConfig{virtualChainId: 42, nodeAddress: []byte{0x1}}
 	v := newHeaderValidator(cfg, log.DefaultTestingLogger(t))
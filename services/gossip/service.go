@@ -82,3 +82,13 @@ func (s *service) OnTransportMessageReceived(ctx context.Context, payloads [][]b
 		s.receivedBlockSyncMessage(ctx, header, payloads[1:])
 	}
 }
+
+// the handlers trust the sender a message claims, so a message claiming a sender other than the node the transport
+// authenticated is dropped
+func (s *service) isFromAuthenticatedSender(ctx context.Context, sender *gossipmessages.SenderSignature) bool {
+	if err := s.headerValidator.validateMessageSender(ctx, sender); err != nil {
+		s.logger.Error("dropping a received message from a sender other than the peer", log.Error(err), trace.LogFieldFrom(ctx))
+		return false
+	}
+	return true
+}
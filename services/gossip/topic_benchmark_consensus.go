@@ -99,6 +99,9 @@ func (s *service) receivedBenchmarkConsensusCommitted(ctx context.Context, heade
 	if err != nil {
 		return
 	}
+	if !s.isFromAuthenticatedSender(ctx, message.Sender) {
+		return
+	}
 
 	s.handlers.RLock()
 	defer s.handlers.RUnlock()
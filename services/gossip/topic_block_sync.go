@@ -60,6 +60,9 @@ func (s *service) receivedBlockSyncAvailabilityRequest(ctx context.Context, head
 	if err != nil {
 		return
 	}
+	if !s.isFromAuthenticatedSender(ctx, message.Sender) {
+		return
+	}
 
 	s.handlers.RLock()
 	defer s.handlers.RUnlock()
@@ -98,6 +101,9 @@ func (s *service) receivedBlockSyncAvailabilityResponse(ctx context.Context, hea
 	if err != nil {
 		return
 	}
+	if !s.isFromAuthenticatedSender(ctx, message.Sender) {
+		return
+	}
 
 	s.handlers.RLock()
 	defer s.handlers.RUnlock()
@@ -136,6 +142,9 @@ func (s *service) receivedBlockSyncRequest(ctx context.Context, header *gossipme
 	if err != nil {
 		return
 	}
+	if !s.isFromAuthenticatedSender(ctx, message.Sender) {
+		return
+	}
 
 	s.handlers.RLock()
 	defer s.handlers.RUnlock()
@@ -174,6 +183,9 @@ func (s *service) receivedBlockSyncResponse(ctx context.Context, header *gossipm
 	if err != nil {
 		return
 	}
+	if !s.isFromAuthenticatedSender(ctx, message.Sender) {
+		return
+	}
 
 	s.handlers.RLock()
 	defer s.handlers.RUnlock()
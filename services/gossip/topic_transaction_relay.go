@@ -62,6 +62,9 @@ func (s *service) receivedForwardedTransactions(ctx context.Context, header *gos
 	if err != nil {
 		return
 	}
+	if !s.isFromAuthenticatedSender(ctx, message.Sender) {
+		return
+	}
 
 	logger.Info("received forwarded transactions",
 		log.Stringable("sender", message.Sender),
@@ -0,0 +1,88 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package publicapi
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/crypto/bloom"
+	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/orbs-spec/types/go/services"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+)
+
+// a single request searches at most this many blocks, longer ranges are searched page by page
+const findEventsMaxBlockRange = 10000
+
+// ToBlockHeight is optional and defaults to the last committed block
+type FindEventsInput struct {
+	FromBlockHeight primitives.BlockHeight
+	ToBlockHeight   primitives.BlockHeight
+	Query           *bloom.EventsQuery
+}
+
+// ToBlockHeight is the last block searched, which is below the requested one when the range was too long
+type FindEventsOutput struct {
+	RequestStatus   protocol.RequestStatus
+	FromBlockHeight primitives.BlockHeight
+	ToBlockHeight   primitives.BlockHeight
+	Events          []*bloom.FoundEvent
+}
+
+func (s *service) FindEvents(parentCtx context.Context, input *FindEventsInput) (*FindEventsOutput, error) {
+	ctx := trace.NewContext(parentCtx, "PublicApi.FindEvents")
+	logger := s.logger.WithTags(trace.LogFieldFrom(ctx), log.String("flow", "checkpoint"))
+
+	finder, ok := s.blockStorage.(blockstorage.BlockQueries)
+	if !ok {
+		err := errors.New("block storage does not find events")
+		logger.Info("find events is not supported", log.Error(err))
+		return &FindEventsOutput{RequestStatus: protocol.REQUEST_STATUS_SYSTEM_ERROR}, err
+	}
+
+	if input.Query == nil || input.Query.EventName == "" || input.FromBlockHeight == 0 {
+		err := errors.New("missing event name or first block height")
+		logger.Info("find events received input failed", log.Error(err))
+		return &FindEventsOutput{RequestStatus: protocol.REQUEST_STATUS_BAD_REQUEST}, err
+	}
+
+	out, err := s.blockStorage.GetLastCommittedBlockHeight(ctx, &services.GetLastCommittedBlockHeightInput{})
+	if err != nil {
+		logger.Info("block storage failed while getting last block", log.Error(err))
+		return &FindEventsOutput{RequestStatus: protocol.REQUEST_STATUS_SYSTEM_ERROR}, err
+	}
+	toHeight := input.ToBlockHeight
+	if toHeight == 0 || toHeight > out.LastCommittedBlockHeight {
+		toHeight = out.LastCommittedBlockHeight
+	}
+	if input.FromBlockHeight > toHeight {
+		err := errors.Errorf("block height %d is not committed", input.FromBlockHeight)
+		logger.Info("find events failed to get requested block height", log.Error(err))
+		return &FindEventsOutput{RequestStatus: protocol.REQUEST_STATUS_NOT_FOUND}, err
+	}
+	if toHeight-input.FromBlockHeight >= findEventsMaxBlockRange {
+		toHeight = input.FromBlockHeight + findEventsMaxBlockRange - 1
+	}
+
+	logger.Info("find events request received", log.String("event-name", string(input.Query.EventName)), log.Uint64("from-block-height", uint64(input.FromBlockHeight)), log.Uint64("to-block-height", uint64(toHeight)))
+
+	events, err := finder.FindEvents(ctx, input.FromBlockHeight, toHeight, input.Query)
+	if err != nil {
+		logger.Info("block storage failed to find events", log.Error(err))
+		return &FindEventsOutput{RequestStatus: protocol.REQUEST_STATUS_BAD_REQUEST}, err
+	}
+
+	return &FindEventsOutput{
+		RequestStatus:   protocol.REQUEST_STATUS_COMPLETED,
+		FromBlockHeight: input.FromBlockHeight,
+		ToBlockHeight:   toHeight,
+		Events:          events,
+	}, nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package publicapi
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/crypto/merkle"
+	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
+	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
+	"github.com/orbs-network/orbs-network-go/services/statestorage"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/orbs-spec/types/go/services"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+)
+
+type GetStateProofInput struct {
+	BlockHeight  primitives.BlockHeight // zero for the last committed block
+	ContractName primitives.ContractName
+	Key          []byte
+}
+
+// The value is the one the block was executed against, proven by the trie proof against the block's pre-execution state merkle root.
+// The results block header holds that root and is signed by the results block proof.
+type GetStateProofOutput struct {
+	RequestStatus      protocol.RequestStatus
+	BlockHeight        primitives.BlockHeight
+	BlockTimestamp     primitives.TimestampNano
+	Value              []byte
+	StateMerkleRoot    primitives.Sha256
+	Proof              *merkle.TrieProof
+	ResultsBlockHeader *protocol.ResultsBlockHeader
+	ResultsBlockProof  *protocol.ResultsBlockProof
+}
+
+func (s *service) GetStateProof(parentCtx context.Context, input *GetStateProofInput) (*GetStateProofOutput, error) {
+	ctx := trace.NewContext(parentCtx, "PublicApi.GetStateProof")
+	logger := s.logger.WithTags(trace.LogFieldFrom(ctx), logfields.BlockHeight(input.BlockHeight), log.String("flow", "checkpoint"))
+
+	reader, ok := s.stateStorage.(statestorage.HistoricalState)
+	if !ok {
+		err := errors.New("state storage does not generate state proofs")
+		logger.Info("get state proof is not supported", log.Error(err))
+		return &GetStateProofOutput{RequestStatus: protocol.REQUEST_STATUS_SYSTEM_ERROR}, err
+	}
+
+	if input.ContractName == "" {
+		err := errors.New("missing contract name")
+		logger.Info("get state proof received input failed", log.Error(err))
+		return &GetStateProofOutput{RequestStatus: protocol.REQUEST_STATUS_BAD_REQUEST}, err
+	}
+
+	logger.Info("get state proof request received", log.String("contract", string(input.ContractName)))
+
+	blockHeight := input.BlockHeight
+	if blockHeight == 0 {
+		out, err := s.blockStorage.GetLastCommittedBlockHeight(ctx, &services.GetLastCommittedBlockHeightInput{})
+		if err != nil {
+			logger.Info("block storage failed while getting last block", log.Error(err))
+			return &GetStateProofOutput{RequestStatus: protocol.REQUEST_STATUS_SYSTEM_ERROR}, err
+		}
+		if out.LastCommittedBlockHeight == 0 {
+			err := errors.New("no block was committed yet")
+			logger.Info("get state proof failed", log.Error(err))
+			return &GetStateProofOutput{RequestStatus: protocol.REQUEST_STATUS_NOT_FOUND}, err
+		}
+		blockHeight = out.LastCommittedBlockHeight
+	}
+
+	bpc, err := s.blockStorage.GetBlockPair(ctx, &services.GetBlockPairInput{BlockHeight: blockHeight})
+	if err != nil {
+		logger.Info("block storage failed", log.Error(err))
+		return &GetStateProofOutput{RequestStatus: protocol.REQUEST_STATUS_SYSTEM_ERROR}, err
+	}
+	if bpc.BlockPair == nil {
+		err := errors.Errorf("block height %d is not committed", blockHeight)
+		logger.Info("get state proof failed to get requested block height", log.Error(err))
+		return &GetStateProofOutput{RequestStatus: protocol.REQUEST_STATUS_NOT_FOUND}, err
+	}
+	header := bpc.BlockPair.ResultsBlock.Header
+
+	// the block was executed against the state committed by its previous block
+	root, value, proof, err := reader.GetStateProof(ctx, blockHeight-1, input.ContractName, input.Key)
+	if err != nil {
+		logger.Info("state storage failed to generate state proof", log.Error(err))
+		return &GetStateProofOutput{RequestStatus: protocol.REQUEST_STATUS_BAD_REQUEST, BlockHeight: blockHeight, BlockTimestamp: header.Timestamp()}, err
+	}
+	if !root.Equal(header.PreExecutionStateMerkleRootHash()) {
+		err := errors.Errorf("state merkle root %s does not match pre-execution state merkle root %s of block %d", root, header.PreExecutionStateMerkleRootHash(), blockHeight)
+		logger.Error("get state proof failed", log.Error(err))
+		return &GetStateProofOutput{RequestStatus: protocol.REQUEST_STATUS_SYSTEM_ERROR, BlockHeight: blockHeight, BlockTimestamp: header.Timestamp()}, err
+	}
+
+	return &GetStateProofOutput{
+		RequestStatus:      protocol.REQUEST_STATUS_COMPLETED,
+		BlockHeight:        blockHeight,
+		BlockTimestamp:     header.Timestamp(),
+		Value:              value,
+		StateMerkleRoot:    root,
+		Proof:              proof,
+		ResultsBlockHeader: header,
+		ResultsBlockProof:  bpc.BlockPair.ResultsBlock.BlockProof,
+	}, nil
+}
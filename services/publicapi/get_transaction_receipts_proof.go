@@ -0,0 +1,89 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package publicapi
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/crypto/merkle"
+	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
+	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/orbs-spec/types/go/services"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+)
+
+type GetTransactionReceiptsProofInput struct {
+	BlockHeight primitives.BlockHeight
+	Txhashes    []primitives.Sha256
+}
+
+// The receipts of the requested transactions, in the order of the proof's indices, are proven together by the multi proof
+// against the receipts merkle root of the results block header, which is signed by the results block proof.
+type GetTransactionReceiptsProofOutput struct {
+	RequestStatus       protocol.RequestStatus
+	BlockHeight         primitives.BlockHeight
+	BlockTimestamp      primitives.TimestampNano
+	TransactionReceipts []*protocol.TransactionReceipt
+	Proof               *merkle.OrderedTreeMultiProof
+	ResultsBlockHeader  *protocol.ResultsBlockHeader
+	ResultsBlockProof   *protocol.ResultsBlockProof
+}
+
+func (s *service) GetTransactionReceiptsProof(parentCtx context.Context, input *GetTransactionReceiptsProofInput) (*GetTransactionReceiptsProofOutput, error) {
+	ctx := trace.NewContext(parentCtx, "PublicApi.GetTransactionReceiptsProof")
+	logger := s.logger.WithTags(trace.LogFieldFrom(ctx), logfields.BlockHeight(input.BlockHeight), log.String("flow", "checkpoint"))
+
+	generator, ok := s.blockStorage.(blockstorage.BlockQueries)
+	if !ok {
+		err := errors.New("block storage does not generate receipts proofs")
+		logger.Info("get transaction receipts proof is not supported", log.Error(err))
+		return &GetTransactionReceiptsProofOutput{RequestStatus: protocol.REQUEST_STATUS_SYSTEM_ERROR}, err
+	}
+
+	if input.BlockHeight == 0 || len(input.Txhashes) == 0 {
+		err := errors.New("missing block height or transaction hashes")
+		logger.Info("get transaction receipts proof received input failed", log.Error(err))
+		return &GetTransactionReceiptsProofOutput{RequestStatus: protocol.REQUEST_STATUS_BAD_REQUEST}, err
+	}
+
+	logger.Info("get transaction receipts proof request received", log.Int("num-transactions", len(input.Txhashes)))
+
+	out, err := s.blockStorage.GetLastCommittedBlockHeight(ctx, &services.GetLastCommittedBlockHeightInput{})
+	if err != nil {
+		logger.Info("block storage failed while getting last block", log.Error(err))
+		return &GetTransactionReceiptsProofOutput{RequestStatus: protocol.REQUEST_STATUS_SYSTEM_ERROR}, err
+	}
+	if input.BlockHeight > out.LastCommittedBlockHeight {
+		err := errors.Errorf("block height %d is not committed", input.BlockHeight)
+		logger.Info("get transaction receipts proof failed to get requested block height", log.Error(err))
+		return &GetTransactionReceiptsProofOutput{RequestStatus: protocol.REQUEST_STATUS_NOT_FOUND}, err
+	}
+
+	block, proof, err := generator.GenerateReceiptsProof(ctx, input.BlockHeight, input.Txhashes)
+	if err != nil {
+		logger.Info("block storage failed to generate receipts proof", log.Error(err))
+		return &GetTransactionReceiptsProofOutput{RequestStatus: protocol.REQUEST_STATUS_BAD_REQUEST, BlockHeight: input.BlockHeight}, err
+	}
+
+	receipts := make([]*protocol.TransactionReceipt, 0, len(proof.Indices))
+	for _, index := range proof.Indices {
+		receipts = append(receipts, block.TransactionReceipts[index])
+	}
+
+	return &GetTransactionReceiptsProofOutput{
+		RequestStatus:       protocol.REQUEST_STATUS_COMPLETED,
+		BlockHeight:         input.BlockHeight,
+		BlockTimestamp:      block.Header.Timestamp(),
+		TransactionReceipts: receipts,
+		Proof:               proof,
+		ResultsBlockHeader:  block.Header,
+		ResultsBlockProof:   block.BlockProof,
+	}, nil
+}
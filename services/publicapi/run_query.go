@@ -11,6 +11,7 @@ import (
 	"github.com/orbs-network/orbs-network-go/crypto/digest"
 	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
 	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/protocol"
 	"github.com/orbs-network/orbs-spec/types/go/protocol/client"
 	"github.com/orbs-network/orbs-spec/types/go/services"
@@ -20,6 +21,15 @@ import (
 )
 
 func (s *service) RunQuery(parentCtx context.Context, input *services.RunQueryInput) (*services.RunQueryOutput, error) {
+	return s.runQuery(parentCtx, input, 0) // recent block height
+}
+
+// Queries at past block heights are served only as far back as state storage keeps revisions, all of them in archival mode.
+func (s *service) RunQueryAtBlockHeight(parentCtx context.Context, input *services.RunQueryInput, blockHeight primitives.BlockHeight) (*services.RunQueryOutput, error) {
+	return s.runQuery(parentCtx, input, blockHeight)
+}
+
+func (s *service) runQuery(parentCtx context.Context, input *services.RunQueryInput, blockHeight primitives.BlockHeight) (*services.RunQueryOutput, error) {
 	ctx := trace.NewContext(parentCtx, "PublicApi.RunQuery")
 
 	if input.ClientRequest == nil {
@@ -37,13 +47,13 @@ func (s *service) RunQuery(parentCtx context.Context, input *services.RunQueryIn
 		return toRunQueryOutput(&queryOutput{requestStatus: protocol.REQUEST_STATUS_BAD_REQUEST}), err
 	}
 
-	logger.Info("run query request received")
+	logger.Info("run query request received", logfields.BlockHeight(blockHeight))
 
 	start := time.Now()
 	defer s.metrics.runQueryTime.RecordSince(start)
 
 	callOutput, err := s.virtualMachine.ProcessQuery(ctx, &services.ProcessQueryInput{
-		BlockHeight: 0, // recent block height
+		BlockHeight: blockHeight,
 		SignedQuery: input.ClientRequest.SignedQuery(),
 	})
 	if err != nil {
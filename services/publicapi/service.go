@@ -12,6 +12,7 @@ import (
 	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
 	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/protocol"
 	"github.com/orbs-network/orbs-spec/types/go/services"
 	"github.com/orbs-network/orbs-spec/types/go/services/handlers"
@@ -21,11 +22,21 @@ import (
 
 var LogTag = log.Service("public-api")
 
+// Requests served besides services.PublicApi, which only serves the recent state and proves a single receipt per request.
+// The http server exposes them
+type HistoricalQueries interface {
+	RunQueryAtBlockHeight(ctx context.Context, input *services.RunQueryInput, blockHeight primitives.BlockHeight) (*services.RunQueryOutput, error)
+	GetStateProof(ctx context.Context, input *GetStateProofInput) (*GetStateProofOutput, error)
+	GetTransactionReceiptsProof(ctx context.Context, input *GetTransactionReceiptsProofInput) (*GetTransactionReceiptsProofOutput, error)
+	FindEvents(ctx context.Context, input *FindEventsInput) (*FindEventsOutput, error)
+}
+
 type service struct {
 	config          config.PublicApiConfig
 	transactionPool services.TransactionPool
 	virtualMachine  services.VirtualMachine
 	blockStorage    services.BlockStorage
+	stateStorage    services.StateStorage
 	logger          log.Logger
 
 	waiter *waiter
@@ -62,6 +73,7 @@ func NewPublicApi(
 	transactionPool services.TransactionPool,
 	virtualMachine services.VirtualMachine,
 	blockStorage services.BlockStorage,
+	stateStorage services.StateStorage,
 	logger log.Logger,
 	metricFactory metric.Factory,
 ) services.PublicApi {
@@ -70,6 +82,7 @@ func NewPublicApi(
 		transactionPool: transactionPool,
 		virtualMachine:  virtualMachine,
 		blockStorage:    blockStorage,
+		stateStorage:    stateStorage,
 		logger:          logger.WithTags(LogTag),
 
 		waiter:  newWaiter(),
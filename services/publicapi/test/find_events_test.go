@@ -0,0 +1,98 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"context"
+	"github.com/orbs-network/go-mock"
+	"github.com/orbs-network/orbs-network-go/crypto/bloom"
+	"github.com/orbs-network/orbs-network-go/services/publicapi"
+	"github.com/orbs-network/orbs-network-go/test"
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestFindEvents_SearchesUpToLastCommittedBlock(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		harness := newPublicApiHarness(ctx, t, 1*time.Second, 1*time.Minute)
+
+		events := []*bloom.FoundEvent{{BlockHeight: 3, Txhash: []byte("some transaction")}}
+		harness.prepareGetLastBlock(builders.BlockPair().WithHeight(8).Build())
+		harness.prepareFindEvents(2, 8, events)
+
+		result, err := harness.papi.(publicapi.HistoricalQueries).FindEvents(ctx, &publicapi.FindEventsInput{
+			FromBlockHeight: 2,
+			Query:           &bloom.EventsQuery{EventName: "Transfer"},
+		})
+
+		harness.verifyMocks(t) // contract test
+
+		// value test
+		require.NoError(t, err, "error happened when it should not")
+		require.Equal(t, protocol.REQUEST_STATUS_COMPLETED, result.RequestStatus, "got wrong status")
+		require.EqualValues(t, 8, result.ToBlockHeight, "got wrong last block searched")
+		require.Equal(t, events, result.Events, "got wrong events")
+	})
+}
+
+func TestFindEvents_LimitsBlockRange(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		harness := newPublicApiHarness(ctx, t, 1*time.Second, 1*time.Minute)
+
+		harness.prepareGetLastBlock(builders.BlockPair().WithHeight(50000).Build())
+		harness.prepareFindEvents(1, 10000, nil)
+
+		result, err := harness.papi.(publicapi.HistoricalQueries).FindEvents(ctx, &publicapi.FindEventsInput{
+			FromBlockHeight: 1,
+			ToBlockHeight:   40000,
+			Query:           &bloom.EventsQuery{EventName: "Transfer"},
+		})
+
+		harness.verifyMocks(t) // contract test
+
+		// value test
+		require.NoError(t, err, "error happened when it should not")
+		require.EqualValues(t, 10000, result.ToBlockHeight, "expected the range to be cut")
+	})
+}
+
+func TestFindEvents_BlockNotCommitted(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		harness := newPublicApiHarness(ctx, t, 1*time.Second, 1*time.Minute)
+
+		harness.prepareGetLastBlock(builders.BlockPair().WithHeight(8).Build())
+		harness.bksMock.Never("FindEvents", mock.Any, mock.Any, mock.Any, mock.Any)
+
+		result, err := harness.papi.(publicapi.HistoricalQueries).FindEvents(ctx, &publicapi.FindEventsInput{
+			FromBlockHeight: 9,
+			Query:           &bloom.EventsQuery{EventName: "Transfer"},
+		})
+
+		harness.verifyMocks(t) // contract test
+
+		// value test
+		require.Error(t, err, "error did not happen when it should")
+		require.Equal(t, protocol.REQUEST_STATUS_NOT_FOUND, result.RequestStatus, "got wrong status")
+	})
+}
+
+func TestFindEvents_MissingEventName(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		harness := newPublicApiHarness(ctx, t, 1*time.Second, 1*time.Minute)
+
+		result, err := harness.papi.(publicapi.HistoricalQueries).FindEvents(ctx, &publicapi.FindEventsInput{FromBlockHeight: 1, Query: &bloom.EventsQuery{}})
+
+		harness.verifyMocks(t) // contract test
+
+		// value test
+		require.Error(t, err, "error did not happen when it should")
+		require.Equal(t, protocol.REQUEST_STATUS_BAD_REQUEST, result.RequestStatus, "got wrong status")
+	})
+}
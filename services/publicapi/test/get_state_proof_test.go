@@ -0,0 +1,86 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/crypto/hash"
+	"github.com/orbs-network/orbs-network-go/crypto/merkle"
+	"github.com/orbs-network/orbs-network-go/services/publicapi"
+	"github.com/orbs-network/orbs-network-go/test"
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestGetStateProof_ProvesValueAgainstPreExecutionRootOfBlock(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		harness := newPublicApiHarness(ctx, t, 1*time.Second, 1*time.Minute)
+
+		root := hash.CalcSha256([]byte("root"))
+		proof := &merkle.TrieProof{}
+		blockPair := builders.BlockPair().WithHeight(8).Build()
+		require.NoError(t, blockPair.ResultsBlock.Header.MutatePreExecutionStateMerkleRootHash(root))
+		harness.prepareGetBlock(blockPair, nil)
+		harness.prepareGetStateProof(7, root, []byte("value"), proof)
+
+		result, err := harness.papi.(publicapi.HistoricalQueries).GetStateProof(ctx, &publicapi.GetStateProofInput{BlockHeight: 8, ContractName: "foo", Key: []byte("bar")})
+
+		harness.verifyMocks(t) // contract test
+
+		// value test
+		require.NoError(t, err, "error happened when it should not")
+		require.Equal(t, protocol.REQUEST_STATUS_COMPLETED, result.RequestStatus, "got wrong status")
+		require.EqualValues(t, 8, result.BlockHeight, "got wrong block height")
+		require.Equal(t, []byte("value"), result.Value, "got wrong value")
+		require.Equal(t, root, result.StateMerkleRoot, "got wrong state merkle root")
+		require.Equal(t, proof, result.Proof, "got wrong proof")
+		require.Equal(t, blockPair.ResultsBlock.Header, result.ResultsBlockHeader, "got wrong results block header")
+		require.Equal(t, blockPair.ResultsBlock.BlockProof, result.ResultsBlockProof, "got wrong results block proof")
+	})
+}
+
+func TestGetStateProof_DefaultsToLastCommittedBlock(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		harness := newPublicApiHarness(ctx, t, 1*time.Second, 1*time.Minute)
+
+		blockPair := builders.BlockPair().WithHeight(8).Build()
+		harness.prepareGetLastBlock(blockPair)
+		harness.prepareGetBlock(blockPair, nil)
+		harness.prepareGetStateProof(7, blockPair.ResultsBlock.Header.PreExecutionStateMerkleRootHash(), []byte("value"), &merkle.TrieProof{})
+
+		result, err := harness.papi.(publicapi.HistoricalQueries).GetStateProof(ctx, &publicapi.GetStateProofInput{ContractName: "foo", Key: []byte("bar")})
+
+		harness.verifyMocks(t) // contract test
+
+		// value test
+		require.NoError(t, err, "error happened when it should not")
+		require.Equal(t, protocol.REQUEST_STATUS_COMPLETED, result.RequestStatus, "got wrong status")
+		require.EqualValues(t, 8, result.BlockHeight, "got wrong block height")
+	})
+}
+
+func TestGetStateProof_StateStorageFails(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		harness := newPublicApiHarness(ctx, t, 1*time.Second, 1*time.Minute)
+
+		blockPair := builders.BlockPair().WithHeight(8).Build()
+		harness.prepareGetBlock(blockPair, nil)
+		harness.getStateProofFails()
+
+		result, err := harness.papi.(publicapi.HistoricalQueries).GetStateProof(ctx, &publicapi.GetStateProofInput{BlockHeight: 8, ContractName: "foo", Key: []byte("bar")})
+
+		harness.verifyMocks(t) // contract test
+
+		// value test
+		require.Error(t, err, "error did not happen when it should")
+		require.Equal(t, protocol.REQUEST_STATUS_BAD_REQUEST, result.RequestStatus, "got wrong status")
+		require.Nil(t, result.Proof, "expected no proof")
+	})
+}
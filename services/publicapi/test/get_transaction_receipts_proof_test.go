@@ -0,0 +1,104 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"context"
+	"github.com/orbs-network/go-mock"
+	"github.com/orbs-network/orbs-network-go/crypto/merkle"
+	"github.com/orbs-network/orbs-network-go/services/publicapi"
+	"github.com/orbs-network/orbs-network-go/test"
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestGetTransactionReceiptsProof_ReturnsReceiptsInProofOrder(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		harness := newPublicApiHarness(ctx, t, 1*time.Second, 1*time.Minute)
+
+		blockPair := builders.BlockPair().WithHeight(8).WithTransactions(4).WithReceiptsForTransactions().Build()
+		receipts := blockPair.ResultsBlock.TransactionReceipts
+		proof := &merkle.OrderedTreeMultiProof{NumValues: 4, Indices: []int{1, 3}}
+		harness.prepareGetLastBlock(blockPair)
+		harness.prepareGenerateReceiptsProof(8, blockPair.ResultsBlock, proof)
+
+		result, err := harness.papi.(publicapi.HistoricalQueries).GetTransactionReceiptsProof(ctx, &publicapi.GetTransactionReceiptsProofInput{
+			BlockHeight: 8,
+			Txhashes:    []primitives.Sha256{receipts[3].Txhash(), receipts[1].Txhash()},
+		})
+
+		harness.verifyMocks(t) // contract test
+
+		// value test
+		require.NoError(t, err, "error happened when it should not")
+		require.Equal(t, protocol.REQUEST_STATUS_COMPLETED, result.RequestStatus, "got wrong status")
+		require.EqualValues(t, 8, result.BlockHeight, "got wrong block height")
+		require.Equal(t, []*protocol.TransactionReceipt{receipts[1], receipts[3]}, result.TransactionReceipts, "got wrong receipts")
+		require.Equal(t, proof, result.Proof, "got wrong proof")
+		require.Equal(t, blockPair.ResultsBlock.Header, result.ResultsBlockHeader, "got wrong results block header")
+		require.Equal(t, blockPair.ResultsBlock.BlockProof, result.ResultsBlockProof, "got wrong results block proof")
+	})
+}
+
+func TestGetTransactionReceiptsProof_BlockNotCommitted(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		harness := newPublicApiHarness(ctx, t, 1*time.Second, 1*time.Minute)
+
+		harness.prepareGetLastBlock(builders.BlockPair().WithHeight(8).Build())
+		harness.bksMock.Never("GenerateReceiptsProof", mock.Any, mock.Any, mock.Any)
+
+		result, err := harness.papi.(publicapi.HistoricalQueries).GetTransactionReceiptsProof(ctx, &publicapi.GetTransactionReceiptsProofInput{
+			BlockHeight: 9,
+			Txhashes:    []primitives.Sha256{primitives.Sha256("some transaction")},
+		})
+
+		harness.verifyMocks(t) // contract test
+
+		// value test
+		require.Error(t, err, "error did not happen when it should")
+		require.Equal(t, protocol.REQUEST_STATUS_NOT_FOUND, result.RequestStatus, "got wrong status")
+	})
+}
+
+func TestGetTransactionReceiptsProof_BlockStorageFails(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		harness := newPublicApiHarness(ctx, t, 1*time.Second, 1*time.Minute)
+
+		harness.prepareGetLastBlock(builders.BlockPair().WithHeight(8).Build())
+		harness.generateReceiptsProofFails()
+
+		result, err := harness.papi.(publicapi.HistoricalQueries).GetTransactionReceiptsProof(ctx, &publicapi.GetTransactionReceiptsProofInput{
+			BlockHeight: 8,
+			Txhashes:    []primitives.Sha256{primitives.Sha256("not a transaction in the block")},
+		})
+
+		harness.verifyMocks(t) // contract test
+
+		// value test
+		require.Error(t, err, "error did not happen when it should")
+		require.Equal(t, protocol.REQUEST_STATUS_BAD_REQUEST, result.RequestStatus, "got wrong status")
+		require.Nil(t, result.Proof, "expected no proof")
+	})
+}
+
+func TestGetTransactionReceiptsProof_MissingTransactions(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		harness := newPublicApiHarness(ctx, t, 1*time.Second, 1*time.Minute)
+
+		result, err := harness.papi.(publicapi.HistoricalQueries).GetTransactionReceiptsProof(ctx, &publicapi.GetTransactionReceiptsProofInput{BlockHeight: 8})
+
+		harness.verifyMocks(t) // contract test
+
+		// value test
+		require.Error(t, err, "error did not happen when it should")
+		require.Equal(t, protocol.REQUEST_STATUS_BAD_REQUEST, result.RequestStatus, "got wrong status")
+	})
+}
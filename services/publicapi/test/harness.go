@@ -10,9 +10,12 @@ import (
 	"context"
 	"github.com/orbs-network/go-mock"
 	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/crypto/bloom"
+	"github.com/orbs-network/orbs-network-go/crypto/merkle"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
 	"github.com/orbs-network/orbs-network-go/services/publicapi"
 	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/protocol"
 	"github.com/orbs-network/orbs-spec/types/go/services"
 	"github.com/orbs-network/scribe/log"
@@ -23,10 +26,50 @@ import (
 )
 
 type harness struct {
-	papi    services.PublicApi
-	txpMock *services.MockTransactionPool
-	bksMock *services.MockBlockStorage
-	vmMock  *services.MockVirtualMachine
+	papi      services.PublicApi
+	txpMock   *services.MockTransactionPool
+	bksMock   *blockStorageMock
+	vmMock    *services.MockVirtualMachine
+	stateMock *stateStorageMock
+}
+
+// implements blockstorage.BlockQueries besides the service interface
+type blockStorageMock struct {
+	services.MockBlockStorage
+}
+
+func (s *blockStorageMock) GenerateReceiptsProof(ctx context.Context, blockHeight primitives.BlockHeight, txHashes []primitives.Sha256) (*protocol.ResultsBlockContainer, *merkle.OrderedTreeMultiProof, error) {
+	ret := s.Called(ctx, blockHeight, txHashes)
+	if proof := ret.Get(1); proof != nil {
+		return ret.Get(0).(*protocol.ResultsBlockContainer), proof.(*merkle.OrderedTreeMultiProof), ret.Error(2)
+	}
+	return nil, nil, ret.Error(2)
+}
+
+func (s *blockStorageMock) FindEvents(ctx context.Context, fromHeight primitives.BlockHeight, toHeight primitives.BlockHeight, query *bloom.EventsQuery) ([]*bloom.FoundEvent, error) {
+	ret := s.Called(ctx, fromHeight, toHeight, query)
+	if events := ret.Get(0); events != nil {
+		return events.([]*bloom.FoundEvent), ret.Error(1)
+	}
+	return nil, ret.Error(1)
+}
+
+// implements statestorage.HistoricalState besides the service interface
+type stateStorageMock struct {
+	services.MockStateStorage
+}
+
+func (s *stateStorageMock) GetStateStorageBlockTimestamp(ctx context.Context, blockHeight primitives.BlockHeight) (primitives.TimestampNano, error) {
+	ret := s.Called(ctx, blockHeight)
+	return ret.Get(0).(primitives.TimestampNano), ret.Error(1)
+}
+
+func (s *stateStorageMock) GetStateProof(ctx context.Context, blockHeight primitives.BlockHeight, contract primitives.ContractName, key []byte) (primitives.Sha256, []byte, *merkle.TrieProof, error) {
+	ret := s.Called(ctx, blockHeight, contract, key)
+	if proof := ret.Get(2); proof != nil {
+		return ret.Get(0).(primitives.Sha256), ret.Get(1).([]byte), proof.(*merkle.TrieProof), ret.Error(3)
+	}
+	return nil, nil, nil, ret.Error(3)
 }
 
 func newPublicApiHarness(ctx context.Context, tb testing.TB, txTimeout time.Duration, outOfSyncWarningTime time.Duration) *harness {
@@ -34,13 +77,15 @@ func newPublicApiHarness(ctx context.Context, tb testing.TB, txTimeout time.Dura
 	cfg := config.ForPublicApiTests(uint32(builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID), txTimeout, outOfSyncWarningTime)
 	txpMock := makeTxMock()
 	vmMock := &services.MockVirtualMachine{}
-	bksMock := &services.MockBlockStorage{}
-	papi := publicapi.NewPublicApi(cfg, txpMock, vmMock, bksMock, logger, metric.NewRegistry())
+	bksMock := &blockStorageMock{}
+	stateMock := &stateStorageMock{}
+	papi := publicapi.NewPublicApi(cfg, txpMock, vmMock, bksMock, stateMock, logger, metric.NewRegistry())
 	return &harness{
-		papi:    papi,
-		txpMock: txpMock,
-		bksMock: bksMock,
-		vmMock:  vmMock,
+		papi:      papi,
+		txpMock:   txpMock,
+		bksMock:   bksMock,
+		vmMock:    vmMock,
+		stateMock: stateMock,
 	}
 }
 
@@ -155,6 +200,26 @@ func (h *harness) getBlockFails() {
 	h.bksMock.When("GetBlockPair", mock.Any, mock.Any).Return(nil, errors.Errorf("someErr")).Times(1)
 }
 
+func (h *harness) prepareGetStateProof(blockHeight primitives.BlockHeight, root primitives.Sha256, value []byte, proof *merkle.TrieProof) {
+	h.stateMock.When("GetStateProof", mock.Any, blockHeight, mock.Any, mock.Any).Return(root, value, proof, nil).Times(1)
+}
+
+func (h *harness) getStateProofFails() {
+	h.stateMock.When("GetStateProof", mock.Any, mock.Any, mock.Any, mock.Any).Return(nil, nil, nil, errors.Errorf("someErr")).Times(1)
+}
+
+func (h *harness) prepareGenerateReceiptsProof(blockHeight primitives.BlockHeight, block *protocol.ResultsBlockContainer, proof *merkle.OrderedTreeMultiProof) {
+	h.bksMock.When("GenerateReceiptsProof", mock.Any, blockHeight, mock.Any).Return(block, proof, nil).Times(1)
+}
+
+func (h *harness) generateReceiptsProofFails() {
+	h.bksMock.When("GenerateReceiptsProof", mock.Any, mock.Any, mock.Any).Return(nil, nil, errors.Errorf("someErr")).Times(1)
+}
+
+func (h *harness) prepareFindEvents(fromHeight primitives.BlockHeight, toHeight primitives.BlockHeight, events []*bloom.FoundEvent) {
+	h.bksMock.When("FindEvents", mock.Any, fromHeight, toHeight, mock.Any).Return(events, nil).Times(1)
+}
+
 func (h *harness) verifyMocks(t *testing.T) {
 	// contract test
 	ok, errCalled := h.txpMock.Verify()
@@ -166,4 +231,7 @@ func (h *harness) verifyMocks(t *testing.T) {
 	ok, errCalled = h.vmMock.Verify()
 	require.True(t, ok, "virtual machine mock called incorrectly")
 	require.NoError(t, errCalled, "error happened when it should not")
+	ok, errCalled = h.stateMock.Verify()
+	require.True(t, ok, "state storage mock called incorrectly")
+	require.NoError(t, errCalled, "error happened when it should not")
 }
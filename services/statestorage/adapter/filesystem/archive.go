@@ -0,0 +1,524 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package filesystem
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
+	"github.com/orbs-network/orbs-network-go/services/statestorage/adapter"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const archiveFilename = "archive"
+const archiveTimestampsFilename = "archive.timestamps"
+const archiveHeadsFilename = "archive.heads"
+const compactedArchiveHeadsFilename = "archive.heads.compacting"
+
+const archiveFormatMagic = uint32(0x56435241)      // "ARCV"
+const archiveHeadsFormatMagic = uint32(0x44414548) // "HEAD"
+const versionsEntryMagic = uint32(0x53524556)      // "VERS"
+const headsEntryMagic = uint32(0x53444548)         // "HEDS"
+
+// the latest versions are checkpointed whenever the archive grows this much, bounding the part of it scanned on startup
+const headsCheckpointInterval = 64 * 1024 * 1024
+
+const timestampSize = 8
+
+type versionHeader struct {
+	Height       uint64
+	Previous     int64 // offset of the previous version of the same key, -1 for the first one
+	ContractSize uint32
+	RecordSize   uint32
+}
+
+var versionHeaderSize = binary.Size(versionHeader{})
+
+type archiveKey struct {
+	contract primitives.ContractName
+	key      string
+}
+
+// Every version of every key is appended to the archive file and points at the previous version of the same key, so a read
+// as of a past height walks back from the latest version. Only the offset of the latest version of every key is kept in memory,
+// it is checkpointed to the heads file so a restart only scans the versions appended since. The timestamp of every height
+// is kept at a fixed position of the timestamps file.
+type stateArchive struct {
+	config config.FilesystemStatePersistenceConfig
+	logger log.Logger
+
+	mutex            sync.RWMutex
+	file             *os.File
+	fileSize         int64
+	checkpointedSize int64
+	timestamps       *os.File
+	since            primitives.BlockHeight
+	height           primitives.BlockHeight
+	heads            map[archiveKey]int64
+}
+
+func newArchiveFileHeader(networkId, vchainId uint32) *stateFileHeader {
+	header := newStateFileHeader(networkId, vchainId)
+	header.Magic = archiveFormatMagic
+	return header
+}
+
+func newArchiveHeadsFileHeader(networkId, vchainId uint32) *stateFileHeader {
+	header := newStateFileHeader(networkId, vchainId)
+	header.Magic = archiveHeadsFormatMagic
+	return header
+}
+
+// versions archived above the persisted state height were never applied to the state, they are truncated
+func openStateArchive(conf config.FilesystemStatePersistenceConfig, logger log.Logger, stateHeight primitives.BlockHeight) (*stateArchive, error) {
+	file, err := openStateFile(archiveFileName(conf), newArchiveFileHeader, conf, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamps, err := os.OpenFile(archiveTimestampsFileName(conf), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		closeSilently(file, logger)
+		return nil, errors.Wrapf(err, "failed to open archive timestamps file %s", archiveTimestampsFileName(conf))
+	}
+
+	a := &stateArchive{
+		config:     conf,
+		logger:     logger,
+		file:       file,
+		timestamps: timestamps,
+		heads:      make(map[archiveKey]int64),
+	}
+
+	err = a.restore(stateHeight)
+	if err != nil {
+		a.close()
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func (a *stateArchive) restore(stateHeight primitives.BlockHeight) error {
+	info, err := a.file.Stat()
+	if err != nil {
+		return err
+	}
+	offset, err := a.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return errors.Wrap(err, "error reading archive file")
+	}
+
+	checkpointed, err := a.readHeadsCheckpoint()
+	switch {
+	case os.IsNotExist(errors.Cause(err)):
+	case err != nil:
+		a.logger.Error("ignoring invalid archive heads file, scanning the whole archive", log.Error(err))
+	case checkpointed.height > stateHeight || checkpointed.offset > info.Size():
+		a.logger.Info("ignoring archive heads file ahead of the persisted state, scanning the whole archive", logfields.BlockHeight(checkpointed.height))
+	default:
+		a.heads, a.since, a.height = checkpointed.heads, checkpointed.since, checkpointed.height
+		offset = checkpointed.offset
+		a.checkpointedSize = offset
+	}
+
+	_, err = a.file.Seek(offset, io.SeekStart)
+	if err != nil {
+		return errors.Wrapf(err, "failed to seek to archive file offset %d", offset)
+	}
+
+	r := bufio.NewReader(a.file)
+	numEntries := 0
+	for {
+		header, body, entrySize, err := decodeChecksummedEntry(r, versionsEntryMagic, info.Size()-offset)
+		if err != nil {
+			if err != io.EOF {
+				a.logger.Error("scanned archive file, found and ignoring invalid entry", log.Int64("valid-bytes", offset), log.Error(err))
+			}
+			break
+		}
+		height := primitives.BlockHeight(header.Height)
+		if height > stateHeight {
+			a.logger.Info("scanned archive file, truncating versions above the persisted state", logfields.BlockHeight(height))
+			break
+		}
+
+		err = a.applyVersions(offset+int64(entryHeaderSize), body)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read archived versions of block height %d", height)
+		}
+		a.applyHeight(height)
+
+		offset += int64(entrySize)
+		numEntries++
+	}
+
+	err = a.file.Truncate(offset)
+	if err != nil {
+		return errors.Wrapf(err, "failed to truncate archive file to offset %d", offset)
+	}
+	_, err = a.file.Seek(offset, io.SeekStart)
+	if err != nil {
+		return errors.Wrapf(err, "failed to seek to archive file offset %d", offset)
+	}
+	a.fileSize = offset
+
+	err = a.timestamps.Truncate(int64(a.height+1) * timestampSize)
+	if err != nil {
+		return errors.Wrap(err, "failed to truncate archive timestamps file")
+	}
+
+	a.logger.Info("scanned archive file", log.Int("entries", numEntries), log.Int("keys", len(a.heads)), log.Int64("valid-bytes", offset), logfields.BlockHeight(a.height))
+	return nil
+}
+
+// the first write holds the full state at its height, which is a diff from the empty genesis state only at the first block
+func (a *stateArchive) applyHeight(height primitives.BlockHeight) {
+	if a.height == 0 && height > 1 {
+		a.since = height
+	}
+	a.height = height
+}
+
+func (a *stateArchive) applyVersions(bodyOffset int64, body []byte) error {
+	r := bytes.NewReader(body)
+	for r.Len() > 0 {
+		versionOffset := bodyOffset + int64(len(body)-r.Len())
+		contract, record, err := readVersion(r)
+		if err != nil {
+			return err
+		}
+		a.heads[archiveKey{contract, string(record.Key())}] = versionOffset
+	}
+	return nil
+}
+
+func readVersion(r *bytes.Reader) (primitives.ContractName, *protocol.StateRecord, error) {
+	header := &versionHeader{}
+	err := binary.Read(r, binary.LittleEndian, header)
+	if err != nil {
+		return "", nil, err
+	}
+	if int(header.ContractSize)+int(header.RecordSize) > r.Len() {
+		return "", nil, errors.Errorf("version size %d exceeds remaining entry size %d", header.ContractSize+header.RecordSize, r.Len())
+	}
+
+	data := make([]byte, header.ContractSize+header.RecordSize)
+	_, err = io.ReadFull(r, data)
+	if err != nil {
+		return "", nil, err
+	}
+	return primitives.ContractName(data[:header.ContractSize]), protocol.StateRecordReader(data[header.ContractSize:]), nil
+}
+
+func (a *stateArchive) lastHeight() primitives.BlockHeight {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	return a.height
+}
+
+func (a *stateArchive) add(height primitives.BlockHeight, ts primitives.TimestampNano, diff adapter.ChainState) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.file == nil {
+		return errors.Errorf("archive file is closed")
+	}
+	if height <= a.height && a.height > 0 {
+		return errors.Errorf("cannot archive state of block height %d over state of block height %d", height, a.height)
+	}
+
+	body := &bytes.Buffer{}
+	heads := make(map[archiveKey]int64)
+	for contract, records := range diff {
+		for key, record := range records {
+			k := archiveKey{contract, key}
+			previous, ok := a.heads[k]
+			if !ok {
+				previous = -1
+			}
+			heads[k] = a.fileSize + int64(entryHeaderSize) + int64(body.Len())
+
+			header := &versionHeader{Height: uint64(height), Previous: previous, ContractSize: uint32(len(contract)), RecordSize: uint32(len(record.Raw()))}
+			err := binary.Write(body, binary.LittleEndian, header)
+			if err != nil {
+				return err
+			}
+			body.WriteString(string(contract))
+			body.Write(record.Raw())
+		}
+	}
+
+	entry, err := encodeChecksummedEntry(versionsEntryMagic, height, ts, body.Bytes())
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode archived versions of block height %d", height)
+	}
+
+	err = a.appendEntry(entry, height, ts)
+	if err != nil {
+		return err
+	}
+
+	for k, offset := range heads {
+		a.heads[k] = offset
+	}
+	a.applyHeight(height)
+
+	if a.fileSize-a.checkpointedSize > headsCheckpointInterval {
+		err = a.writeHeadsCheckpoint()
+		if err != nil { // the archive is still intact, a restart just scans more of it
+			a.logger.Error("failed to checkpoint archive heads", log.Error(err))
+		}
+	}
+
+	return nil
+}
+
+func (a *stateArchive) appendEntry(entry []byte, height primitives.BlockHeight, ts primitives.TimestampNano) error {
+	n, err := a.file.Write(entry)
+	if err == nil {
+		err = a.file.Sync()
+	}
+	if err == nil {
+		tsBytes := make([]byte, timestampSize)
+		binary.LittleEndian.PutUint64(tsBytes, uint64(ts))
+		_, err = a.timestamps.WriteAt(tsBytes, int64(height)*timestampSize)
+	}
+	if err == nil {
+		err = a.timestamps.Sync()
+	}
+	if err != nil {
+		a.rollbackTo(a.fileSize)
+		return errors.Wrap(err, "failed to write archived versions to disk")
+	}
+
+	a.fileSize += int64(n)
+	return nil
+}
+
+// a partially written entry must not remain in front of the next one
+func (a *stateArchive) rollbackTo(offset int64) {
+	err := a.file.Truncate(offset)
+	if err == nil {
+		_, err = a.file.Seek(offset, io.SeekStart)
+	}
+	if err == nil {
+		err = a.file.Sync()
+	}
+	if err != nil {
+		a.logger.Error("failed to roll back partial archive write", log.Error(err), log.Int64("offset", offset))
+	}
+}
+
+func (a *stateArchive) checkHeight(height primitives.BlockHeight) error {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	if height < a.since {
+		return errors.Errorf("requested height %d is too old. oldest archived block height is %d", height, a.since)
+	}
+	if height > a.height {
+		return errors.Errorf("requested height %d is too new. most recent archived block height is %d", height, a.height)
+	}
+	return nil
+}
+
+// walks back from the latest version of the key to the last one written at or before the given height
+func (a *stateArchive) read(height primitives.BlockHeight, contract primitives.ContractName, key string) (*protocol.StateRecord, bool, error) {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	if a.file == nil {
+		return nil, false, errors.Errorf("archive file is closed")
+	}
+
+	offset, ok := a.heads[archiveKey{contract, key}]
+	for ok && offset >= 0 {
+		header := &versionHeader{}
+		err := binary.Read(io.NewSectionReader(a.file, offset, int64(versionHeaderSize)), binary.LittleEndian, header)
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "failed to read archived version at offset %d", offset)
+		}
+		if primitives.BlockHeight(header.Height) > height {
+			offset = header.Previous
+			continue
+		}
+
+		raw := make([]byte, header.RecordSize)
+		_, err = a.file.ReadAt(raw, offset+int64(versionHeaderSize)+int64(header.ContractSize))
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "failed to read archived version at offset %d", offset)
+		}
+		record := protocol.StateRecordReader(raw)
+		return record, len(record.Value()) > 0, nil
+	}
+	return nil, false, nil
+}
+
+func (a *stateArchive) readTimestamp(height primitives.BlockHeight) (primitives.TimestampNano, error) {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	if a.timestamps == nil {
+		return 0, errors.Errorf("archive timestamps file is closed")
+	}
+
+	tsBytes := make([]byte, timestampSize)
+	_, err := a.timestamps.ReadAt(tsBytes, int64(height)*timestampSize)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to read archived timestamp of block height %d", height)
+	}
+	return primitives.TimestampNano(binary.LittleEndian.Uint64(tsBytes)), nil
+}
+
+type headsCheckpoint struct {
+	height primitives.BlockHeight
+	since  primitives.BlockHeight
+	offset int64
+	heads  map[archiveKey]int64
+}
+
+func (a *stateArchive) readHeadsCheckpoint() (*headsCheckpoint, error) {
+	filename := archiveHeadsFileName(a.config)
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open archive heads file %s", filename)
+	}
+	defer closeSilently(file, a.logger)
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	err = newArchiveHeadsFileHeader(0, 0).read(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading archive heads file header")
+	}
+	header, body, _, err := decodeChecksummedEntry(bufio.NewReader(file), headsEntryMagic, info.Size())
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading archive heads file")
+	}
+
+	r := bytes.NewReader(body)
+	checkpoint := &headsCheckpoint{height: primitives.BlockHeight(header.Height), heads: make(map[archiveKey]int64)}
+	var since uint64
+	err = binary.Read(r, binary.LittleEndian, &since)
+	if err == nil {
+		err = binary.Read(r, binary.LittleEndian, &checkpoint.offset)
+	}
+	for err == nil && r.Len() > 0 {
+		var contract, key []byte
+		var offset int64
+		contract, err = readChunk(r)
+		if err == nil {
+			key, err = readChunk(r)
+		}
+		if err == nil {
+			err = binary.Read(r, binary.LittleEndian, &offset)
+		}
+		checkpoint.heads[archiveKey{primitives.ContractName(contract), string(key)}] = offset
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading archive heads file")
+	}
+	checkpoint.since = primitives.BlockHeight(since)
+
+	return checkpoint, nil
+}
+
+// callers must hold the mutex for writing
+func (a *stateArchive) writeHeadsCheckpoint() error {
+	body := &bytes.Buffer{}
+	err := binary.Write(body, binary.LittleEndian, uint64(a.since))
+	if err == nil {
+		err = binary.Write(body, binary.LittleEndian, a.fileSize)
+	}
+	for k, offset := range a.heads {
+		if err == nil {
+			err = writeChunk(body, []byte(k.contract))
+		}
+		if err == nil {
+			err = writeChunk(body, []byte(k.key))
+		}
+		if err == nil {
+			err = binary.Write(body, binary.LittleEndian, offset)
+		}
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to encode archive heads")
+	}
+	entry, err := encodeChecksummedEntry(headsEntryMagic, a.height, 0, body.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "failed to encode archive heads")
+	}
+
+	compactedFilename := filepath.Join(a.config.StateStorageFileSystemDataDir(), compactedArchiveHeadsFilename)
+	compacted, err := os.OpenFile(compactedFilename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open archive heads file %s", compactedFilename)
+	}
+	defer closeSilently(compacted, a.logger)
+
+	err = newArchiveHeadsFileHeader(0, uint32(a.config.VirtualChainId())).write(compacted)
+	if err == nil {
+		_, err = compacted.Write(entry)
+	}
+	if err == nil {
+		err = compacted.Sync()
+	}
+	if err == nil {
+		err = os.Rename(compactedFilename, archiveHeadsFileName(a.config))
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to write archive heads file %s", compactedFilename)
+	}
+	err = syncDir(a.config.StateStorageFileSystemDataDir())
+	if err != nil {
+		a.logger.Error("failed to sync state storage data dir after checkpointing archive heads", log.Error(err))
+	}
+
+	a.checkpointedSize = a.fileSize
+	a.logger.Info("checkpointed archive heads", log.Int("keys", len(a.heads)), log.Int64("archive-size", a.fileSize), logfields.BlockHeight(a.height))
+	return nil
+}
+
+func (a *stateArchive) close() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.file != nil {
+		closeSilently(a.file, a.logger)
+		a.file = nil
+	}
+	if a.timestamps != nil {
+		closeSilently(a.timestamps, a.logger)
+		a.timestamps = nil
+	}
+}
+
+func archiveFileName(config config.FilesystemStatePersistenceConfig) string {
+	return filepath.Join(config.StateStorageFileSystemDataDir(), archiveFilename)
+}
+
+func archiveTimestampsFileName(config config.FilesystemStatePersistenceConfig) string {
+	return filepath.Join(config.StateStorageFileSystemDataDir(), archiveTimestampsFilename)
+}
+
+func archiveHeadsFileName(config config.FilesystemStatePersistenceConfig) string {
+	return filepath.Join(config.StateStorageFileSystemDataDir(), archiveHeadsFilename)
+}
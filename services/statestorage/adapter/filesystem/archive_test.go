@@ -0,0 +1,105 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package filesystem
+
+import (
+	"fmt"
+	"github.com/orbs-network/orbs-network-go/services/statestorage/adapter"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+type archiveTestConfig struct {
+	dir string
+}
+
+func (c *archiveTestConfig) StateStorageFileSystemDataDir() string {
+	return c.dir
+}
+
+func (c *archiveTestConfig) StateStorageArchivalMode() bool {
+	return true
+}
+
+func (c *archiveTestConfig) VirtualChainId() primitives.VirtualChainId {
+	return 42
+}
+
+func archiveValueAt(h primitives.BlockHeight) adapter.ChainState {
+	record := (&protocol.StateRecordBuilder{Key: []byte("k1"), Value: []byte(fmt.Sprintf("v%d", h))}).Build()
+	return adapter.ChainState{"foo": {"k1": record}}
+}
+
+func requireArchivedValue(t *testing.T, a *stateArchive, h primitives.BlockHeight) {
+	record, ok, err := a.read(h, "foo", "k1")
+	require.NoError(t, err)
+	require.True(t, ok, "expected key to be archived at height %d", h)
+	require.EqualValues(t, fmt.Sprintf("v%d", h), record.Value())
+}
+
+func TestStateArchive_RestoresFromHeadsCheckpointAndVersionsFollowingIt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state_archive")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	conf := &archiveTestConfig{dir: dir}
+
+	a, err := openStateArchive(conf, log.DefaultTestingLogger(t), 0)
+	require.NoError(t, err)
+	for h := primitives.BlockHeight(1); h <= 3; h++ {
+		require.NoError(t, a.add(h, primitives.TimestampNano(h*1000), archiveValueAt(h)))
+	}
+	a.mutex.Lock()
+	require.NoError(t, a.writeHeadsCheckpoint())
+	a.mutex.Unlock()
+	for h := primitives.BlockHeight(4); h <= 5; h++ {
+		require.NoError(t, a.add(h, primitives.TimestampNano(h*1000), archiveValueAt(h)))
+	}
+	a.close()
+
+	reopened, err := openStateArchive(conf, log.DefaultTestingLogger(t), 5)
+	require.NoError(t, err)
+	defer reopened.close()
+
+	require.True(t, reopened.checkpointedSize > 0, "expected archive to be restored from the heads checkpoint")
+	require.EqualValues(t, 5, reopened.lastHeight())
+	for h := primitives.BlockHeight(1); h <= 5; h++ {
+		requireArchivedValue(t, reopened, h)
+		ts, err := reopened.readTimestamp(h)
+		require.NoError(t, err)
+		require.EqualValues(t, h*1000, ts)
+	}
+}
+
+func TestStateArchive_TruncatesVersionsAboveStateHeight(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state_archive")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	conf := &archiveTestConfig{dir: dir}
+
+	a, err := openStateArchive(conf, log.DefaultTestingLogger(t), 0)
+	require.NoError(t, err)
+	for h := primitives.BlockHeight(1); h <= 3; h++ {
+		require.NoError(t, a.add(h, primitives.TimestampNano(h*1000), archiveValueAt(h)))
+	}
+	a.close()
+
+	reopened, err := openStateArchive(conf, log.DefaultTestingLogger(t), 2)
+	require.NoError(t, err)
+	defer reopened.close()
+
+	require.EqualValues(t, 2, reopened.lastHeight())
+	requireArchivedValue(t, reopened, 2)
+	require.Error(t, reopened.checkHeight(3))
+
+	require.NoError(t, reopened.add(3, 3000, archiveValueAt(3)), "expected the truncated height to be archived again")
+	requireArchivedValue(t, reopened, 3)
+}
@@ -0,0 +1,273 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package filesystem
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/orbs-network/orbs-network-go/services/statestorage/adapter"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/pkg/errors"
+	"hash/crc32"
+	"io"
+)
+
+const stateFormatMagic = uint32(0x54415453) // "STAT"
+const nodesFormatMagic = uint32(0x45444f4e) // "NODE"
+const stateFormatVersion = 0
+const entryMagic = uint32(0x46464944) // "DIFF"
+const entryVersion = 0
+
+var entryHeaderSize = binary.Size(entryHeader{})
+
+const checksumSize = 4
+
+type stateFileHeader struct {
+	Magic       uint32
+	FileVersion uint32
+	NetworkId   uint32
+	ChainId     uint32
+}
+
+// every entry holds the records of a single Write() call, a torn entry fails its checksum and is discarded as a whole
+type entryHeader struct {
+	Magic     uint32
+	Version   uint32
+	Height    uint64
+	Timestamp uint64
+	BodySize  uint32
+}
+
+type stateEntry struct {
+	height primitives.BlockHeight
+	ts     primitives.TimestampNano
+	root   primitives.Sha256
+	diff   adapter.ChainState
+}
+
+func newStateFileHeader(networkId, vchainId uint32) *stateFileHeader {
+	return &stateFileHeader{
+		Magic:       stateFormatMagic,
+		FileVersion: stateFormatVersion,
+		NetworkId:   networkId,
+		ChainId:     vchainId,
+	}
+}
+
+func newNodesFileHeader(networkId, vchainId uint32) *stateFileHeader {
+	header := newStateFileHeader(networkId, vchainId)
+	header.Magic = nodesFormatMagic
+	return header
+}
+
+// verifies the magic number the header was created with
+func (sfh *stateFileHeader) read(r io.Reader) error {
+	expectedMagic := sfh.Magic
+	checkSum := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	tr := io.TeeReader(r, checkSum)
+	err := binary.Read(tr, binary.LittleEndian, sfh)
+	if err != nil {
+		return err
+	}
+
+	var sum32 uint32
+	err = binary.Read(r, binary.LittleEndian, &sum32)
+	if err != nil {
+		return errors.Wrapf(err, "failed reading header checksum")
+	}
+
+	if sum32 != checkSum.Sum32() {
+		return fmt.Errorf("invalid header, bad checksum")
+	}
+
+	if sfh.Magic != expectedMagic {
+		return fmt.Errorf("invalid magic number %v", sfh.Magic)
+	}
+	if sfh.FileVersion != stateFormatVersion {
+		return fmt.Errorf("invalid version %d", sfh.FileVersion)
+	}
+	return nil
+}
+
+func (sfh *stateFileHeader) write(w io.Writer) error {
+	checkSum := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+
+	err := binary.Write(io.MultiWriter(w, checkSum), binary.LittleEndian, sfh)
+	if err != nil {
+		return err
+	}
+
+	return binary.Write(w, binary.LittleEndian, checkSum.Sum32())
+}
+
+func encodeEntry(height primitives.BlockHeight, ts primitives.TimestampNano, root primitives.Sha256, diff adapter.ChainState) ([]byte, error) {
+	body := &bytes.Buffer{}
+	err := writeChunk(body, root)
+	if err != nil {
+		return nil, err
+	}
+	for contract, records := range diff {
+		for _, record := range records {
+			err = writeChunk(body, []byte(contract))
+			if err != nil {
+				return nil, err
+			}
+			err = writeChunk(body, record.Raw())
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return encodeChecksummedEntry(entryMagic, height, ts, body.Bytes())
+}
+
+func encodeChecksummedEntry(magic uint32, height primitives.BlockHeight, ts primitives.TimestampNano, body []byte) ([]byte, error) {
+	header := &entryHeader{
+		Magic:     magic,
+		Version:   entryVersion,
+		Height:    uint64(height),
+		Timestamp: uint64(ts),
+		BodySize:  uint32(len(body)),
+	}
+
+	entry := &bytes.Buffer{}
+	checkSum := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	cw := io.MultiWriter(entry, checkSum)
+	err := binary.Write(cw, binary.LittleEndian, header)
+	if err != nil {
+		return nil, err
+	}
+	_, err = cw.Write(body)
+	if err != nil {
+		return nil, err
+	}
+	err = binary.Write(entry, binary.LittleEndian, checkSum.Sum32())
+	if err != nil {
+		return nil, err
+	}
+
+	return entry.Bytes(), nil
+}
+
+// sizeLimit guards against allocating huge buffers when reading a corrupt entry header
+func decodeEntry(r io.Reader, sizeLimit int64) (*stateEntry, int, error) {
+	header, body, entrySize, err := decodeChecksummedEntry(r, entryMagic, sizeLimit)
+	if err != nil {
+		return nil, entrySize, err
+	}
+
+	entry, err := parseEntryBody(header, body)
+	if err != nil {
+		return nil, entrySize, err
+	}
+	return entry, entrySize, nil
+}
+
+func decodeChecksummedEntry(r io.Reader, magic uint32, sizeLimit int64) (*entryHeader, []byte, int, error) {
+	checkSum := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	tr := io.TeeReader(r, checkSum)
+
+	header := &entryHeader{}
+	err := binary.Read(tr, binary.LittleEndian, header)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	if header.Magic != magic {
+		return nil, nil, entryHeaderSize, fmt.Errorf("invalid entry magic number %v", header.Magic)
+	}
+	if header.Version != entryVersion {
+		return nil, nil, entryHeaderSize, fmt.Errorf("invalid entry version %d", header.Version)
+	}
+
+	entrySize := entryHeaderSize + int(header.BodySize) + checksumSize
+	if int64(entrySize) > sizeLimit {
+		return nil, nil, entryHeaderSize, fmt.Errorf("entry size %d exceeds remaining file size %d", entrySize, sizeLimit)
+	}
+
+	body := make([]byte, header.BodySize)
+	_, err = io.ReadFull(tr, body)
+	if err != nil {
+		return nil, nil, entryHeaderSize, err
+	}
+
+	var sum32 uint32
+	err = binary.Read(r, binary.LittleEndian, &sum32)
+	if err != nil {
+		return nil, nil, entryHeaderSize + len(body), err
+	}
+	if sum32 != checkSum.Sum32() {
+		return nil, nil, entrySize, fmt.Errorf("entry checksum mismatch. computed: %v recorded: %v", checkSum.Sum32(), sum32)
+	}
+
+	return header, body, entrySize, nil
+}
+
+func parseEntryBody(header *entryHeader, body []byte) (*stateEntry, error) {
+	r := bytes.NewReader(body)
+
+	root, err := readChunk(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed reading merkle root")
+	}
+
+	diff := make(adapter.ChainState)
+	for r.Len() > 0 {
+		contract, err := readChunk(r)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed reading contract name")
+		}
+		raw, err := readChunk(r)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed reading state record")
+		}
+
+		contractName := primitives.ContractName(contract)
+		if _, ok := diff[contractName]; !ok {
+			diff[contractName] = make(adapter.ContractState)
+		}
+		record := protocol.StateRecordReader(raw)
+		diff[contractName][string(record.Key())] = record
+	}
+
+	return &stateEntry{
+		height: primitives.BlockHeight(header.Height),
+		ts:     primitives.TimestampNano(header.Timestamp),
+		root:   root,
+		diff:   diff,
+	}, nil
+}
+
+func writeChunk(w io.Writer, chunk []byte) error {
+	err := binary.Write(w, binary.LittleEndian, uint32(len(chunk)))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(chunk)
+	return err
+}
+
+func readChunk(r *bytes.Reader) ([]byte, error) {
+	var chunkLength uint32
+	err := binary.Read(r, binary.LittleEndian, &chunkLength)
+	if err != nil {
+		return nil, err
+	}
+	if int(chunkLength) > r.Len() {
+		return nil, fmt.Errorf("chunk length %d exceeds remaining entry size %d", chunkLength, r.Len())
+	}
+
+	chunk := make([]byte, chunkLength)
+	_, err = io.ReadFull(r, chunk)
+	if err != nil {
+		return nil, err
+	}
+	return chunk, nil
+}
@@ -0,0 +1,490 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/crypto/merkle"
+	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/statestorage/adapter"
+	"github.com/orbs-network/orbs-network-go/services/statestorage/adapter/memory"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+)
+
+const stateFilename = "state"
+const compactedStateFilename = "state.compacting"
+
+// the diff log is rewritten as a single full state entry when it grows this many times larger than the last full state entry
+const compactionRatio = 2
+const minSizeForCompaction = 16 * 1024 * 1024
+
+type metrics struct {
+	size *metric.Gauge
+}
+
+func newMetrics(m metric.Factory) *metrics {
+	return &metrics{
+		size: m.NewGauge("StateStoragePersistence.FileSystemSize.Bytes"),
+	}
+}
+
+// Keeps the full state in memory and appends every written diff to a log file, which is replayed on startup.
+// In archival mode every written diff is also kept in an archive on disk, which serves reads as of past block heights.
+type FilesystemStatePersistence struct {
+	*memory.InMemoryStatePersistence
+	config  config.FilesystemStatePersistenceConfig
+	logger  log.Logger
+	metrics *metrics
+	archive *stateArchive // nil unless in archival mode
+
+	mutex         sync.Mutex
+	file          *os.File
+	fileSize      int64
+	compactedSize int64
+
+	nodes *FilesystemNodeStore
+}
+
+func NewStatePersistence(ctx context.Context, conf config.FilesystemStatePersistenceConfig, parent log.Logger, metricFactory metric.Factory) (*FilesystemStatePersistence, error) {
+	logger := parent.WithTags(log.String("adapter", "state-storage"))
+
+	file, err := openStateFile(stateFileName(conf), newStateFileHeader, conf, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	persistence := &FilesystemStatePersistence{
+		InMemoryStatePersistence: memory.NewStatePersistence(metricFactory),
+		config:                   conf,
+		logger:                   logger,
+		metrics:                  newMetrics(metricFactory),
+		file:                     file,
+	}
+
+	err = persistence.replay()
+	if err != nil {
+		closeSilently(file, logger)
+		return nil, err
+	}
+
+	if conf.StateStorageArchivalMode() {
+		err = persistence.openArchive()
+		if err != nil {
+			closeSilently(file, logger)
+			return nil, err
+		}
+	}
+
+	persistence.nodes, err = NewNodeStore(ctx, conf, parent, metricFactory)
+	if err != nil {
+		closeSilently(file, logger)
+		persistence.closeArchive()
+		return nil, err
+	}
+
+	persistence.closeOnContextDone(ctx)
+
+	return persistence, nil
+}
+
+func openStateFile(filename string, newHeader func(networkId, vchainId uint32) *stateFileHeader, conf config.FilesystemStatePersistenceConfig, logger log.Logger) (*os.File, error) {
+	dir := conf.StateStorageFileSystemDataDir()
+	err := os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to verify data directory exists %s", dir)
+	}
+
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open state file for writing %s", filename)
+	}
+
+	err = advisoryLockExclusive(file)
+	if err != nil {
+		closeSilently(file, logger)
+		return nil, errors.Wrapf(err, "failed to obtain exclusive lock for writing %s", filename)
+	}
+
+	err = validateFileHeader(file, newHeader, conf, logger)
+	if err != nil {
+		closeSilently(file, logger)
+		return nil, errors.Wrapf(err, "failed to validate state file header %s", filename)
+	}
+
+	return file, nil
+}
+
+func validateFileHeader(file *os.File, newHeader func(networkId, vchainId uint32) *stateFileHeader, conf config.FilesystemStatePersistenceConfig, logger log.Logger) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if info.Size() == 0 {
+		logger.Info("creating new state file", log.String("path", file.Name()))
+		err = newHeader(0, uint32(conf.VirtualChainId())).write(file)
+		if err != nil {
+			return errors.Wrapf(err, "error writing state file header")
+		}
+		return file.Sync()
+	}
+
+	header := newHeader(0, 0)
+	err = header.read(file)
+	if err != nil {
+		return errors.Wrapf(err, "error reading state file header")
+	}
+
+	if header.ChainId != uint32(conf.VirtualChainId()) {
+		return fmt.Errorf("state file virtual chain id mismatch. found vchain id %d expected %d", header.ChainId, conf.VirtualChainId())
+	}
+
+	return nil
+}
+
+// applies every valid entry in the log and truncates whatever follows the last one, typically a write torn by a crash
+func (f *FilesystemStatePersistence) replay() error {
+	info, err := f.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	offset, err := f.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return errors.Wrap(err, "error reading state file")
+	}
+
+	numEntries := 0
+	for {
+		entry, entrySize, err := decodeEntry(f.file, info.Size()-offset)
+		if err != nil {
+			if err != io.EOF {
+				f.logger.Error("replayed state file, found and ignoring invalid entry", log.Int64("valid-bytes", offset), log.Error(err))
+			}
+			break
+		}
+
+		err = f.InMemoryStatePersistence.Write(entry.height, entry.ts, entry.root, entry.diff)
+		if err != nil {
+			return errors.Wrapf(err, "failed to apply state for block height %d", entry.height)
+		}
+
+		offset += int64(entrySize)
+		numEntries++
+		if numEntries == 1 {
+			f.compactedSize = offset
+		}
+	}
+
+	err = f.file.Truncate(offset)
+	if err != nil {
+		return errors.Wrapf(err, "failed to truncate state file to offset %d", offset)
+	}
+	_, err = f.file.Seek(offset, io.SeekStart)
+	if err != nil {
+		return errors.Wrapf(err, "failed to seek to state file offset %d", offset)
+	}
+
+	f.fileSize = offset
+	if numEntries == 0 {
+		f.compactedSize = offset
+	}
+	f.metrics.size.Update(f.fileSize)
+
+	height, _, _, _ := f.ReadMetadata()
+	f.logger.Info("replayed state file", log.Int("entries", numEntries), log.Int64("valid-bytes", offset), logfields.BlockHeight(height))
+	return nil
+}
+
+// An archive missing the latest heights, such as one created by a node switched to archival mode, catches up from the diff log
+func (f *FilesystemStatePersistence) openArchive() error {
+	height, _, _, err := f.ReadMetadata()
+	if err != nil {
+		return err
+	}
+
+	f.archive, err = openStateArchive(f.config, f.logger, height)
+	if err != nil {
+		return err
+	}
+	if f.archive.lastHeight() == height {
+		return nil
+	}
+
+	err = f.catchUpArchive()
+	if err != nil {
+		f.closeArchive()
+		return err
+	}
+	return nil
+}
+
+func (f *FilesystemStatePersistence) catchUpArchive() error {
+	archivedHeight := f.archive.lastHeight()
+
+	offset, err := f.file.Seek(0, io.SeekStart)
+	if err == nil {
+		err = validateFileHeader(f.file, newStateFileHeader, f.config, f.logger)
+	}
+	if err == nil {
+		offset, err = f.file.Seek(0, io.SeekCurrent)
+	}
+	if err != nil {
+		return errors.Wrap(err, "error reading state file")
+	}
+
+	previousHeight := primitives.BlockHeight(0)
+	for offset < f.fileSize {
+		entry, entrySize, err := decodeEntry(f.file, f.fileSize-offset)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read state file at offset %d", offset)
+		}
+
+		if entry.height > archivedHeight {
+			if previousHeight != archivedHeight { // a compacted log holds the full state at its first height only
+				return errors.Errorf("archive of past revisions ends at block height %d, the state file only holds the state from block height %d", archivedHeight, entry.height)
+			}
+			err = f.archive.add(entry.height, entry.ts, entry.diff)
+			if err != nil {
+				return err
+			}
+			archivedHeight = entry.height
+		}
+		previousHeight = entry.height
+		offset += int64(entrySize)
+	}
+
+	f.logger.Info("caught up archive of past revisions from state file", logfields.BlockHeight(archivedHeight))
+	return nil
+}
+
+func (f *FilesystemStatePersistence) closeArchive() {
+	if f.archive != nil {
+		f.archive.close()
+	}
+}
+
+func (f *FilesystemStatePersistence) ReadAt(height primitives.BlockHeight, contract primitives.ContractName, key string) (*protocol.StateRecord, bool, error) {
+	if f.archive == nil {
+		return nil, false, errors.New("state persistence does not keep an archive of past revisions")
+	}
+	if err := f.archive.checkHeight(height); err != nil {
+		return nil, false, err
+	}
+	return f.archive.read(height, contract, key)
+}
+
+func (f *FilesystemStatePersistence) ReadTimestampAt(height primitives.BlockHeight) (primitives.TimestampNano, error) {
+	if f.archive == nil {
+		return 0, errors.New("state persistence does not keep an archive of past revisions")
+	}
+	if err := f.archive.checkHeight(height); err != nil {
+		return 0, err
+	}
+	return f.archive.readTimestamp(height)
+}
+
+// The merkle trie nodes are kept next to the state file, so the state merkle root survives restarts
+func (f *FilesystemStatePersistence) MerkleNodeStore() merkle.NodeStore {
+	return f.nodes
+}
+
+func (f *FilesystemStatePersistence) Write(height primitives.BlockHeight, ts primitives.TimestampNano, root primitives.Sha256, diff adapter.ChainState) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.file == nil {
+		return errors.Errorf("state file is closed")
+	}
+
+	entry, err := encodeEntry(height, ts, root, diff)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode state for block height %d", height)
+	}
+
+	offset := f.fileSize
+	err = f.appendEntry(entry)
+	if err != nil {
+		return err
+	}
+
+	if f.archive != nil { // rejects the same heights as the in memory state, so it is never ahead of it
+		err = f.archive.add(height, ts, diff)
+	}
+	if err == nil {
+		err = f.InMemoryStatePersistence.Write(height, ts, root, diff)
+	}
+	if err != nil { // the log must not keep a height which was never applied, it would be replayed on the next start
+		f.rollbackTo(offset)
+		f.fileSize = offset
+		f.metrics.size.Update(f.fileSize)
+		return err
+	}
+
+	// the archive holds every past revision on its own, so the log is compacted in archival mode as well
+	if f.fileSize > minSizeForCompaction && f.fileSize > compactionRatio*f.compactedSize {
+		err = f.compact()
+		if err != nil { // the diff log is still intact, we'll try again on the next write
+			f.logger.Error("failed to compact state file", log.Error(err))
+		}
+	}
+
+	return nil
+}
+
+func (f *FilesystemStatePersistence) appendEntry(entry []byte) error {
+	n, err := f.file.Write(entry)
+	if err == nil {
+		err = f.file.Sync()
+	}
+	if err != nil {
+		f.rollbackTo(f.fileSize)
+		return errors.Wrap(err, "failed to write state to disk")
+	}
+
+	f.fileSize += int64(n)
+	f.metrics.size.Update(f.fileSize)
+	return nil
+}
+
+// a partially written or rejected entry must not remain in front of the next one
+func (f *FilesystemStatePersistence) rollbackTo(offset int64) {
+	err := f.file.Truncate(offset)
+	if err == nil {
+		_, err = f.file.Seek(offset, io.SeekStart)
+	}
+	if err == nil {
+		err = f.file.Sync()
+	}
+	if err != nil {
+		f.logger.Error("failed to roll back partial state write", log.Error(err), log.Int64("offset", offset))
+	}
+}
+
+func (f *FilesystemStatePersistence) compact() error {
+	height, ts, root, err := f.ReadMetadata()
+	if err != nil {
+		return err
+	}
+
+	fullState := make(adapter.ChainState)
+	err = f.Each(func(contract primitives.ContractName, record *protocol.StateRecord) {
+		if _, ok := fullState[contract]; !ok {
+			fullState[contract] = make(adapter.ContractState)
+		}
+		fullState[contract][string(record.Key())] = record
+	})
+	if err != nil {
+		return err
+	}
+
+	entry, err := encodeEntry(height, ts, root, fullState)
+	if err != nil {
+		return err
+	}
+
+	compactedFilename := filepath.Join(f.config.StateStorageFileSystemDataDir(), compactedStateFilename)
+	compacted, err := os.OpenFile(compactedFilename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open compacted state file %s", compactedFilename)
+	}
+
+	err = advisoryLockExclusive(compacted)
+	if err == nil {
+		err = newStateFileHeader(0, uint32(f.config.VirtualChainId())).write(compacted)
+	}
+	if err == nil {
+		_, err = compacted.Write(entry)
+	}
+	if err == nil {
+		err = compacted.Sync()
+	}
+	if err == nil {
+		err = os.Rename(compactedFilename, stateFileName(f.config))
+	}
+	if err != nil {
+		closeSilently(compacted, f.logger)
+		return errors.Wrapf(err, "failed to write compacted state file %s", compactedFilename)
+	}
+	// the compacted file replaced the diff log already, the next writes must go to it even if the rename may not survive a crash
+	err = syncDir(f.config.StateStorageFileSystemDataDir())
+	if err != nil {
+		f.logger.Error("failed to sync state storage data dir after compacting state file", log.Error(err))
+	}
+
+	size, err := compacted.Seek(0, io.SeekCurrent)
+	if err != nil {
+		closeSilently(compacted, f.logger)
+		return errors.Wrap(err, "failed to read compacted state file size")
+	}
+
+	closeSilently(f.file, f.logger)
+	f.file = compacted
+	f.fileSize = size
+	f.compactedSize = size
+	f.metrics.size.Update(f.fileSize)
+
+	f.logger.Info("compacted state file", log.Int64("size", size), logfields.BlockHeight(height))
+	return nil
+}
+
+func (f *FilesystemStatePersistence) closeOnContextDone(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+
+		err := f.file.Close()
+		if err != nil {
+			f.logger.Error("failed to close state file", log.String("filename", f.file.Name()))
+		} else {
+			f.logger.Info("closed state file", log.String("filename", f.file.Name()))
+		}
+		f.file = nil
+		f.closeArchive()
+	}()
+}
+
+func advisoryLockExclusive(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+}
+
+// a rename is only durable once the directory holding the file is synced, otherwise a crash may bring back the old file
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	closeErr := d.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+func stateFileName(config config.FilesystemStatePersistenceConfig) string {
+	return filepath.Join(config.StateStorageFileSystemDataDir(), stateFilename)
+}
+
+func closeSilently(file *os.File, logger log.Logger) {
+	err := file.Close()
+	if err != nil {
+		logger.Error("failed to close file", log.Error(err), log.String("filename", file.Name()))
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package filesystem
+
+import (
+	"container/list"
+	"sync"
+)
+
+// bounds the memory of trie nodes read from the merkle nodes file, the nodes near the roots are read by every lookup
+const nodeCacheMaxSizeBytes = 32 * 1024 * 1024
+
+type cachedNode struct {
+	key  string
+	data []byte
+}
+
+// Keeps the data of recently read trie nodes, evicting the least recently read once their total size exceeds the bound.
+// Trie nodes are addressed by the hash of their data, so a cached node never goes stale, it is only removed once deleted
+type nodeCache struct {
+	maxSize int
+
+	mutex   sync.Mutex
+	size    int
+	order   *list.List // of *cachedNode, most recently read first
+	entries map[string]*list.Element
+}
+
+func newNodeCache(maxSize int) *nodeCache {
+	return &nodeCache{
+		maxSize: maxSize,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (c *nodeCache) get(key string) ([]byte, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*cachedNode).data, true
+}
+
+func (c *nodeCache) add(key string, data []byte) {
+	if len(data) > c.maxSize {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cachedNode{key: key, data: data})
+	c.size += len(data)
+
+	for c.size > c.maxSize {
+		c.removeElement(c.order.Back())
+	}
+}
+
+func (c *nodeCache) remove(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		c.removeElement(e)
+	}
+}
+
+func (c *nodeCache) removeElement(e *list.Element) {
+	n := c.order.Remove(e).(*cachedNode)
+	delete(c.entries, n.key)
+	c.size -= len(n.data)
+}
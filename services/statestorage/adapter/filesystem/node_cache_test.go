@@ -0,0 +1,43 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package filesystem
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNodeCache_EvictsLeastRecentlyReadNodesBeyondMaxSize(t *testing.T) {
+	cache := newNodeCache(10)
+	cache.add("a", make([]byte, 4))
+	cache.add("b", make([]byte, 4))
+	_, ok := cache.get("a")
+	require.True(t, ok)
+
+	cache.add("c", make([]byte, 4))
+
+	_, ok = cache.get("b")
+	require.False(t, ok, "least recently read node should be evicted")
+	_, ok = cache.get("a")
+	require.True(t, ok, "recently read node should be kept")
+	_, ok = cache.get("c")
+	require.True(t, ok, "added node should be kept")
+	require.Equal(t, 8, cache.size)
+}
+
+func TestNodeCache_DoesNotKeepNodeLargerThanMaxSizeOrRemovedNode(t *testing.T) {
+	cache := newNodeCache(10)
+	cache.add("a", make([]byte, 11))
+	_, ok := cache.get("a")
+	require.False(t, ok)
+
+	cache.add("b", make([]byte, 4))
+	cache.remove("b")
+	_, ok = cache.get("b")
+	require.False(t, ok)
+	require.Zero(t, cache.size)
+}
@@ -0,0 +1,187 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package filesystem
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/orbs-network/orbs-network-go/crypto/hash"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/pkg/errors"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+)
+
+const nodesEntryMagic = uint32(0x54494d43) // "CMIT"
+const nodesEntryVersion = 0
+
+var nodesFileHeaderSize = int64(binary.Size(stateFileHeader{}) + checksumSize)
+var nodesEntryHeaderSize = binary.Size(nodesEntryHeader{})
+var nodeRecordHeaderSize = hash.SHA256_HASH_SIZE_BYTES + 8
+
+// every entry holds the node changes of a single Commit() call followed by the roots they leave behind,
+// a torn entry fails its checksum and is discarded as a whole
+type nodesEntryHeader struct {
+	Magic    uint32
+	Version  uint32
+	NumRoots uint32
+	BodySize uint32
+}
+
+// a record sets the reference count of a node, the node's data is written only when it is first stored
+type nodeRecord struct {
+	hash   primitives.Sha256
+	refs   uint32
+	data   []byte
+	offset int64 // of the data in the file, set once encoded or decoded
+	size   uint32
+}
+
+type nodesEntry struct {
+	roots   []primitives.Sha256
+	records []*nodeRecord
+}
+
+func nodesEntrySize(numRoots int, records []*nodeRecord) int64 {
+	size := int64(nodesEntryHeaderSize + numRoots*hash.SHA256_HASH_SIZE_BYTES + checksumSize)
+	for _, record := range records {
+		size += int64(nodeRecordHeaderSize) + int64(record.size)
+	}
+	return size
+}
+
+// writes the entry at the given file offset, setting the offset of every record's data.
+// readData supplies the data of records which do not hold it, so compaction never keeps all nodes in memory
+func encodeNodesEntry(w io.Writer, offset int64, roots []primitives.Sha256, records []*nodeRecord, readData func(*nodeRecord) ([]byte, error)) error {
+	checkSum := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	cw := io.MultiWriter(w, checkSum)
+
+	header := &nodesEntryHeader{
+		Magic:    nodesEntryMagic,
+		Version:  nodesEntryVersion,
+		NumRoots: uint32(len(roots)),
+		BodySize: uint32(nodesEntrySize(len(roots), records) - int64(nodesEntryHeaderSize+checksumSize)),
+	}
+	err := binary.Write(cw, binary.LittleEndian, header)
+	if err != nil {
+		return err
+	}
+	for _, root := range roots {
+		_, err = cw.Write(root)
+		if err != nil {
+			return err
+		}
+	}
+	offset += int64(nodesEntryHeaderSize + len(roots)*hash.SHA256_HASH_SIZE_BYTES)
+
+	for _, record := range records {
+		data := record.data
+		if data == nil && record.size > 0 {
+			data, err = readData(record)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = cw.Write(record.hash)
+		if err == nil {
+			err = binary.Write(cw, binary.LittleEndian, []uint32{record.refs, record.size})
+		}
+		if err == nil {
+			_, err = cw.Write(data)
+		}
+		if err != nil {
+			return err
+		}
+		record.offset = offset + int64(nodeRecordHeaderSize)
+		offset = record.offset + int64(record.size)
+	}
+
+	return binary.Write(w, binary.LittleEndian, checkSum.Sum32())
+}
+
+// node data is skipped rather than read, only its offset is kept. sizeLimit guards against a corrupt entry header
+func decodeNodesEntry(r io.Reader, offset int64, sizeLimit int64) (*nodesEntry, int64, error) {
+	checkSum := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	tr := io.TeeReader(r, checkSum)
+
+	header := &nodesEntryHeader{}
+	err := binary.Read(tr, binary.LittleEndian, header)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if header.Magic != nodesEntryMagic {
+		return nil, 0, fmt.Errorf("invalid entry magic number %v", header.Magic)
+	}
+	if header.Version != nodesEntryVersion {
+		return nil, 0, fmt.Errorf("invalid entry version %d", header.Version)
+	}
+
+	entrySize := int64(nodesEntryHeaderSize) + int64(header.BodySize) + checksumSize
+	if entrySize > sizeLimit {
+		return nil, 0, fmt.Errorf("entry size %d exceeds remaining file size %d", entrySize, sizeLimit)
+	}
+	rootsSize := int64(header.NumRoots) * hash.SHA256_HASH_SIZE_BYTES
+	if rootsSize > int64(header.BodySize) {
+		return nil, 0, fmt.Errorf("%d roots exceed entry body size %d", header.NumRoots, header.BodySize)
+	}
+
+	entry := &nodesEntry{}
+	for i := uint32(0); i < header.NumRoots; i++ {
+		root := make(primitives.Sha256, hash.SHA256_HASH_SIZE_BYTES)
+		_, err = io.ReadFull(tr, root)
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "failed reading root")
+		}
+		entry.roots = append(entry.roots, root)
+	}
+
+	remaining := int64(header.BodySize) - rootsSize
+	offset += int64(nodesEntryHeaderSize) + rootsSize
+	for remaining > 0 {
+		if remaining < int64(nodeRecordHeaderSize) {
+			return nil, 0, fmt.Errorf("node record header exceeds remaining entry size %d", remaining)
+		}
+		record := &nodeRecord{hash: make(primitives.Sha256, hash.SHA256_HASH_SIZE_BYTES)}
+		_, err = io.ReadFull(tr, record.hash)
+		if err == nil {
+			err = binary.Read(tr, binary.LittleEndian, &record.refs)
+		}
+		if err == nil {
+			err = binary.Read(tr, binary.LittleEndian, &record.size)
+		}
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "failed reading node record")
+		}
+		remaining -= int64(nodeRecordHeaderSize)
+
+		if int64(record.size) > remaining {
+			return nil, 0, fmt.Errorf("node size %d exceeds remaining entry size %d", record.size, remaining)
+		}
+		_, err = io.CopyN(ioutil.Discard, tr, int64(record.size))
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "failed reading node data")
+		}
+		remaining -= int64(record.size)
+
+		record.offset = offset + int64(nodeRecordHeaderSize)
+		offset = record.offset + int64(record.size)
+		entry.records = append(entry.records, record)
+	}
+
+	var sum32 uint32
+	err = binary.Read(r, binary.LittleEndian, &sum32)
+	if err != nil {
+		return nil, 0, err
+	}
+	if sum32 != checkSum.Sum32() {
+		return nil, 0, fmt.Errorf("entry checksum mismatch. computed: %v recorded: %v", checkSum.Sum32(), sum32)
+	}
+
+	return entry, entrySize, nil
+}
@@ -0,0 +1,404 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package filesystem
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const nodesFilename = "merkle"
+const compactedNodesFilename = "merkle.compacting"
+
+type storedNode struct {
+	offset int64  // of the node's data in the file, negative until committed
+	size   uint32 // of the node's data
+	refs   uint32
+	data   []byte // kept only until committed
+}
+
+// Keeps the merkle trie nodes in a log file, only their offsets and reference counts are kept in memory along with a bounded
+// cache of recently read nodes. The offsets still take memory in proportion to the number of live nodes, about a hundred bytes each.
+// Every commit appends the nodes stored and the reference counts changed since the previous one, which are replayed on startup.
+type FilesystemNodeStore struct {
+	config config.FilesystemStatePersistenceConfig
+	logger log.Logger
+	size   *metric.Gauge
+	cache  *nodeCache
+
+	mutex         sync.RWMutex
+	file          *os.File
+	fileSize      int64
+	compactedSize int64
+	nodes         map[string]*storedNode
+	changed       map[string]bool // nodes whose reference count changed since the last commit
+	roots         []primitives.Sha256
+}
+
+func NewNodeStore(ctx context.Context, conf config.FilesystemStatePersistenceConfig, parent log.Logger, metricFactory metric.Factory) (*FilesystemNodeStore, error) {
+	logger := parent.WithTags(log.String("adapter", "state-storage-merkle"))
+
+	file, err := openStateFile(nodesFileName(conf), newNodesFileHeader, conf, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &FilesystemNodeStore{
+		config:  conf,
+		logger:  logger,
+		size:    metricFactory.NewGauge("StateStoragePersistence.MerkleNodesFileSize.Bytes"),
+		cache:   newNodeCache(nodeCacheMaxSizeBytes),
+		file:    file,
+		nodes:   make(map[string]*storedNode),
+		changed: make(map[string]bool),
+	}
+
+	err = store.replay()
+	if err != nil {
+		closeSilently(file, logger)
+		return nil, err
+	}
+
+	store.closeOnContextDone(ctx)
+
+	return store, nil
+}
+
+// applies every valid entry in the log and truncates whatever follows the last one, typically a write torn by a crash
+func (s *FilesystemNodeStore) replay() error {
+	info, err := s.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	offset, err := s.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return errors.Wrap(err, "error reading merkle nodes file")
+	}
+
+	r := bufio.NewReader(s.file)
+	numEntries := 0
+	for {
+		entry, entrySize, err := decodeNodesEntry(r, offset, info.Size()-offset)
+		if err != nil {
+			if err != io.EOF {
+				s.logger.Error("replayed merkle nodes file, found and ignoring invalid entry", log.Int64("valid-bytes", offset), log.Error(err))
+			}
+			break
+		}
+
+		for _, record := range entry.records {
+			s.applyRecord(record)
+		}
+		s.roots = entry.roots
+
+		offset += entrySize
+		numEntries++
+		if numEntries == 1 {
+			s.compactedSize = offset
+		}
+	}
+
+	err = s.file.Truncate(offset)
+	if err != nil {
+		return errors.Wrapf(err, "failed to truncate merkle nodes file to offset %d", offset)
+	}
+	_, err = s.file.Seek(offset, io.SeekStart)
+	if err != nil {
+		return errors.Wrapf(err, "failed to seek to merkle nodes file offset %d", offset)
+	}
+
+	s.fileSize = offset
+	if numEntries == 0 {
+		s.compactedSize = offset
+	}
+	s.size.Update(s.fileSize)
+
+	s.logger.Info("replayed merkle nodes file", log.Int("entries", numEntries), log.Int("nodes", len(s.nodes)), log.Int64("valid-bytes", offset))
+	return nil
+}
+
+func (s *FilesystemNodeStore) applyRecord(record *nodeRecord) {
+	if record.refs == 0 {
+		delete(s.nodes, string(record.hash))
+		return
+	}
+	if n, ok := s.nodes[string(record.hash)]; ok {
+		n.refs = record.refs
+		return
+	}
+	s.nodes[string(record.hash)] = &storedNode{offset: record.offset, size: record.size, refs: record.refs}
+}
+
+func (s *FilesystemNodeStore) Get(hash primitives.Sha256) ([]byte, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	n, ok := s.nodes[string(hash)]
+	if !ok || n.refs == 0 {
+		return nil, errors.Errorf("trie node %s not found", hash)
+	}
+	if n.data != nil {
+		return n.data, nil
+	}
+	if data, ok := s.cache.get(string(hash)); ok {
+		return data, nil
+	}
+
+	data, err := s.readData(n)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.add(string(hash), data)
+	return data, nil
+}
+
+func (s *FilesystemNodeStore) readData(n *storedNode) ([]byte, error) {
+	if n.data != nil {
+		return n.data, nil
+	}
+	if s.file == nil {
+		return nil, errors.Errorf("merkle nodes file is closed")
+	}
+
+	data := make([]byte, n.size)
+	_, err := s.file.ReadAt(data, n.offset)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read trie node at offset %d", n.offset)
+	}
+	return data, nil
+}
+
+func (s *FilesystemNodeStore) Put(hash primitives.Sha256, data []byte) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.changed[string(hash)] = true
+	if n, ok := s.nodes[string(hash)]; ok {
+		n.refs++
+		return n.refs == 1, nil // a node released since the last commit was deleted along with its references
+	}
+	s.nodes[string(hash)] = &storedNode{offset: -1, size: uint32(len(data)), refs: 1, data: data}
+	return true, nil
+}
+
+func (s *FilesystemNodeStore) Reference(hash primitives.Sha256) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	n, ok := s.nodes[string(hash)]
+	if !ok || n.refs == 0 {
+		return errors.Errorf("trie node %s not found", hash)
+	}
+	n.refs++
+	s.changed[string(hash)] = true
+	return nil
+}
+
+// a deleted node remains until the next commit, which records its deletion
+func (s *FilesystemNodeStore) Release(hash primitives.Sha256) ([]byte, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	n, ok := s.nodes[string(hash)]
+	if !ok || n.refs == 0 {
+		return nil, false, errors.Errorf("trie node %s not found", hash)
+	}
+	n.refs--
+	s.changed[string(hash)] = true
+	if n.refs > 0 {
+		return nil, false, nil
+	}
+
+	data, err := s.readData(n)
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+func (s *FilesystemNodeStore) Roots() ([]primitives.Sha256, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return append([]primitives.Sha256{}, s.roots...), nil
+}
+
+func (s *FilesystemNodeStore) Commit(roots []primitives.Sha256) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.file == nil {
+		return errors.Errorf("merkle nodes file is closed")
+	}
+
+	records := make([]*nodeRecord, 0, len(s.changed))
+	for key := range s.changed {
+		n := s.nodes[key]
+		if n.offset < 0 && n.refs == 0 { // never written
+			continue
+		}
+		record := &nodeRecord{hash: primitives.Sha256(key), refs: n.refs}
+		if n.offset < 0 {
+			record.data = n.data
+			record.size = n.size
+		}
+		records = append(records, record)
+	}
+
+	entry := &bytes.Buffer{}
+	err := encodeNodesEntry(entry, s.fileSize, roots, records, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode merkle nodes")
+	}
+	err = s.appendEntry(entry.Bytes())
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if n := s.nodes[string(record.hash)]; n.offset < 0 {
+			n.offset = record.offset
+			n.data = nil
+		}
+	}
+	for key := range s.changed {
+		if s.nodes[key].refs == 0 {
+			delete(s.nodes, key)
+			s.cache.remove(key)
+		}
+	}
+	s.changed = make(map[string]bool)
+	s.roots = append([]primitives.Sha256{}, roots...)
+
+	if s.fileSize > minSizeForCompaction && s.fileSize > compactionRatio*s.compactedSize {
+		err = s.compact()
+		if err != nil { // the log is still intact, we'll try again on the next commit
+			s.logger.Error("failed to compact merkle nodes file", log.Error(err))
+		}
+	}
+
+	return nil
+}
+
+func (s *FilesystemNodeStore) appendEntry(entry []byte) error {
+	n, err := s.file.Write(entry)
+	if err == nil {
+		err = s.file.Sync()
+	}
+	if err != nil {
+		s.rollbackTo(s.fileSize)
+		return errors.Wrap(err, "failed to write merkle nodes to disk")
+	}
+
+	s.fileSize += int64(n)
+	s.size.Update(s.fileSize)
+	return nil
+}
+
+// a partially written entry must not remain in front of the next one
+func (s *FilesystemNodeStore) rollbackTo(offset int64) {
+	err := s.file.Truncate(offset)
+	if err == nil {
+		_, err = s.file.Seek(offset, io.SeekStart)
+	}
+	if err != nil {
+		s.logger.Error("failed to roll back partial merkle nodes write", log.Error(err), log.Int64("offset", offset))
+	}
+}
+
+// rewrites the live nodes as a single entry, reading them one at a time from the current file
+func (s *FilesystemNodeStore) compact() error {
+	records := make([]*nodeRecord, 0, len(s.nodes))
+	for key, n := range s.nodes {
+		records = append(records, &nodeRecord{hash: primitives.Sha256(key), refs: n.refs, size: n.size})
+	}
+	readData := func(record *nodeRecord) ([]byte, error) {
+		return s.readData(s.nodes[string(record.hash)])
+	}
+
+	compactedFilename := filepath.Join(s.config.StateStorageFileSystemDataDir(), compactedNodesFilename)
+	compacted, err := os.OpenFile(compactedFilename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open compacted merkle nodes file %s", compactedFilename)
+	}
+
+	w := bufio.NewWriter(compacted)
+	err = advisoryLockExclusive(compacted)
+	if err == nil {
+		err = newNodesFileHeader(0, uint32(s.config.VirtualChainId())).write(w)
+	}
+	if err == nil {
+		err = encodeNodesEntry(w, nodesFileHeaderSize, s.roots, records, readData)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = compacted.Sync()
+	}
+	if err == nil {
+		err = os.Rename(compactedFilename, nodesFileName(s.config))
+	}
+	if err != nil {
+		closeSilently(compacted, s.logger)
+		return errors.Wrapf(err, "failed to write compacted merkle nodes file %s", compactedFilename)
+	}
+	// the compacted file replaced the log already, the next commits must go to it even if the rename may not survive a crash
+	err = syncDir(s.config.StateStorageFileSystemDataDir())
+	if err != nil {
+		s.logger.Error("failed to sync state storage data dir after compacting merkle nodes file", log.Error(err))
+	}
+
+	size, err := compacted.Seek(0, io.SeekCurrent)
+	if err != nil {
+		closeSilently(compacted, s.logger)
+		return errors.Wrap(err, "failed to read compacted merkle nodes file size")
+	}
+
+	for _, record := range records {
+		s.nodes[string(record.hash)].offset = record.offset
+	}
+	closeSilently(s.file, s.logger)
+	s.file = compacted
+	s.fileSize = size
+	s.compactedSize = size
+	s.size.Update(s.fileSize)
+
+	s.logger.Info("compacted merkle nodes file", log.Int64("size", size), log.Int("nodes", len(s.nodes)))
+	return nil
+}
+
+func (s *FilesystemNodeStore) closeOnContextDone(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		err := s.file.Close()
+		if err != nil {
+			s.logger.Error("failed to close merkle nodes file", log.String("filename", s.file.Name()))
+		} else {
+			s.logger.Info("closed merkle nodes file", log.String("filename", s.file.Name()))
+		}
+		s.file = nil
+	}()
+}
+
+func nodesFileName(config config.FilesystemStatePersistenceConfig) string {
+	return filepath.Join(config.StateStorageFileSystemDataDir(), nodesFilename)
+}
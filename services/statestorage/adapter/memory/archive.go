@@ -0,0 +1,104 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package memory
+
+import (
+	"github.com/orbs-network/orbs-network-go/services/statestorage/adapter"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/pkg/errors"
+	"sort"
+)
+
+type stateVersion struct {
+	height primitives.BlockHeight
+	record *protocol.StateRecord // holds the zero value from the height the key was deleted
+}
+
+// Every version of every key, in the order of the heights they were written at
+type stateArchive struct {
+	since      primitives.BlockHeight
+	versions   map[primitives.ContractName]map[string][]stateVersion
+	timestamps map[primitives.BlockHeight]primitives.TimestampNano
+}
+
+func newStateArchive() *stateArchive {
+	return &stateArchive{
+		versions:   make(map[primitives.ContractName]map[string][]stateVersion),
+		timestamps: map[primitives.BlockHeight]primitives.TimestampNano{0: 0},
+	}
+}
+
+// the first write holds the full state at its height, which is a diff from the empty genesis state only at the first block
+func (a *stateArchive) add(prevHeight primitives.BlockHeight, height primitives.BlockHeight, ts primitives.TimestampNano, diff adapter.ChainState) {
+	if prevHeight == 0 && height > 1 {
+		a.since = height
+	}
+	a.timestamps[height] = ts
+
+	for contract, records := range diff {
+		if _, ok := a.versions[contract]; !ok {
+			a.versions[contract] = make(map[string][]stateVersion)
+		}
+		for key, record := range records {
+			a.versions[contract][key] = append(a.versions[contract][key], stateVersion{height: height, record: record})
+		}
+	}
+}
+
+func (a *stateArchive) checkHeight(height primitives.BlockHeight, lastHeight primitives.BlockHeight) error {
+	if height < a.since {
+		return errors.Errorf("requested height %d is too old. oldest archived block height is %d", height, a.since)
+	}
+	if height > lastHeight {
+		return errors.Errorf("requested height %d is too new. most recent archived block height is %d", height, lastHeight)
+	}
+	return nil
+}
+
+func (a *stateArchive) read(height primitives.BlockHeight, contract primitives.ContractName, key string) (*protocol.StateRecord, bool) {
+	versions := a.versions[contract][key]
+	i := sort.Search(len(versions), func(i int) bool { return versions[i].height > height })
+	if i == 0 {
+		return nil, false
+	}
+	record := versions[i-1].record
+	return record, !isZeroValue(record.Value())
+}
+
+func (sp *InMemoryStatePersistence) ReadAt(height primitives.BlockHeight, contract primitives.ContractName, key string) (*protocol.StateRecord, bool, error) {
+	sp.mutex.RLock()
+	defer sp.mutex.RUnlock()
+
+	if sp.archive == nil {
+		return nil, false, errors.New("state persistence does not keep an archive of past revisions")
+	}
+	if err := sp.archive.checkHeight(height, sp.height); err != nil {
+		return nil, false, err
+	}
+
+	record, ok := sp.archive.read(height, contract, key)
+	return record, ok, nil
+}
+
+func (sp *InMemoryStatePersistence) ReadTimestampAt(height primitives.BlockHeight) (primitives.TimestampNano, error) {
+	sp.mutex.RLock()
+	defer sp.mutex.RUnlock()
+
+	if sp.archive == nil {
+		return 0, errors.New("state persistence does not keep an archive of past revisions")
+	}
+	if err := sp.archive.checkHeight(height, sp.height); err != nil {
+		return 0, err
+	}
+
+	ts, ok := sp.archive.timestamps[height]
+	if !ok {
+		return 0, errors.Errorf("no revision was archived for block height %d", height)
+	}
+	return ts, nil
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/orbs-network/orbs-network-go/services/statestorage/adapter"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/pkg/errors"
 	"sort"
 	"strings"
 	"sync"
@@ -38,6 +39,7 @@ type InMemoryStatePersistence struct {
 	height     primitives.BlockHeight
 	ts         primitives.TimestampNano
 	merkleRoot primitives.Sha256
+	archive    *stateArchive
 }
 
 func NewStatePersistence(metricFactory metric.Factory) *InMemoryStatePersistence {
@@ -53,6 +55,13 @@ func NewStatePersistence(metricFactory metric.Factory) *InMemoryStatePersistence
 	}
 }
 
+// Also keeps every past revision of the state, so it can be read as of any block height written to it
+func NewArchivalStatePersistence(metricFactory metric.Factory) *InMemoryStatePersistence {
+	sp := NewStatePersistence(metricFactory)
+	sp.archive = newStateArchive()
+	return sp
+}
+
 func (sp *InMemoryStatePersistence) reportSize() {
 	nContracts := 0
 	nKeys := 0
@@ -68,7 +77,16 @@ func (sp *InMemoryStatePersistence) Write(height primitives.BlockHeight, ts prim
 	sp.mutex.Lock()
 	defer sp.mutex.Unlock()
 
+	if height <= sp.height && sp.height > 0 {
+		return errors.Errorf("cannot write state of block height %d over state of block height %d", height, sp.height)
+	}
+
+	if sp.archive != nil {
+		sp.archive.add(sp.height, height, ts, diff)
+	}
+
 	sp.height = height
+	sp.ts = ts
 	sp.merkleRoot = root
 
 	for contract, records := range diff {
@@ -108,6 +126,18 @@ func (sp *InMemoryStatePersistence) ReadMetadata() (primitives.BlockHeight, prim
 	return sp.height, sp.ts, sp.merkleRoot, nil
 }
 
+func (sp *InMemoryStatePersistence) Each(callback func(contract primitives.ContractName, record *protocol.StateRecord)) error {
+	sp.mutex.RLock()
+	defer sp.mutex.RUnlock()
+
+	for contract, records := range sp.fullState {
+		for _, record := range records {
+			callback(contract, record)
+		}
+	}
+	return nil
+}
+
 func (sp *InMemoryStatePersistence) Dump() string {
 	output := strings.Builder{}
 	output.WriteString("{")
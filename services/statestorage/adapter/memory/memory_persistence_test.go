@@ -32,13 +32,62 @@ func TestWriteStateAddAndRemoveKeyFromPersistentStorage(t *testing.T) {
 	require.EqualValues(t, "foo", record.Key(), "after writing a key/value it should be returned")
 	require.EqualValues(t, "bar", record.Value(), "after writing a key/value it should be returned")
 
-	d.writeSingleValueBlock(1, "foo", "foo", "")
+	d.writeSingleValueBlock(2, "foo", "foo", "")
 
 	_, ok, err = d.Read("foo", "foo")
 	require.NoError(t, err, "unexpected error")
 	require.EqualValues(t, false, ok, "writing zero value to state did not remove key")
 }
 
+func TestArchivalStatePersistence_ReadsPastRevisions(t *testing.T) {
+	d := &driver{NewArchivalStatePersistence(metric.NewRegistry())}
+
+	require.NoError(t, d.writeSingleValueBlock(1, "foo", "foo", "bar"))
+	require.NoError(t, d.writeSingleValueBlock(2, "foo", "other", "baz"))
+	require.NoError(t, d.writeSingleValueBlock(3, "foo", "foo", "bar3"))
+	require.NoError(t, d.writeSingleValueBlock(4, "foo", "foo", ""))
+
+	_, ok, err := d.ReadAt(0, "foo", "foo")
+	require.NoError(t, err)
+	require.False(t, ok, "key should not exist before it was written")
+
+	for h, expected := range map[primitives.BlockHeight]string{1: "bar", 2: "bar", 3: "bar3"} {
+		record, ok, err := d.ReadAt(h, "foo", "foo")
+		require.NoError(t, err)
+		require.True(t, ok, "key should exist at height %d", h)
+		require.EqualValues(t, expected, record.Value(), "unexpected value at height %d", h)
+	}
+
+	_, ok, err = d.ReadAt(4, "foo", "foo")
+	require.NoError(t, err)
+	require.False(t, ok, "key removed at height 4 should not exist")
+
+	_, _, err = d.ReadAt(5, "foo", "foo")
+	require.Error(t, err, "expected reading a height not yet written to fail")
+}
+
+func TestArchivalStatePersistence_StartsFromFirstWrittenHeight(t *testing.T) {
+	d := &driver{NewArchivalStatePersistence(metric.NewRegistry())}
+
+	require.NoError(t, d.writeSingleValueBlock(10, "foo", "foo", "bar")) // as if a state snapshot was imported
+
+	_, _, err := d.ReadAt(9, "foo", "foo")
+	require.Error(t, err, "expected reading a height before the archive starts to fail")
+
+	record, ok, err := d.ReadAt(10, "foo", "foo")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.EqualValues(t, "bar", record.Value())
+}
+
+func TestStatePersistence_ReadAtFailsWhenNotArchiving(t *testing.T) {
+	d := newDriver()
+	require.NoError(t, d.writeSingleValueBlock(1, "foo", "foo", "bar"))
+
+	_, _, err := d.ReadAt(1, "foo", "foo")
+	require.Error(t, err)
+}
+
 type driver struct {
 	*InMemoryStatePersistence
 }
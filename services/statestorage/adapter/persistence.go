@@ -7,6 +7,7 @@
 package adapter
 
 import (
+	"github.com/orbs-network/orbs-network-go/crypto/merkle"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/protocol"
 )
@@ -18,4 +19,18 @@ type StatePersistence interface {
 	Write(height primitives.BlockHeight, ts primitives.TimestampNano, root primitives.Sha256, diff ChainState) error
 	Read(contract primitives.ContractName, key string) (*protocol.StateRecord, bool, error)
 	ReadMetadata() (primitives.BlockHeight, primitives.TimestampNano, primitives.Sha256, error)
+	Each(callback func(contract primitives.ContractName, record *protocol.StateRecord)) error
+}
+
+// A state persistence which keeps every revision written to it, serving reads as of past block heights
+type ArchivalStatePersistence interface {
+	StatePersistence
+	ReadAt(height primitives.BlockHeight, contract primitives.ContractName, key string) (*protocol.StateRecord, bool, error)
+	ReadTimestampAt(height primitives.BlockHeight) (primitives.TimestampNano, error)
+}
+
+// A state persistence which also keeps the state merkle trie, so it is not rebuilt from the full state on restart
+type MerkleNodePersistence interface {
+	StatePersistence
+	MerkleNodeStore() merkle.NodeStore
 }
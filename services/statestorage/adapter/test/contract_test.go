@@ -0,0 +1,132 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/statestorage/adapter"
+	"github.com/orbs-network/orbs-network-go/services/statestorage/adapter/memory"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func withEachAdapter(t *testing.T, testFunc func(t *testing.T, adapter adapter.StatePersistence)) {
+	adapters := []*adapterUnderTest{
+		newInMemoryAdapter(t),
+		newFilesystemAdapter(t),
+	}
+	for _, a := range adapters {
+		t.Run(a.name, func(t *testing.T) {
+			defer a.cleanup()
+			testFunc(t, a.adapter)
+		})
+	}
+}
+
+func TestStatePersistenceContract_ReadMetadataWhenEmptyReturnsGenesis(t *testing.T) {
+	withEachAdapter(t, func(t *testing.T, adapter adapter.StatePersistence) {
+		height, ts, _, err := adapter.ReadMetadata()
+		require.NoError(t, err)
+		require.EqualValues(t, 0, height)
+		require.EqualValues(t, 0, ts)
+	})
+}
+
+func TestStatePersistenceContract_ReadNonExistingKey(t *testing.T) {
+	withEachAdapter(t, func(t *testing.T, adapter adapter.StatePersistence) {
+		_, ok, err := adapter.Read("foo", "bar")
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+}
+
+func TestStatePersistenceContract_WritesAndReadsMetadata(t *testing.T) {
+	withEachAdapter(t, func(t *testing.T, adapter adapter.StatePersistence) {
+		require.NoError(t, writeSingleValueBlock(adapter, 1, "foo", "foo", "bar"))
+		require.NoError(t, writeSingleValueBlock(adapter, 2, "foo", "foo", "baz"))
+
+		height, ts, root, err := adapter.ReadMetadata()
+		require.NoError(t, err)
+		require.EqualValues(t, 2, height)
+		require.EqualValues(t, 2000, ts)
+		require.EqualValues(t, primitives.Sha256{2}, root)
+	})
+}
+
+func TestStatePersistenceContract_WriteAddsAndRemovesKeys(t *testing.T) {
+	withEachAdapter(t, func(t *testing.T, adapter adapter.StatePersistence) {
+		require.NoError(t, writeSingleValueBlock(adapter, 1, "foo", "foo", "bar"))
+		requireValue(t, adapter, "foo", "foo", "bar")
+
+		require.NoError(t, writeSingleValueBlock(adapter, 2, "foo", "foo", ""))
+		_, ok, err := adapter.Read("foo", "foo")
+		require.NoError(t, err)
+		require.False(t, ok, "writing zero value to state did not remove key")
+	})
+}
+
+func TestStatePersistenceContract_RejectsWriteOfPastHeight(t *testing.T) {
+	withEachAdapter(t, func(t *testing.T, adapter adapter.StatePersistence) {
+		require.NoError(t, writeSingleValueBlock(adapter, 2, "foo", "foo", "bar"))
+		require.Error(t, writeSingleValueBlock(adapter, 1, "foo", "foo", "baz"), "writing state of a past height should be rejected")
+
+		height, _, _, err := adapter.ReadMetadata()
+		require.NoError(t, err)
+		require.EqualValues(t, 2, height)
+		requireValue(t, adapter, "foo", "foo", "bar")
+	})
+}
+
+func TestStatePersistenceContract_EachVisitsEveryRecord(t *testing.T) {
+	withEachAdapter(t, func(t *testing.T, adapter adapter.StatePersistence) {
+		require.NoError(t, writeSingleValueBlock(adapter, 1, "foo", "k1", "v1"))
+		require.NoError(t, writeSingleValueBlock(adapter, 2, "bar", "k2", "v2"))
+		require.NoError(t, writeSingleValueBlock(adapter, 3, "foo", "k3", "v3"))
+
+		visited := map[string]string{}
+		err := adapter.Each(func(contract primitives.ContractName, record *protocol.StateRecord) {
+			visited[string(contract)+"/"+string(record.Key())] = string(record.Value())
+		})
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"foo/k1": "v1", "bar/k2": "v2", "foo/k3": "v3"}, visited)
+	})
+}
+
+type adapterUnderTest struct {
+	name    string
+	adapter adapter.StatePersistence
+	cleanup func()
+}
+
+func newInMemoryAdapter(tb testing.TB) *adapterUnderTest {
+	return &adapterUnderTest{
+		name:    "In Memory Adapter",
+		adapter: memory.NewStatePersistence(metric.NewRegistry()),
+		cleanup: func() {},
+	}
+}
+
+func newFilesystemAdapter(tb testing.TB) *adapterUnderTest {
+	conf := newTempFileConfig()
+
+	persistence, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(tb), conf)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return &adapterUnderTest{
+		name:    "File System Adapter",
+		adapter: persistence,
+		cleanup: func() {
+			closeAdapter()
+			conf.cleanDir()
+		},
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/statestorage/adapter"
+	"github.com/orbs-network/orbs-network-go/services/statestorage/adapter/filesystem"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const stateFilename = "state"
+const nodesFilename = "merkle"
+
+func NewFilesystemAdapterDriver(logger log.Logger, conf *localConfig) (*filesystem.FilesystemStatePersistence, func(), error) {
+	ctx, cancelCtx := context.WithCancel(context.Background())
+
+	persistence, err := filesystem.NewStatePersistence(ctx, conf, logger, metric.NewRegistry())
+	if err != nil {
+		cancelCtx()
+		return nil, nil, err
+	}
+
+	closeAdapter := func() {
+		cancelCtx()
+		time.Sleep(100 * time.Millisecond) // time to release any lock
+	}
+
+	return persistence, closeAdapter, nil
+}
+
+type localConfig struct {
+	dir      string
+	chainId  primitives.VirtualChainId
+	archival bool
+}
+
+func newTempFileConfig() *localConfig {
+	dirName, err := ioutil.TempDir("", "contract_test_state_persist")
+	if err != nil {
+		panic(err)
+	}
+	return &localConfig{
+		dir:     dirName,
+		chainId: 0xFF,
+	}
+}
+
+func (l *localConfig) StateStorageFileSystemDataDir() string {
+	return l.dir
+}
+
+func (l *localConfig) StateStorageArchivalMode() bool {
+	return l.archival
+}
+
+func (l *localConfig) VirtualChainId() primitives.VirtualChainId {
+	return l.chainId
+}
+
+func (l *localConfig) cleanDir() {
+	_ = os.RemoveAll(l.StateStorageFileSystemDataDir()) // ignore errors - nothing to do
+}
+
+func (l *localConfig) setVirtualChainId(id primitives.VirtualChainId) {
+	l.chainId = id
+}
+
+func (l *localConfig) setArchivalMode(archival bool) {
+	l.archival = archival
+}
+
+func getFileSize(t *testing.T, conf *localConfig) int64 {
+	info, err := os.Stat(filepath.Join(conf.StateStorageFileSystemDataDir(), stateFilename))
+	require.NoError(t, err)
+	return info.Size()
+}
+
+func truncateFile(t *testing.T, conf *localConfig, size int64) {
+	err := os.Truncate(filepath.Join(conf.StateStorageFileSystemDataDir(), stateFilename), size)
+	require.NoError(t, err)
+}
+
+func writeSingleValueBlock(p adapter.StatePersistence, h primitives.BlockHeight, c, k, v string) error {
+	record := (&protocol.StateRecordBuilder{Key: []byte(k), Value: []byte(v)}).Build()
+	diff := adapter.ChainState{primitives.ContractName(c): {k: record}}
+	return p.Write(h, primitives.TimestampNano(h*1000), primitives.Sha256{byte(h)}, diff)
+}
+
+func requireValue(t *testing.T, p adapter.StatePersistence, c, k, expected string) {
+	record, ok, err := p.Read(primitives.ContractName(c), k)
+	require.NoError(t, err)
+	require.True(t, ok, "expected key %s to exist in contract %s", k, c)
+	require.EqualValues(t, expected, record.Value())
+}
+
+func getNodesFileSize(t *testing.T, conf *localConfig) int64 {
+	info, err := os.Stat(filepath.Join(conf.StateStorageFileSystemDataDir(), nodesFilename))
+	require.NoError(t, err)
+	return info.Size()
+}
+
+func truncateNodesFile(t *testing.T, conf *localConfig, size int64) {
+	err := os.Truncate(filepath.Join(conf.StateStorageFileSystemDataDir(), nodesFilename), size)
+	require.NoError(t, err)
+}
@@ -0,0 +1,177 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/scribe/log"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFileSystemStatePersistence_ResumesFromLastWrittenHeight(t *testing.T) {
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+
+	persistence, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+	require.NoError(t, writeSingleValueBlock(persistence, 1, "foo", "k1", "v1"))
+	require.NoError(t, writeSingleValueBlock(persistence, 2, "foo", "k2", "v2"))
+	require.NoError(t, writeSingleValueBlock(persistence, 3, "foo", "k1", ""))
+	closeAdapter()
+
+	reopened, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+	defer closeAdapter()
+
+	height, ts, root, err := reopened.ReadMetadata()
+	require.NoError(t, err)
+	require.EqualValues(t, 3, height)
+	require.EqualValues(t, 3000, ts)
+	require.EqualValues(t, primitives.Sha256{3}, root)
+
+	requireValue(t, reopened, "foo", "k2", "v2")
+	_, ok, err := reopened.Read("foo", "k1")
+	require.NoError(t, err)
+	require.False(t, ok, "key removed before restart should not exist")
+}
+
+func TestFileSystemStatePersistence_RestoresArchiveOfPastRevisions(t *testing.T) {
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+	conf.setArchivalMode(true)
+
+	persistence, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+	require.NoError(t, writeSingleValueBlock(persistence, 1, "foo", "k1", "v1"))
+	require.NoError(t, writeSingleValueBlock(persistence, 2, "foo", "k1", "v2"))
+	require.NoError(t, writeSingleValueBlock(persistence, 3, "foo", "k1", ""))
+	closeAdapter()
+
+	reopened, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+	defer closeAdapter()
+
+	record, ok, err := reopened.ReadAt(1, "foo", "k1")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.EqualValues(t, "v1", record.Value())
+
+	ts, err := reopened.ReadTimestampAt(2)
+	require.NoError(t, err)
+	require.EqualValues(t, 2000, ts)
+
+	_, ok, err = reopened.ReadAt(3, "foo", "k1")
+	require.NoError(t, err)
+	require.False(t, ok, "key removed at height 3 should not exist")
+}
+
+func TestFileSystemStatePersistence_ArchiveCatchesUpFromStateFile(t *testing.T) {
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+
+	persistence, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+	require.NoError(t, writeSingleValueBlock(persistence, 1, "foo", "k1", "v1"))
+	require.NoError(t, writeSingleValueBlock(persistence, 2, "foo", "k1", "v2"))
+	closeAdapter()
+
+	conf.setArchivalMode(true)
+	reopened, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+	defer closeAdapter()
+
+	record, ok, err := reopened.ReadAt(1, "foo", "k1")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.EqualValues(t, "v1", record.Value(), "expected archive to be built from the diffs in the state file")
+
+	require.NoError(t, writeSingleValueBlock(reopened, 3, "foo", "k1", "v3"))
+	record, ok, err = reopened.ReadAt(2, "foo", "k1")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.EqualValues(t, "v2", record.Value())
+}
+
+func TestFileSystemStatePersistence_RecoverFromPartiallyWrittenEntry(t *testing.T) {
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+
+	persistence, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+	require.NoError(t, writeSingleValueBlock(persistence, 1, "foo", "k1", "v1"))
+	sizeAfterFirstEntry := getFileSize(t, conf)
+	require.NoError(t, writeSingleValueBlock(persistence, 2, "foo", "k2", "v2"))
+	closeAdapter()
+
+	truncateFile(t, conf, getFileSize(t, conf)-3) // cut some bytes from the last entry
+
+	reopened, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLoggerAllowingErrors(t, "replayed state file, found and ignoring invalid entry"), conf)
+	require.NoError(t, err)
+	defer closeAdapter()
+
+	height, _, _, err := reopened.ReadMetadata()
+	require.NoError(t, err)
+	require.EqualValues(t, 1, height, "expected partially written entry to be ignored")
+	require.Equal(t, sizeAfterFirstEntry, getFileSize(t, conf), "expected partially written entry to be truncated")
+
+	require.NoError(t, writeSingleValueBlock(reopened, 2, "foo", "k2", "v2")) // re-append lost entry
+	requireValue(t, reopened, "foo", "k2", "v2")
+}
+
+func TestFileSystemStatePersistence_RejectedWriteIsNotReplayedOnReopen(t *testing.T) {
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+
+	persistence, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+	require.NoError(t, writeSingleValueBlock(persistence, 2, "foo", "k1", "v1"))
+	sizeAfterFirstEntry := getFileSize(t, conf)
+	require.Error(t, writeSingleValueBlock(persistence, 1, "foo", "k1", "v2"))
+	require.Equal(t, sizeAfterFirstEntry, getFileSize(t, conf), "expected rejected entry to be removed from the state file")
+	closeAdapter()
+
+	reopened, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+	defer closeAdapter()
+
+	height, _, _, err := reopened.ReadMetadata()
+	require.NoError(t, err)
+	require.EqualValues(t, 2, height)
+	requireValue(t, reopened, "foo", "k1", "v1")
+	require.NoError(t, writeSingleValueBlock(reopened, 3, "foo", "k1", "v3"))
+}
+
+func TestFileSystemStatePersistence_DetectsVirtualChainMismatch(t *testing.T) {
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+
+	_, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+	closeAdapter()
+
+	conf.setVirtualChainId(conf.VirtualChainId() + 1)
+	_, _, err = NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.Error(t, err, "expected to fail opening a state file of a different virtual chain")
+}
+
+func TestFileSystemStatePersistence_TakesExclusiveLock(t *testing.T) {
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+
+	_, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+
+	_, _, err = NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.Error(t, err, "expected second adapter on the same file to fail obtaining a lock")
+
+	closeAdapter()
+
+	_, closeAdapter, err = NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err, "expected to obtain a lock after the first adapter closed")
+	closeAdapter()
+}
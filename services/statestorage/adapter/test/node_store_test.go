@@ -0,0 +1,91 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"github.com/orbs-network/orbs-network-go/crypto/hash"
+	"github.com/orbs-network/orbs-network-go/crypto/merkle"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/scribe/log"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFileSystemNodeStore_RestoresMerkleRootsAfterRestart(t *testing.T) {
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+
+	persistence, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+	forest, emptyRoot, err := merkle.NewForestWithNodeStore(persistence.MerkleNodeStore())
+	require.NoError(t, err)
+	root1 := updateForest(t, forest, emptyRoot, "k1", "v1", "k2", "v2")
+	root2 := updateForest(t, forest, root1, "k1", "v3")
+	forest.Forget(emptyRoot)
+	forest.Forget(root1)
+	closeAdapter()
+
+	reopened, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+	defer closeAdapter()
+
+	roots, err := reopened.MerkleNodeStore().Roots()
+	require.NoError(t, err)
+	require.Equal(t, []primitives.Sha256{root2}, roots)
+
+	restored, _, err := merkle.NewForestWithNodeStore(reopened.MerkleNodeStore())
+	require.NoError(t, err)
+	requireForestValue(t, restored, root2, "k1", "v3")
+	requireForestValue(t, restored, root2, "k2", "v2")
+}
+
+func TestFileSystemNodeStore_RecoverFromPartiallyWrittenEntry(t *testing.T) {
+	conf := newTempFileConfig()
+	defer conf.cleanDir()
+
+	persistence, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLogger(t), conf)
+	require.NoError(t, err)
+	forest, emptyRoot, err := merkle.NewForestWithNodeStore(persistence.MerkleNodeStore())
+	require.NoError(t, err)
+	root1 := updateForest(t, forest, emptyRoot, "k1", "v1")
+	sizeAfterRoot1 := getNodesFileSize(t, conf)
+	updateForest(t, forest, root1, "k1", "v2")
+	closeAdapter()
+
+	truncateNodesFile(t, conf, getNodesFileSize(t, conf)-3) // cut some bytes from the last entry
+
+	reopened, closeAdapter, err := NewFilesystemAdapterDriver(log.DefaultTestingLoggerAllowingErrors(t, "replayed merkle nodes file, found and ignoring invalid entry"), conf)
+	require.NoError(t, err)
+	defer closeAdapter()
+
+	roots, err := reopened.MerkleNodeStore().Roots()
+	require.NoError(t, err)
+	require.Equal(t, []primitives.Sha256{emptyRoot, root1}, roots, "expected partially written entry to be ignored")
+	require.Equal(t, sizeAfterRoot1, getNodesFileSize(t, conf), "expected partially written entry to be truncated")
+
+	restored, _, err := merkle.NewForestWithNodeStore(reopened.MerkleNodeStore())
+	require.NoError(t, err)
+	requireForestValue(t, restored, root1, "k1", "v1")
+}
+
+func updateForest(t *testing.T, forest *merkle.Forest, root primitives.Sha256, keyValues ...string) primitives.Sha256 {
+	diffs := make(merkle.TrieDiffs, 0, len(keyValues)/2)
+	for i := 0; i < len(keyValues); i += 2 {
+		diffs = append(diffs, &merkle.TrieDiff{Key: hash.CalcSha256([]byte(keyValues[i])), Value: hash.CalcSha256([]byte(keyValues[i+1]))})
+	}
+	newRoot, err := forest.Update(root, diffs)
+	require.NoError(t, err)
+	return newRoot
+}
+
+func requireForestValue(t *testing.T, forest *merkle.Forest, root primitives.Sha256, key, value string) {
+	proof, err := forest.GetProof(root, hash.CalcSha256([]byte(key)))
+	require.NoError(t, err)
+	verified, err := forest.Verify(root, proof, hash.CalcSha256([]byte(key)), hash.CalcSha256([]byte(value)))
+	require.NoError(t, err)
+	require.True(t, verified, "expected key %s to have value %s", key, value)
+}
@@ -22,6 +22,7 @@ import (
 type merkleRevisions interface {
 	Update(rootMerkle primitives.Sha256, diffs merkle.TrieDiffs) (primitives.Sha256, error)
 	Forget(rootHash primitives.Sha256)
+	GetProof(rootHash primitives.Sha256, path []byte) (*merkle.TrieProof, error)
 }
 
 type revisionDiff struct {
@@ -43,9 +44,10 @@ type rollingRevisions struct {
 	persistedHeight    primitives.BlockHeight
 	persistedRoot      primitives.Sha256
 	persistedTs        primitives.TimestampNano
+	archive            adapter.ArchivalStatePersistence // nil unless past revisions are kept
 }
 
-func newRollingRevisions(logger log.Logger, persist adapter.StatePersistence, transientRevisions int, merkle merkleRevisions) *rollingRevisions {
+func newRollingRevisions(logger log.Logger, persist adapter.StatePersistence, transientRevisions int, merkle merkleRevisions, archive adapter.ArchivalStatePersistence) *rollingRevisions {
 	h, ts, r, err := persist.ReadMetadata()
 	if err != nil {
 		panic(fmt.Sprintf("could not load state metadata, err=%s", err.Error()))
@@ -62,6 +64,7 @@ func newRollingRevisions(logger log.Logger, persist adapter.StatePersistence, tr
 		persistedHeight:    h,
 		persistedTs:        ts,
 		persistedRoot:      r,
+		archive:            archive,
 	}
 
 	return result
@@ -104,7 +107,7 @@ func toMerkleInput(diff adapter.ChainState) merkle.TrieDiffs {
 	for contractName, contractState := range diff {
 		for _, r := range contractState {
 			result = append(result, &merkle.TrieDiff{
-				Key:   hash.CalcSha256([]byte(contractName), r.Key()),
+				Key:   toMerkleKey(contractName, r.Key()),
 				Value: hash.CalcSha256(r.Value()),
 			})
 		}
@@ -112,6 +115,73 @@ func toMerkleInput(diff adapter.ChainState) merkle.TrieDiffs {
 	return result
 }
 
+func toMerkleKey(contract primitives.ContractName, key []byte) []byte {
+	return hash.CalcSha256([]byte(contract), key)
+}
+
+// a persistence which keeps the merkle trie nodes lets the forest keep its roots across restarts
+func newMerkleForest(persist adapter.StatePersistence) (*merkle.Forest, primitives.Sha256, error) {
+	if p, ok := persist.(adapter.MerkleNodePersistence); ok {
+		return merkle.NewForestWithNodeStore(p.MerkleNodeStore())
+	}
+	forest, emptyRoot := merkle.NewForest()
+	return forest, emptyRoot, nil
+}
+
+// a persisted state which survived a restart has a merkle root unknown to a freshly created forest,
+// unless the forest kept it in its node store. roots of revisions which were not persisted are forgotten
+func restoreMerkleForest(forest *merkle.Forest, emptyRoot primitives.Sha256, persist adapter.StatePersistence) error {
+	height, _, persistedRoot, err := persist.ReadMetadata()
+	if err != nil {
+		return errors.Wrap(err, "could not load state metadata")
+	}
+	if height == 0 {
+		forgetRootsExcept(forest, emptyRoot)
+		return nil
+	}
+	for _, root := range forest.Roots() {
+		if root.Equal(persistedRoot) {
+			forgetRootsExcept(forest, persistedRoot)
+			return nil
+		}
+	}
+	forgetRootsExcept(forest, emptyRoot)
+
+	fullState := make(adapter.ChainState)
+	err = persist.Each(func(contract primitives.ContractName, record *protocol.StateRecord) {
+		if _, ok := fullState[contract]; !ok {
+			fullState[contract] = make(adapter.ContractState)
+		}
+		fullState[contract][string(record.Key())] = record
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not read persisted state")
+	}
+
+	root, err := forest.Update(emptyRoot, toMerkleInput(fullState))
+	if err != nil {
+		return errors.Wrap(err, "failed to updated merkle tree")
+	}
+	if !root.Equal(persistedRoot) {
+		return errors.Errorf("merkle root of persisted state at block height %d is %s while persisted root is %s", height, root, persistedRoot)
+	}
+	forest.Forget(emptyRoot)
+
+	return nil
+}
+
+// keeps a single root of the given hash
+func forgetRootsExcept(forest *merkle.Forest, rootHash primitives.Sha256) {
+	kept := false
+	for _, root := range forest.Roots() {
+		if !kept && root.Equal(rootHash) {
+			kept = true
+			continue
+		}
+		forest.Forget(root)
+	}
+}
+
 func (ls *rollingRevisions) evictRevisions() error {
 	for len(ls.revisions) > ls.transientRevisions {
 		d := ls.revisions[0]
@@ -144,11 +214,30 @@ func (ls *rollingRevisions) getRevisionRecord(height primitives.BlockHeight, con
 	}
 
 	if ls.persistedHeight > height {
+		if ls.archive != nil {
+			return ls.archive.ReadAt(height, contract, key)
+		}
 		return nil, false, errors.Errorf("requested height %d is too old. oldest available block height is %d", height, ls.persistedHeight)
 	}
 	return ls.persist.Read(contract, key)
 }
 
+func (ls *rollingRevisions) getRevisionTimestamp(height primitives.BlockHeight) (primitives.TimestampNano, error) {
+	for i := len(ls.revisions) - 1; i >= 0; i-- {
+		if ls.revisions[i].height == height {
+			return ls.revisions[i].ts, nil
+		}
+	}
+
+	if height == ls.persistedHeight {
+		return ls.persistedTs, nil
+	}
+	if ls.archive != nil && height < ls.persistedHeight {
+		return ls.archive.ReadTimestampAt(height)
+	}
+	return 0, fmt.Errorf("could not locate timestamp for height %d. oldest available block height is %d", height, ls.persistedHeight)
+}
+
 func (ls *rollingRevisions) getRevisionHash(height primitives.BlockHeight) (primitives.Sha256, error) {
 	for i := len(ls.revisions) - 1; i >= 0; i-- {
 		if ls.revisions[i].height == height {
@@ -163,6 +252,20 @@ func (ls *rollingRevisions) getRevisionHash(height primitives.BlockHeight) (prim
 	return ls.persistedRoot, nil
 }
 
+// merkle roots are kept only for the transient revisions and the persisted one, even in archival mode
+func (ls *rollingRevisions) getRevisionProof(height primitives.BlockHeight, contract primitives.ContractName, key string) (primitives.Sha256, *merkle.TrieProof, error) {
+	root, err := ls.getRevisionHash(height)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	proof, err := ls.merkle.GetProof(root, toMerkleKey(contract, []byte(key)))
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "could not generate merkle proof for height %d", height)
+	}
+	return root, proof, nil
+}
+
 func isZeroValue(value []byte) bool {
 	return bytes.Equal(value, []byte{})
 }
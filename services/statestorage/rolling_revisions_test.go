@@ -172,7 +172,7 @@ func newDriver(tb testing.TB, persistence adapter.StatePersistence, layers int,
 		m.When("Forget", mock.Any).Return(nil).Times(1)
 	}
 	d := &driver{
-		inner: newRollingRevisions(log.DefaultTestingLogger(tb), persistence, layers, m),
+		inner: newRollingRevisions(log.DefaultTestingLogger(tb), persistence, layers, m, nil),
 	}
 	return d
 }
@@ -229,6 +229,9 @@ func (spm *StatePersistenceMock) Read(contract primitives.ContractName, key stri
 func (spm *StatePersistenceMock) ReadMetadata() (primitives.BlockHeight, primitives.TimestampNano, primitives.Sha256, error) {
 	return 0, 0, primitives.Sha256{}, nil
 }
+func (spm *StatePersistenceMock) Each(callback func(contract primitives.ContractName, record *protocol.StateRecord)) error {
+	return nil
+}
 
 type MerkleMock struct {
 	mock.Mock
@@ -253,3 +256,7 @@ func (mm *MerkleMock) Update(rootMerkle primitives.Sha256, diffs merkle.TrieDiff
 func (mm *MerkleMock) Forget(rootHash primitives.Sha256) {
 	mm.Mock.Called(rootHash)
 }
+func (mm *MerkleMock) GetProof(rootHash primitives.Sha256, path []byte) (*merkle.TrieProof, error) {
+	ret := mm.Mock.Called(rootHash, path)
+	return ret.Get(0).(*merkle.TrieProof), ret.Error(1)
+}
@@ -40,6 +40,13 @@ func newMetrics(m metric.Factory) *metrics {
 	}
 }
 
+// Reads of past block heights served besides services.StateStorage, which only reads the state of recent ones.
+// The virtual machine runs queries as of a past block with them, and the public api proves state values
+type HistoricalState interface {
+	GetStateStorageBlockTimestamp(ctx context.Context, blockHeight primitives.BlockHeight) (primitives.TimestampNano, error)
+	GetStateProof(ctx context.Context, blockHeight primitives.BlockHeight, contract primitives.ContractName, key []byte) (primitives.Sha256, []byte, *merkle.TrieProof, error)
+}
+
 type service struct {
 	config         config.StateStorageConfig
 	blockTracker   *synchronization.BlockTracker
@@ -47,28 +54,71 @@ type service struct {
 	logger         log.Logger
 	metrics        *metrics
 
-	mutex     sync.RWMutex
-	revisions *rollingRevisions
+	mutex       sync.RWMutex
+	revisions   *rollingRevisions
+	persistence adapter.StatePersistence
+	forest      *merkle.Forest
+	emptyRoot   primitives.Sha256
+
+	// a state loaded from persistence is trusted only once it matches the next committed block
+	unverifiedPersistedHeight primitives.BlockHeight
+
+	// a snapshot is imported only once block sync commits the block following it, and only if that block expects its merkle root
+	pendingSnapshot *StateSnapshot
 }
 
 func NewStateStorage(config config.StateStorageConfig, persistence adapter.StatePersistence, heightReporter adapter.BlockHeightReporter, parent log.Logger, metricFactory metric.Factory) services.StateStorage {
-	forest, _ := merkle.NewForest()
 	logger := parent.WithTags(LogTag)
 	if heightReporter == nil {
 		heightReporter = synchronization.NopHeightReporter{}
 	}
+	forest, emptyRoot, err := newMerkleForest(persistence)
+	if err != nil {
+		panic(fmt.Sprintf("could not load state merkle tree, err=%s", err.Error()))
+	}
+	if err := restoreMerkleForest(forest, emptyRoot, persistence); err != nil {
+		panic(fmt.Sprintf("could not restore state merkle tree, err=%s", err.Error()))
+	}
+	persistedHeight, _, _, err := persistence.ReadMetadata()
+	if err != nil {
+		panic(fmt.Sprintf("could not load state metadata, err=%s", err.Error()))
+	}
+	var archive adapter.ArchivalStatePersistence
+	if config.StateStorageArchivalMode() {
+		var ok bool
+		if archive, ok = persistence.(adapter.ArchivalStatePersistence); !ok {
+			panic("archival mode is configured but state persistence does not keep past revisions")
+		}
+	}
+
 	return &service{
 		config:         config,
-		blockTracker:   synchronization.NewBlockTracker(logger, 0, uint16(config.BlockTrackerGraceDistance())),
+		blockTracker:   synchronization.NewBlockTracker(logger, uint64(persistedHeight), uint16(config.BlockTrackerGraceDistance())),
 		heightReporter: heightReporter,
 		logger:         logger,
 		metrics:        newMetrics(metricFactory),
 
-		mutex:     sync.RWMutex{},
-		revisions: newRollingRevisions(logger, persistence, int(config.StateStorageHistorySnapshotNum()), forest),
+		mutex:       sync.RWMutex{},
+		revisions:   newRollingRevisions(logger, persistence, int(config.StateStorageHistorySnapshotNum()), forest, archive),
+		persistence: persistence,
+		forest:      forest,
+		emptyRoot:   emptyRoot,
+
+		unverifiedPersistedHeight: persistedHeight,
 	}
 }
 
+// Starts from an empty state and skips the blocks the snapshot covers. The snapshot, already checked by VerifyStateSnapshot,
+// is imported once block sync commits the block following it, if that block expects the merkle root of the snapshot.
+// Otherwise the snapshot is discarded and the state is rebuilt from the blocks. A node which has state of its own ignores it
+func NewStateStorageImportingSnapshot(config config.StateStorageConfig, persistence adapter.StatePersistence, snapshot *StateSnapshot, heightReporter adapter.BlockHeightReporter, parent log.Logger, metricFactory metric.Factory) services.StateStorage {
+	s := NewStateStorage(config, persistence, heightReporter, parent, metricFactory).(*service)
+	if s.revisions.getCurrentHeight() == 0 {
+		s.pendingSnapshot = snapshot
+	}
+	return s
+}
+
 func (s *service) CommitStateDiff(ctx context.Context, input *services.CommitStateDiffInput) (*services.CommitStateDiffOutput, error) {
 	logger := s.logger.WithTags(trace.LogFieldFrom(ctx))
 
@@ -84,12 +134,39 @@ func (s *service) CommitStateDiff(ctx context.Context, input *services.CommitSta
 
 	logger.Info("trying to commit state diff", logfields.BlockHeight(commitBlockHeight), log.Int("number-of-state-diffs", len(input.ContractStateDiffs)))
 
+	if snapshot := s.pendingSnapshot; snapshot != nil {
+		if commitBlockHeight != snapshot.Height+1 {
+			return &services.CommitStateDiffOutput{NextDesiredBlockHeight: snapshot.Height + 1}, nil
+		}
+
+		s.pendingSnapshot = nil
+		if !snapshot.MerkleRoot.Equal(input.ResultsBlockHeader.PreExecutionStateMerkleRootHash()) {
+			logger.Error("discarding state snapshot which does not match the block following it, rebuilding the state from the blocks", log.Stringable("snapshot-merkle-root", snapshot.MerkleRoot), log.Stringable("expected-merkle-root", input.ResultsBlockHeader.PreExecutionStateMerkleRootHash()), logfields.BlockHeight(commitBlockHeight))
+			return &services.CommitStateDiffOutput{NextDesiredBlockHeight: s.revisions.getCurrentHeight() + 1}, nil
+		}
+
+		err := s.importSnapshot(snapshot)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to import state snapshot of block height %d", snapshot.Height)
+		}
+		logger.Info("imported state snapshot matching the block following it", logfields.BlockHeight(snapshot.Height))
+	}
+
 	currentHeight := s.revisions.getCurrentHeight()
 	if currentHeight+1 != commitBlockHeight {
 		return &services.CommitStateDiffOutput{NextDesiredBlockHeight: currentHeight + 1}, nil
 	}
 
 	// TODO(v1) assert input.ResultsBlockHeader.PreExecutionStateRootHash() == s.revisions.getRevisionHash(commitBlockHeight - 1)
+	if s.unverifiedPersistedHeight > 0 {
+		err := s.verifyPersistedState(input.ResultsBlockHeader)
+		if err != nil {
+			// the block is already in block storage, so a restart fails on VerifyPersistedState until the state is rebuilt
+			logger.Error("persisted state does not match committed block, node must be restarted", log.Error(err), logfields.BlockHeight(commitBlockHeight))
+			return &services.CommitStateDiffOutput{NextDesiredBlockHeight: commitBlockHeight}, err
+		}
+		s.unverifiedPersistedHeight = 0
+	}
 
 	err := s.revisions.addRevision(commitBlockHeight, commitTimestamp, inflateChainState(input.ContractStateDiffs))
 	if err != nil {
@@ -105,6 +182,57 @@ func (s *service) CommitStateDiff(ctx context.Context, input *services.CommitSta
 	return &services.CommitStateDiffOutput{NextDesiredBlockHeight: commitBlockHeight + 1}, nil
 }
 
+// the snapshot matched the block following it already, so the imported state needs no further verification
+func (s *service) importSnapshot(snapshot *StateSnapshot) error {
+	err := writeStateSnapshot(snapshot, s.persistence)
+	if err != nil {
+		return err
+	}
+	err = restoreMerkleForest(s.forest, s.emptyRoot, s.persistence)
+	if err != nil {
+		return err
+	}
+	s.revisions = newRollingRevisions(s.logger, s.persistence, int(s.config.StateStorageHistorySnapshotNum()), s.forest, s.revisions.archive)
+
+	for h := primitives.BlockHeight(1); h <= snapshot.Height; h++ {
+		s.blockTracker.IncrementTo(h)
+		s.heightReporter.IncrementTo(h)
+	}
+	s.metrics.blockHeight.Update(int64(snapshot.Height))
+	return nil
+}
+
+func (s *service) verifyPersistedState(header *protocol.ResultsBlockHeader) error {
+	persistedRoot, err := s.revisions.getRevisionHash(s.unverifiedPersistedHeight)
+	if err != nil {
+		return errors.Wrapf(err, "could not find a merkle root for persisted block height %d", s.unverifiedPersistedHeight)
+	}
+	return verifyPersistedRoot(s.unverifiedPersistedHeight, persistedRoot, header)
+}
+
+// Checks the persisted state against the header of the block following it, which holds the merkle root the state should
+// have. Meant for node startup, so a node whose state can never commit another block stops right away
+func VerifyPersistedState(persistence adapter.StatePersistence, nextBlockHeader *protocol.ResultsBlockHeader) error {
+	height, _, root, err := persistence.ReadMetadata()
+	if err != nil {
+		return err
+	}
+	if height == 0 || nextBlockHeader.BlockHeight() != height+1 {
+		return nil
+	}
+	return verifyPersistedRoot(height, root, nextBlockHeader)
+}
+
+func verifyPersistedRoot(height primitives.BlockHeight, root primitives.Sha256, nextBlockHeader *protocol.ResultsBlockHeader) error {
+	if !root.Equal(nextBlockHeader.PreExecutionStateMerkleRootHash()) {
+		return errors.Errorf("persisted state merkle root at block height %d is %s while block %d expects pre-execution state merkle root %s. "+
+			"The state files are corrupt or were imported from a snapshot of another chain: stop the node, delete the state files "+
+			"and start it again to rebuild the state from the blocks, or set STATE_STORAGE_SNAPSHOT_URL to a trusted node to import its state",
+			height, root, nextBlockHeader.BlockHeight(), nextBlockHeader.PreExecutionStateMerkleRootHash())
+	}
+	return nil
+}
+
 func (s *service) ReadKeys(ctx context.Context, input *services.ReadKeysInput) (*services.ReadKeysOutput, error) {
 	if input.ContractName == "" {
 		return nil, errors.Errorf("missing contract name")
@@ -121,7 +249,7 @@ func (s *service) ReadKeys(ctx context.Context, input *services.ReadKeysInput) (
 	defer s.mutex.RUnlock()
 
 	currentHeight := s.revisions.getCurrentHeight()
-	if input.BlockHeight+primitives.BlockHeight(s.config.StateStorageHistorySnapshotNum()) <= currentHeight {
+	if !s.config.StateStorageArchivalMode() && input.BlockHeight+primitives.BlockHeight(s.config.StateStorageHistorySnapshotNum()) <= currentHeight {
 		return nil, errors.Errorf("unsupported block height: block %v too old. currently at %v. keeping %v back", input.BlockHeight, currentHeight, primitives.BlockHeight(s.config.StateStorageHistorySnapshotNum()))
 	}
 
@@ -159,6 +287,47 @@ func (s *service) GetStateStorageBlockHeight(ctx context.Context, input *service
 	return result, nil
 }
 
+func (s *service) GetStateStorageBlockTimestamp(ctx context.Context, blockHeight primitives.BlockHeight) (primitives.TimestampNano, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if currentHeight := s.revisions.getCurrentHeight(); blockHeight > currentHeight {
+		return 0, errors.Errorf("unsupported block height: block %d is not yet committed. currently at %d", blockHeight, currentHeight)
+	}
+	return s.revisions.getRevisionTimestamp(blockHeight)
+}
+
+// Proves a key's value against the state merkle root of a block height. A missing key is returned with the zero value and a proof of its exclusion.
+func (s *service) GetStateProof(ctx context.Context, blockHeight primitives.BlockHeight, contract primitives.ContractName, key []byte) (primitives.Sha256, []byte, *merkle.TrieProof, error) {
+	if contract == "" {
+		return nil, nil, nil, errors.Errorf("missing contract name")
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, s.config.BlockTrackerGraceTimeout())
+	defer cancel()
+	if err := s.blockTracker.WaitForBlock(timeoutCtx, blockHeight); err != nil {
+		return nil, nil, nil, errors.Wrapf(err, "unsupported block height: block %d is not yet committed", blockHeight)
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	root, proof, err := s.revisions.getRevisionProof(blockHeight, contract, string(key))
+	if err != nil {
+		return nil, nil, nil, errors.Wrapf(err, "unsupported block height: no merkle root for block height %d", blockHeight)
+	}
+
+	record, ok, err := s.revisions.getRevisionRecord(blockHeight, contract, string(key))
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "persistence layer error")
+	}
+	value := newZeroValue()
+	if ok {
+		value = record.Value()
+	}
+	return root, value, proof, nil
+}
+
 func (s *service) GetStateHash(ctx context.Context, input *services.GetStateHashInput) (*services.GetStateHashOutput, error) {
 	timeoutCtx, cancel := context.WithTimeout(ctx, s.config.BlockTrackerGraceTimeout())
 	defer cancel()
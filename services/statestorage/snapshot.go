@@ -0,0 +1,256 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package statestorage
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"github.com/orbs-network/orbs-network-go/crypto/merkle"
+	"github.com/orbs-network/orbs-network-go/services/statestorage/adapter"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/pkg/errors"
+	"hash/crc32"
+	"io"
+)
+
+const snapshotMagic = uint32(0x50414e53) // "SNAP"
+const snapshotVersion = 0
+const maxSnapshotChunkSize = 64 * 1024 * 1024
+const maxSnapshotAttempts = 5
+
+type snapshotHeader struct {
+	Magic          uint32
+	Version        uint32
+	VirtualChainId uint32
+	Height         uint64
+	Timestamp      uint64
+	NumRecords     uint64
+}
+
+// The full persisted state at a single block height, used to bootstrap new nodes without replaying the chain
+type StateSnapshot struct {
+	VirtualChainId primitives.VirtualChainId
+	Height         primitives.BlockHeight
+	Timestamp      primitives.TimestampNano
+	MerkleRoot     primitives.Sha256
+	State          adapter.ChainState
+}
+
+// persistence is written to while the snapshot is taken, so we retry until the state did not move under our feet
+func TakeStateSnapshot(vchainId primitives.VirtualChainId, persistence adapter.StatePersistence) (*StateSnapshot, error) {
+	for attempt := 0; attempt < maxSnapshotAttempts; attempt++ {
+		height, ts, root, err := persistence.ReadMetadata()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not load state metadata")
+		}
+
+		state := make(adapter.ChainState)
+		err = persistence.Each(func(contract primitives.ContractName, record *protocol.StateRecord) {
+			if _, ok := state[contract]; !ok {
+				state[contract] = make(adapter.ContractState)
+			}
+			state[contract][string(record.Key())] = record
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read persisted state")
+		}
+
+		heightAfter, _, _, err := persistence.ReadMetadata()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not load state metadata")
+		}
+		if heightAfter == height {
+			return &StateSnapshot{
+				VirtualChainId: vchainId,
+				Height:         height,
+				Timestamp:      ts,
+				MerkleRoot:     root,
+				State:          state,
+			}, nil
+		}
+	}
+	return nil, errors.Errorf("state kept changing while taking a snapshot, gave up after %d attempts", maxSnapshotAttempts)
+}
+
+func (s *StateSnapshot) numRecords() (count uint64) {
+	for _, records := range s.State {
+		count += uint64(len(records))
+	}
+	return
+}
+
+func (s *StateSnapshot) Encode(w io.Writer) error {
+	checkSum := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	bw := bufio.NewWriter(w)
+	cw := io.MultiWriter(bw, checkSum)
+
+	header := &snapshotHeader{
+		Magic:          snapshotMagic,
+		Version:        snapshotVersion,
+		VirtualChainId: uint32(s.VirtualChainId),
+		Height:         uint64(s.Height),
+		Timestamp:      uint64(s.Timestamp),
+		NumRecords:     s.numRecords(),
+	}
+	err := binary.Write(cw, binary.LittleEndian, header)
+	if err != nil {
+		return err
+	}
+
+	err = writeSnapshotChunk(cw, s.MerkleRoot)
+	if err != nil {
+		return err
+	}
+
+	for contract, records := range s.State {
+		for _, record := range records {
+			err = writeSnapshotChunk(cw, []byte(contract))
+			if err != nil {
+				return err
+			}
+			err = writeSnapshotChunk(cw, record.Raw())
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = binary.Write(bw, binary.LittleEndian, checkSum.Sum32())
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func ReadStateSnapshot(r io.Reader) (*StateSnapshot, error) {
+	checkSum := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	tr := io.TeeReader(bufio.NewReader(r), checkSum)
+
+	header := &snapshotHeader{}
+	err := binary.Read(tr, binary.LittleEndian, header)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed reading snapshot header")
+	}
+	if header.Magic != snapshotMagic {
+		return nil, fmt.Errorf("invalid snapshot magic number %v", header.Magic)
+	}
+	if header.Version != snapshotVersion {
+		return nil, fmt.Errorf("invalid snapshot version %d", header.Version)
+	}
+
+	root, err := readSnapshotChunk(tr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed reading snapshot merkle root")
+	}
+
+	state := make(adapter.ChainState)
+	for i := uint64(0); i < header.NumRecords; i++ {
+		contract, err := readSnapshotChunk(tr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed reading contract name of record %d", i)
+		}
+		raw, err := readSnapshotChunk(tr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed reading state record %d", i)
+		}
+
+		contractName := primitives.ContractName(contract)
+		if _, ok := state[contractName]; !ok {
+			state[contractName] = make(adapter.ContractState)
+		}
+		record := protocol.StateRecordReader(raw)
+		state[contractName][string(record.Key())] = record
+	}
+
+	computed := checkSum.Sum32()
+	var sum32 uint32
+	err = binary.Read(tr, binary.LittleEndian, &sum32)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed reading snapshot checksum")
+	}
+	if sum32 != computed {
+		return nil, fmt.Errorf("snapshot checksum mismatch. computed: %v recorded: %v", computed, sum32)
+	}
+
+	return &StateSnapshot{
+		VirtualChainId: primitives.VirtualChainId(header.VirtualChainId),
+		Height:         primitives.BlockHeight(header.Height),
+		Timestamp:      primitives.TimestampNano(header.Timestamp),
+		MerkleRoot:     root,
+		State:          state,
+	}, nil
+}
+
+// checks the snapshot is self consistent, the merkle root itself is verified against the following block once it is synced
+func (s *StateSnapshot) Verify() error {
+	forest, emptyRoot := merkle.NewForest()
+	root, err := forest.Update(emptyRoot, toMerkleInput(s.State))
+	if err != nil {
+		return errors.Wrap(err, "failed to updated merkle tree")
+	}
+	if !root.Equal(s.MerkleRoot) {
+		return errors.Errorf("merkle root of snapshot state at block height %d is %s while snapshot root is %s", s.Height, root, s.MerkleRoot)
+	}
+	return nil
+}
+
+// checks the snapshot belongs to the virtual chain and is self consistent, which says nothing of whether the chain ever had this state
+func VerifyStateSnapshot(snapshot *StateSnapshot, vchainId primitives.VirtualChainId) error {
+	if snapshot.VirtualChainId != vchainId {
+		return errors.Errorf("snapshot virtual chain id mismatch. found vchain id %d expected %d", snapshot.VirtualChainId, vchainId)
+	}
+	return snapshot.Verify()
+}
+
+func ImportStateSnapshot(snapshot *StateSnapshot, vchainId primitives.VirtualChainId, persistence adapter.StatePersistence) error {
+	err := VerifyStateSnapshot(snapshot, vchainId)
+	if err != nil {
+		return err
+	}
+	return writeStateSnapshot(snapshot, persistence)
+}
+
+func writeStateSnapshot(snapshot *StateSnapshot, persistence adapter.StatePersistence) error {
+	height, _, _, err := persistence.ReadMetadata()
+	if err != nil {
+		return errors.Wrap(err, "could not load state metadata")
+	}
+	if height != 0 {
+		return errors.Errorf("cannot import a snapshot into a non-empty state. state is at block height %d", height)
+	}
+
+	return persistence.Write(snapshot.Height, snapshot.Timestamp, snapshot.MerkleRoot, snapshot.State)
+}
+
+func writeSnapshotChunk(w io.Writer, chunk []byte) error {
+	err := binary.Write(w, binary.LittleEndian, uint32(len(chunk)))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(chunk)
+	return err
+}
+
+func readSnapshotChunk(r io.Reader) ([]byte, error) {
+	var chunkLength uint32
+	err := binary.Read(r, binary.LittleEndian, &chunkLength)
+	if err != nil {
+		return nil, err
+	}
+	if chunkLength > maxSnapshotChunkSize {
+		return nil, fmt.Errorf("chunk length %d exceeds max limit %d", chunkLength, maxSnapshotChunkSize)
+	}
+
+	chunk := make([]byte, chunkLength)
+	_, err = io.ReadFull(r, chunk)
+	if err != nil {
+		return nil, err
+	}
+	return chunk, nil
+}
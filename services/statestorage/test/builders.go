@@ -33,6 +33,11 @@ func (b *commitStateDiffInputBuilder) WithBlockTimestamp(t int) *commitStateDiff
 	return b
 }
 
+func (b *commitStateDiffInputBuilder) WithPreExecutionStateRootHash(root primitives.Sha256) *commitStateDiffInputBuilder {
+	b.headerBuilder.PreExecutionStateMerkleRootHash = root
+	return b
+}
+
 func (b *commitStateDiffInputBuilder) WithDiff(diff *protocol.ContractStateDiff) *commitStateDiffInputBuilder {
 	b.diffs = append(b.diffs, diff)
 	return b
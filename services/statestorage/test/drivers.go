@@ -11,8 +11,10 @@ import (
 	"context"
 	"fmt"
 	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/crypto/merkle"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
 	"github.com/orbs-network/orbs-network-go/services/statestorage"
+	"github.com/orbs-network/orbs-network-go/services/statestorage/adapter"
 	"github.com/orbs-network/orbs-network-go/services/statestorage/adapter/memory"
 	"github.com/orbs-network/orbs-network-go/test/builders"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
@@ -47,6 +49,30 @@ func newStateStorageDriverWithGrace(numOfStateRevisionsToRetain uint32, graceBlo
 	return &Driver{service: statestorage.NewStateStorage(cfg, p, nil, logger, registry)}
 }
 
+func NewArchivalStateStorageDriver(numOfStateRevisionsToRetain uint32) *Driver {
+	cfg := config.ForArchivalStateStorageTest(numOfStateRevisionsToRetain)
+	registry := metric.NewRegistry()
+
+	p := memory.NewArchivalStatePersistence(registry)
+	logger := log.GetLogger().WithOutput() // a mute logger
+
+	return &Driver{service: statestorage.NewStateStorage(cfg, p, nil, logger, registry)}
+}
+
+func newStateStorageDriverWithPersistence(numOfStateRevisionsToRetain uint32, p adapter.StatePersistence) *Driver {
+	cfg := config.ForStateStorageTest(numOfStateRevisionsToRetain, 0, 0)
+	logger := log.GetLogger().WithOutput() // a mute logger
+
+	return &Driver{service: statestorage.NewStateStorage(cfg, p, nil, logger, metric.NewRegistry())}
+}
+
+func newStateStorageDriverImportingSnapshot(numOfStateRevisionsToRetain uint32, p adapter.StatePersistence, snapshot *statestorage.StateSnapshot) *Driver {
+	cfg := config.ForStateStorageTest(numOfStateRevisionsToRetain, 0, 0)
+	logger := log.GetLogger().WithOutput() // a mute logger
+
+	return &Driver{service: statestorage.NewStateStorageImportingSnapshot(cfg, p, snapshot, nil, logger, metric.NewRegistry())}
+}
+
 func (d *Driver) ReadSingleKey(ctx context.Context, contract string, key string) ([]byte, error) {
 	h, _, _ := d.GetBlockHeightAndTimestamp(ctx)
 	return d.ReadSingleKeyFromRevision(ctx, h, contract, key)
@@ -91,6 +117,10 @@ func (d *Driver) ReadKeysFromRevision(ctx context.Context, revision int, contrac
 	return result, nil
 }
 
+func (d *Driver) GetStateProof(ctx context.Context, revision int, contract string, key string) (primitives.Sha256, []byte, *merkle.TrieProof, error) {
+	return d.service.(statestorage.HistoricalState).GetStateProof(ctx, primitives.BlockHeight(revision), primitives.ContractName(contract), []byte(key))
+}
+
 func (d *Driver) GetBlockHeightAndTimestamp(ctx context.Context) (int, int, error) {
 	output, err := d.service.GetStateStorageBlockHeight(ctx, &services.GetStateStorageBlockHeightInput{})
 	return int(output.LastCommittedBlockHeight), int(output.LastCommittedBlockTimestamp), err
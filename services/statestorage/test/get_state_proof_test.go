@@ -0,0 +1,79 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/crypto/hash"
+	"github.com/orbs-network/orbs-network-go/crypto/merkle"
+	"github.com/orbs-network/orbs-network-go/test"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/services"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestGetStateProofOfExistingKeyVerifiesAgainstStateHash(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		d := NewStateStorageDriver(5)
+		d.CommitValuePairs(ctx, "foo", "bar", "baz", "qux", "quux")
+
+		root, value, proof, err := d.GetStateProof(ctx, 1, "foo", "bar")
+		require.NoError(t, err)
+		require.Equal(t, []byte("baz"), value, "expected the committed value")
+
+		stateHash, err := d.service.GetStateHash(ctx, &services.GetStateHashInput{BlockHeight: 1})
+		require.NoError(t, err)
+		require.Equal(t, stateHash.StateMerkleRootHash, root, "proof should be against the state merkle root of the block height")
+
+		requireProofVerifies(t, root, proof, "foo", "bar", value)
+	})
+}
+
+func TestGetStateProofOfMissingKeyProvesExclusion(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		d := NewStateStorageDriver(5)
+		d.CommitValuePairs(ctx, "foo", "bar", "baz", "qux", "quux")
+
+		root, value, proof, err := d.GetStateProof(ctx, 1, "foo", "missing")
+		require.NoError(t, err)
+		require.Empty(t, value, "expected the zero value for a missing key")
+
+		requireProofVerifies(t, root, proof, "foo", "missing", value)
+	})
+}
+
+func TestGetStateProofAtPastBlockHeight(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		d := NewStateStorageDriver(5)
+		d.CommitValuePairs(ctx, "foo", "bar", "baz")
+		d.CommitValuePairs(ctx, "foo", "bar", "new-baz")
+
+		root, value, proof, err := d.GetStateProof(ctx, 1, "foo", "bar")
+		require.NoError(t, err)
+		require.Equal(t, []byte("baz"), value, "expected the value at the requested block height")
+
+		requireProofVerifies(t, root, proof, "foo", "bar", value)
+	})
+}
+
+func TestGetStateProofFailsOutsideOfRetainedRevisions(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		d := NewStateStorageDriver(1)
+		d.CommitValuePairs(ctx, "foo", "bar", "baz")
+		d.CommitValuePairs(ctx, "foo", "bar", "new-baz")
+
+		_, _, _, err := d.GetStateProof(ctx, 0, "foo", "bar")
+		require.Error(t, err, "expected no proof for a block height which merkle root was forgotten")
+	})
+}
+
+func requireProofVerifies(t *testing.T, root primitives.Sha256, proof *merkle.TrieProof, contract string, key string, value []byte) {
+	verified, err := merkle.VerifyTrieProof(root, proof, hash.CalcSha256([]byte(contract), []byte(key)), value)
+	require.NoError(t, err)
+	require.True(t, verified, "proof should verify against the state merkle root")
+}
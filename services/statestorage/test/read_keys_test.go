@@ -110,6 +110,24 @@ func TestReadKeysOutsideSupportedBlockRetention(t *testing.T) {
 	})
 }
 
+func TestReadKeysOutsideBlockRetentionInArchivalMode(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		key := "foo"
+
+		d := NewArchivalStateStorageDriver(1)
+		d.CommitValuePairsAtHeight(ctx, 1, "contract", key, "bar")
+		d.CommitValuePairsAtHeight(ctx, 2, "contract", key, "foo")
+		d.CommitValuePairsAtHeight(ctx, 3, "contract", key, "")
+		d.CommitValuePairsAtHeight(ctx, 4, "contract", "other", "baz")
+
+		for h, expected := range map[int]string{1: "bar", 2: "foo", 3: "", 4: ""} {
+			output, err := d.ReadSingleKeyFromRevision(ctx, h, "contract", key)
+			require.NoError(t, err, "unexpected error reading block height %d", h)
+			require.EqualValues(t, expected, output, "unexpected value at block height %d", h)
+		}
+	})
+}
+
 func TestReadKeysObservesWriteOrder(t *testing.T) {
 	test.WithContext(func(ctx context.Context) {
 		key := "foo"
@@ -0,0 +1,157 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/statestorage/adapter/filesystem"
+	"github.com/orbs-network/orbs-network-go/test"
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/services"
+	"github.com/orbs-network/scribe/log"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type filesystemStateConfig struct {
+	dir string
+}
+
+func (c *filesystemStateConfig) StateStorageFileSystemDataDir() string {
+	return c.dir
+}
+
+func (c *filesystemStateConfig) StateStorageArchivalMode() bool {
+	return false
+}
+
+func (c *filesystemStateConfig) VirtualChainId() primitives.VirtualChainId {
+	return 42
+}
+
+func TestCommitStateDiff_ResumesFromPersistedStateAfterRestart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state_storage_restart")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	conf := &filesystemStateConfig{dir: dir}
+
+	test.WithContext(func(ctx context.Context) {
+		persistCtx, closePersistence := context.WithCancel(ctx)
+		p, err := filesystem.NewStatePersistence(persistCtx, conf, log.DefaultTestingLogger(t), metric.NewRegistry())
+		require.NoError(t, err)
+
+		d := newStateStorageDriverWithPersistence(1, p)
+		for h := 1; h <= 10; h++ {
+			_, err := d.CommitValuePairsAtHeight(ctx, h, "foo", fmt.Sprintf("key%d", h%3), fmt.Sprintf("value%d", h))
+			require.NoError(t, err)
+		}
+		rootAtNine, err := d.service.GetStateHash(ctx, &services.GetStateHashInput{BlockHeight: 9})
+		require.NoError(t, err)
+		rootAtTen, err := d.service.GetStateHash(ctx, &services.GetStateHashInput{BlockHeight: 10})
+		require.NoError(t, err)
+
+		closePersistence()
+		time.Sleep(100 * time.Millisecond) // time to release the lock
+
+		p, err = filesystem.NewStatePersistence(ctx, conf, log.DefaultTestingLogger(t), metric.NewRegistry())
+		require.NoError(t, err)
+
+		restarted := newStateStorageDriverWithPersistence(1, p)
+		h, _, err := restarted.GetBlockHeightAndTimestamp(ctx)
+		require.NoError(t, err)
+		require.EqualValues(t, 9, h, "expected to resume from the last block evicted to persistence")
+
+		value, err := restarted.ReadSingleKey(ctx, "foo", "key0")
+		require.NoError(t, err)
+		require.EqualValues(t, "value9", value)
+
+		_, err = restarted.CommitStateDiff(ctx, CommitStateDiff().
+			WithBlockHeight(10).
+			WithPreExecutionStateRootHash(rootAtNine.StateMerkleRootHash).
+			WithDiff(builders.ContractStateDiff().WithContractName("foo").WithStringRecord("key1", "value10").Build()).
+			Build())
+		require.NoError(t, err)
+
+		restoredRootAtTen, err := restarted.service.GetStateHash(ctx, &services.GetStateHashInput{BlockHeight: 10})
+		require.NoError(t, err)
+		require.EqualValues(t, rootAtTen.StateMerkleRootHash, restoredRootAtTen.StateMerkleRootHash, "expected merkle root to be restored after restart")
+	})
+}
+
+func TestGetStateProof_ProvesPersistedStateAfterRestart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state_storage_restart")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	conf := &filesystemStateConfig{dir: dir}
+
+	test.WithContext(func(ctx context.Context) {
+		persistCtx, closePersistence := context.WithCancel(ctx)
+		p, err := filesystem.NewStatePersistence(persistCtx, conf, log.DefaultTestingLogger(t), metric.NewRegistry())
+		require.NoError(t, err)
+
+		d := newStateStorageDriverWithPersistence(1, p)
+		d.CommitValuePairs(ctx, "foo", "key1", "value1")
+		d.CommitValuePairs(ctx, "foo", "key1", "value2")
+
+		closePersistence()
+		time.Sleep(100 * time.Millisecond) // time to release the lock
+
+		p, err = filesystem.NewStatePersistence(ctx, conf, log.DefaultTestingLogger(t), metric.NewRegistry())
+		require.NoError(t, err)
+		roots, err := p.MerkleNodeStore().Roots()
+		require.NoError(t, err)
+		require.Len(t, roots, 2, "expected the merkle roots of the persisted and transient revisions to be kept")
+
+		restarted := newStateStorageDriverWithPersistence(1, p)
+		roots, err = p.MerkleNodeStore().Roots()
+		require.NoError(t, err)
+		require.Len(t, roots, 1, "expected only the merkle root of the persisted revision to be kept after restart")
+
+		root, value, proof, err := restarted.GetStateProof(ctx, 1, "foo", "key1")
+		require.NoError(t, err)
+		require.EqualValues(t, "value1", value)
+		requireProofVerifies(t, root, proof, "foo", "key1", value)
+	})
+}
+
+func TestCommitStateDiff_RebuildsMerkleTrieWhenMerkleNodesAreLost(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state_storage_restart")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	conf := &filesystemStateConfig{dir: dir}
+
+	test.WithContext(func(ctx context.Context) {
+		persistCtx, closePersistence := context.WithCancel(ctx)
+		p, err := filesystem.NewStatePersistence(persistCtx, conf, log.DefaultTestingLogger(t), metric.NewRegistry())
+		require.NoError(t, err)
+
+		d := newStateStorageDriverWithPersistence(1, p)
+		d.CommitValuePairs(ctx, "foo", "key1", "value1")
+		d.CommitValuePairs(ctx, "foo", "key1", "value2")
+		rootAtOne, err := d.service.GetStateHash(ctx, &services.GetStateHashInput{BlockHeight: 1})
+		require.NoError(t, err)
+
+		closePersistence()
+		time.Sleep(100 * time.Millisecond) // time to release the lock
+		require.NoError(t, os.Remove(filepath.Join(dir, "merkle")))
+
+		p, err = filesystem.NewStatePersistence(ctx, conf, log.DefaultTestingLogger(t), metric.NewRegistry())
+		require.NoError(t, err)
+
+		restarted := newStateStorageDriverWithPersistence(1, p)
+		restoredRootAtOne, err := restarted.service.GetStateHash(ctx, &services.GetStateHashInput{BlockHeight: 1})
+		require.NoError(t, err)
+		require.EqualValues(t, rootAtOne.StateMerkleRootHash, restoredRootAtOne.StateMerkleRootHash, "expected merkle root to be rebuilt from the persisted state")
+	})
+}
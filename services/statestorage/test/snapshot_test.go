@@ -0,0 +1,210 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/statestorage"
+	"github.com/orbs-network/orbs-network-go/services/statestorage/adapter/memory"
+	"github.com/orbs-network/orbs-network-go/test"
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/services"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+const snapshotVirtualChainId = primitives.VirtualChainId(42)
+
+// commits blocks 1..10 so that blocks 1..9 are evicted to persistence, returns the pre-execution state root of block 10
+func writeStateForSnapshot(ctx context.Context, t *testing.T, p *memory.InMemoryStatePersistence) primitives.Sha256 {
+	d := newStateStorageDriverWithPersistence(1, p)
+	for h := 1; h <= 10; h++ {
+		_, err := d.CommitValuePairsAtHeight(ctx, h, fmt.Sprintf("contract%d", h%2), fmt.Sprintf("key%d", h%4), fmt.Sprintf("value%d", h))
+		require.NoError(t, err)
+	}
+	rootAtNine, err := d.service.GetStateHash(ctx, &services.GetStateHashInput{BlockHeight: 9})
+	require.NoError(t, err)
+	return rootAtNine.StateMerkleRootHash
+}
+
+func exportSnapshot(t *testing.T, p *memory.InMemoryStatePersistence) []byte {
+	snapshot, err := statestorage.TakeStateSnapshot(snapshotVirtualChainId, p)
+	require.NoError(t, err)
+	buf := &bytes.Buffer{}
+	require.NoError(t, snapshot.Encode(buf))
+	return buf.Bytes()
+}
+
+func TestStateSnapshot_ExportAndImportRestoresState(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		source := memory.NewStatePersistence(metric.NewRegistry())
+		rootAtNine := writeStateForSnapshot(ctx, t, source)
+		encoded := exportSnapshot(t, source)
+
+		snapshot, err := statestorage.ReadStateSnapshot(bytes.NewReader(encoded))
+		require.NoError(t, err)
+		require.EqualValues(t, 9, snapshot.Height)
+		require.EqualValues(t, rootAtNine, snapshot.MerkleRoot)
+
+		target := memory.NewStatePersistence(metric.NewRegistry())
+		require.NoError(t, statestorage.ImportStateSnapshot(snapshot, snapshotVirtualChainId, target))
+		require.Equal(t, source.Dump(), target.Dump(), "expected imported state to be identical to exported state")
+
+		d := newStateStorageDriverWithPersistence(1, target)
+		h, _, err := d.GetBlockHeightAndTimestamp(ctx)
+		require.NoError(t, err)
+		require.EqualValues(t, 9, h)
+
+		_, err = d.CommitStateDiff(ctx, CommitStateDiff().
+			WithBlockHeight(10).
+			WithPreExecutionStateRootHash(rootAtNine).
+			WithDiff(builders.ContractStateDiff().WithContractName("contract0").WithStringRecord("key2", "value10").Build()).
+			Build())
+		require.NoError(t, err, "expected following block to match imported state")
+	})
+}
+
+func TestStateSnapshot_RejectsFollowingBlockWithDifferentPreExecutionRoot(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		source := memory.NewStatePersistence(metric.NewRegistry())
+		writeStateForSnapshot(ctx, t, source)
+
+		snapshot, err := statestorage.ReadStateSnapshot(bytes.NewReader(exportSnapshot(t, source)))
+		require.NoError(t, err)
+		target := memory.NewStatePersistence(metric.NewRegistry())
+		require.NoError(t, statestorage.ImportStateSnapshot(snapshot, snapshotVirtualChainId, target))
+
+		d := newStateStorageDriverWithPersistence(1, target)
+		_, err = d.CommitStateDiff(ctx, CommitStateDiff().
+			WithBlockHeight(10).
+			WithPreExecutionStateRootHash(primitives.Sha256{1, 2, 3}).
+			WithDiff(builders.ContractStateDiff().WithContractName("contract0").WithStringRecord("key2", "value10").Build()).
+			Build())
+		require.Error(t, err, "expected block not matching the imported state to be rejected")
+	})
+}
+
+func TestStateSnapshot_PendingSnapshotIsImportedWhenFollowingBlockMatches(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		source := memory.NewStatePersistence(metric.NewRegistry())
+		rootAtNine := writeStateForSnapshot(ctx, t, source)
+		snapshot, err := statestorage.ReadStateSnapshot(bytes.NewReader(exportSnapshot(t, source)))
+		require.NoError(t, err)
+
+		target := memory.NewStatePersistence(metric.NewRegistry())
+		d := newStateStorageDriverImportingSnapshot(2, target, snapshot)
+
+		out, err := d.CommitValuePairsAtHeight(ctx, 1, "contract1", "key1", "value1")
+		require.NoError(t, err)
+		require.EqualValues(t, 10, out.NextDesiredBlockHeight, "expected blocks covered by the snapshot to be skipped")
+		h, _, err := d.GetBlockHeightAndTimestamp(ctx)
+		require.NoError(t, err)
+		require.EqualValues(t, 0, h, "expected snapshot not to be imported before the block following it")
+
+		out, err = d.CommitStateDiff(ctx, CommitStateDiff().
+			WithBlockHeight(10).
+			WithPreExecutionStateRootHash(rootAtNine).
+			WithDiff(builders.ContractStateDiff().WithContractName("contract0").WithStringRecord("key2", "value10").Build()).
+			Build())
+		require.NoError(t, err)
+		require.EqualValues(t, 11, out.NextDesiredBlockHeight)
+
+		value, err := d.ReadSingleKeyFromRevision(ctx, 9, "contract1", "key1")
+		require.NoError(t, err)
+		require.EqualValues(t, "value9", value, "expected state of the snapshot to be imported")
+		value, err = d.ReadSingleKey(ctx, "contract0", "key2")
+		require.NoError(t, err)
+		require.EqualValues(t, "value10", value, "expected the following block to be committed on top of the snapshot")
+	})
+}
+
+func TestStateSnapshot_PendingSnapshotIsDiscardedWhenFollowingBlockDoesNotMatch(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		source := memory.NewStatePersistence(metric.NewRegistry())
+		writeStateForSnapshot(ctx, t, source)
+		snapshot, err := statestorage.ReadStateSnapshot(bytes.NewReader(exportSnapshot(t, source)))
+		require.NoError(t, err)
+
+		target := memory.NewStatePersistence(metric.NewRegistry())
+		d := newStateStorageDriverImportingSnapshot(1, target, snapshot)
+
+		out, err := d.CommitStateDiff(ctx, CommitStateDiff().
+			WithBlockHeight(10).
+			WithPreExecutionStateRootHash(primitives.Sha256{1, 2, 3}).
+			WithDiff(builders.ContractStateDiff().WithContractName("contract0").WithStringRecord("key2", "value10").Build()).
+			Build())
+		require.NoError(t, err)
+		require.EqualValues(t, 1, out.NextDesiredBlockHeight, "expected state to be rebuilt from the first block")
+
+		height, _, _, err := target.ReadMetadata()
+		require.NoError(t, err)
+		require.EqualValues(t, 0, height, "expected discarded snapshot not to be written")
+
+		out, err = d.CommitValuePairsAtHeight(ctx, 1, "contract1", "key1", "value1")
+		require.NoError(t, err)
+		require.EqualValues(t, 2, out.NextDesiredBlockHeight)
+	})
+}
+
+func TestStateSnapshot_ReadRejectsCorruptedStream(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		source := memory.NewStatePersistence(metric.NewRegistry())
+		writeStateForSnapshot(ctx, t, source)
+		encoded := exportSnapshot(t, source)
+
+		encoded[len(encoded)/2] ^= 0x01
+		_, err := statestorage.ReadStateSnapshot(bytes.NewReader(encoded))
+		require.Error(t, err)
+
+		_, err = statestorage.ReadStateSnapshot(bytes.NewReader(encoded[:len(encoded)-3]))
+		require.Error(t, err)
+	})
+}
+
+func TestStateSnapshot_ImportRejectsTamperedState(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		source := memory.NewStatePersistence(metric.NewRegistry())
+		writeStateForSnapshot(ctx, t, source)
+
+		snapshot, err := statestorage.TakeStateSnapshot(snapshotVirtualChainId, source)
+		require.NoError(t, err)
+		delete(snapshot.State, "contract1")
+
+		err = statestorage.ImportStateSnapshot(snapshot, snapshotVirtualChainId, memory.NewStatePersistence(metric.NewRegistry()))
+		require.Error(t, err, "expected state not matching the snapshot merkle root to be rejected")
+	})
+}
+
+func TestStateSnapshot_ImportRejectsOtherVirtualChain(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		source := memory.NewStatePersistence(metric.NewRegistry())
+		writeStateForSnapshot(ctx, t, source)
+
+		snapshot, err := statestorage.TakeStateSnapshot(snapshotVirtualChainId, source)
+		require.NoError(t, err)
+
+		err = statestorage.ImportStateSnapshot(snapshot, snapshotVirtualChainId+1, memory.NewStatePersistence(metric.NewRegistry()))
+		require.Error(t, err)
+	})
+}
+
+func TestStateSnapshot_VerifyPersistedStateAgainstFollowingBlock(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		p := memory.NewStatePersistence(metric.NewRegistry())
+		rootAtNine := writeStateForSnapshot(ctx, t, p)
+
+		matching := CommitStateDiff().WithBlockHeight(10).WithPreExecutionStateRootHash(rootAtNine).Build()
+		require.NoError(t, statestorage.VerifyPersistedState(p, matching.ResultsBlockHeader))
+
+		mismatching := CommitStateDiff().WithBlockHeight(10).WithPreExecutionStateRootHash(primitives.Sha256{1, 2, 3}).Build()
+		require.Error(t, statestorage.VerifyPersistedState(p, mismatching.ResultsBlockHeader), "expected state not matching the following block to fail verification")
+	})
+}
@@ -9,6 +9,7 @@ package transactionpool
 import (
 	"context"
 	"github.com/orbs-network/orbs-network-go/crypto/digest"
+	"github.com/orbs-network/orbs-network-go/crypto/signer"
 	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
 	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
 	"github.com/orbs-network/orbs-network-go/synchronization"
@@ -27,7 +28,6 @@ import (
 
 type TransactionForwarderConfig interface {
 	NodeAddress() primitives.NodeAddress
-	NodePrivateKey() primitives.EcdsaSecp256K1PrivateKey
 	TransactionPoolPropagationBatchSize() uint16
 	TransactionPoolPropagationBatchingTimeout() time.Duration
 }
@@ -61,6 +61,7 @@ func (s *service) HandleForwardedTransactions(ctx context.Context, input *gossip
 
 type transactionForwarder struct {
 	logger log.Logger
+	signer signer.Signer
 	config TransactionForwarderConfig
 	gossip gossiptopics.TransactionRelay
 
@@ -69,9 +70,10 @@ type transactionForwarder struct {
 	transactionAdded  chan uint16
 }
 
-func NewTransactionForwarder(ctx context.Context, logger log.Logger, config TransactionForwarderConfig, gossip gossiptopics.TransactionRelay) *transactionForwarder {
+func NewTransactionForwarder(ctx context.Context, logger log.Logger, signer signer.Signer, config TransactionForwarderConfig, gossip gossiptopics.TransactionRelay) *transactionForwarder {
 	f := &transactionForwarder{
 		logger:            logger.WithTags(log.String("component", "transaction-forwarder")),
+		signer:            signer,
 		config:            config,
 		gossip:            gossip,
 		forwardQueueMutex: &sync.Mutex{},
@@ -125,7 +127,7 @@ func (f *transactionForwarder) drainQueueAndForward(ctx context.Context) {
 		return
 	}
 
-	sig, err := digest.SignAsNode(f.config.NodePrivateKey(), oneBigHash)
+	sig, err := f.signer.SignAsNode(ctx, 0, oneBigHash)
 	if err != nil {
 		logger.Error("error signing transactions", log.Error(err), log.StringableSlice("transactions", txs))
 		return
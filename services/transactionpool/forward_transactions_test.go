@@ -10,6 +10,7 @@ import (
 	"context"
 	"github.com/orbs-network/go-mock"
 	"github.com/orbs-network/orbs-network-go/crypto/digest"
+	"github.com/orbs-network/orbs-network-go/crypto/signer"
 	"github.com/orbs-network/orbs-network-go/test"
 	"github.com/orbs-network/orbs-network-go/test/builders"
 	testKeys "github.com/orbs-network/orbs-network-go/test/crypto/keys"
@@ -32,10 +33,6 @@ func (c *forwarderConfig) NodeAddress() primitives.NodeAddress {
 	return c.keyPair.NodeAddress()
 }
 
-func (c *forwarderConfig) NodePrivateKey() primitives.EcdsaSecp256K1PrivateKey {
-	return c.keyPair.PrivateKey()
-}
-
 func (c *forwarderConfig) TransactionPoolPropagationBatchSize() uint16 {
 	return c.queueSize
 }
@@ -62,13 +59,14 @@ func TestForwardsTransactionAfterTimeout(t *testing.T) {
 		gossip := &gossiptopics.MockTransactionRelay{}
 		cfg := &forwarderConfig{3, testKeys.EcdsaSecp256K1KeyPairForTests(0)}
 
-		txForwarder := NewTransactionForwarder(ctx, log.DefaultTestingLogger(t), cfg, gossip)
+		logger := log.DefaultTestingLogger(t)
+		txForwarder := NewTransactionForwarder(ctx, logger, signer.NewLocalSigner(cfg.keyPair.PrivateKey(), logger), cfg, gossip)
 
 		tx := builders.TransferTransaction().Build()
 		anotherTx := builders.TransferTransaction().Build()
 
 		oneBigHash, _, _ := HashTransactions(tx, anotherTx)
-		sig, _ := digest.SignAsNode(cfg.NodePrivateKey(), oneBigHash)
+		sig, _ := digest.SignAsNode(cfg.keyPair.PrivateKey(), oneBigHash)
 
 		expectTransactionsToBeForwarded(gossip, cfg.NodeAddress(), sig, tx, anotherTx)
 
@@ -85,13 +83,14 @@ func TestForwardsTransactionAfterLimitWasReached(t *testing.T) {
 		gossip := &gossiptopics.MockTransactionRelay{}
 		cfg := &forwarderConfig{2, testKeys.EcdsaSecp256K1KeyPairForTests(0)}
 
-		txForwarder := NewTransactionForwarder(ctx, log.DefaultTestingLogger(t), cfg, gossip)
+		logger := log.DefaultTestingLogger(t)
+		txForwarder := NewTransactionForwarder(ctx, logger, signer.NewLocalSigner(cfg.keyPair.PrivateKey(), logger), cfg, gossip)
 
 		tx := builders.TransferTransaction().Build()
 		anotherTx := builders.TransferTransaction().Build()
 
 		oneBigHash, _, _ := HashTransactions(tx, anotherTx)
-		sig, _ := digest.SignAsNode(cfg.NodePrivateKey(), oneBigHash)
+		sig, _ := digest.SignAsNode(cfg.keyPair.PrivateKey(), oneBigHash)
 
 		expectTransactionsToBeForwarded(gossip, cfg.NodeAddress(), sig, tx, anotherTx)
 
@@ -9,6 +9,7 @@ package transactionpool
 import (
 	"context"
 	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/crypto/signer"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
 	"github.com/orbs-network/orbs-network-go/synchronization"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
@@ -23,6 +24,7 @@ func NewTransactionPool(ctx context.Context,
 	gossip gossiptopics.TransactionRelay,
 	virtualMachine services.VirtualMachine,
 	blockHeightReporter BlockHeightReporter,
+	signer signer.Signer,
 	config config.TransactionPoolConfig,
 	parent log.Logger,
 	metricFactory metric.Factory) services.TransactionPool {
@@ -37,7 +39,7 @@ func NewTransactionPool(ctx context.Context,
 
 	logger := parent.WithTags(LogTag)
 
-	txForwarder := NewTransactionForwarder(ctx, logger, config, gossip)
+	txForwarder := NewTransactionForwarder(ctx, logger, signer, config, gossip)
 
 	s := &service{
 		gossip:         gossip,
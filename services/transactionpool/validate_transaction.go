@@ -81,6 +81,8 @@ func (c *validationContext) validateTransactionVirtualChainId(transaction *proto
 	return nil
 }
 
+// TODO(v1) accept ECDSA secp256k1 and m-of-n multisig signers, blocked until orbs-spec defines their Signer schemes.
+// protocol.Signer of the vendored orbs-spec only has the Eddsa scheme, so a transaction cannot carry any other signer yet
 func (c *validationContext) validateSignatureType(transaction *protocol.SignedTransaction) *ErrTransactionRejected {
 	tx := transaction.Transaction()
 	if !tx.Signer().IsSchemeEddsa() {
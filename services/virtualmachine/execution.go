@@ -11,10 +11,12 @@ import (
 	"github.com/orbs-network/orbs-network-go/crypto/digest"
 	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
 	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
+	"github.com/orbs-network/orbs-network-go/services/statestorage"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/protocol"
 	"github.com/orbs-network/orbs-spec/types/go/services"
 	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
 )
 
 type TransactionOrQuery interface {
@@ -119,6 +121,17 @@ func (s *service) getRecentCommittedBlockHeight(ctx context.Context) (primitives
 	return output.LastCommittedBlockHeight, output.LastCommittedBlockTimestamp, nil
 }
 
+func (s *service) getPastCommittedBlockTimestamp(ctx context.Context, blockHeight primitives.BlockHeight, committedBlockHeight primitives.BlockHeight) (primitives.TimestampNano, error) {
+	if blockHeight > committedBlockHeight {
+		return 0, errors.Errorf("block %d is not yet committed. most recent committed block height is %d", blockHeight, committedBlockHeight)
+	}
+	reader, ok := s.stateStorage.(statestorage.HistoricalState)
+	if !ok {
+		return 0, errors.New("state storage does not support queries at past block heights")
+	}
+	return reader.GetStateStorageBlockTimestamp(ctx, blockHeight)
+}
+
 func encodeTransactionReceipt(transaction *protocol.Transaction, result protocol.ExecutionResult, outputArgs *protocol.ArgumentArray, outputEvents *protocol.EventsArray) *protocol.TransactionReceipt {
 	return (&protocol.TransactionReceiptBuilder{
 		Txhash:              digest.CalcTxHash(transaction),
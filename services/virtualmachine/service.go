@@ -8,7 +8,10 @@ package virtualmachine
 
 import (
 	"context"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/crypto/signature"
 	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
 	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
 	"github.com/orbs-network/orbs-network-go/services/processor/native"
 	"github.com/orbs-network/orbs-network-go/services/processor/native/repository/GlobalPreOrder"
@@ -17,33 +20,52 @@ import (
 	"github.com/orbs-network/orbs-spec/types/go/services/handlers"
 	"github.com/orbs-network/scribe/log"
 	"github.com/pkg/errors"
+	"time"
 )
 
 var LogTag = log.Service("virtual-machine")
 
+type metrics struct {
+	signatureVerificationTime *metric.Histogram
+}
+
+func newMetrics(factory metric.Factory) *metrics {
+	return &metrics{
+		signatureVerificationTime: factory.NewLatency("VirtualMachine.TransactionSetSignatureVerificationTime.Millis", 10*time.Second),
+	}
+}
+
 type service struct {
 	stateStorage         services.StateStorage
 	processors           map[protocol.ProcessorType]services.Processor
 	crosschainConnectors map[protocol.CrosschainConnectorType]services.CrosschainConnector
+	config               config.VirtualMachineConfig
 	logger               log.Logger
+	metrics              *metrics
 
-	contexts *executionContextProvider
+	contexts          *executionContextProvider
+	signatureVerifier *signature.Ed25519ParallelVerifier // shared by all concurrent pre-order checks
 }
 
 func NewVirtualMachine(
 	stateStorage services.StateStorage,
 	processors map[protocol.ProcessorType]services.Processor,
 	crosschainConnectors map[protocol.CrosschainConnectorType]services.CrosschainConnector,
+	config config.VirtualMachineConfig,
 	logger log.Logger,
+	metricFactory metric.Factory,
 ) services.VirtualMachine {
 
 	s := &service{
 		processors:           processors,
 		crosschainConnectors: crosschainConnectors,
 		stateStorage:         stateStorage,
+		config:               config,
 		logger:               logger.WithTags(LogTag),
+		metrics:              newMetrics(metricFactory),
 
-		contexts: newExecutionContextProvider(),
+		contexts:          newExecutionContextProvider(),
+		signatureVerifier: signature.NewEd25519ParallelVerifier(int(config.VirtualMachineSignatureVerificationWorkers())),
 	}
 
 	for _, processor := range processors {
@@ -56,11 +78,11 @@ func NewVirtualMachine(
 func (s *service) ProcessQuery(ctx context.Context, input *services.ProcessQueryInput) (*services.ProcessQueryOutput, error) {
 	logger := s.logger.WithTags(trace.LogFieldFrom(ctx))
 
-	if input.BlockHeight != 0 {
-		panic("Run local method with specific block height is not yet supported")
-	}
-
 	committedBlockHeight, committedBlockTimestamp, err := s.getRecentCommittedBlockHeight(ctx)
+	if err == nil && input.BlockHeight != 0 { // a past block height, served by state storage in archival mode
+		committedBlockTimestamp, err = s.getPastCommittedBlockTimestamp(ctx, input.BlockHeight, committedBlockHeight)
+		committedBlockHeight = input.BlockHeight
+	}
 	if err != nil {
 		return &services.ProcessQueryOutput{
 			CallResult:              protocol.EXECUTION_RESULT_ERROR_UNEXPECTED,
@@ -10,6 +10,8 @@ import (
 	"context"
 	"fmt"
 	"github.com/orbs-network/go-mock"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
 	"github.com/orbs-network/orbs-network-go/services/virtualmachine"
 	"github.com/orbs-network/orbs-network-go/test/builders"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
@@ -56,7 +58,9 @@ func newHarness(tb testing.TB) *harness {
 		stateStorage,
 		processorsForService,
 		crosschainConnectorsForService,
+		config.ForVirtualMachineTests(4),
 		logger,
+		metric.NewRegistry(),
 	)
 
 	return &harness{
@@ -98,8 +102,12 @@ func (h *harness) handleSdkCallWithSystemPermissions(ctx context.Context, execut
 }
 
 func (h *harness) processQuery(ctx context.Context, contractName primitives.ContractName, methodName primitives.MethodName) (protocol.ExecutionResult, []byte, primitives.BlockHeight, []byte, error) {
+	return h.processQueryAtBlockHeight(ctx, 0, contractName, methodName) // recent
+}
+
+func (h *harness) processQueryAtBlockHeight(ctx context.Context, blockHeight primitives.BlockHeight, contractName primitives.ContractName, methodName primitives.MethodName) (protocol.ExecutionResult, []byte, primitives.BlockHeight, []byte, error) {
 	output, err := h.service.ProcessQuery(ctx, &services.ProcessQueryInput{
-		BlockHeight: 0, // recent
+		BlockHeight: blockHeight,
 		SignedQuery: (&protocol.SignedQueryBuilder{
 			Query: &protocol.QueryBuilder{
 				Signer:             nil,
@@ -57,6 +57,36 @@ func TestPreOrder_DifferentSignerSchemes(t *testing.T) {
 	}
 }
 
+func TestPreOrder_VerifiesSignaturesOfABatchInParallel(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		h := newHarness(t)
+
+		h.expectSystemContractCalled(globalpreorder_systemcontract.CONTRACT_NAME, globalpreorder_systemcontract.METHOD_APPROVE, nil)
+
+		var txs []*protocol.SignedTransaction
+		var expectedStatuses []protocol.TransactionStatus
+		for i := 0; i < 20; i++ {
+			switch i % 5 {
+			case 1:
+				txs = append(txs, builders.Transaction().WithInvalidEd25519Signer(keys.Ed25519KeyPairForTests(i%10)).Build())
+				expectedStatuses = append(expectedStatuses, protocol.TRANSACTION_STATUS_REJECTED_SIGNATURE_MISMATCH)
+			case 3:
+				txs = append(txs, builders.Transaction().WithInvalidSignerScheme().Build())
+				expectedStatuses = append(expectedStatuses, protocol.TRANSACTION_STATUS_REJECTED_UNKNOWN_SIGNER_SCHEME)
+			default:
+				txs = append(txs, builders.Transaction().WithEd25519Signer(keys.Ed25519KeyPairForTests(i%10)).Build())
+				expectedStatuses = append(expectedStatuses, protocol.TRANSACTION_STATUS_PRE_ORDER_VALID)
+			}
+		}
+
+		results, err := h.transactionSetPreOrder(ctx, txs)
+		require.NoError(t, err, "transaction set pre order should not fail on signature problems")
+		require.Equal(t, expectedStatuses, results, "each transaction should get the status of its own signature")
+
+		h.verifySystemContractCalled(t)
+	})
+}
+
 func TestPreOrder_GlobalSubscriptionContractNotApproved(t *testing.T) {
 	test.WithContext(func(ctx context.Context) {
 		h := newHarness(t)
@@ -84,3 +84,28 @@ func TestProcessQuery_UnexpectedError(t *testing.T) {
 		h.verifyNativeContractMethodCalled(t)
 	})
 }
+
+func TestProcessQuery_PastBlockHeightFailsWithoutStateArchive(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		h := newHarness(t)
+		h.expectStateStorageBlockHeightRequested(12)
+
+		result, _, _, _, err := h.processQueryAtBlockHeight(ctx, 5, "Contract1", "method1")
+		require.Error(t, err, "process query at a past block height should fail when state storage does not keep past revisions")
+		require.Equal(t, protocol.EXECUTION_RESULT_ERROR_UNEXPECTED, result)
+
+		h.verifyStateStorageBlockHeightRequested(t)
+	})
+}
+
+func TestProcessQuery_FutureBlockHeightFails(t *testing.T) {
+	test.WithContext(func(ctx context.Context) {
+		h := newHarness(t)
+		h.expectStateStorageBlockHeightRequested(12)
+
+		_, _, _, _, err := h.processQueryAtBlockHeight(ctx, 13, "Contract1", "method1")
+		require.Error(t, err, "process query at a block height not yet committed should fail")
+
+		h.verifyStateStorageBlockHeightRequested(t)
+	})
+}
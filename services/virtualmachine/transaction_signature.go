@@ -10,9 +10,17 @@ import (
 	"github.com/orbs-network/orbs-network-go/crypto/digest"
 	"github.com/orbs-network/orbs-network-go/crypto/signature"
 	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"time"
 )
 
 func (s *service) verifyTransactionSignatures(signedTransactions []*protocol.SignedTransaction, resultStatuses []protocol.TransactionStatus) {
+	start := time.Now()
+	defer s.metrics.signatureVerificationTime.RecordSince(start)
+
+	// the Ed25519 signers are verified in parallel, indices maps them back to their transactions
+	var ed25519Verifications []*signature.Ed25519Verification
+	var indices []int
+
 	for i, signedTransaction := range signedTransactions {
 
 		// skip transactions that already failed due to different reasons
@@ -23,20 +31,31 @@ func (s *service) verifyTransactionSignatures(signedTransactions []*protocol.Sig
 		// check transaction signature
 		switch signedTransaction.Transaction().Signer().Scheme() {
 		case protocol.SIGNER_SCHEME_EDDSA:
-			if verifyEd25519Signer(signedTransaction) {
-				resultStatuses[i] = protocol.TRANSACTION_STATUS_PRE_ORDER_VALID
-			} else {
-				resultStatuses[i] = protocol.TRANSACTION_STATUS_REJECTED_SIGNATURE_MISMATCH
-			}
-		default:
+			ed25519Verifications = append(ed25519Verifications, ed25519VerificationOf(signedTransaction))
+			indices = append(indices, i)
+		default: // TODO(v1) verify ECDSA secp256k1 and multisig signers once orbs-spec defines their Signer schemes
 			resultStatuses[i] = protocol.TRANSACTION_STATUS_REJECTED_UNKNOWN_SIGNER_SCHEME
 		}
 
 	}
+
+	valid := s.signatureVerifier.Verify(ed25519Verifications)
+	for j, i := range indices {
+		if valid[j] {
+			resultStatuses[i] = protocol.TRANSACTION_STATUS_PRE_ORDER_VALID
+		} else {
+			resultStatuses[i] = protocol.TRANSACTION_STATUS_REJECTED_SIGNATURE_MISMATCH
+		}
+	}
 }
 
-func verifyEd25519Signer(signedTransaction *protocol.SignedTransaction) bool {
-	signerPublicKey := signedTransaction.Transaction().Signer().Eddsa().SignerPublicKey()
-	txHash := digest.CalcTxHash(signedTransaction.Transaction())
-	return signature.VerifyEd25519(signerPublicKey, txHash, signedTransaction.Signature())
+// the transaction hash is calculated by the worker verifying the signature
+func ed25519VerificationOf(signedTransaction *protocol.SignedTransaction) *signature.Ed25519Verification {
+	return &signature.Ed25519Verification{
+		PublicKey: signedTransaction.Transaction().Signer().Eddsa().SignerPublicKey(),
+		Signature: signedTransaction.Signature(),
+		SignedData: func() []byte {
+			return digest.CalcTxHash(signedTransaction.Transaction())
+		},
+	}
 }
@@ -0,0 +1,31 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package acceptance
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/services/processor/native/repository/BenchmarkToken"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestGossipRelay_CommitsTransactionWhenNodesAreLinkedOnlyToNeighbors(t *testing.T) {
+	newHarness().
+		WithNumNodes(7).
+		WithGossipRelay(2, 7). // a ring, most nodes are reached over several hops
+		Start(t, func(t testing.TB, ctx context.Context, network *NetworkHarness) {
+			token := network.DeployBenchmarkTokenContract(ctx, 5)
+
+			_, txHash := token.Transfer(ctx, 0, 17, 5, 6)
+			network.WaitForTransactionInState(ctx, txHash)
+
+			for i := range network.Nodes {
+				require.EqualValues(t, benchmarktoken.TOTAL_SUPPLY-17, token.GetBalance(ctx, i, 5), "getBalance result for the sender on node %d", i)
+				require.EqualValues(t, 17, token.GetBalance(ctx, i, 6), "getBalance result for the receiver on node %d", i)
+			}
+		})
+}
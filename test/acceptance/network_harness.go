@@ -16,7 +16,9 @@ import (
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
 	blockStorageAdapter "github.com/orbs-network/orbs-network-go/services/blockstorage/adapter/testkit"
 	ethereumAdapter "github.com/orbs-network/orbs-network-go/services/crosschainconnector/ethereum/adapter"
+	gossipAdapter "github.com/orbs-network/orbs-network-go/services/gossip/adapter"
 	memoryGossip "github.com/orbs-network/orbs-network-go/services/gossip/adapter/memory"
+	relayGossip "github.com/orbs-network/orbs-network-go/services/gossip/adapter/relay"
 	gossipTestAdapter "github.com/orbs-network/orbs-network-go/services/gossip/adapter/testkit"
 	testGossipAdapter "github.com/orbs-network/orbs-network-go/services/gossip/adapter/testkit"
 	"github.com/orbs-network/orbs-network-go/services/processor/native/adapter/fake"
@@ -53,14 +55,14 @@ func usingABenchmarkConsensusNetwork(tb testing.TB, f func(ctx context.Context,
 	ctx, cancel := context.WithCancel(context.Background())
 	supervised.Recover(logger, func() {
 		defer cancel()
-		network := newAcceptanceTestNetwork(ctx, logger, consensus.CONSENSUS_ALGO_TYPE_BENCHMARK_CONSENSUS, nil, 2, DEFAULT_ACCEPTANCE_MAX_TX_PER_BLOCK, DEFAULT_ACCEPTANCE_REQUIRED_QUORUM_PERCENTAGE, DEFAULT_ACCEPTANCE_VIRTUAL_CHAIN_ID, DEFAULT_ACCEPTANCE_EMPTY_BLOCK_TIME)
+		network := newAcceptanceTestNetwork(ctx, logger, consensus.CONSENSUS_ALGO_TYPE_BENCHMARK_CONSENSUS, nil, 2, DEFAULT_ACCEPTANCE_MAX_TX_PER_BLOCK, DEFAULT_ACCEPTANCE_REQUIRED_QUORUM_PERCENTAGE, DEFAULT_ACCEPTANCE_VIRTUAL_CHAIN_ID, DEFAULT_ACCEPTANCE_EMPTY_BLOCK_TIME, nil)
 		network.CreateAndStartNodes(ctx, 2)
 		f(ctx, network)
 	})
 }
 
 func newAcceptanceTestNetwork(ctx context.Context, testLogger log.Logger, consensusAlgo consensus.ConsensusAlgoType, preloadedBlocks []*protocol.BlockPairContainer,
-	numNodes int, maxTxPerBlock uint32, requiredQuorumPercentage uint32, vcid primitives.VirtualChainId, emptyBlockTime time.Duration) *NetworkHarness {
+	numNodes int, maxTxPerBlock uint32, requiredQuorumPercentage uint32, vcid primitives.VirtualChainId, emptyBlockTime time.Duration, gossipRelay config.GossipRelayConfig) *NetworkHarness {
 
 	testLogger.Info("===========================================================================")
 	testLogger.Info("creating acceptance test network", log.String("consensus", consensusAlgo.String()), log.Int("num-nodes", numNodes))
@@ -87,7 +89,12 @@ func newAcceptanceTestNetwork(ctx context.Context, testLogger log.Logger, consen
 		emptyBlockTime,
 	)
 
-	sharedTamperingTransport := gossipTestAdapter.NewTamperingTransport(testLogger, memoryGossip.NewTransport(ctx, testLogger, genesisValidatorNodes))
+	var sharedTransport gossipAdapter.Transport = memoryGossip.NewTransport(ctx, testLogger, genesisValidatorNodes)
+	if gossipRelay != nil { // nodes are only linked to their neighbors in the relay overlay
+		sharedTransport = relayGossip.NewTransport(sharedTransport, nodeOrder, gossipRelay, testLogger, metric.NewRegistry())
+	}
+
+	sharedTamperingTransport := gossipTestAdapter.NewTamperingTransport(testLogger, sharedTransport)
 	sharedCompiler := nativeProcessorAdapter.NewCompiler()
 	sharedEthereumSimulator := ethereumAdapter.NewEthereumSimulatorConnection(testLogger)
 
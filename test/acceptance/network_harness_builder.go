@@ -8,6 +8,7 @@ package acceptance
 
 import (
 	"context"
+	"github.com/orbs-network/orbs-network-go/config"
 	"github.com/orbs-network/orbs-network-go/services/gossip/adapter/memory"
 	"github.com/orbs-network/orbs-network-go/synchronization/supervised"
 	"github.com/orbs-network/orbs-network-go/test"
@@ -45,6 +46,7 @@ type networkHarnessBuilder struct {
 	blockChain               []*protocol.BlockPairContainer
 	virtualChainId           primitives.VirtualChainId
 	emptyBlockTime           time.Duration
+	gossipRelay              config.GossipRelayConfig
 }
 
 func newHarness() *networkHarnessBuilder {
@@ -104,6 +106,12 @@ func (b *networkHarnessBuilder) WithMaxTxPerBlock(maxTxPerBlock uint32) *network
 	return b
 }
 
+// nodes gossip over a relay overlay in which every node is linked only to its neighbors, instead of to all other nodes
+func (b *networkHarnessBuilder) WithGossipRelay(maxDegree uint32, maxHops uint32) *networkHarnessBuilder {
+	b.gossipRelay = config.ForGossipRelayTests(maxDegree, maxHops)
+	return b
+}
+
 func (b *networkHarnessBuilder) AllowingErrors(allowedErrors ...string) *networkHarnessBuilder {
 	b.allowedErrors = append(b.allowedErrors, allowedErrors...)
 	return b
@@ -139,7 +147,7 @@ func (b *networkHarnessBuilder) runTest(tb testing.TB, consensusAlgo consensus.C
 		// TODO: if we experience flakiness during system shutdown move TestTerminated to be under test.WithContextWithTimeout
 
 		test.WithContextWithTimeout(TEST_TIMEOUT_HARD_LIMIT, func(ctx context.Context) {
-			network := newAcceptanceTestNetwork(ctx, logger, consensusAlgo, b.blockChain, b.numNodes, b.maxTxPerBlock, b.requiredQuorumPercentage, b.virtualChainId, b.emptyBlockTime)
+			network := newAcceptanceTestNetwork(ctx, logger, consensusAlgo, b.blockChain, b.numNodes, b.maxTxPerBlock, b.requiredQuorumPercentage, b.virtualChainId, b.emptyBlockTime, b.gossipRelay)
 
 			logger.Info("acceptance network created")
 			defer printTestIdOnFailure(tb, testId)
@@ -33,6 +33,11 @@ func (r *receipt) WithTransaction(t *protocol.Transaction) *receipt {
 	return r
 }
 
+func (r *receipt) WithEvents(events ...*protocol.EventBuilder) *receipt {
+	r.builder.OutputEventsArray = PackedEventsArrayEncode(events)
+	return r
+}
+
 func (r *receipt) Build() *protocol.TransactionReceipt {
 	return r.builder.Build()
 }
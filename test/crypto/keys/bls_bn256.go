@@ -0,0 +1,43 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package keys
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"github.com/orbs-network/orbs-network-go/crypto/keys"
+)
+
+// Deals the same key shares to every test asking for the same threshold and number of shares
+func BlsBn256ThresholdKeysForTests(threshold int, numShares int) *keys.BlsBn256ThresholdKeys {
+	thresholdKeys, err := keys.GenerateBlsBn256ThresholdKeys(&seededReader{seed: []byte(fmt.Sprintf("bls keys for tests %d of %d", threshold, numShares))}, threshold, numShares)
+	if err != nil {
+		panic(fmt.Sprintf("failed to deal bls keys for tests: %s", err))
+	}
+	return thresholdKeys
+}
+
+// an endless stream of the SHA256 of the seed and a counter
+type seededReader struct {
+	seed    []byte
+	counter uint64
+	buffer  []byte
+}
+
+func (r *seededReader) Read(p []byte) (int, error) {
+	for len(r.buffer) < len(p) {
+		counter := make([]byte, 8)
+		binary.BigEndian.PutUint64(counter, r.counter)
+		r.counter++
+		sum := sha256.Sum256(append(counter, r.seed...))
+		r.buffer = append(r.buffer, sum[:]...)
+	}
+	n := copy(p, r.buffer)
+	r.buffer = r.buffer[n:]
+	return n, nil
+}